@@ -0,0 +1,506 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api <method> <path>",
+	Short: "make an authenticated api request",
+	Long: `make an authenticated request to the runpod rest api and print the response.
+
+the path is relative to the rest api url (https://rest.runpod.io/v1 unless
+RUNPOD_API_URL or restApiUrl is set), and the configured api key is sent.
+
+-f key=value adds a string field and -F key=value a typed one: true, false,
+null and numbers keep their type, and @file (or @- for stdin) reads the value
+from a file. fields go in the query string of GET and DELETE requests and in a
+json body otherwise. --input sends a file (or - for stdin) as the body
+instead, and fields then go in the query string.
+
+--paginate repeats a GET with increasing limit and offset parameters until a
+page comes back short, and prints all items as one list.
+
+use "runpodctl api graphql" for the graphql api.`,
+	Example: `  runpodctl api GET /pods
+  runpodctl api GET /endpoints -f includeWorkers=true
+  runpodctl api POST /pods/<pod-id>/stop
+  runpodctl api PATCH /endpoints/<endpoint-id> -F workersMax=5
+  runpodctl api POST /templates --input template.json
+  runpodctl api GET /templates --paginate`,
+	Args: cobra.ExactArgs(2),
+	RunE: runAPI,
+}
+
+var apiGraphQLCmd = &cobra.Command{
+	Use:   "graphql",
+	Short: "make an authenticated graphql request",
+	Long: `send a graphql query or mutation to the runpod graphql api and print the
+response.
+
+the query is sent to https://api.runpod.io/graphql unless RUNPOD_GRAPHQL_URL
+or apiUrl is set. -f and -F set variables the same way they set fields for
+"runpodctl api". a response with errors is printed and then fails.
+
+--paginate needs a query that takes an $offset variable (and usually $limit);
+it is repeated with increasing offsets until the first list in the data comes
+back short, and the lists are joined.`,
+	Example: `  runpodctl api graphql -q 'query { myself { id email } }'
+  runpodctl api graphql -q 'query Pod($id: String!) { pod(input: {podId: $id}) { id desiredStatus } }' -F id=<pod-id>
+  runpodctl api graphql -q @listings.graphql --paginate`,
+	Args: cobra.NoArgs,
+	RunE: runAPIGraphQL,
+}
+
+var (
+	apiRawFields   []string
+	apiTypedFields []string
+	apiInput       string
+	apiPaginate    bool
+	apiQuery       string
+)
+
+// apiPageSize is the page size --paginate asks for when no limit is given
+const apiPageSize = 100
+
+func init() {
+	for _, c := range []*cobra.Command{apiCmd, apiGraphQLCmd} {
+		c.Flags().StringArrayVarP(&apiRawFields, "raw-field", "f", nil, "add a string field (key=value)")
+		c.Flags().StringArrayVarP(&apiTypedFields, "field", "F", nil, "add a typed field (key=value, @file reads a file)")
+		c.Flags().BoolVar(&apiPaginate, "paginate", false, "fetch every page and print them as one list")
+	}
+	apiCmd.Flags().StringVar(&apiInput, "input", "", "file to send as the request body (- for stdin)")
+	apiGraphQLCmd.Flags().StringVarP(&apiQuery, "query", "q", "", "graphql query (@file reads a file)")
+
+	apiCmd.AddCommand(apiGraphQLCmd)
+}
+
+var apiMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+func runAPI(cmd *cobra.Command, args []string) error {
+	method := strings.ToUpper(args[0])
+	if !apiMethods[method] {
+		return fmt.Errorf("unsupported method %q (use GET, POST, PUT, PATCH or DELETE)", args[0])
+	}
+	if apiPaginate && method != http.MethodGet {
+		return fmt.Errorf("--paginate only works with GET")
+	}
+
+	endpoint, params, err := splitAPIPath(args[1])
+	if err != nil {
+		return err
+	}
+	fields, err := parseAPIFields(apiRawFields, apiTypedFields, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	var body interface{}
+	switch {
+	case apiInput != "":
+		data, err := readAPIFile(apiInput, cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("%s is not valid json", apiInput)
+		}
+		body = json.RawMessage(data)
+		addAPIQuery(params, fields)
+	case method == http.MethodGet || method == http.MethodDelete:
+		addAPIQuery(params, fields)
+	case len(fields) > 0:
+		body = fields
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	if apiPaginate {
+		items, err := paginateREST(client, endpoint, params)
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+		return printAPIResult(cmd, items)
+	}
+
+	data, err := client.Request(method, endpoint, params, body)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	return printAPIResponse(cmd, data)
+}
+
+func runAPIGraphQL(cmd *cobra.Command, args []string) error {
+	query := apiQuery
+	if strings.HasPrefix(query, "@") {
+		data, err := readAPIFile(strings.TrimPrefix(query, "@"), cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		query = string(data)
+	}
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("--query is required")
+	}
+	variables, err := parseAPIFields(apiRawFields, apiTypedFields, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if apiPaginate && !strings.Contains(query, "$offset") {
+		return fmt.Errorf("--paginate needs a query that takes an $offset variable")
+	}
+
+	client, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	if apiPaginate {
+		resp, err := paginateGraphQL(client, query, variables)
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+		return printAPIResult(cmd, resp)
+	}
+
+	data, err := client.Query(api.GraphQLInput{Query: query, Variables: variables})
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	resp, err := decodeGraphQLResponse(data)
+	if resp != nil {
+		// a response with errors is printed too, it may carry partial data
+		if printErr := printAPIResult(cmd, resp); printErr != nil {
+			return printErr
+		}
+	}
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	return nil
+}
+
+// splitAPIPath splits a path that may carry a query string
+func splitAPIPath(path string) (string, url.Values, error) {
+	if strings.Contains(path, "://") {
+		return "", nil, fmt.Errorf("pass a path relative to the api url, e.g. /pods")
+	}
+	path, rawQuery, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid query string: %w", err)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path, params, nil
+}
+
+// parseAPIFields parses -f (string) and -F (typed) key=value fields
+func parseAPIFields(raw, typed []string, stdin io.Reader) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	for _, field := range raw {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field %q, expected key=value", field)
+		}
+		fields[key] = value
+	}
+	for _, field := range typed {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field %q, expected key=value", field)
+		}
+		typedValue, err := typedAPIValue(value, stdin)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		fields[key] = typedValue
+	}
+	return fields, nil
+}
+
+func typedAPIValue(value string, stdin io.Reader) (interface{}, error) {
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if strings.HasPrefix(value, "@") {
+		data, err := readAPIFile(strings.TrimPrefix(value, "@"), stdin)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f, nil
+	}
+	return value, nil
+}
+
+func readAPIFile(path string, stdin io.Reader) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+func addAPIQuery(params url.Values, fields map[string]interface{}) {
+	for key, value := range fields {
+		if value == nil {
+			params.Set(key, "")
+			continue
+		}
+		params.Set(key, fmt.Sprint(value))
+	}
+}
+
+// paginateREST fetches a list endpoint page by page with limit and offset
+// query parameters
+func paginateREST(client *api.Client, endpoint string, params url.Values) ([]interface{}, error) {
+	limit, err := apiIntParam(params, "limit", apiPageSize)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := apiIntParam(params, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("limit", strconv.Itoa(limit))
+
+	return paginate(offset, limit, func(offset int) ([]interface{}, error) {
+		params.Set("offset", strconv.Itoa(offset))
+		data, err := client.Get(endpoint, params)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeAPIJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		page, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s does not return a list, so it can't be paginated", endpoint)
+		}
+		return page, nil
+	})
+}
+
+func apiIntParam(params url.Values, key string, fallback int) (int, error) {
+	value := params.Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || (key == "limit" && n == 0) {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return n, nil
+}
+
+// paginateGraphQL runs a query with increasing $offset and joins the first
+// list in each response's data into the first response
+func paginateGraphQL(client *api.GraphQLClient, query string, variables map[string]interface{}) (map[string]interface{}, error) {
+	limit := 0
+	switch value := variables["limit"].(type) {
+	case int64:
+		limit = int(value)
+	case nil:
+		if strings.Contains(query, "$limit") {
+			limit = apiPageSize
+			variables["limit"] = limit
+		}
+	default:
+		return nil, fmt.Errorf("limit must be a number, use -F limit=<n>")
+	}
+	offset := 0
+	if value, ok := variables["offset"].(int64); ok {
+		offset = int(value)
+	}
+
+	var first map[string]interface{}
+	var listPath []string
+	items, err := paginate(offset, limit, func(offset int) ([]interface{}, error) {
+		variables["offset"] = offset
+		data, err := client.Query(api.GraphQLInput{Query: query, Variables: variables})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := decodeGraphQLResponse(data)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = resp
+			if listPath = findAPIList(resp["data"]); listPath == nil {
+				return nil, fmt.Errorf("the response has no list to paginate")
+			}
+		}
+		page, _ := apiValueAt(resp["data"], listPath).([]interface{})
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parent, _ := apiValueAt(first["data"], listPath[:len(listPath)-1]).(map[string]interface{})
+	parent[listPath[len(listPath)-1]] = items
+	return first, nil
+}
+
+// paginate fetches pages from offset until one comes back short or empty,
+// or starts with the same item as the last one, as it does when the api
+// ignores the offset. a limit of 0 means the page size is unknown.
+func paginate(offset, limit int, fetch func(offset int) ([]interface{}, error)) ([]interface{}, error) {
+	items := []interface{}{}
+	var lastFirst []byte
+	for {
+		page, err := fetch(offset)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		pageFirst, _ := json.Marshal(page[0])
+		if lastFirst != nil && bytes.Equal(pageFirst, lastFirst) {
+			break
+		}
+		lastFirst = pageFirst
+
+		items = append(items, page...)
+		if limit > 0 && len(page) < limit {
+			break
+		}
+		offset += len(page)
+	}
+	return items, nil
+}
+
+// findAPIList returns the key path of the first list in value, looking
+// through objects in key order
+func findAPIList(value interface{}) []string {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := object[key].([]interface{}); ok {
+			return []string{key}
+		}
+		if path := findAPIList(object[key]); path != nil {
+			return append([]string{key}, path...)
+		}
+	}
+	return nil
+}
+
+func apiValueAt(value interface{}, path []string) interface{} {
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// decodeGraphQLResponse decodes a graphql response, returning it along with
+// an error for the first error it reports
+func decodeGraphQLResponse(data []byte) (map[string]interface{}, error) {
+	value, err := decodeAPIJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected graphql response: %s", data)
+	}
+	if errs, _ := resp["errors"].([]interface{}); len(errs) > 0 {
+		message := fmt.Sprint(errs[0])
+		if first, ok := errs[0].(map[string]interface{}); ok && first["message"] != nil {
+			message = fmt.Sprint(first["message"])
+		}
+		return resp, fmt.Errorf("graphql error: %s", message)
+	}
+	return resp, nil
+}
+
+func decodeAPIJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return value, nil
+}
+
+// printAPIResponse prints a json response in the output format, and
+// anything else as it came
+func printAPIResponse(cmd *cobra.Command, data []byte) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	value, err := decodeAPIJSON(data)
+	if err != nil {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	return printAPIResult(cmd, value)
+}
+
+func printAPIResult(cmd *cobra.Command, value interface{}) error {
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(value, &output.Config{Format: format, Raw: true})
+}
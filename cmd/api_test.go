@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func resetAPIVars(t *testing.T) {
+	t.Helper()
+	origRaw, origTyped, origInput, origPaginate, origQuery := apiRawFields, apiTypedFields, apiInput, apiPaginate, apiQuery
+	t.Cleanup(func() {
+		apiRawFields, apiTypedFields, apiInput, apiPaginate, apiQuery = origRaw, origTyped, origInput, origPaginate, origQuery
+	})
+	apiRawFields, apiTypedFields, apiInput, apiPaginate, apiQuery = nil, nil, "", false, ""
+}
+
+// startFakeAPI serves handler as both the rest and the graphql api
+func startFakeAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("missing api key on %s %s", r.Method, r.URL)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	t.Setenv("RUNPOD_API_URL", server.URL+"/v1")
+	t.Setenv("RUNPOD_GRAPHQL_URL", server.URL+"/graphql")
+}
+
+func runAPICaptured(t *testing.T, run func(*cobra.Command, []string) error, stdin string, args ...string) (string, error) {
+	t.Helper()
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetErr(io.Discard)
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	runErr := run(cmd, args)
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String(), runErr
+}
+
+func TestRunAPI_GetKeepsResponseAsIs(t *testing.T) {
+	resetAPIVars(t)
+	startFakeAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/pods" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if r.URL.Query().Get("computeType") != "GPU" || r.URL.Query().Get("includeMachine") != "true" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[{"id":"pod-1","gpuTypeId":"NVIDIA A40","costPerHr":0.390000}]`))
+	})
+
+	apiRawFields = []string{"includeMachine=true"}
+	stdout, err := runAPICaptured(t, runAPI, "", "get", "pods?computeType=GPU")
+	if err != nil {
+		t.Fatalf("api: %v", err)
+	}
+	if !strings.Contains(stdout, `"gpuTypeId": "NVIDIA A40"`) || !strings.Contains(stdout, `"costPerHr": 0.390000`) {
+		t.Fatalf("expected the response unchanged, got %s", stdout)
+	}
+}
+
+func TestRunAPI_Body(t *testing.T) {
+	resetAPIVars(t)
+	var bodies []string
+	var queries []string
+	startFakeAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		queries = append(queries, r.URL.RawQuery)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"id":"ep-1"}`))
+	})
+
+	apiRawFields = []string{"name=my-endpoint", "workersMin=2"}
+	apiTypedFields = []string{"workersMax=5", "flashboot=true", "scalerValue=1.5", "templateId=null"}
+	if _, err := runAPICaptured(t, runAPI, "", "PATCH", "/endpoints/ep-1"); err != nil {
+		t.Fatalf("api: %v", err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(bodies[0]), &body); err != nil {
+		t.Fatalf("parse %q: %v", bodies[0], err)
+	}
+	if body["name"] != "my-endpoint" || body["workersMin"] != "2" || body["workersMax"] != float64(5) ||
+		body["flashboot"] != true || body["scalerValue"] != 1.5 || body["templateId"] != nil {
+		t.Fatalf("unexpected body %v", body)
+	}
+
+	// --input sends the body as is and fields go in the query string
+	resetAPIVars(t)
+	apiInput = "-"
+	apiRawFields = []string{"dryRun=true"}
+	stdout, err := runAPICaptured(t, runAPI, `{"imageName":"repo/img:1"}`, "POST", "/templates")
+	if err != nil {
+		t.Fatalf("api: %v", err)
+	}
+	if bodies[1] != `{"imageName":"repo/img:1"}` || queries[1] != "dryRun=true" {
+		t.Fatalf("unexpected request %q ?%s", bodies[1], queries[1])
+	}
+	if stdout != "" {
+		t.Fatalf("expected no output for an empty response, got %q", stdout)
+	}
+}
+
+func TestRunAPI_Errors(t *testing.T) {
+	resetAPIVars(t)
+	startFakeAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"pod not found"}`))
+	})
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"HEAD", "/pods"}, "unsupported method"},
+		{[]string{"GET", "https://rest.runpod.io/v1/pods"}, "pass a path relative to the api url"},
+		{[]string{"GET", "/pods/x"}, `{"error":"pod not found"} (status 404)`},
+	}
+	for _, tc := range cases {
+		if _, err := runAPICaptured(t, runAPI, "", tc.args...); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: got %v, want %q", tc.args, err, tc.want)
+		}
+	}
+
+	apiPaginate = true
+	if _, err := runAPICaptured(t, runAPI, "", "POST", "/pods"); err == nil || !strings.Contains(err.Error(), "only works with GET") {
+		t.Errorf("expected --paginate to need GET, got %v", err)
+	}
+	apiPaginate = false
+	apiInput = "-"
+	if _, err := runAPICaptured(t, runAPI, "not json", "POST", "/pods"); err == nil || !strings.Contains(err.Error(), "not valid json") {
+		t.Errorf("expected invalid input, got %v", err)
+	}
+}
+
+func TestRunAPI_Paginate(t *testing.T) {
+	resetAPIVars(t)
+	requests := 0
+	startFakeAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		page := []map[string]int{}
+		for i := offset; i < offset+limit && i < 250; i++ {
+			page = append(page, map[string]int{"n": i})
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+
+	apiPaginate = true
+	stdout, err := runAPICaptured(t, runAPI, "", "GET", "/items")
+	if err != nil {
+		t.Fatalf("api: %v", err)
+	}
+	var items []map[string]int
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(items) != 250 || items[249]["n"] != 249 || requests != 3 {
+		t.Fatalf("expected 250 items in 3 requests, got %d in %d", len(items), requests)
+	}
+
+	// an api that ignores the offset stops at the first repeated page
+	requests = 0
+	startFakeAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"n":0},{"n":1}]`))
+	})
+	apiRawFields = []string{"limit=2"}
+	stdout, err = runAPICaptured(t, runAPI, "", "GET", "/items")
+	if err != nil {
+		t.Fatalf("api: %v", err)
+	}
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil || len(items) != 2 || requests != 2 {
+		t.Fatalf("expected 2 items in 2 requests, got %v in %d (%v)", items, requests, err)
+	}
+}
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func TestRunAPIGraphQL(t *testing.T) {
+	resetAPIVars(t)
+	var got graphqlRequest
+	startFakeAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.Variables["id"] == "missing" {
+			w.Write([]byte(`{"data":{"pod":null},"errors":[{"message":"pod not found"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"pod":{"id":"pod-1","machine":{"gpuTypeId":"NVIDIA A40"}}}}`))
+	})
+
+	apiQuery = `query Pod($id: String!, $count: Int) { pod(input: {podId: $id}) { id } }`
+	apiTypedFields = []string{"id=pod-1", "count=2"}
+	stdout, err := runAPICaptured(t, runAPIGraphQL, "")
+	if err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	if got.Query != apiQuery || got.Variables["id"] != "pod-1" || got.Variables["count"] != float64(2) {
+		t.Fatalf("unexpected request %+v", got)
+	}
+	if !strings.Contains(stdout, `"gpuTypeId": "NVIDIA A40"`) {
+		t.Fatalf("unexpected output %s", stdout)
+	}
+
+	// errors are printed and fail the command
+	apiTypedFields = []string{"id=missing"}
+	stdout, err = runAPICaptured(t, runAPIGraphQL, "")
+	if err == nil || err.Error() != "graphql error: pod not found" {
+		t.Fatalf("expected a graphql error, got %v", err)
+	}
+	if !strings.Contains(stdout, `"pod not found"`) {
+		t.Fatalf("expected the response printed, got %s", stdout)
+	}
+
+	// the query can come from stdin
+	apiQuery = "@-"
+	apiTypedFields = []string{"id=pod-1"}
+	if _, err := runAPICaptured(t, runAPIGraphQL, "query { myself { id } }"); err != nil || got.Query != "query { myself { id } }" {
+		t.Fatalf("expected the query from stdin, got %q (%v)", got.Query, err)
+	}
+}
+
+func TestRunAPIGraphQL_Paginate(t *testing.T) {
+	resetAPIVars(t)
+	var offsets []float64
+	startFakeAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		limit, offset := req.Variables["limit"].(float64), req.Variables["offset"].(float64)
+		offsets = append(offsets, offset)
+		listings := []map[string]int{}
+		for i := int(offset); i < int(offset+limit) && i < 5; i++ {
+			listings = append(listings, map[string]int{"n": i})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"listings": listings}})
+	})
+
+	apiQuery = `query Listings($limit: Int, $offset: Int) { listings(input: {limit: $limit, offset: $offset}) { id } }`
+	apiTypedFields = []string{"limit=2"}
+	apiPaginate = true
+	stdout, err := runAPICaptured(t, runAPIGraphQL, "")
+	if err != nil {
+		t.Fatalf("graphql: %v", err)
+	}
+	var resp struct {
+		Data struct {
+			Listings []map[string]int `json:"listings"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("parse %s: %v", stdout, err)
+	}
+	if len(resp.Data.Listings) != 5 || resp.Data.Listings[4]["n"] != 4 || len(offsets) != 3 || offsets[2] != 4 {
+		t.Fatalf("expected 5 listings from offsets 0, 2, 4; got %v from %v", resp.Data.Listings, offsets)
+	}
+
+	apiQuery = `query { listings { id } }`
+	if _, err := runAPICaptured(t, runAPIGraphQL, ""); err == nil || !strings.Contains(err.Error(), "$offset") {
+		t.Fatalf("expected --paginate to need $offset, got %v", err)
+	}
+}
+
+func TestPrintAPIResponse_NonJSONGoesToCommandOutput(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	if err := printAPIResponse(cmd, []byte("plain text\n")); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "plain text\n" {
+		t.Fatalf("expected the body on the command's output, got %q", stdout.String())
+	}
+}
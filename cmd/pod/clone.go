@@ -0,0 +1,290 @@
+package pod
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <pod-id>",
+	Short: "create a copy of a pod",
+	Long: `create a new pod with the same image, env, ports, disks and network volume as an existing pod.
+
+local volume data is not copied; only a network volume is shared between the two pods.
+
+examples:
+  # clone onto the same gpu type
+  runpodctl pod clone <pod-id>
+
+  # clone onto a bigger gpu in another data center
+  runpodctl pod clone <pod-id> --gpu-id "NVIDIA H100 80GB HBM3" --datacenter EU-RO-1`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClone,
+}
+
+// cloneOptions are the overrides applied on top of the source pod's config.
+type cloneOptions struct {
+	Name       string
+	GpuTypeID  string
+	GpuCount   int
+	DataCenter string
+	CloudType  string
+}
+
+var (
+	cloneName       string
+	cloneGpuTypeID  string
+	cloneGpuCount   int
+	cloneDataCenter string
+	cloneCloudType  string
+)
+
+func init() {
+	cloneCmd.Flags().StringVar(&cloneName, "name", "", "name for the new pod (default: source pod name)")
+	cloneCmd.Flags().StringVar(&cloneGpuTypeID, "gpu-id", "", "gpu id for the new pod (default: source pod gpu)")
+	cloneCmd.Flags().IntVar(&cloneGpuCount, "gpu-count", 0, "number of gpus for the new pod (default: source pod gpu count)")
+	cloneCmd.Flags().StringVar(&cloneDataCenter, "datacenter", "", "data center id for the new pod (must match the network volume, if any)")
+	cloneCmd.Flags().StringVar(&cloneCloudType, "cloud-type", "", "cloud type, SECURE or COMMUNITY (default: source pod cloud type)")
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	podID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	source, err := client.GetPod(podID, true, true)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	if hasLocalVolumeData(source) {
+		fmt.Fprintf(os.Stderr, "note: pod %s has a %dgb local volume; its data is not copied to the clone\n", source.ID, source.VolumeInGb)
+	}
+
+	clone, err := clonePod(client, source, currentCloneOptions())
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to clone pod: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(clone, &output.Config{Format: format})
+}
+
+func currentCloneOptions() cloneOptions {
+	return cloneOptions{
+		Name:       cloneName,
+		GpuTypeID:  cloneGpuTypeID,
+		GpuCount:   cloneGpuCount,
+		DataCenter: cloneDataCenter,
+		CloudType:  cloneCloudType,
+	}
+}
+
+// clonePod creates the copy and returns the create response tagged with the
+// source pod id. on-demand gpu pods go through graphql (like pod create, so
+// ssh stays enabled); cpu and spot pods use rest, the create that takes
+// interruptible.
+func clonePod(client *api.Client, source *api.Pod, opts cloneOptions) (map[string]interface{}, error) {
+	if isCPUPod(source) || source.Interruptible {
+		req, err := buildCloneRESTRequest(source, opts)
+		if err != nil {
+			return nil, err
+		}
+		pod, err := client.CreatePod(req)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"id":            pod.ID,
+			"name":          pod.Name,
+			"imageName":     pod.ImageName,
+			"desiredStatus": pod.DesiredStatus,
+			"clonedFrom":    source.ID,
+		}, nil
+	}
+
+	req, err := buildCloneGQLInput(source, opts)
+	if err != nil {
+		return nil, err
+	}
+	gqlClient, err := api.NewGraphQLClient()
+	if err != nil {
+		return nil, err
+	}
+	pod, err := gqlClient.CreatePod(req)
+	if err != nil {
+		return nil, err
+	}
+	pod["clonedFrom"] = source.ID
+	return pod, nil
+}
+
+func buildCloneGQLInput(source *api.Pod, opts cloneOptions) (*api.CreatePodGQLInput, error) {
+	dataCenter, err := cloneDataCenterID(source, opts.DataCenter)
+	if err != nil {
+		return nil, err
+	}
+
+	gpuTypeID := strings.TrimSpace(opts.GpuTypeID)
+	if gpuTypeID == "" {
+		gpuTypeID = sourceGpuTypeID(source)
+	}
+	if gpuTypeID == "" {
+		return nil, fmt.Errorf("could not determine the gpu type of pod %s; pass --gpu-id", source.ID)
+	}
+
+	gpuCount := opts.GpuCount
+	if gpuCount <= 0 {
+		gpuCount = source.GpuCount
+	}
+
+	req := &api.CreatePodGQLInput{
+		CloudType:               cloneCloudTypeValue(source, opts.CloudType),
+		ContainerDiskInGb:       source.ContainerDiskInGb,
+		DataCenterId:            dataCenter,
+		GpuCount:                gpuCount,
+		GpuTypeId:               gpuTypeID,
+		ImageName:               source.ImageName,
+		Name:                    cloneNameValue(source, opts.Name),
+		Ports:                   strings.Join(source.Ports, ","),
+		StartSsh:                true,
+		TemplateId:              source.TemplateID,
+		VolumeInGb:              source.VolumeInGb,
+		VolumeMountPath:         source.VolumeMountPath,
+		DockerArgs:              api.DockerArgsJSON(source.DockerEntrypoint, source.DockerStartCmd),
+		ContainerRegistryAuthId: source.ContainerRegistryAuthID,
+	}
+	if source.NetworkVolume != nil {
+		// the network volume replaces the pod volume; don't also provision one.
+		req.NetworkVolumeId = source.NetworkVolume.ID
+		req.VolumeInGb = 0
+	}
+
+	keys := make([]string, 0, len(source.Env))
+	for k := range source.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		req.Env = append(req.Env, &api.PodEnvVar{Key: k, Value: source.Env[k]})
+	}
+
+	return req, nil
+}
+
+func buildCloneRESTRequest(source *api.Pod, opts cloneOptions) (*api.PodCreateRequest, error) {
+	cpu := isCPUPod(source)
+	if cpu && strings.TrimSpace(opts.GpuTypeID) != "" {
+		return nil, fmt.Errorf("--gpu-id is not supported when cloning a cpu pod")
+	}
+
+	dataCenter, err := cloneDataCenterID(source, opts.DataCenter)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &api.PodCreateRequest{
+		Name:                    cloneNameValue(source, opts.Name),
+		ImageName:               source.ImageName,
+		TemplateID:              source.TemplateID,
+		ComputeType:             "CPU",
+		ContainerDiskInGb:       source.ContainerDiskInGb,
+		VolumeInGb:              source.VolumeInGb,
+		VolumeMountPath:         source.VolumeMountPath,
+		Ports:                   source.Ports,
+		Env:                     source.Env,
+		CloudType:               cloneCloudTypeValue(source, opts.CloudType),
+		DockerArgs:              api.DockerArgsJSON(source.DockerEntrypoint, source.DockerStartCmd),
+		ContainerRegistryAuthID: source.ContainerRegistryAuthID,
+		Interruptible:           source.Interruptible,
+	}
+	if !cpu {
+		gpuTypeID := strings.TrimSpace(opts.GpuTypeID)
+		if gpuTypeID == "" {
+			gpuTypeID = sourceGpuTypeID(source)
+		}
+		req.ComputeType = "GPU"
+		req.GpuTypeIDs = []string{gpuTypeID}
+		req.GpuCount = source.GpuCount
+		if opts.GpuCount > 0 {
+			req.GpuCount = opts.GpuCount
+		}
+	}
+	if dataCenter != "" {
+		req.DataCenterIDs = []string{dataCenter}
+	}
+	if source.NetworkVolume != nil {
+		req.NetworkVolumeID = source.NetworkVolume.ID
+		req.VolumeInGb = 0
+	}
+
+	return req, nil
+}
+
+// cloneDataCenterID picks the data center for the clone. a network volume is
+// pinned to its data center, so the clone must land there too.
+func cloneDataCenterID(source *api.Pod, requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	if source.NetworkVolume == nil || source.NetworkVolume.DataCenterID == "" {
+		return requested, nil
+	}
+	volumeDC := source.NetworkVolume.DataCenterID
+	if requested != "" && !strings.EqualFold(requested, volumeDC) {
+		return "", fmt.Errorf("pod %s uses network volume %s in %s; the clone cannot be placed in %s", source.ID, source.NetworkVolume.ID, volumeDC, requested)
+	}
+	return volumeDC, nil
+}
+
+func sourceGpuTypeID(source *api.Pod) string {
+	if source.GpuTypeID != "" {
+		return source.GpuTypeID
+	}
+	if source.Machine != nil {
+		if id, ok := source.Machine["gpuTypeId"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+func isCPUPod(source *api.Pod) bool {
+	return source.GpuCount == 0 && sourceGpuTypeID(source) == ""
+}
+
+// hasLocalVolumeData reports whether the pod keeps data on a pod-local volume,
+// which is tied to the host and does not follow a clone.
+func hasLocalVolumeData(source *api.Pod) bool {
+	return source.VolumeInGb > 0 && source.NetworkVolume == nil
+}
+
+func cloneNameValue(source *api.Pod, name string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+	return source.Name
+}
+
+// cloneCloudTypeValue is --cloud-type, or else the cloud the source pod's
+// machine is in. a machine that doesn't say is taken as secure cloud.
+func cloneCloudTypeValue(source *api.Pod, cloudType string) string {
+	if cloudType = strings.ToUpper(strings.TrimSpace(cloudType)); cloudType != "" {
+		return cloudType
+	}
+	if secure, ok := source.Machine["secureCloud"].(bool); ok && !secure {
+		return "COMMUNITY"
+	}
+	return "SECURE"
+}
@@ -0,0 +1,173 @@
+package pod
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+)
+
+func TestBuildCloneGQLInput_CopiesSourceConfig(t *testing.T) {
+	source := &api.Pod{
+		ID:                "pod-1",
+		Name:              "trainer",
+		ImageName:         "runpod/pytorch:latest",
+		GpuTypeID:         "NVIDIA A40",
+		GpuCount:          2,
+		ContainerDiskInGb: 50,
+		VolumeInGb:        100,
+		VolumeMountPath:   "/workspace",
+		Ports:             []string{"8888/http", "22/tcp"},
+		Env:               map[string]string{"B": "2", "A": "1"},
+		DockerEntrypoint:  []string{"/bin/sh", "-c"},
+		DockerStartCmd:    []string{"python serve.py --name 'my model'"},
+		Machine:           map[string]interface{}{"secureCloud": false},
+	}
+
+	req, err := buildCloneGQLInput(source, cloneOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.GpuTypeId != "NVIDIA A40" || req.GpuCount != 2 {
+		t.Fatalf("expected source gpu config, got %q x%d", req.GpuTypeId, req.GpuCount)
+	}
+	if req.Name != "trainer" || req.ImageName != "runpod/pytorch:latest" {
+		t.Fatalf("unexpected name/image: %q %q", req.Name, req.ImageName)
+	}
+	if req.Ports != "8888/http,22/tcp" {
+		t.Fatalf("unexpected ports %q", req.Ports)
+	}
+	if req.VolumeInGb != 100 || req.ContainerDiskInGb != 50 {
+		t.Fatalf("unexpected disks: volume %d container %d", req.VolumeInGb, req.ContainerDiskInGb)
+	}
+	if req.DockerArgs != `{"cmd":["python serve.py --name 'my model'"],"entrypoint":["/bin/sh","-c"]}` {
+		t.Fatalf("unexpected docker args %q", req.DockerArgs)
+	}
+	if req.CloudType != "COMMUNITY" {
+		t.Fatalf("expected the source pod's cloud type, got %q", req.CloudType)
+	}
+	if len(req.Env) != 2 || req.Env[0].Key != "A" || req.Env[1].Key != "B" {
+		t.Fatalf("expected sorted env, got %+v", req.Env)
+	}
+	if !req.StartSsh {
+		t.Fatal("expected ssh to be enabled on the clone")
+	}
+}
+
+func TestBuildCloneGQLInput_Overrides(t *testing.T) {
+	source := &api.Pod{ID: "pod-1", Name: "trainer", GpuTypeID: "NVIDIA A40", GpuCount: 1}
+
+	req, err := buildCloneGQLInput(source, cloneOptions{Name: "bigger", GpuTypeID: "NVIDIA H100 80GB HBM3", GpuCount: 4, DataCenter: "EU-RO-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Name != "bigger" || req.GpuTypeId != "NVIDIA H100 80GB HBM3" || req.GpuCount != 4 || req.DataCenterId != "EU-RO-1" {
+		t.Fatalf("overrides not applied: %+v", req)
+	}
+}
+
+func TestBuildCloneGQLInput_NetworkVolumePinsDataCenter(t *testing.T) {
+	source := &api.Pod{
+		ID:            "pod-1",
+		GpuTypeID:     "NVIDIA A40",
+		GpuCount:      1,
+		VolumeInGb:    50,
+		NetworkVolume: &api.NetworkVolume{ID: "vol-1", DataCenterID: "US-GA-1"},
+	}
+
+	req, err := buildCloneGQLInput(source, cloneOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.NetworkVolumeId != "vol-1" || req.DataCenterId != "US-GA-1" || req.VolumeInGb != 0 {
+		t.Fatalf("expected network volume in its data center, got %+v", req)
+	}
+
+	if _, err := buildCloneGQLInput(source, cloneOptions{DataCenter: "EU-RO-1"}); err == nil {
+		t.Fatal("expected error when moving a network volume pod to another data center")
+	}
+}
+
+func TestBuildCloneGQLInput_RequiresGpuType(t *testing.T) {
+	_, err := buildCloneGQLInput(&api.Pod{ID: "pod-1", GpuCount: 1}, cloneOptions{})
+	if err == nil || !strings.Contains(err.Error(), "--gpu-id") {
+		t.Fatalf("expected --gpu-id hint, got %v", err)
+	}
+}
+
+func TestBuildCloneRESTRequest_CPUPod(t *testing.T) {
+	source := &api.Pod{ID: "pod-1", Name: "cpu", ImageName: "ubuntu:22.04", ContainerDiskInGb: 10, ContainerRegistryAuthID: "auth-1"}
+	if !isCPUPod(source) {
+		t.Fatal("expected pod without gpus to be treated as cpu")
+	}
+
+	req, err := buildCloneRESTRequest(source, cloneOptions{DataCenter: "EU-RO-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.ComputeType != "CPU" || req.ImageName != "ubuntu:22.04" || len(req.DataCenterIDs) != 1 || req.ContainerRegistryAuthID != "auth-1" || req.CloudType != "SECURE" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+
+	if _, err := buildCloneRESTRequest(source, cloneOptions{GpuTypeID: "NVIDIA A40"}); err == nil {
+		t.Fatal("expected error for --gpu-id on a cpu pod")
+	}
+}
+
+func TestBuildCloneRESTRequest_SpotPod(t *testing.T) {
+	source := &api.Pod{ID: "pod-1", GpuTypeID: "NVIDIA A40", GpuCount: 2, Interruptible: true}
+
+	req, err := buildCloneRESTRequest(source, cloneOptions{GpuCount: 4, CloudType: "community"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !req.Interruptible || req.ComputeType != "GPU" || len(req.GpuTypeIDs) != 1 || req.GpuTypeIDs[0] != "NVIDIA A40" || req.GpuCount != 4 || req.CloudType != "COMMUNITY" {
+		t.Fatalf("expected a spot gpu request, got %+v", req)
+	}
+}
+
+func TestHasLocalVolumeData(t *testing.T) {
+	if !hasLocalVolumeData(&api.Pod{VolumeInGb: 20}) {
+		t.Fatal("expected local volume to be detected")
+	}
+	if hasLocalVolumeData(&api.Pod{VolumeInGb: 20, NetworkVolume: &api.NetworkVolume{ID: "vol-1"}}) {
+		t.Fatal("network volume data is not local")
+	}
+	if hasLocalVolumeData(&api.Pod{}) {
+		t.Fatal("pod without a volume has no local data")
+	}
+}
+
+type fakePodGetter struct {
+	pods []*api.Pod
+	err  error
+	n    int
+}
+
+func (f *fakePodGetter) GetPod(string, bool, bool) (*api.Pod, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	pod := f.pods[f.n]
+	if f.n < len(f.pods)-1 {
+		f.n++
+	}
+	return pod, nil
+}
+
+func TestWaitForPodRunning(t *testing.T) {
+	getter := &fakePodGetter{pods: []*api.Pod{
+		{DesiredStatus: "RUNNING"},
+		{DesiredStatus: "RUNNING", LastStartedAt: "2026-01-01T00:00:00Z"},
+	}}
+	if err := waitForPodRunning(getter, "pod-2", time.Second, time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failing := &fakePodGetter{err: errors.New("boom")}
+	if err := waitForPodRunning(failing, "pod-2", 5*time.Millisecond, time.Millisecond); err == nil {
+		t.Fatal("expected timeout error")
+	}
+}
@@ -1,13 +1,17 @@
 package pod
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"sort"
 	"strings"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
 	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/registry"
 
 	"github.com/spf13/cobra"
 )
@@ -19,6 +23,11 @@ var createCmd = &cobra.Command{
 
 you can create a pod either from a template or by specifying an image directly.
 
+the image is checked against its registry first: it must exist for
+linux/amd64, and its compressed size is compared with the container disk.
+private images are checked with your local docker credentials when
+--registry-auth-id is set. use --skip-image-check to skip this.
+
 examples:
   # create from template (recommended)
   runpodctl pod create --template-id runpod-torch-v21 --gpu-id "NVIDIA GeForce RTX 4090"
@@ -50,6 +59,8 @@ var (
 	createPublicIP          bool
 	createPorts             string
 	createEnv               string
+	createEnvFile           string
+	createEnvSecrets        []string
 	createCloudType         string
 	createDataCenterIDs     string
 	createSSH               bool
@@ -61,6 +72,7 @@ var (
 	createStopAfter         string
 	createTerminateAfter    string
 	createCompliance        string
+	createSkipImageCheck    bool
 )
 
 func init() {
@@ -77,6 +89,8 @@ func init() {
 	createCmd.Flags().BoolVar(&createPublicIP, "public-ip", false, "require public ip (community cloud only)")
 	createCmd.Flags().StringVar(&createPorts, "ports", "", "comma-separated list of ports (e.g., '8888/http,22/tcp')")
 	createCmd.Flags().StringVar(&createEnv, "env", "", "environment variables as json object")
+	createCmd.Flags().StringVar(&createEnvFile, "env-file", "", "read environment variables from a dotenv file (--env takes precedence)")
+	createCmd.Flags().StringArrayVar(&createEnvSecrets, "env-secret", nil, "set an env var to a runpod secret as KEY=secretName (repeatable)")
 	createCmd.Flags().StringVar(&createCloudType, "cloud-type", "SECURE", "cloud type (SECURE or COMMUNITY)")
 	createCmd.Flags().StringVar(&createDataCenterIDs, "data-center-ids", "", "comma-separated list of data center ids")
 	createCmd.Flags().BoolVar(&createSSH, "ssh", true, "enable ssh on the pod")
@@ -88,214 +102,309 @@ func init() {
 	createCmd.Flags().StringVar(&createStopAfter, "stop-after", "", "auto-stop datetime (e.g., 2026-04-15T00:00:00Z)")
 	createCmd.Flags().StringVar(&createTerminateAfter, "terminate-after", "", "auto-terminate datetime (e.g., 2026-04-15T00:00:00Z)")
 	createCmd.Flags().StringVar(&createCompliance, "compliance", "", "comma-separated compliance requirements (e.g., HIPAA,SOC_2_TYPE_2)")
+	createCmd.Flags().BoolVar(&createSkipImageCheck, "skip-image-check", false, "don't check the image against its registry before creating the pod")
+}
+
+// CreateOptions describe a pod to create. pod create fills them from its
+// flags; other commands that create pods fill them directly.
+type CreateOptions struct {
+	Name              string
+	ImageName         string
+	TemplateID        string
+	ComputeType       string
+	GpuTypeID         string
+	GpuCount          int
+	VolumeInGb        int
+	ContainerDiskInGb int
+	VolumeMountPath   string
+	GlobalNetworking  bool
+	PublicIP          bool
+	Ports             []string
+	Env               map[string]string
+	CloudType         string
+	DataCenterIDs     []string
+	StartSSH          bool
+	NetworkVolumeID   string
+	MinCudaVersion    string
+	DockerArgs        string
+	RegistryAuthID    string
+	CountryCode       string
+	StopAfter         string
+	TerminateAfter    string
+	Compliance        []string
+
+	supportPublicIP bool
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
-	// Validate: either template or image must be provided
-	if createTemplateID == "" && createImageName == "" {
+	opts, err := currentCreateOptions()
+	if err != nil {
+		return err
+	}
+	if err := opts.Validate(cmd.ErrOrStderr()); err != nil {
+		return err
+	}
+
+	if !createSkipImageCheck {
+		if err := checkCreateImage(cmd); err != nil {
+			return err
+		}
+	}
+
+	result, err := Create(opts)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to create pod: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+func currentCreateOptions() (*CreateOptions, error) {
+	env, err := loadCreateEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts := &CreateOptions{
+		Name:              createName,
+		ImageName:         createImageName,
+		TemplateID:        createTemplateID,
+		ComputeType:       createComputeType,
+		GpuTypeID:         createGpuTypeID,
+		GpuCount:          createGpuCount,
+		VolumeInGb:        createVolumeInGb,
+		ContainerDiskInGb: createContainerDiskInGb,
+		VolumeMountPath:   createVolumeMountPath,
+		GlobalNetworking:  createGlobalNetworking,
+		PublicIP:          createPublicIP,
+		Env:               env,
+		CloudType:         createCloudType,
+		StartSSH:          createSSH,
+		NetworkVolumeID:   createNetworkVolumeID,
+		MinCudaVersion:    createMinCudaVersion,
+		DockerArgs:        createDockerArgs,
+		RegistryAuthID:    createRegistryAuthID,
+		CountryCode:       createCountryCode,
+		StopAfter:         createStopAfter,
+		TerminateAfter:    createTerminateAfter,
+	}
+	if createPorts != "" {
+		opts.Ports = strings.Split(createPorts, ",")
+	}
+	if createDataCenterIDs != "" {
+		opts.DataCenterIDs = strings.Split(createDataCenterIDs, ",")
+	}
+	if createCompliance != "" {
+		opts.Compliance = strings.Split(createCompliance, ",")
+	}
+	return opts, nil
+}
+
+// Validate checks the options and fills in the defaulted compute and cloud
+// types. notes about options that have no effect go to stderr.
+func (opts *CreateOptions) Validate(stderr io.Writer) error {
+	// either template or image must be provided
+	if opts.TemplateID == "" && opts.ImageName == "" {
 		return fmt.Errorf("either --template-id or --image is required\n\nuse 'runpodctl template search <term>' to find templates")
 	}
 
-	computeType := strings.ToUpper(strings.TrimSpace(createComputeType))
-	if computeType == "" {
-		computeType = "GPU"
+	computeType := opts.ComputeType
+	opts.ComputeType = strings.ToUpper(strings.TrimSpace(computeType))
+	if opts.ComputeType == "" {
+		opts.ComputeType = "GPU"
 	}
-	switch computeType {
+	switch opts.ComputeType {
 	case "GPU", "CPU":
 	default:
-		return fmt.Errorf("invalid --compute-type %q (use GPU or CPU)", createComputeType)
+		return fmt.Errorf("invalid --compute-type %q (use GPU or CPU)", computeType)
 	}
 
-	gpuTypeID := strings.TrimSpace(createGpuTypeID)
-	if strings.Contains(gpuTypeID, ",") {
+	opts.GpuTypeID = strings.TrimSpace(opts.GpuTypeID)
+	if strings.Contains(opts.GpuTypeID, ",") {
 		return fmt.Errorf("only one gpu id is supported; use --gpu-count for multiple gpus of the same type")
 	}
 
-	if computeType == "CPU" && gpuTypeID != "" {
+	if opts.ComputeType == "CPU" && opts.GpuTypeID != "" {
 		return fmt.Errorf("--gpu-id is not supported for compute type CPU")
 	}
 
-	cloudType := strings.ToUpper(strings.TrimSpace(createCloudType))
-	if cloudType == "" {
-		cloudType = "SECURE"
+	opts.CloudType = strings.ToUpper(strings.TrimSpace(opts.CloudType))
+	if opts.CloudType == "" {
+		opts.CloudType = "SECURE"
 	}
-	if createGlobalNetworking {
-		if computeType != "GPU" {
+	if opts.GlobalNetworking {
+		if opts.ComputeType != "GPU" {
 			return fmt.Errorf("global networking requires compute type GPU")
 		}
-		if cloudType != "SECURE" {
+		if opts.CloudType != "SECURE" {
 			return fmt.Errorf("global networking is only supported on secure cloud (set --cloud-type SECURE)")
 		}
-		if strings.TrimSpace(createDataCenterIDs) != "" {
-			fmt.Fprintln(os.Stderr, "note: global networking availability varies by data center; if create fails, try another secure data center or omit --data-center-ids")
+		if len(opts.DataCenterIDs) > 0 {
+			fmt.Fprintln(stderr, "note: global networking availability varies by data center; if create fails, try another secure data center or omit --data-center-ids")
 		}
 	}
 
-	supportPublicIP := false
-	if createPublicIP {
-		if cloudType == "SECURE" {
-			fmt.Fprintln(os.Stderr, "note: secure cloud pods always have public ips; --public-ip has no effect")
+	opts.supportPublicIP = false
+	if opts.PublicIP {
+		if opts.CloudType == "SECURE" {
+			fmt.Fprintln(stderr, "note: secure cloud pods always have public ips; --public-ip has no effect")
 		}
-		if cloudType == "COMMUNITY" {
-			supportPublicIP = true
+		if opts.CloudType == "COMMUNITY" {
+			opts.supportPublicIP = true
 		}
 	}
 
+	// GraphQL only supports a single dataCenterId
+	if opts.ComputeType == "GPU" && len(opts.DataCenterIDs) > 1 {
+		fmt.Fprintln(stderr, "note: only the first data center id is used; graphql api supports a single data center")
+	}
+	return nil
+}
+
+// Create creates a pod from validated options
+func Create(opts *CreateOptions) (interface{}, error) {
 	var (
 		result interface{}
 		err    error
 	)
-
-	if computeType == "CPU" {
+	if opts.ComputeType == "CPU" {
 		// CPU pods use the REST API (GraphQL requires gpuTypeId)
-		result, err = createPodREST(computeType, gpuTypeID, cloudType, supportPublicIP)
+		result, err = createPodREST(opts)
 	} else {
 		// GPU pods use GraphQL (supports startSsh)
-		result, err = createPodGraphQL(gpuTypeID, cloudType, supportPublicIP)
+		result, err = createPodGraphQL(opts)
 	}
-	if err != nil {
-		if createGlobalNetworking {
-			err = decorateGlobalNetworkingError(err, createDataCenterIDs)
+	if err != nil && opts.GlobalNetworking {
+		err = decorateGlobalNetworkingError(err, strings.Join(opts.DataCenterIDs, ","))
+	}
+	return result, err
+}
+
+// checkCreateImage checks the image the pod will pull, --image or the
+// template's, before the pod starts billing in an image-pull loop
+func checkCreateImage(cmd *cobra.Command) error {
+	image, authID := createImageName, strings.TrimSpace(createRegistryAuthID)
+	if image == "" {
+		client, err := api.NewClient()
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+		template, err := client.GetTemplate(createTemplateID)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "note: couldn't check the image of template %s: %v\n", createTemplateID, err)
+			return nil
+		}
+		image = template.ImageName
+		if authID == "" {
+			authID = template.ContainerRegistryAuthID
 		}
+	}
+	_, err := registry.Preflight(context.Background(), cmd.ErrOrStderr(), image, registry.PreflightOptions{
+		RegistryAuthID:    authID,
+		ContainerDiskInGb: createContainerDiskInGb,
+	})
+	if err != nil {
 		output.Error(err)
-		return fmt.Errorf("failed to create pod: %w", err)
 	}
-
-	format := output.ParseFormat(cmd.Flag("output").Value.String())
-	return output.Print(result, &output.Config{Format: format})
+	return err
 }
 
-func createPodGraphQL(gpuTypeID, cloudType string, supportPublicIP bool) (map[string]interface{}, error) {
+func createPodGraphQL(opts *CreateOptions) (map[string]interface{}, error) {
 	gqlClient, err := api.NewGraphQLClient()
 	if err != nil {
 		return nil, err
 	}
 
 	req := &api.CreatePodGQLInput{
-		CloudType:         cloudType,
-		ContainerDiskInGb: createContainerDiskInGb,
-		GpuCount:          createGpuCount,
-		GpuTypeId:         gpuTypeID,
-		ImageName:         createImageName,
-		Name:              createName,
-		StartSsh:          createSSH,
-		SupportPublicIp:   supportPublicIP,
-		TemplateId:        createTemplateID,
-		VolumeInGb:        createVolumeInGb,
-		VolumeMountPath:   createVolumeMountPath,
-	}
-
-	if createNetworkVolumeID != "" {
-		req.NetworkVolumeId = createNetworkVolumeID
-	}
-
-	if createPorts != "" {
-		req.Ports = createPorts
+		CloudType:               opts.CloudType,
+		ContainerDiskInGb:       opts.ContainerDiskInGb,
+		GpuCount:                opts.GpuCount,
+		GpuTypeId:               opts.GpuTypeID,
+		ImageName:               opts.ImageName,
+		Name:                    opts.Name,
+		StartSsh:                opts.StartSSH,
+		SupportPublicIp:         opts.supportPublicIP,
+		TemplateId:              opts.TemplateID,
+		VolumeInGb:              opts.VolumeInGb,
+		VolumeMountPath:         opts.VolumeMountPath,
+		NetworkVolumeId:         opts.NetworkVolumeID,
+		Ports:                   strings.Join(opts.Ports, ","),
+		MinCudaVersion:          opts.MinCudaVersion,
+		DockerArgs:              opts.DockerArgs,
+		ContainerRegistryAuthId: strings.TrimSpace(opts.RegistryAuthID),
+		CountryCode:             opts.CountryCode,
+		StopAfter:               opts.StopAfter,
+		TerminateAfter:          opts.TerminateAfter,
+		Compliance:              opts.Compliance,
 	}
 
 	// GraphQL only supports a single dataCenterId
-	if createDataCenterIDs != "" {
-		ids := strings.Split(createDataCenterIDs, ",")
-		req.DataCenterId = strings.TrimSpace(ids[0])
-		if len(ids) > 1 {
-			fmt.Fprintln(os.Stderr, "note: only the first data center id is used; graphql api supports a single data center")
-		}
-	}
-
-	if createMinCudaVersion != "" {
-		req.MinCudaVersion = createMinCudaVersion
+	if len(opts.DataCenterIDs) > 0 {
+		req.DataCenterId = strings.TrimSpace(opts.DataCenterIDs[0])
 	}
 
-	if createDockerArgs != "" {
-		req.DockerArgs = createDockerArgs
+	keys := make([]string, 0, len(opts.Env))
+	for k := range opts.Env {
+		keys = append(keys, k)
 	}
-
-	if trimmed := strings.TrimSpace(createRegistryAuthID); trimmed != "" {
-		req.ContainerRegistryAuthId = trimmed
-	}
-
-	if createCountryCode != "" {
-		req.CountryCode = createCountryCode
-	}
-
-	if createStopAfter != "" {
-		req.StopAfter = createStopAfter
-	}
-
-	if createTerminateAfter != "" {
-		req.TerminateAfter = createTerminateAfter
-	}
-
-	if createCompliance != "" {
-		req.Compliance = strings.Split(createCompliance, ",")
-	}
-
-	if createEnv != "" {
-		var envMap map[string]string
-		if err := json.Unmarshal([]byte(createEnv), &envMap); err != nil {
-			return nil, fmt.Errorf("invalid env json: %w", err)
-		}
-		for k, v := range envMap {
-			req.Env = append(req.Env, &api.PodEnvVar{Key: k, Value: v})
-		}
+	sort.Strings(keys)
+	for _, k := range keys {
+		req.Env = append(req.Env, &api.PodEnvVar{Key: k, Value: opts.Env[k]})
 	}
 
 	return gqlClient.CreatePod(req)
 }
 
-func createPodREST(computeType, gpuTypeID, cloudType string, supportPublicIP bool) (*api.Pod, error) {
+func createPodREST(opts *CreateOptions) (*api.Pod, error) {
 	client, err := api.NewClient()
 	if err != nil {
 		return nil, err
 	}
 
 	req := &api.PodCreateRequest{
-		Name:              createName,
-		ImageName:         createImageName,
-		TemplateID:        createTemplateID,
-		ComputeType:       computeType,
-		GlobalNetworking:  createGlobalNetworking,
-		SupportPublicIp:   supportPublicIP,
-		GpuCount:          0,
-		VolumeInGb:        createVolumeInGb,
-		ContainerDiskInGb: createContainerDiskInGb,
-		VolumeMountPath:   createVolumeMountPath,
-		CloudType:         cloudType,
-	}
-
-	if gpuTypeID != "" {
-		req.GpuTypeIDs = []string{gpuTypeID}
+		Name:                    opts.Name,
+		ImageName:               opts.ImageName,
+		TemplateID:              opts.TemplateID,
+		ComputeType:             opts.ComputeType,
+		GlobalNetworking:        opts.GlobalNetworking,
+		SupportPublicIp:         opts.supportPublicIP,
+		GpuCount:                0,
+		VolumeInGb:              opts.VolumeInGb,
+		ContainerDiskInGb:       opts.ContainerDiskInGb,
+		VolumeMountPath:         opts.VolumeMountPath,
+		CloudType:               opts.CloudType,
+		NetworkVolumeID:         opts.NetworkVolumeID,
+		Ports:                   opts.Ports,
+		DataCenterIDs:           opts.DataCenterIDs,
+		MinCudaVersion:          opts.MinCudaVersion,
+		DockerArgs:              opts.DockerArgs,
+		ContainerRegistryAuthID: strings.TrimSpace(opts.RegistryAuthID),
+		Env:                     opts.Env,
+	}
+
+	if opts.GpuTypeID != "" {
+		req.GpuTypeIDs = []string{opts.GpuTypeID}
 	}
 
-	if createNetworkVolumeID != "" {
-		req.NetworkVolumeID = createNetworkVolumeID
-	}
-
-	if createPorts != "" {
-		req.Ports = strings.Split(createPorts, ",")
-	}
-
-	if createDataCenterIDs != "" {
-		req.DataCenterIDs = strings.Split(createDataCenterIDs, ",")
-	}
-
-	if createMinCudaVersion != "" {
-		req.MinCudaVersion = createMinCudaVersion
-	}
-
-	if createDockerArgs != "" {
-		req.DockerArgs = createDockerArgs
-	}
+	return client.CreatePod(req)
+}
 
+// loadCreateEnv combines --env-file with the --env json object; --env wins.
+// --env-secret references are added last and may not repeat a key.
+func loadCreateEnv() (map[string]string, error) {
+	var env map[string]string
 	if createEnv != "" {
-		var env map[string]string
 		if err := json.Unmarshal([]byte(createEnv), &env); err != nil {
 			return nil, fmt.Errorf("invalid env json: %w", err)
 		}
-		req.Env = env
 	}
-
-	return client.CreatePod(req)
+	env, err := envvars.Load(createEnvFile, env)
+	if err != nil {
+		return nil, err
+	}
+	return envvars.WithSecretRefs(env, createEnvSecrets)
 }
 
 func decorateGlobalNetworkingError(err error, dataCenterIDs string) error {
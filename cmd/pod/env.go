@@ -0,0 +1,154 @@
+package pod
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "manage pod environment variables",
+	Long: `list, set and unset environment variables on a pod.
+
+set and unset read the pod's current env, apply the change and write the full
+env back, so other variables are kept. values are masked unless --show-values
+is given. applying env changes may restart the pod.`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list <pod-id>",
+	Short: "list pod environment variables",
+	Long:  "list environment variables on a pod",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEnvList,
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set <pod-id> [KEY=VALUE...]",
+	Short: "set pod environment variables",
+	Long:  "add or change environment variables on a pod, keeping the others",
+	Example: `  runpodctl pod env set <pod-id> HF_HOME=/workspace/hf LOG_LEVEL=debug
+  runpodctl pod env set <pod-id> --env-file .env`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEnvSet,
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset <pod-id> KEY...",
+	Short: "unset pod environment variables",
+	Long:  "remove environment variables from a pod, keeping the others",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runEnvUnset,
+}
+
+var (
+	envShowValues bool
+	envSetFile    string
+)
+
+func init() {
+	envCmd.PersistentFlags().BoolVar(&envShowValues, "show-values", false, "show env values instead of masking them")
+	envSetCmd.Flags().StringVar(&envSetFile, "env-file", "", "read KEY=VALUE lines from a dotenv file")
+
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envUnsetCmd)
+}
+
+func runEnvList(cmd *cobra.Command, args []string) error {
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	pod, err := client.GetPod(args[0], false, false)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	return printPodEnv(cmd, pod.ID, pod.Env)
+}
+
+func runEnvSet(cmd *cobra.Command, args []string) error {
+	podID := args[0]
+
+	assignments, err := envvars.ParseAssignments(args[1:])
+	if err != nil {
+		return err
+	}
+	updates, err := envvars.Load(envSetFile, assignments)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return fmt.Errorf("nothing to set; pass KEY=VALUE arguments or --env-file")
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	pod, err := client.GetPod(podID, false, false)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get existing pod env: %w", err)
+	}
+
+	env := envvars.Merge(pod.Env, updates)
+	if _, err := client.UpdatePodEnv(podID, env); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to update pod env: %w", err)
+	}
+
+	return printPodEnv(cmd, podID, env)
+}
+
+func runEnvUnset(cmd *cobra.Command, args []string) error {
+	podID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	pod, err := client.GetPod(podID, false, false)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get existing pod env: %w", err)
+	}
+
+	env, missing := envvars.Unset(pod.Env, args[1:])
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "note: not set on pod %s: %s\n", podID, strings.Join(missing, ", "))
+	}
+	if len(missing) == len(args[1:]) {
+		return printPodEnv(cmd, podID, pod.Env)
+	}
+
+	if _, err := client.UpdatePodEnv(podID, env); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to update pod env: %w", err)
+	}
+
+	return printPodEnv(cmd, podID, env)
+}
+
+func printPodEnv(cmd *cobra.Command, podID string, env map[string]string) error {
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"id":  podID,
+		"env": envvars.Mask(env, envShowValues),
+	}, &output.Config{Format: format})
+}
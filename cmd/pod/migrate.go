@@ -0,0 +1,151 @@
+package pod
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate <pod-id>",
+	Short: "move a pod to new hardware",
+	Long: `clone a pod, wait for the copy to be running, then stop or delete the original.
+if the copy isn't running within --timeout, it is deleted and the original is kept.
+
+migrate refuses to run when the pod keeps data on a local volume, because that
+data stays on the old host. pass --force to migrate anyway.
+
+examples:
+  # move to another gpu and stop the original
+  runpodctl pod migrate <pod-id> --gpu-id "NVIDIA A100 80GB PCIe"
+
+  # move and delete the original
+  runpodctl pod migrate <pod-id> --then delete`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMigrate,
+}
+
+const migratePollInterval = 5 * time.Second
+
+var (
+	migrateThen    string
+	migrateTimeout time.Duration
+	migrateForce   bool
+)
+
+func init() {
+	migrateCmd.Flags().StringVar(&cloneName, "name", "", "name for the new pod (default: source pod name)")
+	migrateCmd.Flags().StringVar(&cloneGpuTypeID, "gpu-id", "", "gpu id for the new pod (default: source pod gpu)")
+	migrateCmd.Flags().IntVar(&cloneGpuCount, "gpu-count", 0, "number of gpus for the new pod (default: source pod gpu count)")
+	migrateCmd.Flags().StringVar(&cloneDataCenter, "datacenter", "", "data center id for the new pod (must match the network volume, if any)")
+	migrateCmd.Flags().StringVar(&cloneCloudType, "cloud-type", "", "cloud type, SECURE or COMMUNITY (default: source pod cloud type)")
+	migrateCmd.Flags().StringVar(&migrateThen, "then", "stop", "what to do with the original pod once the copy is running (stop or delete)")
+	migrateCmd.Flags().DurationVar(&migrateTimeout, "timeout", 15*time.Minute, "maximum time to wait for the new pod to be running")
+	migrateCmd.Flags().BoolVar(&migrateForce, "force", false, "migrate even if the pod's local volume data would be lost")
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	podID := args[0]
+
+	then := strings.ToLower(strings.TrimSpace(migrateThen))
+	if then != "stop" && then != "delete" {
+		return fmt.Errorf("invalid --then %q (use stop or delete)", migrateThen)
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	source, err := client.GetPod(podID, true, true)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	if hasLocalVolumeData(source) && !migrateForce {
+		err := fmt.Errorf("pod %s has a %dgb local volume at %s that would not be migrated; copy the data to a network volume first or pass --force", source.ID, source.VolumeInGb, source.VolumeMountPath)
+		output.Error(err)
+		return err
+	}
+
+	clone, err := clonePod(client, source, currentCloneOptions())
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to clone pod: %w", err)
+	}
+
+	newID, _ := clone["id"].(string)
+	if newID == "" {
+		err := fmt.Errorf("clone of pod %s returned no pod id", source.ID)
+		output.Error(err)
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "created pod %s, waiting for it to be running...\n", newID)
+
+	if err := waitForPodRunning(client, newID, migrateTimeout, migratePollInterval); err != nil {
+		// don't leave a pod that never came up billing next to the original
+		if deleteErr := client.DeletePod(newID); deleteErr != nil {
+			err = fmt.Errorf("%w; new pod %s could not be deleted (%v) and is still there; original pod %s was kept", err, newID, deleteErr, source.ID)
+		} else {
+			err = fmt.Errorf("%w; new pod %s was deleted and original pod %s was kept", err, newID, source.ID)
+		}
+		output.Error(err)
+		return err
+	}
+
+	if then == "delete" {
+		err = client.DeletePod(source.ID)
+	} else {
+		_, err = client.StopPod(source.ID)
+	}
+	if err != nil {
+		err = fmt.Errorf("new pod %s is running but failed to %s original pod %s: %w", newID, then, source.ID, err)
+		output.Error(err)
+		return err
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"id":           newID,
+		"migratedFrom": source.ID,
+		"original":     then,
+	}, &output.Config{Format: format})
+}
+
+type podGetter interface {
+	GetPod(string, bool, bool) (*api.Pod, error)
+}
+
+// waitForPodRunning polls until the pod reports RUNNING and has actually
+// started on a machine, or the timeout elapses.
+func waitForPodRunning(client podGetter, podID string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		pod, err := client.GetPod(podID, false, false)
+		if err == nil && podIsRunning(pod) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("timed out waiting for pod %s to be running: %w", podID, err)
+			}
+			return fmt.Errorf("timed out waiting for pod %s to be running (status %s)", podID, pod.DesiredStatus)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func podIsRunning(pod *api.Pod) bool {
+	if pod == nil || !strings.EqualFold(pod.DesiredStatus, "RUNNING") {
+		return false
+	}
+	return pod.LastStartedAt != "" || len(pod.Runtime) > 0
+}
@@ -22,4 +22,7 @@ func init() {
 	Cmd.AddCommand(restartCmd)
 	Cmd.AddCommand(resetCmd)
 	Cmd.AddCommand(deleteCmd)
+	Cmd.AddCommand(cloneCmd)
+	Cmd.AddCommand(migrateCmd)
+	Cmd.AddCommand(envCmd)
 }
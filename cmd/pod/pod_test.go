@@ -2,10 +2,18 @@ package pod
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/runpod/runpodctl/internal/registry/registrytest"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func TestPodCmd_Structure(t *testing.T) {
@@ -25,7 +33,7 @@ func TestPodCmd_Structure(t *testing.T) {
 	}
 
 	// check subcommands exist
-	expectedSubcommands := []string{"list", "get <pod-id>", "create", "update <pod-id>", "start <pod-id>", "stop <pod-id>", "restart <pod-id>", "reset <pod-id>", "delete <pod-id>"}
+	expectedSubcommands := []string{"list", "get <pod-id>", "create", "update <pod-id>", "start <pod-id>", "stop <pod-id>", "restart <pod-id>", "reset <pod-id>", "delete <pod-id>", "clone <pod-id>", "migrate <pod-id>"}
 	for _, expected := range expectedSubcommands {
 		found := false
 		for _, cmd := range Cmd.Commands() {
@@ -97,12 +105,80 @@ func TestCreateCmd_Flags(t *testing.T) {
 	if flags.Lookup("ssh") == nil {
 		t.Error("expected --ssh flag")
 	}
+	if flags.Lookup("skip-image-check") == nil {
+		t.Error("expected --skip-image-check flag")
+	}
 	sshFlag := flags.Lookup("ssh")
 	if sshFlag.DefValue != "true" {
 		t.Errorf("expected --ssh default to be true, got %s", sshFlag.DefValue)
 	}
 }
 
+func TestCreateOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CreateOptions
+		want string
+	}{
+		{"no image", CreateOptions{GpuCount: 1}, "--template-id or --image"},
+		{"bad compute type", CreateOptions{ImageName: "x", ComputeType: "tpu"}, "invalid --compute-type"},
+		{"gpu on cpu", CreateOptions{ImageName: "x", ComputeType: "cpu", GpuTypeID: "NVIDIA A40"}, "--gpu-id is not supported"},
+		{"global networking on community", CreateOptions{ImageName: "x", GpuCount: 1, CloudType: "community", GlobalNetworking: true}, "secure cloud"},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(io.Discard); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	opts := CreateOptions{ImageName: "x", ComputeType: " cpu ", CloudType: "community", PublicIP: true}
+	if err := opts.Validate(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if opts.ComputeType != "CPU" || opts.CloudType != "COMMUNITY" || !opts.supportPublicIP {
+		t.Fatalf("unexpected normalized options %+v", opts)
+	}
+}
+
+func TestCheckCreateImage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registryServer := registrytest.NewServer(t)
+	registryServer.Push("team/app", "v1", "linux/amd64", 1<<20)
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/templates/tpl-1" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"id":"tpl-1","imageName":%q}`, registryServer.Image("team/app", "v2"))
+	}))
+	t.Cleanup(apiServer.Close)
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	viper.Set("restApiUrl", apiServer.URL)
+	t.Cleanup(func() { viper.Set("restApiUrl", "") })
+
+	image, templateID, disk := createImageName, createTemplateID, createContainerDiskInGb
+	t.Cleanup(func() { createImageName, createTemplateID, createContainerDiskInGb = image, templateID, disk })
+	createContainerDiskInGb = 20
+
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	createImageName = registryServer.Image("team/app", "v1")
+	if err := checkCreateImage(cmd); err != nil {
+		t.Fatalf("expected the image to pass, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "1.0 MiB compressed") {
+		t.Fatalf("expected the compressed size to be reported, got %q", stderr.String())
+	}
+
+	// the template's image is checked when there's no --image
+	createImageName, createTemplateID = "", "tpl-1"
+	if err := checkCreateImage(cmd); err == nil || !strings.Contains(err.Error(), "team/app:v2 not found") {
+		t.Fatalf("expected the template's missing tag to fail, got %v", err)
+	}
+}
+
 func TestDeleteCmd_Aliases(t *testing.T) {
 	aliases := deleteCmd.Aliases
 	hasRm := false
@@ -245,3 +321,25 @@ func TestParseCreatedAt(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvCmd_Subcommands(t *testing.T) {
+	expected := []string{"list <pod-id>", "set <pod-id> [KEY=VALUE...]", "unset <pod-id> KEY..."}
+	for _, use := range expected {
+		found := false
+		for _, sub := range envCmd.Commands() {
+			if sub.Use == use {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected env subcommand %s not found", use)
+		}
+	}
+	if envCmd.PersistentFlags().Lookup("show-values") == nil {
+		t.Error("expected --show-values flag")
+	}
+	if createCmd.Flags().Lookup("env-file") == nil || updateCmd.Flags().Lookup("env-file") == nil {
+		t.Error("expected --env-file on pod create and update")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
 	"github.com/runpod/runpodctl/internal/output"
 
 	"github.com/spf13/cobra"
@@ -27,6 +28,7 @@ var (
 	updateVolumeMountPath   string
 	updatePorts             string
 	updateEnv               string
+	updateEnvFile           string
 )
 
 func init() {
@@ -36,7 +38,8 @@ func init() {
 	updateCmd.Flags().IntVar(&updateVolumeInGb, "volume-in-gb", 0, "new volume size in gb")
 	updateCmd.Flags().StringVar(&updateVolumeMountPath, "volume-mount-path", "", "new volume mount path")
 	updateCmd.Flags().StringVar(&updatePorts, "ports", "", "new comma-separated list of ports")
-	updateCmd.Flags().StringVar(&updateEnv, "env", "", "new environment variables as json object")
+	updateCmd.Flags().StringVar(&updateEnv, "env", "", "environment variables as json object, merged into the existing env")
+	updateCmd.Flags().StringVar(&updateEnvFile, "env-file", "", "read environment variables from a dotenv file, merged into the existing env (--env takes precedence)")
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
@@ -68,17 +71,24 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if updatePorts != "" {
 		req.Ports = strings.Split(updatePorts, ",")
 	}
-	if updateEnv != "" {
-		env, err := parseUpdateEnv(updateEnv)
+	if updateEnv != "" || updateEnvFile != "" {
+		var env map[string]string
+		if updateEnv != "" {
+			env, err = parseUpdateEnv(updateEnv)
+			if err != nil {
+				return fmt.Errorf("invalid env json: %w", err)
+			}
+		}
+		env, err = envvars.Load(updateEnvFile, env)
 		if err != nil {
-			return fmt.Errorf("invalid env json: %w", err)
+			return err
 		}
 		pod, err := client.GetPod(podID, false, false)
 		if err != nil {
 			output.Error(err)
 			return fmt.Errorf("failed to get existing pod env: %w", err)
 		}
-		req.Env = mergeEnvMaps(pod.Env, env)
+		req.Env = envvars.Merge(pod.Env, env)
 	}
 
 	pod, err := client.UpdatePod(podID, req)
@@ -98,18 +108,3 @@ func parseUpdateEnv(raw string) (map[string]string, error) {
 	}
 	return env, nil
 }
-
-func mergeEnvMaps(existing, updates map[string]string) map[string]string {
-	if len(existing) == 0 && len(updates) == 0 {
-		return nil
-	}
-
-	merged := make(map[string]string, len(existing)+len(updates))
-	for k, v := range existing {
-		merged[k] = v
-	}
-	for k, v := range updates {
-		merged[k] = v
-	}
-	return merged
-}
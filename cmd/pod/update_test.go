@@ -1,6 +1,10 @@
 package pod
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/envvars"
+)
 
 func TestMergeEnvMaps(t *testing.T) {
 	existing := map[string]string{
@@ -12,7 +16,7 @@ func TestMergeEnvMaps(t *testing.T) {
 		"OTHER_VAR": "changed",
 	}
 
-	merged := mergeEnvMaps(existing, updates)
+	merged := envvars.Merge(existing, updates)
 	if merged["PUBLIC_KEY"] != "ssh-ed25519 aaa" {
 		t.Fatalf("expected PUBLIC_KEY to be preserved, got %q", merged["PUBLIC_KEY"])
 	}
@@ -35,7 +39,7 @@ func TestParseUpdateEnv(t *testing.T) {
 }
 
 func TestMergeEnvMapsNil(t *testing.T) {
-	if merged := mergeEnvMaps(nil, nil); merged != nil {
+	if merged := envvars.Merge(nil, nil); merged != nil {
 		t.Fatalf("expected nil merge result, got %#v", merged)
 	}
 }
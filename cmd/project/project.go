@@ -249,7 +249,7 @@ var StartProjectCmd = &cobra.Command{
 	Aliases: []string{"start"},
 	Args:    cobra.ExactArgs(0),
 	Short:   "start a development session for the current project",
-	Long:    "this command establishes a connection between your local development environment and your runpod project environment, allowing for real-time synchronization of changes.",
+	Long:    "this command establishes a connection between your local development environment and your runpod project environment, allowing for real-time synchronization of changes.\n\nto test a worker handler locally without a gpu, use 'runpodctl serverless dev' instead.",
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check for the existence of 'runpod.toml' in the current directory
 		if _, err := os.Stat("runpod.toml"); os.IsNotExist(err) {
@@ -12,9 +12,11 @@ import (
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "create a new registry auth",
-	Long:  "create a new container registry authentication",
-	Args:  cobra.NoArgs,
-	RunE:  runCreate,
+	Long:  "create a new container registry authentication. the password is read from stdin",
+	Example: `  printf '%s' "$GHCR_TOKEN" | runpodctl registry create --name ghcr --username me
+  runpodctl registry create --name dockerhub --username me   # prompts for the password`,
+	Args: cobra.NoArgs,
+	RunE: runCreate,
 }
 
 var (
@@ -26,14 +28,18 @@ var (
 func init() {
 	createCmd.Flags().StringVar(&createName, "name", "", "registry auth name (required)")
 	createCmd.Flags().StringVar(&createUsername, "username", "", "registry username (required)")
-	createCmd.Flags().StringVar(&createPassword, "password", "", "registry password (required)")
+	createCmd.Flags().StringVar(&createPassword, "password", "", "registry password (default: read from stdin; argv is visible to other processes)")
 
 	createCmd.MarkFlagRequired("name")     //nolint:errcheck
 	createCmd.MarkFlagRequired("username") //nolint:errcheck
-	createCmd.MarkFlagRequired("password") //nolint:errcheck
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
+	password, err := readPassword(createPassword, cmd.InOrStdin(), cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+
 	client, err := api.NewClient()
 	if err != nil {
 		output.Error(err)
@@ -43,7 +49,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	req := &api.ContainerRegistryAuthCreateRequest{
 		Name:     createName,
 		Username: createUsername,
-		Password: createPassword,
+		Password: password,
 	}
 
 	auth, err := client.CreateContainerRegistryAuth(req)
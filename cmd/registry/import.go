@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/dockerconfig"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import registry auths from a docker config",
+	Long: `create a registry auth for each registry you are logged in to with docker.
+credentials come from the auths of the docker config, or from the credential
+helper it names (credsStore or credHelpers), run as docker-credential-<helper>.
+
+each auth is named after its registry host, e.g. ghcr.io or docker.io. a
+registry that already has an auth of that name is skipped, unless --overwrite
+is given, in which case its password is rotated and its id kept.`,
+	Example: `  runpodctl registry import --from-docker-config
+  runpodctl registry import --from-docker-config ./config.json --registry ghcr.io`,
+	Args: cobra.NoArgs,
+	RunE: runImport,
+}
+
+var (
+	importDockerConfig string
+	importRegistries   []string
+	importOverwrite    bool
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importDockerConfig, "from-docker-config", "", "docker config to read (default "+dockerconfig.DefaultPath+" or $DOCKER_CONFIG/config.json) (required)")
+	importCmd.Flags().Lookup("from-docker-config").NoOptDefVal = dockerconfig.DefaultPath
+	importCmd.Flags().StringArrayVar(&importRegistries, "registry", nil, "only import this registry (repeatable)")
+	importCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "rotate the password of existing auths with the same name")
+
+	importCmd.MarkFlagRequired("from-docker-config") //nolint:errcheck
+}
+
+type registryCredentials struct {
+	registry string
+	*dockerconfig.Credentials
+}
+
+type importResult struct {
+	Registry string `json:"registry"`
+	Name     string `json:"name"`
+	ID       string `json:"id,omitempty"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	path, err := dockerconfig.Path(importDockerConfig)
+	if err != nil {
+		return err
+	}
+	config, err := dockerconfig.Load(path)
+	if err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, registry := range importRegistries {
+		wanted[dockerconfig.NormalizeRegistry(registry)] = true
+	}
+
+	var results []*importResult
+	var creds []*registryCredentials
+	for _, server := range config.Servers() {
+		registry := dockerconfig.NormalizeRegistry(server)
+		if len(wanted) > 0 && !wanted[registry] {
+			continue
+		}
+		cred, err := config.Credentials(server)
+		if err != nil {
+			results = append(results, &importResult{Registry: registry, Name: registry, Action: "skipped", Reason: err.Error()})
+			continue
+		}
+		creds = append(creds, &registryCredentials{registry: registry, Credentials: cred})
+	}
+	for registry := range wanted {
+		if !containsRegistry(creds, results, registry) {
+			return fmt.Errorf("no credentials for %s in %s", registry, path)
+		}
+	}
+	if len(creds) == 0 && len(results) == 0 {
+		return fmt.Errorf("no registries found in %s", path)
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	existing, err := client.ListContainerRegistryAuths()
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to list registry auths: %w", err)
+	}
+	byName := map[string]string{}
+	for _, auth := range existing {
+		byName[auth.Name] = auth.ID
+	}
+
+	for _, cred := range creds {
+		result := &importResult{Registry: cred.registry, Name: cred.registry}
+		results = append(results, result)
+		if id, ok := byName[cred.registry]; ok {
+			result.ID = id
+			if !importOverwrite {
+				result.Action = "skipped"
+				result.Reason = "an auth with this name exists; use --overwrite to rotate it"
+				continue
+			}
+			if _, err := rotateAuth(id, cred.Username, cred.Password); err != nil {
+				return err
+			}
+			result.Action = "rotated"
+			fmt.Fprintf(cmd.ErrOrStderr(), "rotated %s (%s)\n", cred.registry, id)
+			continue
+		}
+		auth, err := client.CreateContainerRegistryAuth(&api.ContainerRegistryAuthCreateRequest{
+			Name:     cred.registry,
+			Username: cred.Username,
+			Password: cred.Password,
+		})
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to create registry auth for %s: %w", cred.registry, err)
+		}
+		result.ID = auth.ID
+		result.Action = "created"
+		fmt.Fprintf(cmd.ErrOrStderr(), "created %s (%s)\n", cred.registry, auth.ID)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Registry < results[j].Registry })
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(results, &output.Config{Format: format})
+}
+
+func containsRegistry(creds []*registryCredentials, results []*importResult, registry string) bool {
+	for _, cred := range creds {
+		if cred.registry == registry {
+			return true
+		}
+	}
+	for _, result := range results {
+		if result.Registry == registry {
+			return true
+		}
+	}
+	return false
+}
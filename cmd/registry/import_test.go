@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/dockerconfig"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// fakeRegistryAPI serves the rest registry auth endpoints and the graphql
+// update mutation
+type fakeRegistryAPI struct {
+	auths   []map[string]string
+	created []map[string]string
+	updated []map[string]string
+}
+
+func startRegistryAPI(t *testing.T, auths ...map[string]string) *fakeRegistryAPI {
+	t.Helper()
+	fake := &fakeRegistryAPI{auths: auths}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/containerregistryauth":
+			_ = json.NewEncoder(w).Encode(fake.auths)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/containerregistryauth/"):
+			id := strings.TrimPrefix(r.URL.Path, "/containerregistryauth/")
+			for _, auth := range fake.auths {
+				if auth["id"] == id {
+					_ = json.NewEncoder(w).Encode(auth)
+					return
+				}
+			}
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/containerregistryauth":
+			var req map[string]string
+			_ = json.Unmarshal(body, &req)
+			fake.created = append(fake.created, req)
+			_, _ = fmt.Fprintf(w, `{"id":"new-%d","name":%q}`, len(fake.created), req["name"])
+		case r.Method == http.MethodPost:
+			var req struct {
+				Variables struct {
+					Input map[string]string `json:"input"`
+				} `json:"variables"`
+			}
+			_ = json.Unmarshal(body, &req)
+			fake.updated = append(fake.updated, req.Variables.Input)
+			_, _ = fmt.Fprintf(w, `{"data":{"updateRegistryAuth":{"id":%q,"name":"renamed"}}}`, req.Variables.Input["id"])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	viper.Set("restApiUrl", server.URL)
+	viper.Set("apiUrl", server.URL)
+	t.Cleanup(func() {
+		viper.Set("restApiUrl", "")
+		viper.Set("apiUrl", "")
+	})
+	return fake
+}
+
+func resetImportVars(t *testing.T) {
+	t.Helper()
+	config, registries, overwrite := importDockerConfig, importRegistries, importOverwrite
+	username, password := rotateUsername, rotatePassword
+	helper := dockerconfig.RunCredentialHelper
+	t.Cleanup(func() {
+		importDockerConfig, importRegistries, importOverwrite = config, registries, overwrite
+		rotateUsername, rotatePassword = username, password
+		dockerconfig.RunCredentialHelper = helper
+	})
+}
+
+func runRegistryCmd(t *testing.T, run func(*cobra.Command, []string) error, args ...string) (string, error) {
+	t.Helper()
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	cmd.SetErr(io.Discard)
+
+	origStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stdout: %v", err)
+	}
+	os.Stdout = w
+	runErr := run(cmd, args)
+	_ = w.Close()
+	os.Stdout = origStdout
+	printed, _ := io.ReadAll(r)
+	_ = r.Close()
+	return string(printed), runErr
+}
+
+func writeDockerConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunImport(t *testing.T) {
+	fake := startRegistryAPI(t, map[string]string{"id": "auth-1", "name": "docker.io"})
+	resetImportVars(t)
+
+	ghcr := base64.StdEncoding.EncodeToString([]byte("me:ghcr-token"))
+	importDockerConfig = writeDockerConfig(t, `{
+		"auths": {
+			"ghcr.io": {"auth": "`+ghcr+`"},
+			"https://index.docker.io/v1/": {},
+			"quay.io": {"identitytoken": "abc"},
+			"gcr.io": {}
+		},
+		"credsStore": "desktop",
+		"credHelpers": {"gcr.io": "gcloud"}
+	}`)
+	var helperCalls []string
+	dockerconfig.RunCredentialHelper = func(helper, action, input string) ([]byte, error) {
+		helperCalls = append(helperCalls, helper+" "+action+" "+input)
+		switch input {
+		case "https://index.docker.io/v1/":
+			return []byte(`{"ServerURL":"https://index.docker.io/v1/","Username":"me","Secret":"hub-token"}`), nil
+		case "gcr.io":
+			return []byte(`{"ServerURL":"gcr.io","Username":"<token>","Secret":"refresh"}`), nil
+		}
+		return nil, fmt.Errorf("credentials not found")
+	}
+
+	stdout, err := runRegistryCmd(t, runImport)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	var results []importResult
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	actions := map[string]string{}
+	for _, result := range results {
+		actions[result.Registry] = result.Action
+	}
+	want := map[string]string{"docker.io": "skipped", "gcr.io": "skipped", "ghcr.io": "created", "quay.io": "skipped"}
+	if fmt.Sprint(actions) != fmt.Sprint(want) {
+		t.Fatalf("unexpected actions %v\n%s", actions, stdout)
+	}
+	if len(fake.created) != 1 || fake.created[0]["name"] != "ghcr.io" || fake.created[0]["username"] != "me" || fake.created[0]["password"] != "ghcr-token" {
+		t.Fatalf("unexpected creates %v", fake.created)
+	}
+	if strings.Join(helperCalls, ",") != "gcloud get gcr.io,desktop get https://index.docker.io/v1/" {
+		t.Fatalf("unexpected helper calls %v", helperCalls)
+	}
+
+	// --overwrite rotates the existing auth instead of skipping it
+	importOverwrite = true
+	importRegistries = []string{"docker.io"}
+	if _, err := runRegistryCmd(t, runImport); err != nil {
+		t.Fatalf("import --overwrite: %v", err)
+	}
+	if len(fake.updated) != 1 || fake.updated[0]["id"] != "auth-1" || fake.updated[0]["password"] != "hub-token" {
+		t.Fatalf("unexpected updates %v", fake.updated)
+	}
+	if len(fake.created) != 1 {
+		t.Fatalf("expected no new auths, got %v", fake.created)
+	}
+
+	importRegistries = []string{"nvcr.io"}
+	if _, err := runRegistryCmd(t, runImport); err == nil || !strings.Contains(err.Error(), "no credentials for nvcr.io") {
+		t.Fatalf("expected a missing registry error, got %v", err)
+	}
+}
+
+func TestRunRotate(t *testing.T) {
+	fake := startRegistryAPI(t, map[string]string{"id": "auth-1", "name": "ghcr", "username": "me"})
+	resetImportVars(t)
+
+	rotatePassword = "new-token"
+	stdout, err := runRegistryCmd(t, runRotate, "auth-1")
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if !strings.Contains(stdout, `"rotated": true`) || !strings.Contains(stdout, `"id": "auth-1"`) {
+		t.Fatalf("unexpected output %s", stdout)
+	}
+	if len(fake.updated) != 1 || fake.updated[0]["username"] != "me" || fake.updated[0]["password"] != "new-token" {
+		t.Fatalf("expected the current username to be kept, got %v", fake.updated)
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	if password, err := readPassword("flag-value", os.Stdin, io.Discard); err != nil || password != "flag-value" {
+		t.Fatalf("expected the flag to win, got %q (%v)", password, err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.WriteString("s3cret\n")
+	w.Close()
+	if password, err := readPassword("", r, io.Discard); err != nil || password != "s3cret" {
+		t.Fatalf("expected the piped password, got %q (%v)", password, err)
+	}
+	r.Close()
+
+	r, w, _ = os.Pipe()
+	w.Close()
+	if _, err := readPassword("", r, io.Discard); err == nil {
+		t.Fatal("expected an empty password error")
+	}
+	r.Close()
+}
@@ -0,0 +1,26 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/runpod/runpodctl/internal/prompt"
+)
+
+// readPassword returns the --password flag when it is set, and otherwise
+// reads the password from stdin: an interactive stdin gets a hidden prompt,
+// piped stdin is read to eof with one trailing newline stripped.
+func readPassword(flag string, stdin io.Reader, stderr io.Writer) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+
+	password, err := prompt.ReadHidden(stdin, stderr, "registry password")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if password == "" {
+		return "", fmt.Errorf("password is empty; pipe it on stdin")
+	}
+	return password, nil
+}
@@ -17,4 +17,6 @@ func init() {
 	Cmd.AddCommand(getCmd)
 	Cmd.AddCommand(createCmd)
 	Cmd.AddCommand(deleteCmd)
+	Cmd.AddCommand(rotateCmd)
+	Cmd.AddCommand(importCmd)
 }
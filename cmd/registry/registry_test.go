@@ -21,7 +21,7 @@ func TestRegistryCmd_Structure(t *testing.T) {
 	}
 
 	// check subcommands - registry has no update
-	expectedSubcommands := []string{"list", "get <registry-auth-id>", "create", "delete <registry-auth-id>"}
+	expectedSubcommands := []string{"list", "get <registry-auth-id>", "create", "delete <registry-auth-id>", "rotate <registry-auth-id>", "import"}
 	for _, expected := range expectedSubcommands {
 		found := false
 		for _, cmd := range Cmd.Commands() {
@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate <registry-auth-id>",
+	Short: "replace the password of a registry auth",
+	Long: `replace the password of a container registry auth, keeping its id so the
+templates and endpoints that reference it keep working. the password is read
+from stdin; the username stays the same unless --username is given.`,
+	Example: `  printf '%s' "$GHCR_TOKEN" | runpodctl registry rotate abc123
+  runpodctl registry rotate abc123 --username new-user   # prompts for the password`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRotate,
+}
+
+var (
+	rotateUsername string
+	rotatePassword string
+)
+
+func init() {
+	rotateCmd.Flags().StringVar(&rotateUsername, "username", "", "registry username (default: the current username)")
+	rotateCmd.Flags().StringVar(&rotatePassword, "password", "", "new registry password (default: read from stdin; argv is visible to other processes)")
+}
+
+type rotateResult struct {
+	Rotated bool   `json:"rotated"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+}
+
+func runRotate(cmd *cobra.Command, args []string) error {
+	authID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	auth, err := client.GetContainerRegistryAuth(authID)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get registry auth: %w", err)
+	}
+	username := rotateUsername
+	if username == "" {
+		username = auth.Username
+	}
+	if username == "" {
+		return fmt.Errorf("registry auth %s has no username on record; pass --username", authID)
+	}
+
+	password, err := readPassword(rotatePassword, cmd.InOrStdin(), cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+
+	updated, err := rotateAuth(authID, username, password)
+	if err != nil {
+		return err
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(&rotateResult{Rotated: true, ID: updated.ID, Name: updated.Name}, &output.Config{Format: format})
+}
+
+// rotateAuth replaces the credentials of an existing registry auth
+func rotateAuth(authID, username, password string) (*api.ContainerRegistryAuth, error) {
+	gql, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return nil, err
+	}
+	updated, err := gql.UpdateContainerRegistryAuth(&api.ContainerRegistryAuthUpdateInput{
+		ID:       authID,
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		output.Error(err)
+		return nil, fmt.Errorf("failed to rotate registry auth: %w", err)
+	}
+	return updated, nil
+}
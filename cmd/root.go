@@ -15,6 +15,7 @@ import (
 	"github.com/runpod/runpodctl/cmd/pod"
 	"github.com/runpod/runpodctl/cmd/project"
 	"github.com/runpod/runpodctl/cmd/registry"
+	"github.com/runpod/runpodctl/cmd/secret"
 	"github.com/runpod/runpodctl/cmd/serverless"
 	"github.com/runpod/runpodctl/cmd/template"
 	"github.com/runpod/runpodctl/cmd/transfer"
@@ -48,6 +49,7 @@ resources:
   model          manage model repository
   network-volume manage network volumes (alias: nv)
   registry       manage container registry auth (alias: reg)
+  secret         manage secrets
 
 info:
   user           show account info and balance (alias: me)
@@ -59,6 +61,7 @@ utilities:
   doctor         diagnose and fix cli issues
   ssh            manage ssh keys and connections
   send/receive   transfer files to/from pods
+  api            make authenticated rest and graphql requests
 
 deprecated
   get, create, remove, start, stop, exec, project, config, get models`,
@@ -89,6 +92,7 @@ func registerCommands() {
 	rootCmd.AddCommand(model.Cmd)
 	rootCmd.AddCommand(volume.Cmd)
 	rootCmd.AddCommand(registry.Cmd)
+	rootCmd.AddCommand(secret.Cmd)
 	rootCmd.AddCommand(hub.Cmd)
 
 	// Info commands
@@ -103,6 +107,7 @@ func registerCommands() {
 	rootCmd.AddCommand(transfer.SendCmd)
 	rootCmd.AddCommand(transfer.ReceiveCmd)
 	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(apiCmd)
 
 	// Project commands (hidden - deprecated, will be replaced)
 	projectCmd := &cobra.Command{
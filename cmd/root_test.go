@@ -17,7 +17,7 @@ func TestRootCmd_Structure(t *testing.T) {
 func TestRootCmd_HasResourceCommands(t *testing.T) {
 	root := GetRootCmd()
 
-	expectedCommands := []string{"pod", "serverless", "template", "model", "network-volume", "registry", "user", "gpu", "datacenter", "billing"}
+	expectedCommands := []string{"pod", "serverless", "template", "model", "network-volume", "registry", "secret", "user", "gpu", "datacenter", "billing"}
 	for _, expected := range expectedCommands {
 		found := false
 		for _, cmd := range root.Commands() {
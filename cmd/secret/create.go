@@ -0,0 +1,80 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create a new secret",
+	Long:  "create a new secret. the value is read from stdin, or from --from-file",
+	Example: `  printf '%s' "$HF_TOKEN" | runpodctl secret create --name hf_token
+  runpodctl secret create --name hf_token --from-file ./hf_token.txt`,
+	Args: cobra.NoArgs,
+	RunE: runCreate,
+}
+
+var (
+	createName        string
+	createDescription string
+	createFromFile    string
+)
+
+func init() {
+	createCmd.Flags().StringVar(&createName, "name", "", "secret name (required)")
+	createCmd.Flags().StringVar(&createDescription, "description", "", "secret description")
+	createCmd.Flags().StringVar(&createFromFile, "from-file", "", "read the secret value from a file instead of stdin")
+
+	createCmd.MarkFlagRequired("name") //nolint:errcheck
+}
+
+func runCreate(cmd *cobra.Command, args []string) error {
+	name := strings.TrimSpace(createName)
+	if !validSecretName(name) {
+		return fmt.Errorf("invalid secret name %q; use letters, digits, '_' and '-'", createName)
+	}
+
+	value, err := readSecretValue(createFromFile, cmd.InOrStdin(), cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	secret, err := client.CreateSecret(&api.SecretCreateInput{
+		Name:        name,
+		Value:       value,
+		Description: createDescription,
+	})
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(secret, &output.Config{Format: format})
+}
+
+func validSecretName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:     "delete <secret-id-or-name>",
+	Aliases: []string{"rm", "remove"},
+	Short:   "delete a secret",
+	Long:    "delete a secret by id or name",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runDelete,
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	client, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	secret, err := client.GetSecret(args[0])
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if err := client.DeleteSecret(secret.ID); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"deleted": true,
+		"id":      secret.ID,
+		"name":    secret.Name,
+	}, &output.Config{Format: format})
+}
@@ -0,0 +1,35 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var getMetadataCmd = &cobra.Command{
+	Use:   "get-metadata <secret-id-or-name>",
+	Short: "get secret metadata",
+	Long:  "get metadata for a secret by id or name (the value is never shown)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGetMetadata,
+}
+
+func runGetMetadata(cmd *cobra.Command, args []string) error {
+	client, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	secret, err := client.GetSecret(args[0])
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(secret, &output.Config{Format: format})
+}
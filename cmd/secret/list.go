@@ -0,0 +1,36 @@
+package secret
+
+import (
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list all secrets",
+	Long:  "list metadata for all secrets in your account (values are never shown)",
+	Args:  cobra.NoArgs,
+	RunE:  runList,
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	client, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	secrets, err := client.ListSecrets()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	if secrets == nil {
+		secrets = []api.Secret{}
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(secrets, &output.Config{Format: format})
+}
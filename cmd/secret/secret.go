@@ -0,0 +1,25 @@
+package secret
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd is the secret command group
+var Cmd = &cobra.Command{
+	Use:   "secret",
+	Short: "manage secrets",
+	Long: `manage runpod secrets.
+
+secret values are read from stdin or a file, never from command-line arguments,
+so they don't end up in shell history. reference a secret from pod or template
+env as {{ RUNPOD_SECRET_<name> }}, or use --env-secret KEY=<name> on create.`,
+	Aliases: []string{"secrets"},
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(getMetadataCmd)
+	Cmd.AddCommand(createCmd)
+	Cmd.AddCommand(updateCmd)
+	Cmd.AddCommand(deleteCmd)
+}
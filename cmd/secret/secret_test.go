@@ -0,0 +1,101 @@
+package secret
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretCmd_Structure(t *testing.T) {
+	if Cmd.Use != "secret" {
+		t.Errorf("expected use 'secret', got %s", Cmd.Use)
+	}
+
+	expectedSubcommands := []string{"list", "get-metadata <secret-id-or-name>", "create", "update <secret-id-or-name>", "delete <secret-id-or-name>"}
+	for _, expected := range expectedSubcommands {
+		found := false
+		for _, cmd := range Cmd.Commands() {
+			if cmd.Use == expected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected subcommand %s not found", expected)
+		}
+	}
+}
+
+func TestCreateCmd_NoValueFlag(t *testing.T) {
+	// values must come from stdin or a file so they stay out of shell history
+	for _, name := range []string{"value", "secret"} {
+		if createCmd.Flags().Lookup(name) != nil {
+			t.Errorf("create should not have --%s flag", name)
+		}
+		if updateCmd.Flags().Lookup(name) != nil {
+			t.Errorf("update should not have --%s flag", name)
+		}
+	}
+}
+
+func TestReadSecretValue_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.txt")
+	if err := os.WriteFile(path, []byte("hf_abc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := readSecretValue(path, os.Stdin, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "hf_abc" {
+		t.Fatalf("expected trailing newline stripped, got %q", value)
+	}
+}
+
+func TestReadSecretValue_FromPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := w.WriteString("line1\nline2\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	value, err := readSecretValue("", r, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "line1\nline2" {
+		t.Fatalf("unexpected value %q", value)
+	}
+}
+
+func TestReadSecretValue_Empty(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w.Close()
+
+	if _, err := readSecretValue("", r, io.Discard); err == nil {
+		t.Fatal("expected error for empty value")
+	}
+}
+
+func TestValidSecretName(t *testing.T) {
+	for _, name := range []string{"hf_token", "OPENAI-KEY", "a1"} {
+		if !validSecretName(name) {
+			t.Errorf("expected %q to be valid", name)
+		}
+	}
+	for _, name := range []string{"", "has space", "x.y", "a/b"} {
+		if validSecretName(name) {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
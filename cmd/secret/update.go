@@ -0,0 +1,81 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update <secret-id-or-name>",
+	Short: "update or rotate a secret",
+	Long: `update a secret's name or description, or rotate its value.
+
+pass --rotate to read a new value from stdin (or --from-file). pods and
+templates that reference the secret pick up the new value on their next start.`,
+	Example: `  printf '%s' "$NEW_TOKEN" | runpodctl secret update hf_token --rotate
+  runpodctl secret update hf_token --description "read-only hf token"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUpdate,
+}
+
+var (
+	updateName        string
+	updateDescription string
+	updateRotate      bool
+	updateFromFile    string
+)
+
+func init() {
+	updateCmd.Flags().StringVar(&updateName, "name", "", "new secret name")
+	updateCmd.Flags().StringVar(&updateDescription, "description", "", "new secret description")
+	updateCmd.Flags().BoolVar(&updateRotate, "rotate", false, "replace the secret value (read from stdin or --from-file)")
+	updateCmd.Flags().StringVar(&updateFromFile, "from-file", "", "read the new secret value from a file (implies --rotate)")
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	client, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	existing, err := client.GetSecret(args[0])
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	input := &api.SecretUpdateInput{ID: existing.ID}
+	if cmd.Flags().Changed("name") {
+		if !validSecretName(updateName) {
+			return fmt.Errorf("invalid secret name %q; use letters, digits, '_' and '-'", updateName)
+		}
+		input.Name = &updateName
+	}
+	if cmd.Flags().Changed("description") {
+		input.Description = &updateDescription
+	}
+	if updateRotate || updateFromFile != "" {
+		value, err := readSecretValue(updateFromFile, cmd.InOrStdin(), cmd.ErrOrStderr())
+		if err != nil {
+			return err
+		}
+		input.Value = &value
+	}
+	if input.Name == nil && input.Description == nil && input.Value == nil {
+		return fmt.Errorf("nothing to update; pass --name, --description or --rotate")
+	}
+
+	secret, err := client.UpdateSecret(input)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(secret, &output.Config{Format: format})
+}
@@ -0,0 +1,40 @@
+package secret
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/prompt"
+)
+
+// readSecretValue reads a secret value from a file, or from stdin when no file
+// is given. an interactive stdin gets a hidden prompt; piped stdin is read to
+// eof. one trailing newline is stripped so `echo value |` works as expected.
+func readSecretValue(fromFile string, stdin io.Reader, stderr io.Writer) (string, error) {
+	var value string
+	if fromFile != "" {
+		raw, err := os.ReadFile(fromFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret value: %w", err)
+		}
+		value = trimTrailingNewline(string(raw))
+	} else {
+		var err error
+		value, err = prompt.ReadHidden(stdin, stderr, "secret value")
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret value from stdin: %w", err)
+		}
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("secret value is empty; pipe it on stdin or use --from-file")
+	}
+	return value, nil
+}
+
+func trimTrailingNewline(value string) string {
+	value = strings.TrimSuffix(value, "\n")
+	return strings.TrimSuffix(value, "\r")
+}
@@ -0,0 +1,558 @@
+package serverless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench <endpoint-id>",
+	Short: "load test an endpoint and report latency",
+	Long: `send jobs to an endpoint at a fixed rate (--rps) or with a fixed number of
+jobs in flight (--concurrency) and report end-to-end latency percentiles,
+queue delay versus execution time, cold versus warm starts, error rate and
+the cost per 1k requests.
+
+queue delay and execution time come from the job status payload. a job counts
+as a cold start when it is the first job of a worker that was not running when
+the bench started; when the api does not report the worker, jobs with a queue
+delay above --cold-threshold count as cold.
+
+the cost estimate covers execution time plus the queue delay of cold starts;
+idle time kept by the idle timeout is not included. workers are priced at the
+on-demand secure cloud price of the dearest gpu type in the endpoint's first
+gpu pool, times its gpus per worker; set --cost-per-second to use another
+price.
+
+jobs still running on ctrl-c or when --timeout passes are cancelled.
+
+the input file holds either the job input or a full request body with an
+"input" field. save results with --save and compare two runs with
+"serverless bench compare".`,
+	Example: `  runpodctl serverless bench <endpoint-id> --input payload.json --rps 5 --duration 5m --save before.json
+  runpodctl serverless bench <endpoint-id> --input payload.json --concurrency 8 --requests 200
+  runpodctl serverless bench compare before.json after.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBench,
+}
+
+var benchCompareCmd = &cobra.Command{
+	Use:   "compare <baseline.json> <candidate.json>",
+	Short: "compare two saved bench results",
+	Long:  "compare two results saved with \"serverless bench --save\" side by side. change is the relative difference of the candidate to the baseline.",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runBenchCompare,
+}
+
+var (
+	benchInput         string
+	benchRPS           float64
+	benchConcurrency   int
+	benchDuration      time.Duration
+	benchRequests      int
+	benchTimeout       time.Duration
+	benchPollInterval  time.Duration
+	benchColdThreshold time.Duration
+	benchCostPerSecond float64
+	benchSave          string
+)
+
+func init() {
+	benchCmd.Flags().StringVar(&benchInput, "input", "", "json file with the job input (required)")
+	benchCmd.Flags().Float64Var(&benchRPS, "rps", 0, "jobs to submit per second")
+	benchCmd.Flags().IntVar(&benchConcurrency, "concurrency", 0, "jobs to keep in flight (instead of --rps)")
+	benchCmd.Flags().DurationVar(&benchDuration, "duration", time.Minute, "how long to submit jobs")
+	benchCmd.Flags().IntVar(&benchRequests, "requests", 0, "stop after this many jobs (0 for no limit)")
+	benchCmd.Flags().DurationVar(&benchTimeout, "timeout", 10*time.Minute, "give up on a job after this long")
+	benchCmd.Flags().DurationVar(&benchPollInterval, "poll-interval", 500*time.Millisecond, "how often to poll jobs that outlive a runsync call")
+	benchCmd.Flags().DurationVar(&benchColdThreshold, "cold-threshold", 3*time.Second, "queue delay that marks a cold start when the worker is unknown")
+	benchCmd.Flags().Float64Var(&benchCostPerSecond, "cost-per-second", 0, "worker price in usd per second, for the cost estimate (default priced from the endpoint's gpus)")
+	benchCmd.Flags().StringVar(&benchSave, "save", "", "also write the results to this json file")
+	benchCmd.MarkFlagRequired("input") //nolint:errcheck
+
+	benchCmd.AddCommand(benchCompareCmd)
+}
+
+// benchSample is the outcome of one job
+type benchSample struct {
+	started     time.Time
+	latency     time.Duration
+	delayMs     int64
+	executionMs int64
+	workerID    string
+	cold        bool
+	err         string
+	interrupted bool
+}
+
+// benchStats are latency percentiles in milliseconds
+type benchStats struct {
+	P50  float64 `json:"p50Ms"`
+	P95  float64 `json:"p95Ms"`
+	P99  float64 `json:"p99Ms"`
+	Mean float64 `json:"meanMs"`
+	Max  float64 `json:"maxMs"`
+}
+
+// benchGroup summarizes the cold or warm jobs of a run
+type benchGroup struct {
+	Count   int        `json:"count"`
+	Latency benchStats `json:"latency"`
+}
+
+// benchReport is the result of a bench run, as printed and saved
+type benchReport struct {
+	EndpointID    string         `json:"endpointId"`
+	StartedAt     string         `json:"startedAt"`
+	RPS           float64        `json:"rps,omitempty"`
+	Concurrency   int            `json:"concurrency,omitempty"`
+	Duration      string         `json:"duration"`
+	Requests      int            `json:"requests"`
+	Completed     int            `json:"completed"`
+	Failed        int            `json:"failed"`
+	Incomplete    int            `json:"incomplete,omitempty"`
+	ErrorRate     float64        `json:"errorRate"`
+	Throughput    float64        `json:"throughputRps"`
+	Latency       benchStats     `json:"latency"`
+	QueueDelay    benchStats     `json:"queueDelay"`
+	Execution     benchStats     `json:"executionTime"`
+	Cold          benchGroup     `json:"cold"`
+	Warm          benchGroup     `json:"warm"`
+	BilledSeconds float64        `json:"billedSeconds"`
+	CostPer1k     *float64       `json:"costPer1kUsd,omitempty"`
+	Errors        map[string]int `json:"errors,omitempty"`
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+
+	if (benchRPS > 0) == (benchConcurrency > 0) {
+		return fmt.Errorf("set exactly one of --rps or --concurrency")
+	}
+	if benchRPS < 0 || benchConcurrency < 0 || benchRequests < 0 || benchCostPerSecond < 0 {
+		return fmt.Errorf("--rps, --concurrency, --requests and --cost-per-second must not be negative")
+	}
+	if benchDuration <= 0 && benchRequests == 0 {
+		return fmt.Errorf("set a positive --duration or --requests")
+	}
+	if benchTimeout <= 0 || benchPollInterval <= 0 {
+		return fmt.Errorf("--timeout and --poll-interval must be positive")
+	}
+
+	input, err := readJobInput(benchInput)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	stderr := cmd.ErrOrStderr()
+
+	// workers that are already up serve warm jobs from the start
+	warmWorkers := map[string]bool{}
+	endpoint, err := client.GetEndpoint(endpointID, false, true)
+	if err != nil {
+		fmt.Fprintf(stderr, "warning: failed to list workers, cold starts are estimated from queue delay: %v\n", err)
+	} else {
+		for _, worker := range endpoint.Workers {
+			if strings.EqualFold(worker.Status, "RUNNING") {
+				warmWorkers[worker.ID] = true
+			}
+		}
+	}
+
+	costPerSecond := benchCostPerSecond
+	if costPerSecond == 0 && endpoint != nil {
+		if costPerSecond, err = endpointPricePerSecond(client, endpoint); err != nil {
+			fmt.Fprintf(stderr, "warning: failed to price the endpoint's workers, set --cost-per-second for a cost estimate: %v\n", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	job := func(ctx context.Context) benchSample {
+		return benchJob(ctx, client, endpointID, input, benchTimeout, benchPollInterval)
+	}
+
+	started := time.Now()
+	fmt.Fprintf(stderr, "benchmarking %s for %s (ctrl-c to stop early)\n", endpointID, benchDuration)
+	samples := runBenchLoad(ctx, job, benchRPS, benchConcurrency, benchDuration, benchRequests, stderr)
+	elapsed := time.Since(started)
+
+	classifyColdStarts(samples, warmWorkers, benchColdThreshold)
+	report := summarizeBench(samples, elapsed, costPerSecond)
+	report.EndpointID = endpointID
+	report.StartedAt = started.UTC().Format(time.RFC3339)
+	report.RPS = benchRPS
+	report.Concurrency = benchConcurrency
+
+	if benchSave != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(benchSave, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to save results: %w", err)
+		}
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(report, &output.Config{Format: format})
+}
+
+// endpointPricePerSecond prices a worker of a gpu endpoint at the on-demand
+// secure cloud price of the dearest gpu type in its first gpu pool
+func endpointPricePerSecond(client *api.Client, endpoint *api.Endpoint) (float64, error) {
+	first, _, _ := strings.Cut(endpoint.GpuIDs, ",")
+	first = strings.TrimSpace(first)
+	if first == "" || strings.EqualFold(endpoint.ComputeType, "CPU") {
+		return 0, fmt.Errorf("endpoint %s has no gpus", endpoint.ID)
+	}
+
+	gpuTypes := []string{first}
+	pools, err := client.ListServerlessGpuPools()
+	if err != nil {
+		return 0, err
+	}
+	for _, pool := range pools {
+		if strings.EqualFold(pool.ID, first) {
+			gpuTypes = pool.GpuTypeIDs
+			break
+		}
+	}
+
+	prices, err := client.GpuHourlyPrices()
+	if err != nil {
+		return 0, err
+	}
+	var hourly float64
+	for _, gpuType := range gpuTypes {
+		hourly = max(hourly, prices[gpuType])
+	}
+	if hourly == 0 {
+		return 0, fmt.Errorf("no price for gpu %s", first)
+	}
+	return hourly * float64(max(endpoint.GpuCount, 1)) / 3600, nil
+}
+
+// benchJob runs one job to completion, timing it from submission
+func benchJob(ctx context.Context, client *api.Client, endpointID string, input json.RawMessage, timeout, pollInterval time.Duration) benchSample {
+	sample := benchSample{started: time.Now()}
+
+	job, err := awaitJob(ctx, client, endpointID, input, timeout, pollInterval)
+	if ctx.Err() != nil {
+		sample.interrupted = true
+		return sample
+	}
+	if err != nil {
+		sample.err = err.Error()
+		return sample
+	}
+
+	sample.latency = time.Since(sample.started)
+	sample.delayMs = job.DelayTime
+	sample.executionMs = job.ExecutionTime
+	sample.workerID = job.WorkerID
+	if job.Status != api.JobStatusCompleted {
+		sample.err = jobFailure(job)
+	}
+	return sample
+}
+
+// runBenchLoad submits jobs until duration passes, maxRequests jobs were sent
+// or ctx is cancelled, then waits for the jobs in flight. with rps > 0 jobs
+// are started on a fixed schedule, otherwise concurrency loops each run one
+// job at a time.
+func runBenchLoad(ctx context.Context, job func(context.Context) benchSample, rps float64, concurrency int, duration time.Duration, maxRequests int, progress io.Writer) []benchSample {
+	submitCtx := ctx
+	if duration > 0 {
+		var cancel context.CancelFunc
+		submitCtx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+
+	var (
+		mu      sync.Mutex
+		samples []benchSample
+		sent    atomic.Int64
+		wg      sync.WaitGroup
+	)
+	record := func(sample benchSample) {
+		mu.Lock()
+		samples = append(samples, sample)
+		mu.Unlock()
+	}
+	// claim reserves the next request slot, respecting maxRequests
+	claim := func() bool {
+		n := sent.Add(1)
+		if maxRequests > 0 && n > int64(maxRequests) {
+			sent.Add(-1)
+			return false
+		}
+		return true
+	}
+
+	stopProgress := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopProgress:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				done := len(samples)
+				mu.Unlock()
+				fmt.Fprintf(progress, "sent %d, finished %d\n", sent.Load(), done)
+			}
+		}
+	}()
+	defer close(stopProgress)
+
+	if rps > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+		defer ticker.Stop()
+		for claim() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				record(job(ctx))
+			}()
+			select {
+			case <-submitCtx.Done():
+			case <-ticker.C:
+				continue
+			}
+			break
+		}
+	} else {
+		for range concurrency {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for submitCtx.Err() == nil && claim() {
+					record(job(ctx))
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+	return samples
+}
+
+// classifyColdStarts marks the first job of each worker that was not warm at
+// the start as cold. jobs without a worker id fall back to the queue delay.
+func classifyColdStarts(samples []benchSample, warmWorkers map[string]bool, threshold time.Duration) {
+	sort.Slice(samples, func(i, j int) bool { return samples[i].started.Before(samples[j].started) })
+
+	seen := map[string]bool{}
+	for workerID := range warmWorkers {
+		seen[workerID] = true
+	}
+	for i := range samples {
+		sample := &samples[i]
+		if sample.err != "" || sample.interrupted {
+			continue
+		}
+		if sample.workerID == "" {
+			sample.cold = time.Duration(sample.delayMs)*time.Millisecond >= threshold
+			continue
+		}
+		sample.cold = !seen[sample.workerID]
+		seen[sample.workerID] = true
+	}
+}
+
+func summarizeBench(samples []benchSample, elapsed time.Duration, costPerSecond float64) benchReport {
+	report := benchReport{
+		Duration: elapsed.Round(time.Millisecond).String(),
+		Errors:   map[string]int{},
+	}
+
+	var latency, delay, execution, cold, warm []float64
+	var billedMs int64
+	for _, sample := range samples {
+		if sample.interrupted {
+			report.Incomplete++
+			continue
+		}
+		report.Requests++
+		if sample.err != "" {
+			report.Failed++
+			report.Errors[sample.err]++
+		} else {
+			report.Completed++
+			ms := float64(sample.latency) / float64(time.Millisecond)
+			latency = append(latency, ms)
+			if sample.cold {
+				cold = append(cold, ms)
+			} else {
+				warm = append(warm, ms)
+			}
+		}
+		if sample.latency == 0 {
+			// the job never reported timings
+			continue
+		}
+		delay = append(delay, float64(sample.delayMs))
+		execution = append(execution, float64(sample.executionMs))
+		billedMs += sample.executionMs
+		if sample.cold {
+			billedMs += sample.delayMs
+		}
+	}
+
+	if report.Requests > 0 {
+		report.ErrorRate = roundTo(float64(report.Failed)/float64(report.Requests), 4)
+	}
+	if elapsed > 0 {
+		report.Throughput = roundTo(float64(report.Completed)/elapsed.Seconds(), 3)
+	}
+	report.Latency = computeBenchStats(latency)
+	report.QueueDelay = computeBenchStats(delay)
+	report.Execution = computeBenchStats(execution)
+	report.Cold = benchGroup{Count: len(cold), Latency: computeBenchStats(cold)}
+	report.Warm = benchGroup{Count: len(warm), Latency: computeBenchStats(warm)}
+	report.BilledSeconds = roundTo(float64(billedMs)/1000, 3)
+	if costPerSecond > 0 && report.Requests > 0 {
+		cost := roundTo(report.BilledSeconds*costPerSecond/float64(report.Requests)*1000, 4)
+		report.CostPer1k = &cost
+	}
+	if len(report.Errors) == 0 {
+		report.Errors = nil
+	}
+	return report
+}
+
+func computeBenchStats(values []float64) benchStats {
+	if len(values) == 0 {
+		return benchStats{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, value := range sorted {
+		sum += value
+	}
+	return benchStats{
+		P50:  roundTo(percentile(sorted, 50), 1),
+		P95:  roundTo(percentile(sorted, 95), 1),
+		P99:  roundTo(percentile(sorted, 99), 1),
+		Mean: roundTo(sum/float64(len(sorted)), 1),
+		Max:  roundTo(sorted[len(sorted)-1], 1),
+	}
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// benchComparison is one metric of two bench runs
+type benchComparison struct {
+	Metric    string   `json:"metric"`
+	Baseline  float64  `json:"baseline"`
+	Candidate float64  `json:"candidate"`
+	Change    *float64 `json:"change,omitempty"`
+}
+
+func runBenchCompare(cmd *cobra.Command, args []string) error {
+	baseline, err := loadBenchReport(args[0])
+	if err != nil {
+		return err
+	}
+	candidate, err := loadBenchReport(args[1])
+	if err != nil {
+		return err
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(compareBenchReports(baseline, candidate), &output.Config{Format: format})
+}
+
+func loadBenchReport(path string) (*benchReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bench results: %w", err)
+	}
+	var report benchReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse bench results %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+func compareBenchReports(baseline, candidate *benchReport) []benchComparison {
+	cost := func(report *benchReport) float64 {
+		if report.CostPer1k == nil {
+			return 0
+		}
+		return *report.CostPer1k
+	}
+	metrics := []struct {
+		name  string
+		value func(*benchReport) float64
+	}{
+		{"latency.p50Ms", func(r *benchReport) float64 { return r.Latency.P50 }},
+		{"latency.p95Ms", func(r *benchReport) float64 { return r.Latency.P95 }},
+		{"latency.p99Ms", func(r *benchReport) float64 { return r.Latency.P99 }},
+		{"queueDelay.p50Ms", func(r *benchReport) float64 { return r.QueueDelay.P50 }},
+		{"queueDelay.p95Ms", func(r *benchReport) float64 { return r.QueueDelay.P95 }},
+		{"executionTime.p50Ms", func(r *benchReport) float64 { return r.Execution.P50 }},
+		{"executionTime.p95Ms", func(r *benchReport) float64 { return r.Execution.P95 }},
+		{"cold.count", func(r *benchReport) float64 { return float64(r.Cold.Count) }},
+		{"cold.p50Ms", func(r *benchReport) float64 { return r.Cold.Latency.P50 }},
+		{"warm.p50Ms", func(r *benchReport) float64 { return r.Warm.Latency.P50 }},
+		{"errorRate", func(r *benchReport) float64 { return r.ErrorRate }},
+		{"throughputRps", func(r *benchReport) float64 { return r.Throughput }},
+		{"costPer1kUsd", cost},
+	}
+
+	rows := make([]benchComparison, 0, len(metrics))
+	for _, metric := range metrics {
+		row := benchComparison{
+			Metric:    metric.name,
+			Baseline:  metric.value(baseline),
+			Candidate: metric.value(candidate),
+		}
+		if row.Baseline != 0 {
+			change := roundTo((row.Candidate-row.Baseline)/row.Baseline, 4)
+			row.Change = &change
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
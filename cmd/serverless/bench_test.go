@@ -0,0 +1,202 @@
+package serverless
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func resetBenchVars(t *testing.T) {
+	t.Helper()
+	origInput, origRPS, origConcurrency := benchInput, benchRPS, benchConcurrency
+	origDuration, origRequests, origTimeout := benchDuration, benchRequests, benchTimeout
+	origPoll, origCold, origCost, origSave := benchPollInterval, benchColdThreshold, benchCostPerSecond, benchSave
+	t.Cleanup(func() {
+		benchInput, benchRPS, benchConcurrency = origInput, origRPS, origConcurrency
+		benchDuration, benchRequests, benchTimeout = origDuration, origRequests, origTimeout
+		benchPollInterval, benchColdThreshold, benchCostPerSecond, benchSave = origPoll, origCold, origCost, origSave
+	})
+	benchRPS, benchConcurrency = 0, 0
+	benchDuration, benchRequests = time.Minute, 0
+	benchTimeout, benchPollInterval = 10*time.Second, 5*time.Millisecond
+	benchColdThreshold, benchCostPerSecond, benchSave = 3*time.Second, 0, ""
+}
+
+// startFakeBenchAPI serves jobs that alternate between a warm worker (w-1,
+// running before the bench) and a new one (w-2). job 1 needs a status poll
+// and job 4 fails.
+func startFakeBenchAPI(t *testing.T) *atomic.Int64 {
+	t.Helper()
+	var jobs atomic.Int64
+	result := func(n int64) map[string]interface{} {
+		job := map[string]interface{}{"id": fmt.Sprintf("job-%d", n), "status": "COMPLETED", "executionTime": 100}
+		if n%2 == 0 {
+			job["workerId"], job["delayTime"] = "w-1", 10
+		} else {
+			job["workerId"], job["delayTime"] = "w-2", 4000
+		}
+		if n == 4 {
+			job["status"], job["error"] = "FAILED", "boom"
+		}
+		return job
+	}
+
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-1":
+			_, _ = w.Write([]byte(`{"id": "ep-1", "gpuIds": "ADA_24", "gpuCount": 2, "workers": [{"id": "w-1", "desiredStatus": "RUNNING"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			var body struct {
+				Query string `json:"query"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if strings.Contains(body.Query, "serverlessGpuPools") {
+				_, _ = w.Write([]byte(`{"data": {"serverlessGpuPools": [{"id": "ADA_24", "gpuTypeIds": ["NVIDIA L4", "NVIDIA RTX 4000 Ada Generation"]}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"gpuTypes": [
+				{"id": "NVIDIA L4", "lowestPrice": {"uninterruptablePrice": 0.36}},
+				{"id": "NVIDIA RTX 4000 Ada Generation", "lowestPrice": {"uninterruptablePrice": 0.72}},
+				{"id": "NVIDIA A40", "lowestPrice": null}
+			]}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/ep-1/runsync":
+			var body struct {
+				Input map[string]interface{} `json:"input"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Input["prompt"] != "hi" {
+				t.Errorf("unexpected input %v", body.Input)
+			}
+			n := jobs.Add(1)
+			if n == 1 {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "job-1", "status": "IN_PROGRESS"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(result(n))
+		case r.Method == http.MethodGet && r.URL.Path == "/ep-1/status/job-1":
+			_ = json.NewEncoder(w).Encode(result(1))
+		default:
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+		}
+	}))
+	return &jobs
+}
+
+func TestRunBench_ConcurrencyReportAndCompare(t *testing.T) {
+	resetBenchVars(t)
+	jobs := startFakeBenchAPI(t)
+
+	dir := t.TempDir()
+	benchInput = filepath.Join(dir, "payload.json")
+	if err := os.WriteFile(benchInput, []byte(`{"input": {"prompt": "hi"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	benchConcurrency = 2
+	benchRequests = 6
+	benchCostPerSecond = 0.001
+	benchSave = filepath.Join(dir, "run.json")
+
+	stdout, _, err := runCaptured(t, runBench, "ep-1")
+	if err != nil {
+		t.Fatalf("bench: %v", err)
+	}
+	if jobs.Load() != 6 {
+		t.Fatalf("expected 6 jobs, got %d", jobs.Load())
+	}
+
+	var report benchReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("parse report %q: %v", stdout, err)
+	}
+	if report.Requests != 6 || report.Completed != 5 || report.Failed != 1 || report.Errors["FAILED: boom"] != 1 {
+		t.Fatalf("unexpected counts %+v", report)
+	}
+	if report.ErrorRate != 0.1667 || report.Cold.Count != 1 || report.Warm.Count != 4 {
+		t.Fatalf("unexpected error rate or cold/warm split %+v", report)
+	}
+	if report.QueueDelay.Max != 4000 || report.Execution.P50 != 100 {
+		t.Fatalf("unexpected timings %+v / %+v", report.QueueDelay, report.Execution)
+	}
+	// 6 x 100ms execution plus one 4s cold start
+	if report.BilledSeconds != 4.6 || report.CostPer1k == nil || *report.CostPer1k != 0.7667 {
+		t.Fatalf("unexpected cost %+v", report)
+	}
+
+	saved, err := loadBenchReport(benchSave)
+	if err != nil {
+		t.Fatalf("load saved report: %v", err)
+	}
+	saved.Latency.P50 *= 2
+	rows := compareBenchReports(&report, saved)
+	if rows[0].Metric != "latency.p50Ms" || rows[0].Change == nil || *rows[0].Change != 1 {
+		t.Fatalf("unexpected comparison %+v", rows[0])
+	}
+}
+
+func TestRunBench_PricesFromEndpointGpus(t *testing.T) {
+	resetBenchVars(t)
+	startFakeBenchAPI(t)
+	benchInput = filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(benchInput, []byte(`{"prompt": "hi"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	benchConcurrency = 1
+	benchRequests = 2
+
+	stdout, stderr, err := runCaptured(t, runBench, "ep-1")
+	if err != nil {
+		t.Fatalf("bench: %v", err)
+	}
+	var report benchReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("parse report %q: %v", stdout, err)
+	}
+	// 2 gpus at the dearest gpu of ADA_24, $0.72/h: $0.0004/s for 4.2s over 2 jobs
+	if report.BilledSeconds != 4.2 || report.CostPer1k == nil || *report.CostPer1k != 0.84 {
+		t.Fatalf("unexpected cost %+v (%s)", report, stderr)
+	}
+}
+
+func TestRunBench_RequiresOneLoadMode(t *testing.T) {
+	resetBenchVars(t)
+	benchInput = "payload.json"
+
+	benchRPS, benchConcurrency = 1, 1
+	if _, _, err := runCaptured(t, runBench, "ep-1"); err == nil || !strings.Contains(err.Error(), "exactly one of --rps or --concurrency") {
+		t.Fatalf("expected load mode error, got %v", err)
+	}
+	benchRPS, benchConcurrency = 0, 0
+	if _, _, err := runCaptured(t, runBench, "ep-1"); err == nil {
+		t.Fatal("expected load mode error")
+	}
+}
+
+func TestRunBenchLoad_RPSStopsAfterDuration(t *testing.T) {
+	job := func(ctx context.Context) benchSample { return benchSample{started: time.Now(), latency: time.Millisecond} }
+	samples := runBenchLoad(t.Context(), job, 200, 0, 50*time.Millisecond, 0, io.Discard)
+	if len(samples) == 0 || len(samples) > 15 {
+		t.Fatalf("expected about 10 jobs at 200 rps for 50ms, got %d", len(samples))
+	}
+}
+
+func TestComputeBenchStats_NearestRank(t *testing.T) {
+	values := make([]float64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+	stats := computeBenchStats(values)
+	if stats.P50 != 50 || stats.P95 != 95 || stats.P99 != 99 || stats.Max != 100 || stats.Mean != 50.5 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if computeBenchStats(nil) != (benchStats{}) {
+		t.Fatal("expected zero stats for no values")
+	}
+}
@@ -0,0 +1,228 @@
+package serverless
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var chatCmd = &cobra.Command{
+	Use:   "chat <endpoint-id>",
+	Short: "chat with an openai-compatible endpoint",
+	Long: `start an interactive chat with an endpoint that serves the openai-compatible
+api under /openai/v1, such as a vllm worker. replies are streamed to the
+terminal as they are generated.
+
+type /reset to start over and /exit (or ctrl-d) to quit. with an explicit
+--output every reply is printed as one json or yaml document with its token
+usage instead of being streamed.`,
+	Example: `  runpodctl serverless chat <endpoint-id>
+  runpodctl serverless chat <endpoint-id> --system "answer in one sentence" --model meta-llama/Llama-3.1-8B-Instruct`,
+	Args: cobra.ExactArgs(1),
+	RunE: runChat,
+}
+
+var completeCmd = &cobra.Command{
+	Use:   "complete <endpoint-id>",
+	Short: "send a one-shot prompt to an openai-compatible endpoint",
+	Long: `read a prompt from stdin, send it to an endpoint that serves the
+openai-compatible api under /openai/v1 and stream the reply to stdout.
+
+with an explicit --output the reply is printed as one json or yaml document
+with its token usage instead of being streamed.`,
+	Example: `  echo "write a haiku about gpus" | runpodctl serverless complete <endpoint-id>
+  runpodctl serverless complete <endpoint-id> -o json < prompt.txt`,
+	Args: cobra.ExactArgs(1),
+	RunE: runComplete,
+}
+
+var (
+	chatModel       string
+	chatSystem      string
+	chatMaxTokens   int
+	chatTemperature float64
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{chatCmd, completeCmd} {
+		cmd.Flags().StringVar(&chatModel, "model", "", "model to use (default: the first model the endpoint serves)")
+		cmd.Flags().StringVar(&chatSystem, "system", "", "system prompt")
+		cmd.Flags().IntVar(&chatMaxTokens, "max-tokens", 0, "maximum tokens to generate (0 for the server default)")
+		cmd.Flags().Float64Var(&chatTemperature, "temperature", -1, "sampling temperature (default: the server default)")
+	}
+}
+
+// chatSession holds the conversation sent with every request
+type chatSession struct {
+	client     *api.Client
+	endpointID string
+	model      string
+	messages   []api.ChatMessage
+}
+
+func newChatSession(endpointID string) (*chatSession, error) {
+	client, err := api.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	model := chatModel
+	if model == "" {
+		models, err := client.ListOpenAIModels(endpointID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list models, pass --model: %w", err)
+		}
+		if len(models) == 0 {
+			return nil, fmt.Errorf("endpoint serves no models, pass --model")
+		}
+		model = models[0]
+	}
+
+	session := &chatSession{client: client, endpointID: endpointID, model: model}
+	session.reset()
+	return session, nil
+}
+
+func (s *chatSession) reset() {
+	s.messages = nil
+	if chatSystem != "" {
+		s.messages = append(s.messages, api.ChatMessage{Role: "system", Content: chatSystem})
+	}
+}
+
+// send adds prompt to the conversation and returns the reply, streaming it
+// to onToken. a failed turn is dropped from the conversation.
+func (s *chatSession) send(ctx context.Context, prompt string, onToken func(string)) (*api.ChatCompletion, error) {
+	s.messages = append(s.messages, api.ChatMessage{Role: "user", Content: prompt})
+	req := &api.ChatCompletionRequest{
+		Model:     s.model,
+		Messages:  s.messages,
+		MaxTokens: chatMaxTokens,
+	}
+	if chatTemperature >= 0 {
+		temperature := chatTemperature
+		req.Temperature = &temperature
+	}
+
+	completion, err := s.client.StreamChatCompletion(ctx, s.endpointID, req, onToken)
+	if err != nil {
+		s.messages = s.messages[:len(s.messages)-1]
+		return nil, err
+	}
+	s.messages = append(s.messages, api.ChatMessage{Role: "assistant", Content: completion.Content})
+	return completion, nil
+}
+
+// structuredOutput reports whether --output was given explicitly; the
+// default json output would otherwise hide the streamed reply
+func structuredOutput(cmd *cobra.Command) bool {
+	flag := cmd.Flag("output")
+	return flag != nil && flag.Changed
+}
+
+func runChat(cmd *cobra.Command, args []string) error {
+	session, err := newChatSession(args[0])
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	structured := structuredOutput(cmd)
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	stdout, stderr := cmd.OutOrStdout(), cmd.ErrOrStderr()
+	fmt.Fprintf(stderr, "chatting with %s on %s (/reset to start over, /exit to quit)\n", session.model, session.endpointID)
+
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		fmt.Fprint(stderr, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(stderr)
+			return scanner.Err()
+		}
+
+		prompt := strings.TrimSpace(scanner.Text())
+		switch prompt {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		case "/reset":
+			session.reset()
+			fmt.Fprintln(stderr, "conversation cleared")
+			continue
+		}
+
+		var onToken func(string)
+		if !structured {
+			onToken = func(token string) { fmt.Fprint(stdout, token) }
+		}
+		completion, err := session.send(ctx, prompt, onToken)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			continue
+		}
+		if structured {
+			if err := output.Print(completion, &output.Config{Format: format}); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintln(stdout)
+	}
+}
+
+func runComplete(cmd *cobra.Command, args []string) error {
+	prompt, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return fmt.Errorf("failed to read prompt: %w", err)
+	}
+	if strings.TrimSpace(string(prompt)) == "" {
+		return fmt.Errorf("no prompt on stdin")
+	}
+
+	session, err := newChatSession(args[0])
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	structured := structuredOutput(cmd)
+	stdout := cmd.OutOrStdout()
+	var onToken func(string)
+	if !structured {
+		onToken = func(token string) { fmt.Fprint(stdout, token) }
+	}
+
+	completion, err := session.send(ctx, strings.TrimRight(string(prompt), "\n"), onToken)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to complete prompt: %w", err)
+	}
+
+	if structured {
+		format := output.ParseFormat(cmd.Flag("output").Value.String())
+		return output.Print(completion, &output.Config{Format: format})
+	}
+	fmt.Fprintln(stdout)
+	return nil
+}
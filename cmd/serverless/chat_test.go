@@ -0,0 +1,131 @@
+package serverless
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func resetChatVars(t *testing.T) {
+	t.Helper()
+	origModel, origSystem, origMaxTokens, origTemperature := chatModel, chatSystem, chatMaxTokens, chatTemperature
+	t.Cleanup(func() {
+		chatModel, chatSystem, chatMaxTokens, chatTemperature = origModel, origSystem, origMaxTokens, origTemperature
+	})
+	chatModel, chatSystem, chatMaxTokens, chatTemperature = "", "", 0, -1
+}
+
+// startFakeOpenAI serves /models and replies to every chat with the number
+// of messages it received, so tests can follow the conversation
+func startFakeOpenAI(t *testing.T) *[]map[string]interface{} {
+	t.Helper()
+	var requests []map[string]interface{}
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ep-1/openai/v1/models":
+			_, _ = w.Write([]byte(`{"data":[{"id":"served-model"}]}`))
+		case "/ep-1/openai/v1/chat/completions":
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			requests = append(requests, body)
+			messages, _ := body["messages"].([]interface{})
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"got \"}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"%d\"},\"finish_reason\":\"stop\"}]}\n\n", len(messages))
+			fmt.Fprintf(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\n")
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return &requests
+}
+
+func newChatTestCmd(stdin string, output string) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	if output != "" {
+		_ = cmd.Flags().Set("output", output)
+	}
+	var stdout bytes.Buffer
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, &stdout
+}
+
+func TestRunComplete_StreamsReply(t *testing.T) {
+	resetChatVars(t)
+	requests := startFakeOpenAI(t)
+	chatSystem = "be brief"
+
+	cmd, stdout := newChatTestCmd("hello\n", "")
+	if err := runComplete(cmd, []string{"ep-1"}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if stdout.String() != "got 2\n" {
+		t.Fatalf("unexpected reply %q", stdout.String())
+	}
+	if len(*requests) != 1 || (*requests)[0]["model"] != "served-model" {
+		t.Fatalf("expected the served model to be picked, got %v", *requests)
+	}
+}
+
+func TestRunComplete_JSONIncludesUsage(t *testing.T) {
+	resetChatVars(t)
+	startFakeOpenAI(t)
+	chatModel = "my-model"
+
+	cmd, stdout := newChatTestCmd("hello", "json")
+	var runErr error
+	printed := captureStdout(t, func() { runErr = runComplete(cmd, []string{"ep-1"}) })
+	if runErr != nil {
+		t.Fatalf("complete: %v", runErr)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no streamed tokens, got %q", stdout.String())
+	}
+
+	var completion struct {
+		Model   string `json:"model"`
+		Content string `json:"content"`
+		Usage   struct {
+			TotalTokens int `json:"totalTokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal([]byte(printed), &completion); err != nil {
+		t.Fatalf("parse %q: %v", printed, err)
+	}
+	if completion.Model != "my-model" || completion.Content != "got 1" || completion.Usage.TotalTokens != 5 {
+		t.Fatalf("unexpected completion %+v", completion)
+	}
+}
+
+func TestRunChat_KeepsConversationUntilReset(t *testing.T) {
+	resetChatVars(t)
+	requests := startFakeOpenAI(t)
+
+	cmd, stdout := newChatTestCmd("first\nsecond\n/reset\nthird\n/exit\nignored\n", "")
+	if err := runChat(cmd, []string{"ep-1"}); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	// the second turn carries the first exchange; /reset drops it again
+	if stdout.String() != "got 1\ngot 3\ngot 1\n" {
+		t.Fatalf("unexpected replies %q", stdout.String())
+	}
+	if len(*requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(*requests))
+	}
+}
+
+func TestRunComplete_RequiresPrompt(t *testing.T) {
+	resetChatVars(t)
+	cmd, _ := newChatTestCmd("  \n", "")
+	if err := runComplete(cmd, []string{"ep-1"}); err == nil || !strings.Contains(err.Error(), "no prompt") {
+		t.Fatalf("expected missing prompt error, got %v", err)
+	}
+}
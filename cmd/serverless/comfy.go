@@ -0,0 +1,283 @@
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var comfyCmd = &cobra.Command{
+	Use:   "comfy",
+	Short: "run comfyui workflows on an endpoint",
+	Long:  "submit comfyui workflows to an endpoint running the runpod comfyui worker",
+}
+
+var comfyRunCmd = &cobra.Command{
+	Use:   "run <endpoint-id> <workflow.json>",
+	Short: "run a comfyui workflow and save the images",
+	Long: `submit a workflow to an endpoint running the runpod comfyui worker, wait for
+it to finish and write the output images to --out.
+
+the workflow must be in api format (export it with "save (api)" in comfyui).
+--set patches a node input by node id and path, e.g. 6.inputs.text="a cat";
+values of string inputs are used as is, other values are parsed as json.
+--images uploads input images (paths or glob patterns) under their file name,
+for use in load image nodes.
+
+output images may come back as base64 or as bucket urls; both are saved.`,
+	Example: `  runpodctl serverless comfy run <endpoint-id> workflow.json --set 6.inputs.text="a cat" --out ./outputs
+  runpodctl serverless comfy run <endpoint-id> workflow.json --set 3.inputs.seed=42 --images "./in/*.png"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runComfyRun,
+}
+
+var (
+	comfySet          []string
+	comfyImages       []string
+	comfyOut          string
+	comfyTimeout      time.Duration
+	comfyPollInterval time.Duration
+)
+
+func init() {
+	comfyRunCmd.Flags().StringArrayVar(&comfySet, "set", nil, "node input to set, as <node-id>.inputs.<name>=<value> (repeatable)")
+	comfyRunCmd.Flags().StringArrayVar(&comfyImages, "images", nil, "input images or glob patterns to upload (repeatable)")
+	comfyRunCmd.Flags().StringVar(&comfyOut, "out", ".", "directory for the output images")
+	comfyRunCmd.Flags().DurationVar(&comfyTimeout, "timeout", 10*time.Minute, "cancel the job after this long")
+	comfyRunCmd.Flags().DurationVar(&comfyPollInterval, "poll-interval", 2*time.Second, "how often to poll a job that outlives a runsync call")
+
+	comfyCmd.AddCommand(comfyRunCmd)
+}
+
+// comfyImage is an input image as the comfyui worker expects it
+type comfyImage struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// comfyResult is a finished workflow run as printed
+type comfyResult struct {
+	JobID         string        `json:"jobId"`
+	Status        string        `json:"status"`
+	DelayTime     int64         `json:"delayTime"`
+	ExecutionTime int64         `json:"executionTime"`
+	Files         []savedOutput `json:"files"`
+}
+
+func runComfyRun(cmd *cobra.Command, args []string) error {
+	endpointID, workflowPath := args[0], args[1]
+	if comfyTimeout <= 0 || comfyPollInterval <= 0 {
+		return fmt.Errorf("--timeout and --poll-interval must be positive")
+	}
+
+	workflow, err := readComfyWorkflow(workflowPath)
+	if err != nil {
+		return err
+	}
+	for _, assignment := range comfySet {
+		if err := patchWorkflow(workflow, assignment); err != nil {
+			return err
+		}
+	}
+	images, err := readComfyImages(comfyImages)
+	if err != nil {
+		return err
+	}
+
+	input := map[string]interface{}{"workflow": workflow}
+	if len(images) > 0 {
+		input["images"] = images
+	}
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stderr := cmd.ErrOrStderr()
+	fmt.Fprintf(stderr, "running %s on %s\n", filepath.Base(workflowPath), endpointID)
+	job, err := awaitJob(ctx, client, endpointID, body, comfyTimeout, comfyPollInterval)
+	if err != nil {
+		err = withJobID(err)
+		output.Error(err)
+		return err
+	}
+	if job.Status != api.JobStatusCompleted {
+		err := fmt.Errorf("job %s %s", job.ID, jobFailure(job))
+		output.Error(err)
+		return err
+	}
+
+	result := comfyResult{JobID: job.ID, Status: job.Status, DelayTime: job.DelayTime, ExecutionTime: job.ExecutionTime, Files: []savedOutput{}}
+	if len(job.Output) > 0 {
+		_, saved, err := saveJobOutputs(ctx, stderr, job.Output, comfyOut)
+		if err != nil {
+			return err
+		}
+		result.Files = append(result.Files, saved...)
+	}
+	if len(result.Files) == 0 {
+		fmt.Fprintf(stderr, "warning: job %s returned no images: %s\n", job.ID, string(job.Output))
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+// readComfyWorkflow reads an api-format workflow, also accepting a request
+// body for the comfyui worker ({"input": {"workflow": ...}})
+func readComfyWorkflow(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var workflow map[string]interface{}
+	if err := decoder.Decode(&workflow); err != nil {
+		return nil, fmt.Errorf("workflow %s is not a json object: %w", path, err)
+	}
+
+	if input, ok := workflow["input"].(map[string]interface{}); ok {
+		workflow = input
+	}
+	if inner, ok := workflow["workflow"].(map[string]interface{}); ok {
+		workflow = inner
+	}
+	if _, ok := workflow["nodes"].([]interface{}); ok {
+		return nil, fmt.Errorf("workflow %s is in ui format; export it with \"save (api)\" in comfyui", path)
+	}
+	if len(workflow) == 0 {
+		return nil, fmt.Errorf("workflow %s has no nodes", path)
+	}
+	return workflow, nil
+}
+
+// patchWorkflow applies one <node-id>.<path>=<value> assignment. the input
+// must already exist, so a typo fails instead of being ignored by comfyui.
+func patchWorkflow(workflow map[string]interface{}, assignment string) error {
+	target, raw, ok := strings.Cut(assignment, "=")
+	if !ok || target == "" {
+		return fmt.Errorf("invalid --set %q, expected <node-id>.inputs.<name>=<value>", assignment)
+	}
+	parts := strings.Split(target, ".")
+	if len(parts) < 2 {
+		return fmt.Errorf("invalid --set %q, expected <node-id>.inputs.<name>=<value>", assignment)
+	}
+
+	node, ok := workflow[parts[0]]
+	if !ok {
+		return fmt.Errorf("--set %s: node %s not found in workflow", target, parts[0])
+	}
+
+	current := node
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		switch container := current.(type) {
+		case map[string]interface{}:
+			existing, ok := container[part]
+			if !ok {
+				return fmt.Errorf("--set %s: %s not found", target, strings.Join(parts[:i+2], "."))
+			}
+			if last {
+				value, err := comfyValue(existing, raw)
+				if err != nil {
+					return fmt.Errorf("--set %s: %w", target, err)
+				}
+				container[part] = value
+				return nil
+			}
+			current = existing
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(container) {
+				return fmt.Errorf("--set %s: %s not found", target, strings.Join(parts[:i+2], "."))
+			}
+			if last {
+				value, err := comfyValue(container[index], raw)
+				if err != nil {
+					return fmt.Errorf("--set %s: %w", target, err)
+				}
+				container[index] = value
+				return nil
+			}
+			current = container[index]
+		default:
+			return fmt.Errorf("--set %s: %s is not an object", target, strings.Join(parts[:i+1], "."))
+		}
+	}
+	return nil
+}
+
+// comfyValue converts raw to the type of the value it replaces: strings stay
+// strings, anything else is parsed as json
+func comfyValue(existing interface{}, raw string) (interface{}, error) {
+	if _, ok := existing.(string); ok {
+		return raw, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("value %q is not valid json", raw)
+	}
+	return value, nil
+}
+
+// readComfyImages expands the --images patterns and encodes each file as
+// base64 under its file name
+func readComfyImages(patterns []string) ([]comfyImage, error) {
+	var images []comfyImage
+	seen := map[string]string{}
+	for _, pattern := range patterns {
+		paths := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --images pattern %q: %w", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("--images %q matches no files", pattern)
+			}
+			paths = matches
+		}
+
+		for _, path := range paths {
+			name := filepath.Base(path)
+			if previous, ok := seen[name]; ok {
+				return nil, fmt.Errorf("input images %s and %s have the same name", previous, path)
+			}
+			seen[name] = path
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read image: %w", err)
+			}
+			images = append(images, comfyImage{Name: name, Image: base64.StdEncoding.EncodeToString(data)})
+		}
+	}
+	return images, nil
+}
@@ -0,0 +1,116 @@
+package serverless
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWorkflow = `{
+	"3": {"class_type": "KSampler", "inputs": {"seed": 1, "steps": 20, "model": ["4", 0]}},
+	"6": {"class_type": "CLIPTextEncode", "inputs": {"text": "a dog", "clip": ["4", 1]}},
+	"10": {"class_type": "LoadImage", "inputs": {"image": "input.png"}}
+}`
+
+func resetComfyVars(t *testing.T) {
+	t.Helper()
+	origSet, origImages, origOut, origTimeout, origPollInterval := comfySet, comfyImages, comfyOut, comfyTimeout, comfyPollInterval
+	t.Cleanup(func() {
+		comfySet, comfyImages, comfyOut, comfyTimeout, comfyPollInterval = origSet, origImages, origOut, origTimeout, origPollInterval
+	})
+	comfySet, comfyImages, comfyOut, comfyTimeout, comfyPollInterval = nil, nil, t.TempDir(), time.Minute, time.Millisecond
+}
+
+func TestRunComfyRun_PatchesAndSavesImages(t *testing.T) {
+	resetComfyVars(t)
+	encoded := base64.StdEncoding.EncodeToString(testPNG)
+	inputs := startFakeJobAPI(t, `{"images":[{"filename":"ComfyUI_00001_.png","type":"base64","data":"`+encoded+`"}]}`)
+
+	dir := t.TempDir()
+	workflowPath := filepath.Join(dir, "workflow.json")
+	if err := os.WriteFile(workflowPath, []byte(testWorkflow), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "input.png"), testPNG, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	comfySet = []string{"6.inputs.text=a cat", "3.inputs.seed=42"}
+	comfyImages = []string{filepath.Join(dir, "*.png")}
+
+	printed, _, err := runCaptured(t, runComfyRun, "ep-1", workflowPath)
+	if err != nil {
+		t.Fatalf("comfy run: %v", err)
+	}
+
+	input := (*inputs)[0]
+	workflow := input["workflow"].(map[string]interface{})
+	if text := workflow["6"].(map[string]interface{})["inputs"].(map[string]interface{})["text"]; text != "a cat" {
+		t.Fatalf("expected the patched prompt, got %v", text)
+	}
+	if seed := workflow["3"].(map[string]interface{})["inputs"].(map[string]interface{})["seed"]; seed != float64(42) {
+		t.Fatalf("expected a numeric seed, got %#v", seed)
+	}
+	images, _ := input["images"].([]interface{})
+	if len(images) != 1 || images[0].(map[string]interface{})["name"] != "input.png" {
+		t.Fatalf("unexpected images %v", input["images"])
+	}
+
+	var result comfyResult
+	if err := json.Unmarshal([]byte(printed), &result); err != nil {
+		t.Fatalf("parse %q: %v", printed, err)
+	}
+	if len(result.Files) != 1 || result.Files[0].File != filepath.Join(comfyOut, "ComfyUI_00001_.png") {
+		t.Fatalf("unexpected files %+v", result.Files)
+	}
+	if _, err := os.Stat(result.Files[0].File); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatchWorkflow_Errors(t *testing.T) {
+	cases := map[string]string{
+		"9.inputs.text=x":   "node 9 not found",
+		"6.inputs.txt=x":    "6.inputs.txt not found",
+		"3.inputs.seed=abc": "not valid json",
+		"6.inputs.clip.5=1": "6.inputs.clip.5 not found",
+		"6.class_type.x=1":  "6.class_type is not an object",
+		"6.inputs.text":     "expected <node-id>",
+	}
+	for assignment, want := range cases {
+		var workflow map[string]interface{}
+		_ = json.Unmarshal([]byte(testWorkflow), &workflow)
+		err := patchWorkflow(workflow, assignment)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("patchWorkflow(%q) = %v, want %q", assignment, err, want)
+		}
+	}
+}
+
+func TestPatchWorkflow_ReplacesLink(t *testing.T) {
+	var workflow map[string]interface{}
+	_ = json.Unmarshal([]byte(testWorkflow), &workflow)
+	if err := patchWorkflow(workflow, `3.inputs.model=["5", 0]`); err != nil {
+		t.Fatal(err)
+	}
+	if err := patchWorkflow(workflow, `6.inputs.clip.0=7`); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(workflow)
+	if !strings.Contains(string(data), `"model":["5",0]`) || !strings.Contains(string(data), `"clip":["7",1]`) {
+		t.Fatalf("unexpected workflow %s", data)
+	}
+}
+
+func TestReadComfyWorkflow_RejectsUIFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.json")
+	if err := os.WriteFile(path, []byte(`{"nodes": [], "links": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readComfyWorkflow(path); err == nil || !strings.Contains(err.Error(), "save (api)") {
+		t.Fatalf("expected ui format error, got %v", err)
+	}
+}
@@ -1,13 +1,16 @@
 package serverless
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand/v2"
 	"strings"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
 	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/registry"
 
 	"github.com/spf13/cobra"
 )
@@ -25,6 +28,11 @@ requires either --template-id or --hub-id.
 --hub-id accepts both SERVERLESS and POD hub listings.
 hub deployment constraints are applied unless explicitly overridden by cli flags.
 
+with --template-id the template's image is checked against its registry
+first: it must exist for linux/amd64, and its compressed size is compared
+with the container disk. use --skip-image-check to skip this. hub images are
+built by runpod and aren't checked.
+
 examples:
   # create from a template
   runpodctl serverless create --template-id <id> --gpu-id "NVIDIA GeForce RTX 4090"
@@ -61,6 +69,8 @@ var (
 	createDataCenterIDs    string
 	createNetworkVolumeID  string
 	createEnvVars          []string
+	createEnvFile          string
+	createEnvSecrets       []string
 	createMinCudaVersion   string
 	createScaleBy          string
 	createScaleThreshold   int
@@ -69,12 +79,14 @@ var (
 	createExecutionTimeout int
 	createNetworkVolumeIDs string
 	createModelReferences  []string
+	createSkipImageCheck   bool
 )
 
 type serverlessCreateClient interface {
 	GetListing(string) (*api.Listing, error)
 	ResolveServerlessGpuPoolID(string) (string, error)
 	CreateEndpointGQL(*api.EndpointCreateGQLInput) (*api.Endpoint, error)
+	GetTemplate(string) (*api.Template, error)
 }
 
 var newServerlessCreateClient = func() (serverlessCreateClient, error) {
@@ -94,6 +106,8 @@ func init() {
 	createCmd.Flags().StringVar(&createDataCenterIDs, "data-center-ids", "", "comma-separated list of data center ids")
 	createCmd.Flags().StringVar(&createNetworkVolumeID, "network-volume-id", "", "network volume id to attach")
 	createCmd.Flags().StringSliceVar(&createEnvVars, "env", nil, "env vars in KEY=VALUE format; overrides hub defaults (repeatable)")
+	createCmd.Flags().StringVar(&createEnvFile, "env-file", "", "read env vars from a dotenv file; overrides hub defaults (--env takes precedence)")
+	createCmd.Flags().StringArrayVar(&createEnvSecrets, "env-secret", nil, "set an env var to a runpod secret as KEY=secretName; overrides hub defaults (repeatable)")
 	createCmd.Flags().StringVar(&createMinCudaVersion, "min-cuda-version", "", "minimum cuda version (e.g., 12.6)")
 	createCmd.Flags().StringVar(&createScaleBy, "scale-by", "", "autoscale strategy: delay (seconds of queue wait) or requests (pending request count)")
 	createCmd.Flags().IntVar(&createScaleThreshold, "scale-threshold", -1, "trigger point for autoscaler (delay: seconds, requests: count)")
@@ -102,6 +116,7 @@ func init() {
 	createCmd.Flags().IntVar(&createExecutionTimeout, "execution-timeout", -1, "max seconds per request")
 	createCmd.Flags().StringVar(&createNetworkVolumeIDs, "network-volume-ids", "", "comma-separated network volume ids for multi-region")
 	createCmd.Flags().StringArrayVar(&createModelReferences, "model-reference", nil, "hugging face model url with a ref to cache on the endpoint, e.g. https://huggingface.co/<org>/<model>:main; works with --template-id or --hub-id, gpu only (repeatable)")
+	createCmd.Flags().BoolVar(&createSkipImageCheck, "skip-image-check", false, "don't check the template's image against its registry before creating the endpoint")
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
@@ -158,22 +173,9 @@ func runCreate(cmd *cobra.Command, args []string) error {
 			output.Error(err)
 			return fmt.Errorf("failed to get hub listing: %w", err)
 		}
-		if listing.ListedRelease == nil {
-			return fmt.Errorf("hub listing %q has no published release", createHubID)
-		}
-
-		release = listing.ListedRelease
-		if release.Build != nil {
-			imageName = release.Build.ImageName
-		}
-		if imageName == "" {
-			return fmt.Errorf("hub listing %q has no built image; the release may still be building", createHubID)
-		}
-
-		if release.Config != "" {
-			if err := json.Unmarshal([]byte(release.Config), &hubConfig); err != nil {
-				return fmt.Errorf("failed to parse hub release config for %q: %w", createHubID, err)
-			}
+		release, imageName, hubConfig, err = hubListingRelease(listing, createHubID)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -295,18 +297,23 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 
 		// translate hub release env config into pod env vars
-		envMap := make(map[string]string, len(hubConfig.Env))
-		envOrder := make([]string, 0, len(hubConfig.Env))
-		for _, e := range hubConfig.Env {
-			val := ""
-			if e.Input != nil && e.Input.Default != nil {
-				val = fmt.Sprintf("%v", e.Input.Default)
+		envMap, envOrder := hubDefaultEnv(hubConfig)
+
+		// apply user --env-file then --env overrides (take precedence over hub defaults)
+		userKeys := make(map[string]bool)
+		if createEnvFile != "" {
+			fileEnv, err := envvars.ParseFile(createEnvFile)
+			if err != nil {
+				return err
+			}
+			for _, key := range envvars.Keys(fileEnv) {
+				if _, exists := envMap[key]; !exists {
+					envOrder = append(envOrder, key)
+				}
+				envMap[key] = fileEnv[key]
+				userKeys[key] = true
 			}
-			envMap[e.Key] = val
-			envOrder = append(envOrder, e.Key)
 		}
-
-		// apply user --env overrides (take precedence over hub defaults)
 		for _, kv := range createEnvVars {
 			parts := strings.SplitN(kv, "=", 2)
 			if len(parts) != 2 {
@@ -317,13 +324,26 @@ func runCreate(cmd *cobra.Command, args []string) error {
 				envOrder = append(envOrder, key)
 			}
 			envMap[key] = val
+			userKeys[key] = true
 		}
 
-		envVars := make([]*api.PodEnvVar, 0, len(envMap))
-		for _, key := range envOrder {
-			envVars = append(envVars, &api.PodEnvVar{Key: key, Value: envMap[key]})
+		// --env-secret replaces hub defaults but must not repeat a user key
+		secretEnv, err := envvars.WithSecretRefs(nil, createEnvSecrets)
+		if err != nil {
+			return err
+		}
+		for _, key := range envvars.Keys(secretEnv) {
+			if userKeys[key] {
+				return fmt.Errorf("%s is set both as a plain env var and with --env-secret", key)
+			}
+			if _, exists := envMap[key]; !exists {
+				envOrder = append(envOrder, key)
+			}
+			envMap[key] = secretEnv[key]
 		}
 
+		envVars := podEnvVars(envMap, envOrder)
+
 		if endpointName == "" {
 			endpointName = listing.Title
 		}
@@ -353,10 +373,15 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 	} else {
 		input.TemplateID = createTemplateID
+		if !createSkipImageCheck {
+			if err := checkTemplateImage(cmd, client, createTemplateID); err != nil {
+				return err
+			}
+		}
 		// --env only feeds an inline template (hub path); a referenced template's
 		// env is fixed, so saveEndpoint ignores it. don't drop it silently.
-		if len(createEnvVars) > 0 {
-			fmt.Fprintln(cmd.ErrOrStderr(), "note: --env has no effect with --template-id (env is defined by the template); ignoring")
+		if len(createEnvVars) > 0 || createEnvFile != "" || len(createEnvSecrets) > 0 {
+			fmt.Fprintln(cmd.ErrOrStderr(), "note: --env, --env-file and --env-secret have no effect with --template-id (env is defined by the template); ignoring")
 		}
 	}
 
@@ -377,6 +402,72 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	return output.Print(endpoint, &output.Config{Format: format})
 }
 
+// checkTemplateImage checks the image of the endpoint's template before
+// workers start billing in an image-pull loop
+func checkTemplateImage(cmd *cobra.Command, client serverlessCreateClient, templateID string) error {
+	template, err := client.GetTemplate(templateID)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "note: couldn't check the image of template %s: %v\n", templateID, err)
+		return nil
+	}
+	_, err = registry.Preflight(context.Background(), cmd.ErrOrStderr(), template.ImageName, registry.PreflightOptions{
+		RegistryAuthID:    template.ContainerRegistryAuthID,
+		ContainerDiskInGb: template.ContainerDiskInGb,
+	})
+	if err != nil {
+		output.Error(err)
+	}
+	return err
+}
+
+// hubListingRelease returns the published release of a hub listing with its
+// built image and parsed config. hubID is only used in error messages.
+func hubListingRelease(listing *api.Listing, hubID string) (*api.HubRelease, string, api.HubReleaseConfig, error) {
+	var config api.HubReleaseConfig
+	if listing.ListedRelease == nil {
+		return nil, "", config, fmt.Errorf("hub listing %q has no published release", hubID)
+	}
+
+	release := listing.ListedRelease
+	imageName := ""
+	if release.Build != nil {
+		imageName = release.Build.ImageName
+	}
+	if imageName == "" {
+		return nil, "", config, fmt.Errorf("hub listing %q has no built image; the release may still be building", hubID)
+	}
+
+	if release.Config != "" {
+		if err := json.Unmarshal([]byte(release.Config), &config); err != nil {
+			return nil, "", config, fmt.Errorf("failed to parse hub release config for %q: %w", hubID, err)
+		}
+	}
+	return release, imageName, config, nil
+}
+
+// hubDefaultEnv returns the env defaults of a hub release and their order
+func hubDefaultEnv(config api.HubReleaseConfig) (map[string]string, []string) {
+	envMap := make(map[string]string, len(config.Env))
+	envOrder := make([]string, 0, len(config.Env))
+	for _, e := range config.Env {
+		val := ""
+		if e.Input != nil && e.Input.Default != nil {
+			val = fmt.Sprintf("%v", e.Input.Default)
+		}
+		envMap[e.Key] = val
+		envOrder = append(envOrder, e.Key)
+	}
+	return envMap, envOrder
+}
+
+func podEnvVars(envMap map[string]string, envOrder []string) []*api.PodEnvVar {
+	envVars := make([]*api.PodEnvVar, 0, len(envMap))
+	for _, key := range envOrder {
+		envVars = append(envVars, &api.PodEnvVar{Key: key, Value: envMap[key]})
+	}
+	return envVars
+}
+
 // flagChanged reports whether a command-line value was explicitly provided.
 // Hub release values must override CLI defaults, but never explicit user input.
 func flagChanged(cmd *cobra.Command, name string) bool {
@@ -0,0 +1,568 @@
+package serverless
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
+	"github.com/runpod/runpodctl/internal/hf"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "deploy a preset worker as an endpoint",
+	Long: `deploy a well-known worker from the hub in one command.
+
+a preset resolves the hub listing, sizes the gpu, sets the recommended env,
+creates the endpoint and waits until a worker is ready. use "serverless create
+--hub-id" for full control over the endpoint.`,
+}
+
+var deployVllmCmd = &cobra.Command{
+	Use:   "vllm",
+	Short: "deploy a vllm endpoint for a hugging face model",
+	Long: `deploy runpod-workers/worker-vllm serving a hugging face model.
+
+the gpu is sized from the model's parameter count and dtype: the weights plus
+20% and 2 gb of headroom must fit, on the smallest gpu pool that can hold them
+or, failing that, split over 2, 4 or 8 gpus with tensor parallelism. the
+parameter count comes from the hub's safetensors metadata, else from the model
+name (e.g. 7B); set --params when neither has it.
+
+the model is attached as a model reference so it is cached on the workers.
+set HF_TOKEN for gated models, e.g. with --env-secret HF_TOKEN=<secret-name>.`,
+	Example: `  runpodctl serverless deploy vllm --model Qwen/Qwen2.5-7B-Instruct
+  runpodctl serverless deploy vllm --model meta-llama/Llama-3.1-70B-Instruct --dtype fp8 --env-secret HF_TOKEN=hf-token
+  runpodctl serverless deploy vllm --model Qwen/Qwen2.5-7B-Instruct-AWQ --dtype awq --max-model-len 16384`,
+	Args: cobra.NoArgs,
+	RunE: runDeployVllm,
+}
+
+var deployComfyUICmd = &cobra.Command{
+	Use:   "comfyui",
+	Short: "deploy a comfyui endpoint",
+	Long: `deploy runpod-workers/worker-comfyui with the hub release defaults, on the
+smallest gpu pool with at least --vram gb. submit workflows with
+"serverless comfy run".`,
+	Example: `  runpodctl serverless deploy comfyui
+  runpodctl serverless deploy comfyui --vram 48 --workers-max 5`,
+	Args: cobra.NoArgs,
+	RunE: runDeployComfyUI,
+}
+
+const (
+	vllmHubRepo    = "runpod-workers/worker-vllm"
+	comfyUIHubRepo = "runpod-workers/worker-comfyui"
+)
+
+// deployPollInterval is how often --wait checks endpoint health
+var deployPollInterval = 10 * time.Second
+
+var (
+	deployName            string
+	deployHubID           string
+	deployGpuID           string
+	deployGpuCount        int
+	deployWorkersMin      int
+	deployWorkersMax      int
+	deployDataCenterIDs   string
+	deployNetworkVolumeID string
+	deployEnvVars         []string
+	deployEnvSecrets      []string
+	deployWait            bool
+	deployWaitTimeout     time.Duration
+
+	deployModel       string
+	deployRevision    string
+	deployDtype       string
+	deployParams      string
+	deployMaxModelLen int
+
+	deployVRAM int
+)
+
+func init() {
+	deployCmd.PersistentFlags().StringVar(&deployName, "name", "", "endpoint name (default: derived from the preset)")
+	deployCmd.PersistentFlags().StringVar(&deployHubID, "hub-id", "", "hub listing id to deploy instead of the preset's listing")
+	deployCmd.PersistentFlags().StringVar(&deployGpuID, "gpu-id", "", "gpu id or pool id, skips gpu sizing")
+	deployCmd.PersistentFlags().IntVar(&deployGpuCount, "gpu-count", 0, "gpus per worker (default: sized from the model)")
+	deployCmd.PersistentFlags().IntVar(&deployWorkersMin, "workers-min", 0, "minimum number of workers")
+	deployCmd.PersistentFlags().IntVar(&deployWorkersMax, "workers-max", 3, "maximum number of workers")
+	deployCmd.PersistentFlags().StringVar(&deployDataCenterIDs, "data-center-ids", "", "comma-separated list of data center ids")
+	deployCmd.PersistentFlags().StringVar(&deployNetworkVolumeID, "network-volume-id", "", "network volume id to attach")
+	deployCmd.PersistentFlags().StringArrayVar(&deployEnvVars, "env", nil, "extra env vars in KEY=VALUE format; override the preset (repeatable)")
+	deployCmd.PersistentFlags().StringArrayVar(&deployEnvSecrets, "env-secret", nil, "set an env var to a runpod secret as KEY=secretName (repeatable)")
+	deployCmd.PersistentFlags().BoolVar(&deployWait, "wait", true, "wait until a worker is ready")
+	deployCmd.PersistentFlags().DurationVar(&deployWaitTimeout, "wait-timeout", 30*time.Minute, "how long to wait for a ready worker")
+
+	deployVllmCmd.Flags().StringVar(&deployModel, "model", "", "hugging face model id, e.g. Qwen/Qwen2.5-7B-Instruct (required)")
+	deployVllmCmd.Flags().StringVar(&deployRevision, "revision", "main", "model revision (branch, tag or commit)")
+	deployVllmCmd.Flags().StringVar(&deployDtype, "dtype", "auto", "weight dtype: auto, bf16, fp16, fp8, awq or gptq")
+	deployVllmCmd.Flags().StringVar(&deployParams, "params", "", "parameter count when it cannot be detected, e.g. 7B or 8x7B")
+	deployVllmCmd.Flags().IntVar(&deployMaxModelLen, "max-model-len", 8192, "maximum context length (0 for the model default)")
+	deployVllmCmd.MarkFlagRequired("model") //nolint:errcheck
+
+	deployComfyUICmd.Flags().IntVar(&deployVRAM, "vram", 24, "minimum gpu memory in gb")
+
+	deployCmd.AddCommand(deployVllmCmd)
+	deployCmd.AddCommand(deployComfyUICmd)
+}
+
+// deployPlan is what a preset adds on top of the hub release defaults
+type deployPlan struct {
+	hubRepo         string
+	name            string
+	env             map[string]string
+	envOrder        []string
+	gpuIDs          string
+	gpuCount        int
+	modelReferences []string
+}
+
+func (p *deployPlan) setEnv(key, value string) {
+	if p.env == nil {
+		p.env = map[string]string{}
+	}
+	if _, exists := p.env[key]; !exists {
+		p.envOrder = append(p.envOrder, key)
+	}
+	p.env[key] = value
+}
+
+// gpuPool is a serverless gpu pool with its memory per gpu
+type gpuPool struct {
+	id     string
+	vramGB int
+}
+
+// fallbackGpuPools are used when the pools query is unavailable
+var fallbackGpuPools = []gpuPool{
+	{"AMPERE_16", 16}, {"AMPERE_24", 24}, {"ADA_24", 24}, {"AMPERE_48", 48},
+	{"ADA_48_PRO", 48}, {"AMPERE_80", 80}, {"ADA_80_PRO", 80}, {"HOPPER_141", 141},
+}
+
+// serverlessGpuPools returns the pools with their memory, which pool ids
+// carry as their second part (e.g. ADA_24, ADA_32_PRO)
+func serverlessGpuPools(client *api.Client) []gpuPool {
+	pools, err := client.ListServerlessGpuPools()
+	if err != nil || len(pools) == 0 {
+		return fallbackGpuPools
+	}
+	sized := make([]gpuPool, 0, len(pools))
+	for _, pool := range pools {
+		parts := strings.Split(pool.ID, "_")
+		if len(parts) < 2 {
+			continue
+		}
+		if vram, err := strconv.Atoi(parts[1]); err == nil && vram > 0 {
+			sized = append(sized, gpuPool{id: pool.ID, vramGB: vram})
+		}
+	}
+	if len(sized) == 0 {
+		return fallbackGpuPools
+	}
+	return sized
+}
+
+// pickGpuPools returns every pool of the smallest memory size that holds
+// needGB on gpuCount gpus, trying 1, 2, 4 and 8 gpus in turn unless
+// gpuCount is fixed
+func pickGpuPools(pools []gpuPool, needGB float64, gpuCount int) (string, int, error) {
+	counts := []int{1, 2, 4, 8}
+	if gpuCount > 0 {
+		counts = []int{gpuCount}
+	}
+	for _, count := range counts {
+		best := 0
+		for _, pool := range pools {
+			if float64(pool.vramGB*count) >= needGB && (best == 0 || pool.vramGB < best) {
+				best = pool.vramGB
+			}
+		}
+		if best == 0 {
+			continue
+		}
+		var ids []string
+		for _, pool := range pools {
+			if pool.vramGB == best {
+				ids = append(ids, pool.id)
+			}
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ","), count, nil
+	}
+	return "", 0, fmt.Errorf("no gpu pool fits %.0f gb on %d gpus; pass --gpu-id and --gpu-count", needGB, counts[len(counts)-1])
+}
+
+var (
+	paramMoERe   = regexp.MustCompile(`(?i)(\d+)x(\d+(?:\.\d+)?)([bm])`)
+	paramCountRe = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)([bm])`)
+	paramNameRe  = regexp.MustCompile(`(?i)(?:^|[^a-z0-9.])(\d+x)?\d+(?:\.\d+)?[bm](?:$|[^a-z0-9])`)
+)
+
+// parseParamCount parses counts like 7B, 0.5b, 70M, 8x7B or a plain number
+func parseParamCount(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+		return n, nil
+	}
+	scale := func(unit string) float64 {
+		if strings.EqualFold(unit, "b") {
+			return 1e9
+		}
+		return 1e6
+	}
+	if m := paramMoERe.FindStringSubmatch(value); m != nil && m[0] == value {
+		experts, _ := strconv.ParseFloat(m[1], 64)
+		size, _ := strconv.ParseFloat(m[2], 64)
+		return int64(experts * size * scale(m[3])), nil
+	}
+	if m := paramCountRe.FindStringSubmatch(value); m != nil && m[0] == value {
+		size, _ := strconv.ParseFloat(m[1], 64)
+		return int64(size * scale(m[2])), nil
+	}
+	return 0, fmt.Errorf("invalid parameter count %q; use e.g. 7B, 0.5B or 8x7B", value)
+}
+
+// paramCountFromName finds a size like -7B- or _8x7b in a model id
+func paramCountFromName(model string) int64 {
+	name := model[strings.LastIndex(model, "/")+1:]
+	match := paramNameRe.FindString(name)
+	if match == "" {
+		return 0
+	}
+	match = strings.Trim(match, "-_. ")
+	n, err := parseParamCount(match)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// deployQuantization checks --dtype and returns the quantization vllm needs
+// for it
+func deployQuantization(dtype string) (string, error) {
+	switch strings.ToLower(dtype) {
+	case "auto", "", "bf16", "bfloat16", "fp16", "float16", "half":
+		return "", nil
+	case "fp8", "awq", "gptq":
+		return strings.ToLower(dtype), nil
+	}
+	return "", fmt.Errorf("invalid --dtype %q (use auto, bf16, fp16, fp8, awq or gptq)", dtype)
+}
+
+// dtypeBytes is the size of one element of a safetensors dtype
+func dtypeBytes(dtype string) float64 {
+	switch strings.ToUpper(dtype) {
+	case "F64", "I64", "U64":
+		return 8
+	case "F32", "I32", "U32":
+		return 4
+	case "F8_E4M3", "F8_E5M2", "I8", "U8", "BOOL":
+		return 1
+	}
+	return 2
+}
+
+// weightBytes estimates the memory the weights take once loaded. with the
+// checkpoint's per-dtype counts it is the sum of count x element size, which
+// also holds for awq and gptq checkpoints: their int32 tensors are already
+// packed, and their f16 scales are counted as they are. float tensors load
+// at --dtype, and under auto full precision loads as half, as in vllm.
+// without counts, params is sized at --dtype, with auto taken as half.
+func weightBytes(dtype string, byDtype map[string]int64, params int64) float64 {
+	dtype = strings.ToLower(dtype)
+	if len(byDtype) == 0 {
+		switch dtype {
+		case "fp8":
+			return float64(params)
+		case "awq", "gptq":
+			return float64(params) / 2
+		}
+		return float64(params) * 2
+	}
+
+	var total float64
+	for checkpointDtype, count := range byDtype {
+		size := dtypeBytes(checkpointDtype)
+		if upper := strings.ToUpper(checkpointDtype); strings.HasPrefix(upper, "F") || upper == "BF16" {
+			switch dtype {
+			case "bf16", "bfloat16", "fp16", "float16", "half":
+				size = 2
+			case "fp8":
+				size = 1
+			default:
+				size = math.Min(size, 2)
+			}
+		}
+		total += float64(count) * size
+	}
+	return total
+}
+
+// requiredVRAMGB is the memory the weights need plus headroom for
+// activations, cuda graphs and a usable kv cache
+func requiredVRAMGB(weightBytes float64) float64 {
+	return math.Ceil(weightBytes/1e9*1.2 + 2)
+}
+
+func runDeployVllm(cmd *cobra.Command, args []string) error {
+	model := strings.TrimSpace(deployModel)
+	if strings.Count(model, "/") != 1 || strings.HasPrefix(model, "/") || strings.HasSuffix(model, "/") {
+		return fmt.Errorf("--model must be a hugging face model id like Qwen/Qwen2.5-7B-Instruct")
+	}
+	if deployMaxModelLen < 0 {
+		return fmt.Errorf("--max-model-len must not be negative")
+	}
+	quantization, err := deployQuantization(deployDtype)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	stderr := cmd.ErrOrStderr()
+
+	info, err := hf.NewClient().Model(model, deployRevision)
+	if err != nil {
+		// a gated model may still deploy with a token secret; only a missing
+		// model is certain to fail
+		if errors.Is(err, hf.ErrNotFound) {
+			return err
+		}
+		fmt.Fprintf(stderr, "warning: could not read model info: %v\n", err)
+		info = &hf.ModelInfo{ID: model}
+	}
+
+	plan := &deployPlan{hubRepo: vllmHubRepo, name: model[strings.Index(model, "/")+1:]}
+	plan.setEnv("MODEL_NAME", model)
+	if deployRevision != "" && deployRevision != "main" {
+		plan.setEnv("MODEL_REVISION", deployRevision)
+	}
+	if deployMaxModelLen > 0 {
+		plan.setEnv("MAX_MODEL_LEN", strconv.Itoa(deployMaxModelLen))
+	}
+	if quantization != "" {
+		plan.setEnv("QUANTIZATION", quantization)
+	}
+	switch strings.ToLower(deployDtype) {
+	case "bf16", "bfloat16":
+		plan.setEnv("DTYPE", "bfloat16")
+	case "fp16", "float16", "half":
+		plan.setEnv("DTYPE", "float16")
+	}
+	revision := deployRevision
+	if revision == "" {
+		revision = "main"
+	}
+	plan.modelReferences = []string{hf.ModelURL(model) + ":" + revision}
+
+	if deployGpuID != "" {
+		poolID, err := client.ResolveServerlessGpuPoolID(deployGpuID)
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+		plan.gpuIDs, plan.gpuCount = poolID, max(deployGpuCount, 1)
+	} else {
+		params, byDtype := info.Parameters, info.ParametersByDtype
+		if deployParams != "" {
+			if params, err = parseParamCount(deployParams); err != nil {
+				return err
+			}
+			byDtype = nil
+		}
+		if params == 0 {
+			params = paramCountFromName(model)
+		}
+		if params == 0 {
+			return fmt.Errorf("could not detect the parameter count of %s; pass --params (e.g. 7B) or --gpu-id", model)
+		}
+
+		weights := weightBytes(deployDtype, byDtype, params)
+		need := requiredVRAMGB(weights)
+		plan.gpuIDs, plan.gpuCount, err = pickGpuPools(serverlessGpuPools(client), need, deployGpuCount)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "sized %s (%.1fB params, %.1f gb of weights): needs %.0f gb, using %d x %s\n",
+			model, float64(params)/1e9, weights/1e9, need, plan.gpuCount, plan.gpuIDs)
+	}
+	plan.setEnv("TENSOR_PARALLEL_SIZE", strconv.Itoa(plan.gpuCount))
+
+	return deployFromHub(cmd, client, plan)
+}
+
+func runDeployComfyUI(cmd *cobra.Command, args []string) error {
+	if deployVRAM < 1 {
+		return fmt.Errorf("--vram must be at least 1")
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	plan := &deployPlan{hubRepo: comfyUIHubRepo, name: "comfyui"}
+	if deployGpuID != "" {
+		poolID, err := client.ResolveServerlessGpuPoolID(deployGpuID)
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+		plan.gpuIDs, plan.gpuCount = poolID, max(deployGpuCount, 1)
+	} else {
+		plan.gpuIDs, plan.gpuCount, err = pickGpuPools(serverlessGpuPools(client), float64(deployVRAM), max(deployGpuCount, 1))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "using %d x %s (at least %d gb)\n", plan.gpuCount, plan.gpuIDs, deployVRAM)
+	}
+
+	return deployFromHub(cmd, client, plan)
+}
+
+// deployFromHub creates an endpoint from the plan's hub listing, layering
+// the hub env defaults, the plan's env and the user's --env and --env-secret,
+// then optionally waits for a ready worker
+func deployFromHub(cmd *cobra.Command, client *api.Client, plan *deployPlan) error {
+	var listing *api.Listing
+	var err error
+	hubID := deployHubID
+	if hubID != "" {
+		listing, err = client.GetListing(hubID)
+	} else {
+		hubID = plan.hubRepo
+		owner, name, _ := strings.Cut(plan.hubRepo, "/")
+		listing, err = client.GetListingFromRepo(owner, name)
+	}
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get hub listing: %w", err)
+	}
+
+	release, imageName, hubConfig, err := hubListingRelease(listing, hubID)
+	if err != nil {
+		return err
+	}
+
+	envMap, envOrder := hubDefaultEnv(hubConfig)
+	userEnv, err := envvars.ParseAssignments(deployEnvVars)
+	if err != nil {
+		return err
+	}
+	userEnv, err = envvars.WithSecretRefs(userEnv, deployEnvSecrets)
+	if err != nil {
+		return err
+	}
+	for _, layer := range []struct {
+		env   map[string]string
+		order []string
+	}{{plan.env, plan.envOrder}, {userEnv, envvars.Keys(userEnv)}} {
+		for _, key := range layer.order {
+			if _, exists := envMap[key]; !exists {
+				envOrder = append(envOrder, key)
+			}
+			envMap[key] = layer.env[key]
+		}
+	}
+
+	name := strings.TrimSpace(deployName)
+	if name == "" {
+		//nolint:gosec
+		name = fmt.Sprintf("%s-%s", strings.ToLower(plan.name), randomString(5))
+	}
+	if len(name) < 3 {
+		return fmt.Errorf("--name must be at least 3 characters")
+	}
+
+	containerDisk := 10
+	if hubConfig.ContainerDiskInGb > 0 {
+		containerDisk = hubConfig.ContainerDiskInGb
+	}
+
+	input := &api.EndpointCreateGQLInput{
+		Name:            name,
+		HubReleaseID:    release.ID,
+		GpuIDs:          plan.gpuIDs,
+		GpuCount:        plan.gpuCount,
+		WorkersMin:      deployWorkersMin,
+		WorkersMax:      deployWorkersMax,
+		Locations:       deployDataCenterIDs,
+		NetworkVolumeID: deployNetworkVolumeID,
+		MinCudaVersion:  hubMinCudaVersion(hubConfig.AllowedCudaVersions),
+		FlashBootType:   "FLASHBOOT",
+		ModelReferences: plan.modelReferences,
+		Template: &api.EndpointTemplateInput{
+			//nolint:gosec
+			Name:              fmt.Sprintf("%s__template__%s", name, randomString(7)),
+			ImageName:         imageName,
+			ContainerDiskInGb: containerDisk,
+			DockerArgs:        "",
+			Env:               podEnvVars(envMap, envOrder),
+		},
+	}
+
+	endpoint, err := client.CreateEndpointGQL(input)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to create endpoint: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	if !deployWait {
+		return output.Print(endpoint, &output.Config{Format: format})
+	}
+
+	stderr := cmd.ErrOrStderr()
+	fmt.Fprintf(stderr, "created endpoint %s, waiting for a ready worker (up to %s)\n", endpoint.ID, deployWaitTimeout)
+	if err := waitForReadyWorker(client, endpoint.ID, deployWaitTimeout, deployPollInterval, stderr); err != nil {
+		if printErr := output.Print(endpoint, &output.Config{Format: format}); printErr != nil {
+			return printErr
+		}
+		return err
+	}
+	return output.Print(endpoint, &output.Config{Format: format})
+}
+
+// waitForReadyWorker polls endpoint health until a worker is idle, ready or
+// running. new endpoints start workers to pull the image even with zero
+// minimum workers, so this also covers scale-to-zero endpoints.
+func waitForReadyWorker(client *api.Client, endpointID string, timeout, interval time.Duration, progress io.Writer) error {
+	deadline := time.Now().Add(timeout)
+	last := ""
+	for {
+		health, err := client.GetEndpointHealth(endpointID)
+		if err == nil {
+			workers := health.Workers
+			if workers.Idle+workers.Ready+workers.Running > 0 {
+				fmt.Fprintf(progress, "endpoint %s is ready\n", endpointID)
+				return nil
+			}
+			state := fmt.Sprintf("%d initializing, %d throttled, %d unhealthy", workers.Initializing, workers.Throttled, workers.Unhealthy)
+			if state != last {
+				fmt.Fprintf(progress, "workers: %s\n", state)
+				last = state
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("endpoint %s has no ready worker after %s; check it with 'runpodctl serverless workers %s'", endpointID, timeout, endpointID)
+		}
+		time.Sleep(interval)
+	}
+}
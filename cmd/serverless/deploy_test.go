@@ -0,0 +1,254 @@
+package serverless
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetDeployVars(t *testing.T) {
+	t.Helper()
+	origName, origHubID, origGpuID, origGpuCount := deployName, deployHubID, deployGpuID, deployGpuCount
+	origWorkersMin, origWorkersMax, origDataCenterIDs, origNetworkVolumeID := deployWorkersMin, deployWorkersMax, deployDataCenterIDs, deployNetworkVolumeID
+	origEnvVars, origEnvSecrets, origWait, origWaitTimeout := deployEnvVars, deployEnvSecrets, deployWait, deployWaitTimeout
+	origModel, origRevision, origDtype, origParams, origMaxModelLen := deployModel, deployRevision, deployDtype, deployParams, deployMaxModelLen
+	origVRAM, origPollInterval := deployVRAM, deployPollInterval
+	t.Cleanup(func() {
+		deployName, deployHubID, deployGpuID, deployGpuCount = origName, origHubID, origGpuID, origGpuCount
+		deployWorkersMin, deployWorkersMax, deployDataCenterIDs, deployNetworkVolumeID = origWorkersMin, origWorkersMax, origDataCenterIDs, origNetworkVolumeID
+		deployEnvVars, deployEnvSecrets, deployWait, deployWaitTimeout = origEnvVars, origEnvSecrets, origWait, origWaitTimeout
+		deployModel, deployRevision, deployDtype, deployParams, deployMaxModelLen = origModel, origRevision, origDtype, origParams, origMaxModelLen
+		deployVRAM, deployPollInterval = origVRAM, origPollInterval
+	})
+	deployName, deployHubID, deployGpuID, deployGpuCount = "", "", "", 0
+	deployWorkersMin, deployWorkersMax, deployDataCenterIDs, deployNetworkVolumeID = 0, 3, "", ""
+	deployEnvVars, deployEnvSecrets, deployWait, deployWaitTimeout = nil, nil, true, time.Second
+	deployModel, deployRevision, deployDtype, deployParams, deployMaxModelLen = "", "main", "auto", "", 8192
+	deployVRAM, deployPollInterval = 24, time.Millisecond
+}
+
+// startFakeDeployAPI serves the hub listing, gpu pools, saveEndpoint, the
+// endpoint health and the hugging face model api. it returns the captured
+// saveEndpoint input.
+func startFakeDeployAPI(t *testing.T) *map[string]interface{} {
+	t.Helper()
+	var saved map[string]interface{}
+	healthChecks := 0
+
+	server := useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			var body struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			switch {
+			case strings.Contains(body.Query, "listingFromRepo"):
+				if body.Variables["repoOwner"] != "runpod-workers" {
+					t.Errorf("unexpected listing lookup %v", body.Variables)
+				}
+				config := `{"containerDiskInGb":50,"allowedCudaVersions":["12.4"],"env":[{"key":"MODEL_NAME","input":{"default":"facebook/opt-125m"}},{"key":"GPU_MEMORY_UTILIZATION","input":{"default":0.95}}]}`
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"listingFromRepo": map[string]interface{}{
+					"id":            "hub-1",
+					"title":         body.Variables["repoName"],
+					"listedRelease": map[string]interface{}{"id": "rel-1", "config": config, "build": map[string]string{"imageName": "runpod/worker:1"}},
+				}}})
+			case strings.Contains(body.Query, "serverlessGpuPools"):
+				_, _ = w.Write([]byte(`{"data":{"serverlessGpuPools":[{"id":"AMPERE_16"},{"id":"ADA_24"},{"id":"AMPERE_24"},{"id":"AMPERE_80"},{"id":"HOPPER_141"}]}}`))
+			case strings.Contains(body.Query, "saveEndpoint"):
+				saved, _ = body.Variables["input"].(map[string]interface{})
+				_, _ = w.Write([]byte(`{"data":{"saveEndpoint":{"id":"ep-new","name":"x"}}}`))
+			default:
+				t.Errorf("unexpected graphql query %s", body.Query)
+			}
+		case r.URL.Path == "/ep-new/health":
+			healthChecks++
+			workers := map[string]int{"initializing": 1}
+			if healthChecks > 1 {
+				workers = map[string]int{"idle": 1}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"workers": workers})
+		case r.URL.Path == "/api/models/Qwen/Qwen2.5-7B-Instruct/revision/main":
+			_, _ = w.Write([]byte(`{"id":"Qwen/Qwen2.5-7B-Instruct","gated":false,"safetensors":{"parameters":{"BF16":7615616512},"total":7615616512}}`))
+		case strings.HasPrefix(r.URL.Path, "/api/models/meta-llama/"):
+			http.Error(w, "gated", http.StatusUnauthorized)
+		case strings.HasPrefix(r.URL.Path, "/api/models/"):
+			http.NotFound(w, r)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Setenv("HF_ENDPOINT", server.URL)
+	return &saved
+}
+
+func savedEnv(t *testing.T, input map[string]interface{}) map[string]string {
+	t.Helper()
+	template, _ := input["template"].(map[string]interface{})
+	entries, _ := template["env"].([]interface{})
+	env := map[string]string{}
+	for _, entry := range entries {
+		pair := entry.(map[string]interface{})
+		env[pair["key"].(string)] = pair["value"].(string)
+	}
+	return env
+}
+
+func TestRunDeployVllm_SizesGpuAndSetsEnv(t *testing.T) {
+	resetDeployVars(t)
+	saved := startFakeDeployAPI(t)
+	deployModel = "Qwen/Qwen2.5-7B-Instruct"
+	deployEnvVars = []string{"GPU_MEMORY_UTILIZATION=0.9"}
+
+	_, stderr, err := runCaptured(t, runDeployVllm)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	input := *saved
+	if input == nil {
+		t.Fatal("expected saveEndpoint to be called")
+	}
+
+	// 7.6B bf16 params need ceil(7.6*2*1.2+2) = 21 gb: both 24 gb pools
+	if input["gpuIds"] != "ADA_24,AMPERE_24" || input["gpuCount"] != float64(1) {
+		t.Fatalf("unexpected gpu sizing %v x %v", input["gpuIds"], input["gpuCount"])
+	}
+	if input["hubReleaseId"] != "rel-1" || input["minCudaVersion"] != "12.4" {
+		t.Fatalf("expected hub release settings, got %v", input)
+	}
+	refs, _ := input["modelReferences"].([]interface{})
+	if len(refs) != 1 || refs[0] != "https://huggingface.co/Qwen/Qwen2.5-7B-Instruct:main" {
+		t.Fatalf("unexpected model references %v", input["modelReferences"])
+	}
+
+	env := savedEnv(t, input)
+	want := map[string]string{
+		"MODEL_NAME":             "Qwen/Qwen2.5-7B-Instruct",
+		"MAX_MODEL_LEN":          "8192",
+		"TENSOR_PARALLEL_SIZE":   "1",
+		"GPU_MEMORY_UTILIZATION": "0.9",
+	}
+	for key, value := range want {
+		if env[key] != value {
+			t.Fatalf("expected %s=%s, got env %v", key, value, env)
+		}
+	}
+	if !strings.Contains(stderr, "endpoint ep-new is ready") {
+		t.Fatalf("expected wait to finish, got %q", stderr)
+	}
+}
+
+func TestRunDeployVllm_TensorParallelForLargeModels(t *testing.T) {
+	resetDeployVars(t)
+	saved := startFakeDeployAPI(t)
+	deployModel = "meta-llama/Llama-3.1-405B-Instruct"
+	deployDtype = "fp8"
+	deployWait = false
+
+	_, stderr, err := runCaptured(t, runDeployVllm)
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	// a gated model falls back to the size in its name
+	if !strings.Contains(stderr, "warning: could not read model info") {
+		t.Fatalf("expected a warning for the gated model, got %q", stderr)
+	}
+	input := *saved
+	// 405B fp8 needs 488 gb: 4 x 141 gb
+	if input["gpuIds"] != "HOPPER_141" || input["gpuCount"] != float64(4) {
+		t.Fatalf("unexpected gpu sizing %v x %v", input["gpuIds"], input["gpuCount"])
+	}
+	env := savedEnv(t, input)
+	if env["TENSOR_PARALLEL_SIZE"] != "4" || env["QUANTIZATION"] != "fp8" {
+		t.Fatalf("unexpected env %v", env)
+	}
+}
+
+func TestRunDeployVllm_MissingModel(t *testing.T) {
+	resetDeployVars(t)
+	saved := startFakeDeployAPI(t)
+	deployModel = "nobody/does-not-exist"
+
+	_, _, err := runCaptured(t, runDeployVllm)
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Fatalf("expected model not found, got %v", err)
+	}
+	if *saved != nil {
+		t.Fatal("expected no endpoint to be created")
+	}
+}
+
+func TestRunDeployComfyUI_UsesVRAM(t *testing.T) {
+	resetDeployVars(t)
+	saved := startFakeDeployAPI(t)
+	deployVRAM = 48
+	deployWait = false
+
+	if _, _, err := runCaptured(t, runDeployComfyUI); err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if input := *saved; input["gpuIds"] != "AMPERE_80" {
+		t.Fatalf("expected the smallest pool with 48 gb, got %v", input["gpuIds"])
+	}
+}
+
+func TestParseParamCount(t *testing.T) {
+	cases := map[string]int64{
+		"7B":         7_000_000_000,
+		"0.5b":       500_000_000,
+		"125M":       125_000_000,
+		"8x7B":       56_000_000_000,
+		"1000000000": 1_000_000_000,
+	}
+	for in, want := range cases {
+		got, err := parseParamCount(in)
+		if err != nil || got != want {
+			t.Errorf("parseParamCount(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := parseParamCount("big"); err == nil {
+		t.Error("expected error for an invalid count")
+	}
+}
+
+func TestParamCountFromName(t *testing.T) {
+	cases := map[string]int64{
+		"Qwen/Qwen2.5-0.5B-Instruct":         500_000_000,
+		"mistralai/Mixtral-8x7B-Instruct":    56_000_000_000,
+		"meta-llama/Llama-3.1-70B-Instruct":  70_000_000_000,
+		"google/gemma-2b-it":                 2_000_000_000,
+		"microsoft/phi-4":                    0,
+		"deepseek-ai/DeepSeek-R1-Distill-v2": 0,
+	}
+	for in, want := range cases {
+		if got := paramCountFromName(in); got != want {
+			t.Errorf("paramCountFromName(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestWeightBytes(t *testing.T) {
+	awq := map[string]int64{"I32": 1_000_000_000, "F16": 300_000_000}
+	tests := []struct {
+		name    string
+		dtype   string
+		byDtype map[string]int64
+		params  int64
+		want    float64
+	}{
+		{"bf16 checkpoint", "auto", map[string]int64{"BF16": 7_000_000_000}, 0, 14e9},
+		{"full precision loads as half", "auto", map[string]int64{"F32": 1_000_000_000}, 0, 2e9},
+		{"awq packs int32 next to f16 scales", "auto", awq, 0, 4.6e9},
+		{"fp8 casts floats only", "fp8", awq, 0, 4.3e9},
+		{"no counts at half precision", "auto", nil, 7_000_000_000, 14e9},
+		{"no counts awq", "awq", nil, 7_000_000_000, 3.5e9},
+	}
+	for _, tt := range tests {
+		if got := weightBytes(tt.dtype, tt.byDtype, tt.params); got != tt.want {
+			t.Errorf("%s: weightBytes = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
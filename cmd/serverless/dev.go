@@ -0,0 +1,121 @@
+package serverless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/envvars"
+	"github.com/runpod/runpodctl/internal/slsdev"
+
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "run a worker handler behind a local endpoint",
+	Long: `start a local server that implements the queue-based endpoint api
+(/run, /runsync, /status, /stream, /cancel, /health, /purge-queue and webhooks)
+and dispatch each job to your handler.
+
+every job runs in a fresh "python <handler> --test_input <job>" process, the
+runpod-python local test mode, so no gpu or deployment is needed. any endpoint
+id is accepted in the url. stop the server with ctrl-c.`,
+	Example: `  runpodctl serverless dev --handler ./src/handler.py
+  curl -s localhost:8000/v2/local/runsync -d '{"input": {"prompt": "hi"}}'`,
+	Args: cobra.NoArgs,
+	RunE: runDev,
+}
+
+var (
+	devHandler     string
+	devHost        string
+	devPort        int
+	devConcurrency int
+	devPython      string
+	devEnvVars     []string
+	devEnvFile     string
+)
+
+func init() {
+	devCmd.Flags().StringVar(&devHandler, "handler", "", "path to the worker handler file (required)")
+	devCmd.Flags().StringVar(&devHost, "host", "127.0.0.1", "address to listen on")
+	devCmd.Flags().IntVar(&devPort, "port", 8000, "port to listen on")
+	devCmd.Flags().IntVar(&devConcurrency, "concurrency", 1, "number of jobs to run at the same time")
+	devCmd.Flags().StringVar(&devPython, "python", "python3", "python interpreter with runpod installed")
+	devCmd.Flags().StringArrayVar(&devEnvVars, "env", nil, "env vars for the handler in KEY=VALUE format (repeatable)")
+	devCmd.Flags().StringVar(&devEnvFile, "env-file", "", "read handler env vars from a dotenv file (--env takes precedence)")
+
+	devCmd.MarkFlagRequired("handler") //nolint:errcheck
+}
+
+func runDev(cmd *cobra.Command, args []string) error {
+	if info, err := os.Stat(devHandler); err != nil || info.IsDir() {
+		return fmt.Errorf("handler file not found: %s", devHandler)
+	}
+	if devConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	overrides, err := envvars.ParseAssignments(devEnvVars)
+	if err != nil {
+		return err
+	}
+	env, err := envvars.Load(devEnvFile, overrides)
+	if err != nil {
+		return err
+	}
+	environ := make([]string, 0, len(env))
+	for _, key := range envvars.Keys(env) {
+		environ = append(environ, key+"="+env[key])
+	}
+
+	logs := cmd.ErrOrStderr()
+	worker, err := slsdev.NewPythonWorker(devPython, devHandler, environ, logs)
+	if err != nil {
+		return err
+	}
+	defer worker.Close()
+
+	server := slsdev.New(slsdev.Config{
+		Worker:      worker,
+		Concurrency: devConcurrency,
+		Logf: func(format string, args ...interface{}) {
+			fmt.Fprintf(logs, format+"\n", args...)
+		},
+	})
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(devHost, strconv.Itoa(devPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{Handler: server.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpServer.Serve(listener) }()
+	go server.Start(ctx)
+
+	fmt.Fprintf(logs, "serving %s at http://%s/v2/local (ctrl-c to stop)\n", devHandler, listener.Addr())
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
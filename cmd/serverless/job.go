@@ -0,0 +1,228 @@
+package serverless
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "run jobs on an endpoint",
+	Long:  "submit jobs to a queue-based endpoint and wait for their output",
+}
+
+var jobRunCmd = &cobra.Command{
+	Use:   "run <endpoint-id>",
+	Short: "run a job and wait for its output",
+	Long: `submit a job to an endpoint, wait until it finishes and print its status
+and output. the job is cancelled on ctrl-c or when --timeout passes.
+
+the input file holds either the job input or a full request body with an
+"input" field; use "-" to read it from stdin.
+
+with --save-outputs binary values in the output are written to files in the
+given directory and replaced by the file path in the printed output: data
+uris, base64 strings of a recognized file type (images, audio, video, pdf,
+archives) and http or https urls to files of those types, such as bucket
+uploads. a url that fails to download is left in place with a note. a
+"filename" next to a value names its file.`,
+	Example: `  runpodctl serverless job run <endpoint-id> --input payload.json
+  echo '{"prompt": "a cat"}' | runpodctl serverless job run <endpoint-id> --input - --save-outputs ./outputs`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobRun,
+}
+
+var (
+	jobInput        string
+	jobTimeout      time.Duration
+	jobPollInterval time.Duration
+	jobSaveOutputs  string
+)
+
+func init() {
+	jobRunCmd.Flags().StringVar(&jobInput, "input", "", "json file with the job input, - for stdin (required)")
+	jobRunCmd.Flags().DurationVar(&jobTimeout, "timeout", 10*time.Minute, "cancel the job after this long")
+	jobRunCmd.Flags().DurationVar(&jobPollInterval, "poll-interval", time.Second, "how often to poll a job that outlives a runsync call")
+	jobRunCmd.Flags().StringVar(&jobSaveOutputs, "save-outputs", "", "write binary outputs to files in this directory")
+	jobRunCmd.MarkFlagRequired("input") //nolint:errcheck
+
+	jobCmd.AddCommand(jobRunCmd)
+}
+
+// jobSyncWait is how long a single runsync call waits; it stays below the
+// default client timeout so slow jobs fall back to polling
+const jobSyncWait = 20 * time.Second
+
+// jobCancelTimeout bounds the cancel sent for a job that is given up on
+const jobCancelTimeout = 10 * time.Second
+
+// jobResult is a finished job as printed, with saved outputs
+type jobResult struct {
+	*api.Job
+	SavedOutputs []savedOutput `json:"savedOutputs,omitempty"`
+}
+
+func runJobRun(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+	if jobTimeout <= 0 || jobPollInterval <= 0 {
+		return fmt.Errorf("--timeout and --poll-interval must be positive")
+	}
+
+	var input json.RawMessage
+	var err error
+	if jobInput == "-" {
+		input, err = parseJobInput(cmd.InOrStdin(), "stdin")
+	} else {
+		input, err = readJobInput(jobInput)
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	job, err := awaitJob(ctx, client, endpointID, input, jobTimeout, jobPollInterval)
+	if err != nil {
+		err = withJobID(err)
+		output.Error(err)
+		return err
+	}
+
+	result := jobResult{Job: job}
+	if jobSaveOutputs != "" && len(job.Output) > 0 {
+		rewritten, saved, err := saveJobOutputs(ctx, cmd.ErrOrStderr(), job.Output, jobSaveOutputs)
+		if err != nil {
+			return err
+		}
+		job.Output = rewritten
+		result.SavedOutputs = saved
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	if err := output.Print(result, &output.Config{Format: format}); err != nil {
+		return err
+	}
+	if job.Status != api.JobStatusCompleted {
+		return fmt.Errorf("job %s %s", job.ID, jobFailure(job))
+	}
+	return nil
+}
+
+// readJobInput reads the job input from path, unwrapping a full request
+// body ({"input": ...}) when given one
+func readJobInput(path string) (json.RawMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	defer file.Close()
+	return parseJobInput(file, path)
+}
+
+func parseJobInput(r io.Reader, name string) (json.RawMessage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("input from %s is not valid json", name)
+	}
+
+	var body map[string]json.RawMessage
+	if json.Unmarshal(data, &body) == nil {
+		if input, ok := body["input"]; ok {
+			return input, nil
+		}
+	}
+	return json.RawMessage(data), nil
+}
+
+// jobTimeoutError is a job with no final status when the timeout passed. the
+// message leaves out the job id, so bench counts every timeout as one error.
+type jobTimeoutError struct {
+	JobID   string
+	Timeout time.Duration
+}
+
+func (e *jobTimeoutError) Error() string {
+	return fmt.Sprintf("job has no result after %s", e.Timeout)
+}
+
+// withJobID names the job in a timeout error, for commands that run one job
+func withJobID(err error) error {
+	var timeout *jobTimeoutError
+	if errors.As(err, &timeout) && timeout.JobID != "" {
+		return fmt.Errorf("%w (job %s)", err, timeout.JobID)
+	}
+	return err
+}
+
+// awaitJob submits a job and polls it until it reaches a final status. it
+// returns ctx.Err() when ctx is cancelled first and a *jobTimeoutError when
+// timeout passes; either way a job that was submitted is cancelled.
+func awaitJob(ctx context.Context, client *api.Client, endpointID string, input json.RawMessage, timeout, pollInterval time.Duration) (*api.Job, error) {
+	deadline := time.Now().Add(timeout)
+
+	job, err := client.RunJobSync(ctx, endpointID, input, min(jobSyncWait, timeout))
+	for err == nil && !job.Done() {
+		if time.Now().After(deadline) {
+			cancelJob(client, endpointID, job.ID)
+			return nil, &jobTimeoutError{JobID: job.ID, Timeout: timeout}
+		}
+		select {
+		case <-ctx.Done():
+			cancelJob(client, endpointID, job.ID)
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+		var polled *api.Job
+		if polled, err = client.GetJob(ctx, endpointID, job.ID); err == nil {
+			job = polled
+		}
+	}
+	if ctx.Err() != nil {
+		if job != nil && !job.Done() {
+			cancelJob(client, endpointID, job.ID)
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// cancelJob cancels a job that is given up on, so it doesn't keep a worker
+// busy. ctx may be done by then, so the cancel gets its own deadline.
+func cancelJob(client *api.Client, endpointID, jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobCancelTimeout)
+	defer cancel()
+	_, _ = client.CancelJob(ctx, endpointID, jobID)
+}
+
+// jobFailure describes the final status of a job that did not complete
+func jobFailure(job *api.Job) string {
+	if job.Error != "" {
+		return job.Status + ": " + job.Error
+	}
+	return job.Status
+}
@@ -0,0 +1,224 @@
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+)
+
+// testPNG is a png signature padded to a length that passes as a file
+var testPNG = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+
+func resetJobVars(t *testing.T) {
+	t.Helper()
+	origInput, origTimeout, origPollInterval, origSaveOutputs := jobInput, jobTimeout, jobPollInterval, jobSaveOutputs
+	t.Cleanup(func() {
+		jobInput, jobTimeout, jobPollInterval, jobSaveOutputs = origInput, origTimeout, origPollInterval, origSaveOutputs
+	})
+	jobInput, jobTimeout, jobPollInterval, jobSaveOutputs = "", time.Minute, time.Millisecond, ""
+}
+
+// startFakeJobAPI answers runsync with a queued job and the first status
+// poll with output
+func startFakeJobAPI(t *testing.T, output string) *[]map[string]interface{} {
+	t.Helper()
+	var inputs []map[string]interface{}
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ep-1/runsync":
+			var body map[string]map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			inputs = append(inputs, body["input"])
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"IN_QUEUE"}`))
+		case "/ep-1/status/job-1":
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"COMPLETED","delayTime":12,"executionTime":340,"output":` + output + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return &inputs
+}
+
+func TestRunJobRun_SavesOutputs(t *testing.T) {
+	resetJobVars(t)
+	encoded := base64.StdEncoding.EncodeToString(testPNG)
+	inputs := startFakeJobAPI(t, `{"image":"data:image/png;base64,`+encoded+`","seed":42}`)
+	dir := t.TempDir()
+	jobInput = filepath.Join(dir, "payload.json")
+	if err := os.WriteFile(jobInput, []byte(`{"input": {"prompt": "a cat"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jobSaveOutputs = filepath.Join(dir, "out")
+
+	printed, _, err := runCaptured(t, runJobRun, "ep-1")
+	if err != nil {
+		t.Fatalf("job run: %v", err)
+	}
+	if len(*inputs) != 1 || (*inputs)[0]["prompt"] != "a cat" {
+		t.Fatalf("unexpected job input %v", *inputs)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Output struct {
+			Image string `json:"image"`
+			Seed  int    `json:"seed"`
+		} `json:"output"`
+		SavedOutputs []savedOutput `json:"savedOutputs"`
+	}
+	if err := json.Unmarshal([]byte(printed), &result); err != nil {
+		t.Fatalf("parse %q: %v", printed, err)
+	}
+	file := filepath.Join(jobSaveOutputs, "image.png")
+	if result.Status != "COMPLETED" || result.Output.Image != file || result.Output.Seed != 42 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(result.SavedOutputs) != 1 || result.SavedOutputs[0].Path != "output.image" {
+		t.Fatalf("unexpected saved outputs %+v", result.SavedOutputs)
+	}
+	if data, err := os.ReadFile(file); err != nil || !bytes.Equal(data, testPNG) {
+		t.Fatalf("expected the decoded image in %s: %v", file, err)
+	}
+}
+
+func TestSaveJobOutputs_DetectsFiles(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/bucket/a.png":
+			_, _ = w.Write(testPNG)
+		case "/error.png":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>access denied</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	encoded := base64.StdEncoding.EncodeToString(testPNG)
+	hash := strings.Repeat("ab12", 16)
+	output := `{
+		"images": [
+			{"filename": "ComfyUI_00001_.png", "type": "base64", "data": "` + encoded + `"},
+			{"filename": "ComfyUI_00001_.png", "type": "s3_url", "data": "` + server.URL + `/bucket/a.png?X-Amz-Signature=x"}
+		],
+		"plain": "` + encoded + `",
+		"hash": "` + hash + `",
+		"docs": "` + server.URL + `/docs",
+		"page": "` + server.URL + `/index.html",
+		"spec": "` + server.URL + `/spec.json",
+		"missing": "` + server.URL + `/missing.png",
+		"denied": "` + server.URL + `/error.png"
+	}`
+
+	dir := t.TempDir()
+	var stderr bytes.Buffer
+	rewritten, saved, err := saveJobOutputs(t.Context(), &stderr, json.RawMessage(output), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, entry := range saved {
+		got = append(got, entry.Path+"="+filepath.Base(entry.File))
+	}
+	want := "output.images[0].data=ComfyUI_00001_.png output.images[1].data=ComfyUI_00001_-1.png output.plain=plain.png"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("unexpected saved outputs %v", got)
+	}
+	for _, kept := range []string{hash, `/docs"`, `/index.html"`, `/spec.json"`, `/missing.png"`, `/error.png"`} {
+		if !strings.Contains(string(rewritten), kept) {
+			t.Fatalf("expected %s to be kept, got %s", kept, rewritten)
+		}
+	}
+	// only urls to known file types are fetched
+	if strings.Join(requested, " ") != "/error.png /bucket/a.png /missing.png" {
+		t.Fatalf("unexpected downloads %v", requested)
+	}
+	if !strings.Contains(stderr.String(), "note: left output.missing as a url") || !strings.Contains(stderr.String(), "error.png is text/html") {
+		t.Fatalf("expected notes for the failed downloads, got %q", stderr.String())
+	}
+}
+
+func TestReadJobInput_AcceptsBareInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(path, []byte(`{"prompt": "hi"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	input, err := readJobInput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(input) != `{"prompt": "hi"}` {
+		t.Fatalf("unexpected input %s", input)
+	}
+}
+
+func TestAwaitJob_TimeoutAndCancel(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		mu        sync.Mutex
+		cancelled []string
+	)
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ep-1/runsync", "/ep-3/runsync":
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"IN_QUEUE"}`))
+		case "/ep-2/runsync":
+			<-release
+		case "/ep-1/cancel/job-1", "/ep-3/cancel/job-1":
+			mu.Lock()
+			cancelled = append(cancelled, r.URL.Path)
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"CANCELLED"}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"job-1","status":"IN_PROGRESS"}`))
+		}
+	}))
+	t.Cleanup(func() { close(release) })
+	client, err := api.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = awaitJob(context.Background(), client, "ep-1", json.RawMessage(`{}`), 10*time.Millisecond, time.Millisecond)
+	var timeout *jobTimeoutError
+	if !errors.As(err, &timeout) || timeout.JobID != "job-1" || strings.Contains(err.Error(), "job-1") {
+		t.Fatalf("expected an id-free timeout error carrying the job id, got %v", err)
+	}
+	if got := withJobID(err).Error(); !strings.Contains(got, "(job job-1)") {
+		t.Fatalf("expected the job id to be added, got %q", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := awaitJob(ctx, client, "ep-2", json.RawMessage(`{}`), time.Minute, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the runsync wait to end with ctx, got %v", err)
+	}
+
+	// a job given up on while polling is cancelled too
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := awaitJob(ctx, client, "ep-3", json.RawMessage(`{}`), time.Minute, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the poll to end with ctx, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(cancelled, " ") != "/ep-1/cancel/job-1 /ep-3/cancel/job-1" {
+		t.Fatalf("expected the unfinished jobs to be cancelled, got %v", cancelled)
+	}
+}
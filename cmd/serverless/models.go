@@ -0,0 +1,393 @@
+package serverless
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	legacyapi "github.com/runpod/runpodctl/api"
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/hf"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "manage the models cached on an endpoint",
+	Long: `list, add and remove the model references an endpoint caches on its
+workers, one at a time instead of replacing the whole list with
+"serverless update --model-reference".
+
+a reference is a hugging face model with a revision,
+https://huggingface.co/<org>/<model>:<revision> (or just <org>/<model>:<revision>),
+or a runpod model repository model, https://local/<owner>/<name>:<hash> as
+printed by "model add".`,
+}
+
+var modelsListCmd = &cobra.Command{
+	Use:   "list <endpoint-id>",
+	Short: "list the model references of an endpoint",
+	Long: `list the model references of an endpoint with their source and revision.
+
+for runpod model repository references the status of the model version is
+shown (e.g. READY once it can be cached). the api does not report which
+workers already hold a reference in their cache.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runModelsList,
+}
+
+var modelsAddCmd = &cobra.Command{
+	Use:   "add <endpoint-id> <reference>...",
+	Short: "add model references to an endpoint",
+	Long: `add model references to an endpoint, keeping the ones it already has.
+
+every reference is checked before the endpoint changes: hugging face models
+must exist at the revision (the revision defaults to main) and model
+repository models must have a version with the hash, as listed by
+"model list". use --skip-check to only check the syntax.`,
+	Example: `  runpodctl serverless models add <endpoint-id> Qwen/Qwen2.5-0.5B-Instruct:main
+  runpodctl serverless models add <endpoint-id> https://local/<owner>/<name>:<hash>`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runModelsAdd,
+}
+
+var modelsRemoveCmd = &cobra.Command{
+	Use:     "remove <endpoint-id> <reference>...",
+	Aliases: []string{"rm"},
+	Short:   "remove model references from an endpoint",
+	Long: `remove model references from an endpoint, keeping the others. a reference
+without a revision removes every revision of that model.`,
+	Example: `  runpodctl serverless models remove <endpoint-id> Qwen/Qwen2.5-0.5B-Instruct:main
+  runpodctl serverless models remove <endpoint-id> Qwen/Qwen2.5-0.5B-Instruct`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runModelsRemove,
+}
+
+var modelsSkipCheck bool
+
+func init() {
+	modelsAddCmd.Flags().BoolVar(&modelsSkipCheck, "skip-check", false, "only check the reference syntax, not that the model exists")
+
+	modelsCmd.AddCommand(modelsListCmd)
+	modelsCmd.AddCommand(modelsAddCmd)
+	modelsCmd.AddCommand(modelsRemoveCmd)
+}
+
+// model reference sources
+const (
+	modelSourceHuggingFace = "huggingface"
+	modelSourceRepo        = "model-repo"
+)
+
+// modelRepoHost is the host of runpod model repository references
+const modelRepoHost = "local"
+
+// hfRepoPattern matches a hugging face <org>/<model> repo id
+var hfRepoPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*/[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// modelRef is a parsed model reference
+type modelRef struct {
+	Reference string `json:"reference"`
+	Source    string `json:"source"`
+	Model     string `json:"model"`
+	Revision  string `json:"revision,omitempty"`
+	Status    string `json:"status,omitempty"`
+	// hasRevision is false when the revision was left out and defaulted
+	hasRevision bool
+}
+
+// parseModelRef parses a model reference in url or <org>/<model>[:revision]
+// form. hugging face references without a revision get main.
+func parseModelRef(raw string) (*modelRef, error) {
+	raw = strings.TrimSpace(raw)
+	invalid := fmt.Errorf("invalid model reference %q, expected https://huggingface.co/<org>/<model>:<revision>", raw)
+
+	host, path := "huggingface.co", raw
+	if strings.Contains(raw, "://") {
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Scheme != "https" || parsed.RawQuery != "" || parsed.Fragment != "" {
+			return nil, invalid
+		}
+		host, path = parsed.Host, strings.TrimPrefix(parsed.Path, "/")
+	}
+
+	model, revision, hasRevision := strings.Cut(path, ":")
+	if hasRevision && (revision == "" || strings.ContainsAny(revision, ": ")) {
+		return nil, invalid
+	}
+
+	ref := &modelRef{Model: model, Revision: revision, hasRevision: hasRevision}
+	switch host {
+	case "huggingface.co":
+		if !hfRepoPattern.MatchString(model) || strings.Contains(model, "..") {
+			return nil, invalid
+		}
+		ref.Source = modelSourceHuggingFace
+		if !hasRevision {
+			ref.Revision = "main"
+		}
+		ref.Reference = hf.ModelURL(model) + ":" + ref.Revision
+	case modelRepoHost:
+		owner, name, ok := strings.Cut(model, "/")
+		if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid model reference %q, expected https://local/<owner>/<name>:<hash>", raw)
+		}
+		ref.Source = modelSourceRepo
+		ref.Reference = "https://" + modelRepoHost + "/" + model
+		if hasRevision {
+			ref.Reference += ":" + revision
+		}
+	default:
+		return nil, fmt.Errorf("invalid model reference %q: unsupported host %s", raw, host)
+	}
+	return ref, nil
+}
+
+// matches reports whether ref names reference; without a revision any
+// revision of the model matches
+func (r *modelRef) matches(reference *modelRef) bool {
+	if r.Source != reference.Source || !strings.EqualFold(r.Model, reference.Model) {
+		return false
+	}
+	return !r.hasRevision || r.Revision == reference.Revision
+}
+
+func runModelsList(cmd *cobra.Command, args []string) error {
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	endpoint, err := client.GetEndpoint(args[0], false, false)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	refs := modelRefsOf(endpoint.ModelReferences)
+	setModelRepoStatus(cmd, refs)
+	return printModelRefs(cmd, refs)
+}
+
+func runModelsAdd(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+	refs, err := parseModelRefs(args[1:])
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Source == modelSourceRepo && !ref.hasRevision {
+			return fmt.Errorf("model repository reference %s needs a version hash, see 'runpodctl model list'", ref.Reference)
+		}
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	endpoint, err := client.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	if endpoint.ComputeType == "CPU" {
+		return fmt.Errorf("model references are only supported on gpu endpoints")
+	}
+
+	stderr := cmd.ErrOrStderr()
+	updated := append([]string{}, endpoint.ModelReferences...)
+	var added []*modelRef
+	for _, ref := range refs {
+		if containsModelRef(updated, ref) {
+			fmt.Fprintf(stderr, "%s is already on %s\n", ref.Reference, endpointID)
+			continue
+		}
+		updated = append(updated, ref.Reference)
+		added = append(added, ref)
+	}
+	if len(added) == 0 {
+		return printModelRefs(cmd, modelRefsOf(endpoint.ModelReferences))
+	}
+
+	if !modelsSkipCheck {
+		if err := checkModelRefs(cmd, added); err != nil {
+			return err
+		}
+	}
+
+	saved, err := client.UpdateEndpointModels(endpointID, updated)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	return printModelRefs(cmd, modelRefsOf(saved.ModelReferences))
+}
+
+func runModelsRemove(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+	refs, err := parseModelRefs(args[1:])
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	endpoint, err := client.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	// every reference must be on the endpoint before anything is removed
+	removed := make([]bool, len(endpoint.ModelReferences))
+	for _, ref := range refs {
+		found := false
+		for i, reference := range endpoint.ModelReferences {
+			if existing, err := parseModelRef(reference); err == nil && ref.matches(existing) {
+				removed[i], found = true, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s is not a model reference of %s", ref.Reference, endpointID)
+		}
+	}
+
+	kept := []string{}
+	for i, reference := range endpoint.ModelReferences {
+		if !removed[i] {
+			kept = append(kept, reference)
+		}
+	}
+
+	saved, err := client.UpdateEndpointModels(endpointID, kept)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	return printModelRefs(cmd, modelRefsOf(saved.ModelReferences))
+}
+
+func parseModelRefs(raw []string) ([]*modelRef, error) {
+	refs := make([]*modelRef, 0, len(raw))
+	for _, reference := range raw {
+		ref, err := parseModelRef(reference)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+func containsModelRef(references []string, ref *modelRef) bool {
+	for _, reference := range references {
+		if existing, err := parseModelRef(reference); err == nil && existing.Reference == ref.Reference {
+			return true
+		}
+	}
+	return false
+}
+
+// checkModelRefs checks that every reference points at an existing model:
+// hugging face models at their revision and model repository versions by
+// hash. hugging face models that cannot be read (gated, offline) only warn,
+// since the endpoint may hold a token for them.
+func checkModelRefs(cmd *cobra.Command, refs []*modelRef) error {
+	var repoModels []*legacyapi.Model
+	hfClient := hf.NewClient()
+	for _, ref := range refs {
+		switch ref.Source {
+		case modelSourceHuggingFace:
+			if _, err := hfClient.Model(ref.Model, ref.Revision); err != nil {
+				if errors.Is(err, hf.ErrNotFound) {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: could not check %s: %v\n", ref.Reference, err)
+			}
+		case modelSourceRepo:
+			if repoModels == nil {
+				models, err := legacyapi.GetModels(nil)
+				if err != nil {
+					return fmt.Errorf("failed to list the model repository: %w", err)
+				}
+				repoModels = models
+			}
+			if modelRepoVersion(repoModels, ref) == nil {
+				return fmt.Errorf("%s not found in the model repository, see 'runpodctl model list'", ref.Reference)
+			}
+		}
+	}
+	return nil
+}
+
+// setModelRepoStatus fills in the version status of model repository
+// references. a failed lookup only warns; the list is still printed.
+func setModelRepoStatus(cmd *cobra.Command, refs []*modelRef) {
+	var repoRefs []*modelRef
+	for _, ref := range refs {
+		if ref.Source == modelSourceRepo {
+			repoRefs = append(repoRefs, ref)
+		}
+	}
+	if len(repoRefs) == 0 {
+		return
+	}
+
+	models, err := legacyapi.GetModels(nil)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: failed to list the model repository: %v\n", err)
+		return
+	}
+	for _, ref := range repoRefs {
+		ref.Status = "NOT_FOUND"
+		if version := modelRepoVersion(models, ref); version != nil {
+			ref.Status = version.Status
+		}
+	}
+}
+
+// modelRepoVersion returns the model repository version a reference names
+func modelRepoVersion(models []*legacyapi.Model, ref *modelRef) *legacyapi.ModelVersion {
+	owner, name, _ := strings.Cut(ref.Model, "/")
+	for _, model := range models {
+		if model == nil || model.Name != name || (model.Owner != "" && model.Owner != owner) {
+			continue
+		}
+		for _, version := range model.Versions {
+			if version != nil && version.Hash == ref.Revision {
+				return version
+			}
+		}
+	}
+	return nil
+}
+
+// modelRefsOf parses the references of an endpoint, keeping references set
+// outside the cli as they are
+func modelRefsOf(references []string) []*modelRef {
+	refs := make([]*modelRef, 0, len(references))
+	for _, reference := range references {
+		ref, err := parseModelRef(reference)
+		if err != nil {
+			ref = &modelRef{Reference: reference, Source: "unknown"}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+func printModelRefs(cmd *cobra.Command, refs []*modelRef) error {
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(refs, &output.Config{Format: format})
+}
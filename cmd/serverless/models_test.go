@@ -0,0 +1,132 @@
+package serverless
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeModelsAPI serves an endpoint with model references, the model
+// repository and the hugging face model api, and records saveEndpoint calls
+type fakeModelsAPI struct {
+	references []string
+	saved      [][]string
+}
+
+func startFakeModelsAPI(t *testing.T, fake *fakeModelsAPI) {
+	t.Helper()
+	server := useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-1":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "ep-1", "name": "llm", "computeType": "GPU", "modelReferences": fake.references})
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			var body struct {
+				Query     string `json:"query"`
+				Variables struct {
+					Input struct {
+						ModelReferences []string `json:"modelReferences"`
+					} `json:"input"`
+				} `json:"variables"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			switch {
+			case strings.Contains(body.Query, "myModels"):
+				_, _ = w.Write([]byte(`{"data":{"myModels":[{"id":"m-1","owner":"user-1","name":"my-lora","versions":[{"hash":"abc123","status":"READY"}]}]}}`))
+			case strings.Contains(body.Query, "saveEndpoint"):
+				fake.saved = append(fake.saved, body.Variables.Input.ModelReferences)
+				fake.references = body.Variables.Input.ModelReferences
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"saveEndpoint": map[string]interface{}{"id": "ep-1", "modelReferences": fake.references}}})
+			default:
+				t.Errorf("unexpected graphql query %s", body.Query)
+			}
+		case r.URL.Path == "/api/models/Qwen/Qwen2.5-0.5B-Instruct/revision/main":
+			_, _ = w.Write([]byte(`{"id":"Qwen/Qwen2.5-0.5B-Instruct"}`))
+		case strings.HasPrefix(r.URL.Path, "/api/models/"):
+			http.NotFound(w, r)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Setenv("HF_ENDPOINT", server.URL)
+}
+
+func TestRunModelsAdd_KeepsExistingReferences(t *testing.T) {
+	fake := &fakeModelsAPI{references: []string{"https://huggingface.co/org/existing:main"}}
+	startFakeModelsAPI(t, fake)
+
+	_, stderr, err := runCaptured(t, runModelsAdd, "ep-1", "Qwen/Qwen2.5-0.5B-Instruct", "https://local/user-1/my-lora:abc123", "org/existing:main")
+	if err != nil {
+		t.Fatalf("models add: %v", err)
+	}
+	want := "https://huggingface.co/org/existing:main https://huggingface.co/Qwen/Qwen2.5-0.5B-Instruct:main https://local/user-1/my-lora:abc123"
+	if len(fake.saved) != 1 || strings.Join(fake.saved[0], " ") != want {
+		t.Fatalf("unexpected saved references %v", fake.saved)
+	}
+	if !strings.Contains(stderr, "org/existing:main is already on ep-1") {
+		t.Fatalf("expected a note for the existing reference, got %q", stderr)
+	}
+}
+
+func TestRunModelsAdd_ChecksBeforeChanging(t *testing.T) {
+	cases := map[string]string{
+		"nobody/missing:main":                "model not found",
+		"https://local/user-1/my-lora:zzz":   "not found in the model repository",
+		"https://example.com/org/model:main": "unsupported host",
+		"https://local/user-1/my-lora":       "needs a version hash",
+		"not a reference":                    "invalid model reference",
+		"https://huggingface.co/org/model:":  "invalid model reference",
+	}
+	for reference, want := range cases {
+		fake := &fakeModelsAPI{}
+		startFakeModelsAPI(t, fake)
+		_, _, err := runCaptured(t, runModelsAdd, "ep-1", reference)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("add %q: got %v, want %q", reference, err, want)
+		}
+		if len(fake.saved) != 0 {
+			t.Errorf("add %q: expected no change, got %v", reference, fake.saved)
+		}
+	}
+}
+
+func TestRunModelsRemove(t *testing.T) {
+	fake := &fakeModelsAPI{references: []string{
+		"https://huggingface.co/org/model:main",
+		"https://huggingface.co/org/model:v2",
+		"https://huggingface.co/org/other:main",
+	}}
+	startFakeModelsAPI(t, fake)
+
+	if _, _, err := runCaptured(t, runModelsRemove, "ep-1", "org/model"); err != nil {
+		t.Fatalf("models remove: %v", err)
+	}
+	if strings.Join(fake.references, " ") != "https://huggingface.co/org/other:main" {
+		t.Fatalf("expected every revision of org/model removed, got %v", fake.references)
+	}
+
+	_, _, err := runCaptured(t, runModelsRemove, "ep-1", "org/other:main", "org/gone:main")
+	if err == nil || !strings.Contains(err.Error(), "not a model reference of ep-1") {
+		t.Fatalf("expected missing reference error, got %v", err)
+	}
+	if len(fake.saved) != 1 {
+		t.Fatalf("expected nothing removed when one reference is missing, got %v", fake.saved)
+	}
+}
+
+func TestRunModelsList_ShowsRepoStatus(t *testing.T) {
+	fake := &fakeModelsAPI{references: []string{"https://huggingface.co/org/model:main", "https://local/user-1/my-lora:abc123"}}
+	startFakeModelsAPI(t, fake)
+
+	stdout, _, err := runCaptured(t, runModelsList, "ep-1")
+	if err != nil {
+		t.Fatalf("models list: %v", err)
+	}
+	var refs []modelRef
+	if err := json.Unmarshal([]byte(stdout), &refs); err != nil {
+		t.Fatalf("parse %q: %v", stdout, err)
+	}
+	if len(refs) != 2 || refs[0].Source != "huggingface" || refs[0].Model != "org/model" || refs[1].Status != "READY" {
+		t.Fatalf("unexpected references %+v", refs)
+	}
+}
@@ -0,0 +1,312 @@
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/output"
+)
+
+// savedOutput is a job output value written to a file
+type savedOutput struct {
+	Path  string `json:"path"`
+	File  string `json:"file"`
+	Bytes int64  `json:"bytes"`
+}
+
+// minBase64Output is the shortest plain base64 string treated as a file, so
+// ids and short tokens are left alone
+const minBase64Output = 64
+
+// outputExtensions maps the content types of common outputs to the extension
+// used in their file names
+var outputExtensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/webp":      ".webp",
+	"image/gif":       ".gif",
+	"image/bmp":       ".bmp",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"audio/wave":      ".wav",
+	"audio/wav":       ".wav",
+	"audio/mpeg":      ".mp3",
+	"audio/ogg":       ".ogg",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+}
+
+var outputHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
+// maxOutputDownload is the largest output file downloaded from a url
+const maxOutputDownload = 1 << 30
+
+// outputSaver writes output values to files in dir, keeping names unique.
+// urls that can't be downloaded are noted on stderr and left in place.
+type outputSaver struct {
+	ctx    context.Context
+	stderr io.Writer
+	dir    string
+	used   map[string]bool
+	saved  []savedOutput
+}
+
+// saveJobOutputs writes the binary values of a job output to files in dir:
+// data uris, plain base64 of a recognized file type and http or https urls to
+// files of a recognized type. it returns the output with those values
+// replaced by the file path.
+func saveJobOutputs(ctx context.Context, stderr io.Writer, data json.RawMessage, dir string) (json.RawMessage, []savedOutput, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse job output: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	saver := &outputSaver{ctx: ctx, stderr: stderr, dir: dir, used: map[string]bool{}}
+	value, err := saver.walk(value, nil, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rewritten, err := json.Marshal(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rewritten, saver.saved, nil
+}
+
+// walk saves the values under value and returns it with saved values
+// replaced. filename is the "filename" of the enclosing object, if any.
+func (s *outputSaver) walk(value interface{}, at []string, filename string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		name, _ := v["filename"].(string)
+		// sorted, so repeated names are numbered the same way every run
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if key != "filename" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			replaced, err := s.walk(v[key], append(at[:len(at):len(at)], key), name)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = replaced
+		}
+		return v, nil
+	case []interface{}:
+		for i, child := range v {
+			replaced, err := s.walk(child, append(at[:len(at):len(at)], strconv.Itoa(i)), "")
+			if err != nil {
+				return nil, err
+			}
+			v[i] = replaced
+		}
+		return v, nil
+	case string:
+		file, ok, err := s.save(v, at, filename)
+		if err != nil || !ok {
+			return v, err
+		}
+		return file, nil
+	}
+	return value, nil
+}
+
+// save writes value to a file when it holds binary data
+func (s *outputSaver) save(value string, at []string, filename string) (string, bool, error) {
+	data, ext, ok, err := s.decode(value, at)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	name := outputFileName(at, filename, ext)
+	file := filepath.Join(s.dir, s.unique(name))
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		return "", false, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+
+	s.saved = append(s.saved, savedOutput{Path: outputPath(at), File: file, Bytes: int64(len(data))})
+	return file, true, nil
+}
+
+// decode returns the bytes behind an output value and the extension of their
+// file type, or ok false for values that are not files
+func (s *outputSaver) decode(value string, at []string) ([]byte, string, bool, error) {
+	switch {
+	case strings.HasPrefix(value, "data:"):
+		meta, encoded, found := strings.Cut(strings.TrimPrefix(value, "data:"), ",")
+		if !found || !strings.HasSuffix(meta, ";base64") {
+			return nil, "", false, nil
+		}
+		data, err := decodeBase64(encoded)
+		if err != nil {
+			return nil, "", false, nil
+		}
+		return data, extensionForType(strings.TrimSuffix(meta, ";base64"), data), true, nil
+	case strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://"):
+		parsed, err := url.Parse(value)
+		if err != nil || !isOutputExtension(path.Ext(parsed.Path)) {
+			return nil, "", false, nil
+		}
+		data, err := s.download(value)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil, "", false, s.ctx.Err()
+			}
+			fmt.Fprintf(s.stderr, "note: left %s as a url: %v\n", outputPath(at), err)
+			return nil, "", false, nil
+		}
+		return data, strings.ToLower(path.Ext(parsed.Path)), true, nil
+	case len(value) >= minBase64Output:
+		data, err := decodeBase64(value)
+		if err != nil {
+			return nil, "", false, nil
+		}
+		// only recognized file types: hashes and ids are valid base64 too
+		ext, known := outputExtensions[sniffType(data)]
+		if !known {
+			return nil, "", false, nil
+		}
+		return data, ext, true, nil
+	}
+	return nil, "", false, nil
+}
+
+func (s *outputSaver) download(rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := outputHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download output: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to download output %s: status %d", redactQuery(rawURL), resp.StatusCode)
+	}
+	// an html error page or json body under a file name is not an output
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if _, known := outputExtensions[contentType]; !known && contentType != "" && contentType != "application/octet-stream" && contentType != "binary/octet-stream" {
+		return nil, fmt.Errorf("output %s is %s, not a file", redactQuery(rawURL), contentType)
+	}
+	if resp.ContentLength > maxOutputDownload {
+		return nil, fmt.Errorf("output %s is larger than %s", redactQuery(rawURL), output.Bytes(maxOutputDownload))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOutputDownload+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download output %s: %w", redactQuery(rawURL), err)
+	}
+	if len(data) > maxOutputDownload {
+		return nil, fmt.Errorf("output %s is larger than %s", redactQuery(rawURL), output.Bytes(maxOutputDownload))
+	}
+	return data, nil
+}
+
+// isOutputExtension reports whether ext is the extension of a recognized
+// output file type
+func isOutputExtension(ext string) bool {
+	ext = strings.ToLower(ext)
+	if ext == ".jpeg" {
+		return true
+	}
+	for _, known := range outputExtensions {
+		if ext == known {
+			return true
+		}
+	}
+	return false
+}
+
+// unique returns name, or name with a counter when it was already used
+func (s *outputSaver) unique(name string) string {
+	candidate := name
+	ext := filepath.Ext(name)
+	for i := 1; s.used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	s.used[candidate] = true
+	return candidate
+}
+
+// outputFileName names a saved value after its filename, or after its path
+// in the output
+func outputFileName(at []string, filename, ext string) string {
+	if name := filepath.Base(filepath.Clean("/" + filename)); filename != "" && name != "/" {
+		if filepath.Ext(name) == "" {
+			name += ext
+		}
+		return name
+	}
+	if len(at) == 0 {
+		return "output" + ext
+	}
+	return strings.Join(at, "-") + ext
+}
+
+// outputPath renders a path in the output like images[0].data
+func outputPath(at []string) string {
+	var b strings.Builder
+	b.WriteString("output")
+	for _, part := range at {
+		if _, err := strconv.Atoi(part); err == nil {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		b.WriteString("." + part)
+	}
+	return b.String()
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	value = strings.TrimRight(strings.TrimSpace(value), "=")
+	return base64.RawStdEncoding.DecodeString(value)
+}
+
+func sniffType(data []byte) string {
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return contentType
+}
+
+func extensionForType(contentType string, data []byte) string {
+	if contentType == "" {
+		contentType = sniffType(data)
+	}
+	if ext, ok := outputExtensions[contentType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// redactQuery drops the query of a url, which holds the signature of a
+// presigned bucket url
+func redactQuery(rawURL string) string {
+	before, _, _ := strings.Cut(rawURL, "?")
+	return before
+}
@@ -0,0 +1,464 @@
+package serverless
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+)
+
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout <endpoint-id>",
+	Short: "roll out a new image to an endpoint",
+	Long: `roll out a new image to an endpoint without editing its template in place.
+
+the current template is cloned with the new image as the next revision and the
+endpoint is switched to the clone. the endpoint is then watched for the bake
+period; if more jobs fail than --max-failures allows, the endpoint is switched
+back to its previous template automatically.
+
+revisions are regular templates named <endpoint-id>__rev<n>. the first rollout
+also records the template the endpoint was using as a revision, so every
+revision can be restored with "serverless rollback".`,
+	Example: `  runpodctl serverless rollout <endpoint-id> --image myrepo/worker:v2
+  runpodctl serverless rollout <endpoint-id> --image myrepo/worker:v2 --bake 10m --max-failures 0
+  runpodctl serverless rollout history <endpoint-id>`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRollout,
+}
+
+var rolloutHistoryCmd = &cobra.Command{
+	Use:   "history <endpoint-id>",
+	Short: "list the template revisions of an endpoint",
+	Long:  "list the template revisions recorded by rollouts of an endpoint, oldest first, marking the one the endpoint uses",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRolloutHistory,
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <endpoint-id>",
+	Short: "switch an endpoint back to an earlier revision",
+	Long: `switch an endpoint back to an earlier template revision.
+
+without --to the endpoint goes back to the revision before the one it is on.
+use "serverless rollout history" to list the revisions.`,
+	Example: `  runpodctl serverless rollback <endpoint-id>
+  runpodctl serverless rollback <endpoint-id> --to 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRollback,
+}
+
+var (
+	rolloutImage       string
+	rolloutBake        time.Duration
+	rolloutInterval    time.Duration
+	rolloutMaxFailures int
+	rolloutNoRollback  bool
+	rollbackTo         int
+)
+
+func init() {
+	rolloutCmd.Flags().StringVar(&rolloutImage, "image", "", "new docker image, e.g. repo:tag (required)")
+	rolloutCmd.Flags().DurationVar(&rolloutBake, "bake", 5*time.Minute, "how long to watch the endpoint after switching (0 to skip)")
+	rolloutCmd.Flags().DurationVar(&rolloutInterval, "interval", 15*time.Second, "how often to check endpoint health during the bake")
+	rolloutCmd.Flags().IntVar(&rolloutMaxFailures, "max-failures", 3, "roll back when more jobs than this fail during the bake")
+	rolloutCmd.Flags().BoolVar(&rolloutNoRollback, "no-rollback", false, "report failures but keep the new revision")
+	rolloutCmd.MarkFlagRequired("image") //nolint:errcheck
+
+	rollbackCmd.Flags().IntVar(&rollbackTo, "to", 0, "revision to switch to (default: the previous revision)")
+
+	rolloutCmd.AddCommand(rolloutHistoryCmd)
+}
+
+// templateRevision is a template recorded by a rollout
+type templateRevision struct {
+	Revision   int    `json:"revision"`
+	TemplateID string `json:"templateId"`
+	Name       string `json:"name"`
+	Image      string `json:"image"`
+	Current    bool   `json:"current"`
+}
+
+// rolloutBakeResult summarizes the jobs seen while baking a revision
+type rolloutBakeResult struct {
+	Duration  string `json:"duration"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+}
+
+const revisionSeparator = "__rev"
+
+func revisionName(endpointID string, revision int) string {
+	return endpointID + revisionSeparator + strconv.Itoa(revision)
+}
+
+func parseRevisionName(endpointID, name string) (int, bool) {
+	suffix, ok := strings.CutPrefix(name, endpointID+revisionSeparator)
+	if !ok {
+		return 0, false
+	}
+	revision, err := strconv.Atoi(suffix)
+	if err != nil || revision < 1 {
+		return 0, false
+	}
+	return revision, true
+}
+
+// listRevisions returns the revisions of an endpoint, oldest first
+func listRevisions(client *api.Client, endpointID, currentTemplateID string) ([]templateRevision, error) {
+	templates, err := client.ListTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	revisions := []templateRevision{}
+	for _, template := range templates {
+		revision, ok := parseRevisionName(endpointID, template.Name)
+		if !ok {
+			continue
+		}
+		revisions = append(revisions, templateRevision{
+			Revision:   revision,
+			TemplateID: template.ID,
+			Name:       template.Name,
+			Image:      template.ImageName,
+			Current:    template.ID == currentTemplateID,
+		})
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+	return revisions, nil
+}
+
+// cloneTemplateRequest copies everything an endpoint worker depends on from
+// a template, replacing only the name and image
+func cloneTemplateRequest(template *api.Template, name, image string) *api.TemplateCreateRequest {
+	return &api.TemplateCreateRequest{
+		Name:                    name,
+		ImageName:               image,
+		IsServerless:            true,
+		Ports:                   template.Ports,
+		DockerEntrypoint:        template.DockerEntrypoint,
+		DockerStartCmd:          template.DockerStartCmd,
+		Env:                     template.Env,
+		ContainerDiskInGb:       template.ContainerDiskInGb,
+		ContainerRegistryAuthID: template.ContainerRegistryAuthID,
+		VolumeInGb:              template.VolumeInGb,
+		VolumeMountPath:         template.VolumeMountPath,
+		Readme:                  template.Readme,
+	}
+}
+
+func runRollout(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+
+	if strings.TrimSpace(rolloutImage) == "" {
+		return fmt.Errorf("--image is required")
+	}
+	if rolloutBake < 0 {
+		return fmt.Errorf("--bake must not be negative")
+	}
+	if rolloutBake > 0 && rolloutInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if rolloutMaxFailures < 0 {
+		return fmt.Errorf("--max-failures must not be negative")
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	endpoint, err := client.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	if endpoint.TemplateID == "" {
+		return fmt.Errorf("endpoint %s has no template to roll out from", endpointID)
+	}
+
+	current, err := client.GetTemplate(endpoint.TemplateID)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+
+	revisions, err := listRevisions(client, endpointID, endpoint.TemplateID)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	// take the failure baseline before anything changes, so a broken health
+	// api stops the rollout instead of the rollback
+	var baseline *api.EndpointHealth
+	if rolloutBake > 0 {
+		baseline, err = client.GetEndpointHealth(endpointID)
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to get endpoint health: %w", err)
+		}
+	}
+
+	stderr := cmd.ErrOrStderr()
+	next := 1
+	if len(revisions) > 0 {
+		next = revisions[len(revisions)-1].Revision + 1
+	}
+
+	// record the template the endpoint runs today, so it can be rolled back to
+	// even though it was never created by a rollout. an automatic rollback
+	// then lands on that revision, which "serverless rollback" knows about.
+	previousTemplateID := current.ID
+	var created []string
+	onRevision := false
+	for _, revision := range revisions {
+		onRevision = onRevision || revision.Current
+	}
+	if !onRevision {
+		baseTemplate, err := client.CreateTemplate(cloneTemplateRequest(current, revisionName(endpointID, next), current.ImageName))
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to record current template: %w", err)
+		}
+		templatehistory.Record(stderr, "rollout", baseTemplate.ID, nil)
+		fmt.Fprintf(stderr, "recorded current template %s as revision %d (%s)\n", current.ID, next, baseTemplate.ID)
+		previousTemplateID = baseTemplate.ID
+		created = append(created, baseTemplate.ID)
+		next++
+	}
+
+	newTemplate, err := client.CreateTemplate(cloneTemplateRequest(current, revisionName(endpointID, next), rolloutImage))
+	if err != nil {
+		err = fmt.Errorf("failed to create revision: %w", err)
+		output.Error(err)
+		return deleteRolloutTemplates(stderr, client, created, err)
+	}
+	templatehistory.Record(stderr, "rollout", newTemplate.ID, nil)
+	created = append(created, newTemplate.ID)
+
+	if err := client.UpdateEndpointTemplate(endpointID, newTemplate.ID); err != nil {
+		err = fmt.Errorf("failed to switch endpoint to revision %d: %w", next, err)
+		output.Error(err)
+		return deleteRolloutTemplates(stderr, client, created, err)
+	}
+	fmt.Fprintf(stderr, "switched %s to revision %d (%s, %s)\n", endpointID, next, newTemplate.ID, rolloutImage)
+
+	result := map[string]interface{}{
+		"endpointId":         endpointID,
+		"revision":           next,
+		"templateId":         newTemplate.ID,
+		"image":              rolloutImage,
+		"previousTemplateId": previousTemplateID,
+		"status":             "promoted",
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	if rolloutBake == 0 {
+		return output.Print(result, &output.Config{Format: format})
+	}
+
+	bake, exceeded := bakeRollout(client, endpointID, baseline, rolloutBake, rolloutInterval, rolloutMaxFailures, stderr)
+	result["bake"] = bake
+	if !exceeded {
+		return output.Print(result, &output.Config{Format: format})
+	}
+
+	failure := fmt.Errorf("%d jobs failed during the bake (max %d)", bake.Failed, rolloutMaxFailures)
+	if rolloutNoRollback {
+		result["status"] = "failed"
+		if err := output.Print(result, &output.Config{Format: format}); err != nil {
+			return err
+		}
+		return fmt.Errorf("rollout failed: %w; kept revision %d because of --no-rollback", failure, next)
+	}
+
+	if err := client.UpdateEndpointTemplate(endpointID, previousTemplateID); err != nil {
+		output.Error(err)
+		return fmt.Errorf("rollout failed: %w; rollback to template %s failed: %v", failure, previousTemplateID, err)
+	}
+	fmt.Fprintf(stderr, "rolled %s back to template %s\n", endpointID, previousTemplateID)
+
+	result["status"] = "rolled-back"
+	if err := output.Print(result, &output.Config{Format: format}); err != nil {
+		return err
+	}
+	return fmt.Errorf("rollout failed: %w; rolled back to template %s", failure, previousTemplateID)
+}
+
+// deleteRolloutTemplates removes the revision templates a failed rollout
+// created before the endpoint switched to them, and names any it couldn't
+func deleteRolloutTemplates(stderr io.Writer, client *api.Client, templateIDs []string, failure error) error {
+	var left []string
+	for _, templateID := range templateIDs {
+		before := templatehistory.ReadState(stderr, templateID)
+		if err := client.DeleteTemplate(templateID); err != nil {
+			left = append(left, templateID)
+			continue
+		}
+		templatehistory.Record(stderr, "delete", templateID, before)
+	}
+	if len(left) > 0 {
+		return fmt.Errorf("%w; revision templates %s were left behind, delete them with runpodctl template delete", failure, strings.Join(left, ", "))
+	}
+	return failure
+}
+
+// bakeRollout polls endpoint health until the bake period ends or more than
+// maxFailures jobs have failed since baseline. health errors are reported and
+// retried on the next tick rather than treated as failures.
+func bakeRollout(client *api.Client, endpointID string, baseline *api.EndpointHealth, bake, interval time.Duration, maxFailures int, progress io.Writer) (rolloutBakeResult, bool) {
+	start := time.Now()
+	deadline := start.Add(bake)
+	baseCompleted, baseFailed := baseline.Jobs.Completed, baseline.Jobs.Failed
+	result := rolloutBakeResult{}
+
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		time.Sleep(min(interval, remaining))
+
+		health, err := client.GetEndpointHealth(endpointID)
+		if err != nil {
+			fmt.Fprintf(progress, "warning: failed to get endpoint health: %v\n", err)
+			continue
+		}
+
+		// the counters cover a rolling window, so a drop means older jobs
+		// aged out; rebase instead of reporting a negative count
+		if health.Jobs.Completed < baseCompleted || health.Jobs.Failed < baseFailed {
+			baseCompleted = health.Jobs.Completed - result.Completed
+			baseFailed = health.Jobs.Failed - result.Failed
+		}
+		result.Completed = health.Jobs.Completed - baseCompleted
+		result.Failed = health.Jobs.Failed - baseFailed
+
+		elapsed := time.Since(start).Round(time.Second)
+		fmt.Fprintf(progress, "bake %s/%s: %d completed, %d failed\n", elapsed, bake, result.Completed, result.Failed)
+		if result.Failed > maxFailures {
+			result.Duration = time.Since(start).Round(time.Millisecond).String()
+			return result, true
+		}
+	}
+
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	return result, false
+}
+
+func runRolloutHistory(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	endpoint, err := client.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get endpoint: %w", err)
+	}
+
+	revisions, err := listRevisions(client, endpointID, endpoint.TemplateID)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(revisions, &output.Config{Format: format})
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+
+	if rollbackTo < 0 {
+		return fmt.Errorf("--to must be a revision number")
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	endpoint, err := client.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get endpoint: %w", err)
+	}
+
+	revisions, err := listRevisions(client, endpointID, endpoint.TemplateID)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	target, err := rollbackTarget(revisions, rollbackTo)
+	if err != nil {
+		return err
+	}
+
+	if err := client.UpdateEndpointTemplate(endpointID, target.TemplateID); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to switch endpoint to revision %d: %w", target.Revision, err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"endpointId":         endpointID,
+		"revision":           target.Revision,
+		"templateId":         target.TemplateID,
+		"image":              target.Image,
+		"previousTemplateId": endpoint.TemplateID,
+	}, &output.Config{Format: format})
+}
+
+// rollbackTarget picks revision to, or the revision before the current one
+// when to is 0
+func rollbackTarget(revisions []templateRevision, to int) (*templateRevision, error) {
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("endpoint has no revisions; run \"serverless rollout\" first")
+	}
+
+	current := -1
+	for i, revision := range revisions {
+		if revision.Current {
+			current = i
+		}
+	}
+
+	if to > 0 {
+		for i := range revisions {
+			if revisions[i].Revision != to {
+				continue
+			}
+			if i == current {
+				return nil, fmt.Errorf("endpoint is already on revision %d", to)
+			}
+			return &revisions[i], nil
+		}
+		return nil, fmt.Errorf("revision %d not found", to)
+	}
+
+	if current < 0 {
+		return nil, fmt.Errorf("endpoint is not on a recorded revision; pass --to")
+	}
+	if current == 0 {
+		return nil, fmt.Errorf("endpoint is on its oldest revision (%d)", revisions[0].Revision)
+	}
+	return &revisions[current-1], nil
+}
@@ -0,0 +1,350 @@
+package serverless
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/templatehistory"
+	"github.com/spf13/cobra"
+)
+
+// fakeRolloutAPI serves the rest, graphql and endpoint health apis a rollout
+// touches. failedAfterSwitch is added to the failed job counter once the
+// endpoint has been switched away from its original template, and
+// switchFails makes every template switch fail.
+type fakeRolloutAPI struct {
+	mu                sync.Mutex
+	templateID        string
+	templates         []map[string]interface{}
+	created           []map[string]interface{}
+	switches          []string
+	deleted           []string
+	failedAfterSwitch int
+	switchFails       bool
+}
+
+func (f *fakeRolloutAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-1":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "ep-1", "templateId": f.templateID})
+	case r.Method == http.MethodGet && r.URL.Path == "/templates":
+		_ = json.NewEncoder(w).Encode(f.templates)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/templates/"):
+		id := strings.TrimPrefix(r.URL.Path, "/templates/")
+		for _, template := range f.templates {
+			if template["id"] == id {
+				_ = json.NewEncoder(w).Encode(template)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/templates":
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["id"] = "tpl-new-" + body["name"].(string)
+		f.created = append(f.created, body)
+		f.templates = append(f.templates, body)
+		_ = json.NewEncoder(w).Encode(body)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/templates/"):
+		f.deleted = append(f.deleted, strings.TrimPrefix(r.URL.Path, "/templates/"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == "/":
+		var body struct {
+			Query     string `json:"query"`
+			Variables struct {
+				Input struct {
+					TemplateID string `json:"templateId"`
+				} `json:"input"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if strings.Contains(body.Query, "podTemplates") {
+			// template history reads
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"myself": map[string]interface{}{"podTemplates": f.graphQLTemplates()}}})
+			return
+		}
+		if f.switchFails {
+			_, _ = w.Write([]byte(`{"errors": [{"message": "switch failed"}]}`))
+			return
+		}
+		f.templateID = body.Variables.Input.TemplateID
+		f.switches = append(f.switches, f.templateID)
+		_, _ = w.Write([]byte(`{"data": {"updateEndpointTemplate": {"id": "ep-1"}}}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/ep-1/health":
+		failed := 10
+		if f.templateID != "tpl-orig" {
+			failed += f.failedAfterSwitch
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jobs": map[string]int{"completed": 100, "failed": failed},
+		})
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+	}
+}
+
+func startFakeRolloutAPI(t *testing.T, fake *fakeRolloutAPI) {
+	t.Helper()
+	useFakeAPI(t, fake)
+}
+
+func resetRolloutVars(t *testing.T) {
+	t.Helper()
+	origImage, origBake, origInterval := rolloutImage, rolloutBake, rolloutInterval
+	origMaxFailures, origNoRollback, origTo := rolloutMaxFailures, rolloutNoRollback, rollbackTo
+	t.Cleanup(func() {
+		rolloutImage, rolloutBake, rolloutInterval = origImage, origBake, origInterval
+		rolloutMaxFailures, rolloutNoRollback, rollbackTo = origMaxFailures, origNoRollback, origTo
+	})
+	rolloutBake = 30 * time.Millisecond
+	rolloutInterval = 5 * time.Millisecond
+	rolloutMaxFailures = 3
+	rolloutNoRollback = false
+	rollbackTo = 0
+	t.Setenv("HOME", t.TempDir())
+}
+
+// graphQLTemplates returns the templates as graphql lists them, with env as
+// key/value pairs
+func (f *fakeRolloutAPI) graphQLTemplates() []map[string]interface{} {
+	templates := make([]map[string]interface{}, 0, len(f.templates))
+	for _, template := range f.templates {
+		data, _ := json.Marshal(template["env"])
+		var env map[string]string
+		_ = json.Unmarshal(data, &env)
+		var pairs []map[string]string
+		for key, value := range env {
+			pairs = append(pairs, map[string]string{"key": key, "value": value})
+		}
+
+		listed := make(map[string]interface{}, len(template))
+		for key, value := range template {
+			listed[key] = value
+		}
+		listed["env"] = pairs
+		templates = append(templates, listed)
+	}
+	return templates
+}
+
+func newOriginalTemplate() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                "tpl-orig",
+		"name":              "my-worker",
+		"imageName":         "repo/worker:v1",
+		"isServerless":      true,
+		"env":               map[string]string{"MODEL": "llama"},
+		"containerDiskInGb": 15,
+	}
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	origStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stdout: %v", err)
+	}
+	os.Stdout = w
+
+	fn()
+
+	_ = w.Close()
+	os.Stdout = origStdout
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read stdout: %v", err)
+	}
+	_ = r.Close()
+
+	return string(data)
+}
+
+func runCaptured(t *testing.T, run func(*cobra.Command, []string) error, args ...string) (string, string, error) {
+	t.Helper()
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	var runErr error
+	stdout := captureStdout(t, func() {
+		runErr = run(cmd, args)
+	})
+	return stdout, stderr.String(), runErr
+}
+
+func TestRunRollout_RecordsBaselineAndPromotes(t *testing.T) {
+	resetRolloutVars(t)
+	fake := &fakeRolloutAPI{templateID: "tpl-orig", templates: []map[string]interface{}{newOriginalTemplate()}}
+	startFakeRolloutAPI(t, fake)
+
+	rolloutImage = "repo/worker:v2"
+	stdout, _, err := runCaptured(t, runRollout, "ep-1")
+	if err != nil {
+		t.Fatalf("rollout: %v", err)
+	}
+
+	if len(fake.created) != 2 {
+		t.Fatalf("expected baseline and new revision, got %d templates", len(fake.created))
+	}
+	baseline, next := fake.created[0], fake.created[1]
+	if baseline["name"] != "ep-1__rev1" || baseline["imageName"] != "repo/worker:v1" {
+		t.Fatalf("unexpected baseline revision %v", baseline)
+	}
+	if next["name"] != "ep-1__rev2" || next["imageName"] != "repo/worker:v2" || next["isServerless"] != true {
+		t.Fatalf("unexpected new revision %v", next)
+	}
+	if env, _ := next["env"].(map[string]interface{}); env["MODEL"] != "llama" || next["containerDiskInGb"] != float64(15) {
+		t.Fatalf("expected clone to keep env and disk, got %v", next)
+	}
+	if len(fake.switches) != 1 || fake.switches[0] != "tpl-new-ep-1__rev2" {
+		t.Fatalf("unexpected template switches %v", fake.switches)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse output %q: %v", stdout, err)
+	}
+	if result["status"] != "promoted" || result["revision"] != float64(2) || result["previousTemplateId"] != "tpl-new-ep-1__rev1" {
+		t.Fatalf("unexpected result %v", result)
+	}
+
+	history, err := templatehistory.Load("tpl-new-ep-1__rev2")
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if len(history) != 1 || history[0].Action != "rollout" || history[0].After == nil || history[0].After.ImageName != "repo/worker:v2" {
+		t.Fatalf("expected the new revision in the template history, got %+v", history)
+	}
+}
+
+func TestRunRollout_RollsBackWhenFailuresExceedThreshold(t *testing.T) {
+	resetRolloutVars(t)
+	fake := &fakeRolloutAPI{templateID: "tpl-orig", templates: []map[string]interface{}{newOriginalTemplate()}, failedAfterSwitch: 5}
+	startFakeRolloutAPI(t, fake)
+
+	rolloutImage = "repo/worker:broken"
+	stdout, stderr, err := runCaptured(t, runRollout, "ep-1")
+	if err == nil || !strings.Contains(err.Error(), "5 jobs failed during the bake (max 3)") {
+		t.Fatalf("expected bake failure, got %v", err)
+	}
+
+	if len(fake.switches) != 2 || fake.switches[1] != "tpl-new-ep-1__rev1" {
+		t.Fatalf("expected rollback to the recorded revision, got %v", fake.switches)
+	}
+	if !strings.Contains(stderr, "rolled ep-1 back") {
+		t.Fatalf("expected rollback note, got %q", stderr)
+	}
+	if !strings.Contains(stdout, `"status": "rolled-back"`) {
+		t.Fatalf("expected rolled-back result, got %q", stdout)
+	}
+}
+
+func TestRunRollout_NoRollbackKeepsRevision(t *testing.T) {
+	resetRolloutVars(t)
+	fake := &fakeRolloutAPI{templateID: "tpl-orig", templates: []map[string]interface{}{newOriginalTemplate()}, failedAfterSwitch: 5}
+	startFakeRolloutAPI(t, fake)
+
+	rolloutImage = "repo/worker:broken"
+	rolloutNoRollback = true
+	if _, _, err := runCaptured(t, runRollout, "ep-1"); err == nil {
+		t.Fatal("expected rollout failure")
+	}
+	if len(fake.switches) != 1 {
+		t.Fatalf("expected no rollback, got switches %v", fake.switches)
+	}
+}
+
+func TestRunRollback_DefaultsToPreviousRevision(t *testing.T) {
+	resetRolloutVars(t)
+	fake := &fakeRolloutAPI{
+		templateID: "tpl-3",
+		templates: []map[string]interface{}{
+			newOriginalTemplate(),
+			{"id": "tpl-1", "name": "ep-1__rev1", "imageName": "repo/worker:v1"},
+			{"id": "tpl-3", "name": "ep-1__rev3", "imageName": "repo/worker:v3"},
+			{"id": "tpl-2", "name": "ep-1__rev2", "imageName": "repo/worker:v2"},
+			{"id": "tpl-x", "name": "ep-10__rev1", "imageName": "other"},
+		},
+	}
+	startFakeRolloutAPI(t, fake)
+
+	stdout, _, err := runCaptured(t, runRolloutHistory, "ep-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	var history []templateRevision
+	if err := json.Unmarshal([]byte(stdout), &history); err != nil {
+		t.Fatalf("parse history %q: %v", stdout, err)
+	}
+	if len(history) != 3 || history[0].Revision != 1 || history[2].Revision != 3 || !history[2].Current {
+		t.Fatalf("unexpected history %+v", history)
+	}
+
+	if _, _, err := runCaptured(t, runRollback, "ep-1"); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if fake.templateID != "tpl-2" {
+		t.Fatalf("expected rollback to revision 2, got %s", fake.templateID)
+	}
+
+	rollbackTo = 2
+	if _, _, err := runCaptured(t, runRollback, "ep-1"); err == nil || !strings.Contains(err.Error(), "already on revision 2") {
+		t.Fatalf("expected already-on error, got %v", err)
+	}
+	rollbackTo = 1
+	if _, _, err := runCaptured(t, runRollback, "ep-1"); err != nil {
+		t.Fatalf("rollback --to 1: %v", err)
+	}
+	if fake.templateID != "tpl-1" {
+		t.Fatalf("expected rollback to revision 1, got %s", fake.templateID)
+	}
+}
+
+func TestRollbackTarget_RequiresRevision(t *testing.T) {
+	if _, err := rollbackTarget(nil, 0); err == nil {
+		t.Fatal("expected error without revisions")
+	}
+	revisions := []templateRevision{{Revision: 1, TemplateID: "tpl-1"}}
+	if _, err := rollbackTarget(revisions, 0); err == nil || !strings.Contains(err.Error(), "pass --to") {
+		t.Fatalf("expected --to hint, got %v", err)
+	}
+	if _, err := rollbackTarget(revisions, 4); err == nil || !strings.Contains(err.Error(), "revision 4 not found") {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRunRollout_DeletesRevisionsWhenSwitchFails(t *testing.T) {
+	resetRolloutVars(t)
+	fake := &fakeRolloutAPI{templateID: "tpl-orig", templates: []map[string]interface{}{newOriginalTemplate()}, switchFails: true}
+	startFakeRolloutAPI(t, fake)
+
+	rolloutImage = "repo/worker:v2"
+	_, _, err := runCaptured(t, runRollout, "ep-1")
+	if err == nil || !strings.Contains(err.Error(), "failed to switch endpoint to revision 2") {
+		t.Fatalf("expected a switch failure, got %v", err)
+	}
+	if strings.Join(fake.deleted, ",") != "tpl-new-ep-1__rev1,tpl-new-ep-1__rev2" {
+		t.Fatalf("expected the new revision templates to be deleted, got %v", fake.deleted)
+	}
+
+	history, err := templatehistory.Load("tpl-new-ep-1__rev2")
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if len(history) != 2 || history[0].Action != "rollout" || history[1].Action != "delete" || history[1].After != nil {
+		t.Fatalf("expected create and delete in the template history, got %+v", history)
+	}
+}
@@ -0,0 +1,348 @@
+package serverless
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/cron"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "scale endpoints on a schedule",
+	Long:  "scale endpoints' workers on a schedule of cron-style rules",
+}
+
+var scheduleApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "apply a scaling schedule",
+	Long: `apply a schedule of cron-style scaling rules.
+
+each rule sets workersMin and/or workersMax of an endpoint when its cron
+expression fires, and holds until another rule for the same endpoint fires.
+the rule that fired most recently wins, so applying the schedule at any time
+puts every endpoint in the state it should be in now.
+
+  timezone: Europe/Berlin        # optional, defaults to local time
+  rules:
+    - name: business-hours
+      endpoint: <endpoint-id>
+      cron: "0 8 * * mon-fri"    # minute hour day month weekday
+      workersMin: 2
+      workersMax: 10
+    - name: nights
+      endpoint: <endpoint-id>
+      cron: "0 19 * * mon-fri"
+      workersMin: 0
+
+by default the schedule is evaluated every --interval until ctrl-c, re-reading
+the file each time. with --once it is evaluated a single time, for running
+from a system cron. endpoints held warm by "serverless warm" are left alone
+until the warm-up ends.`,
+	Example: `  runpodctl serverless schedule apply -f schedule.yaml
+  runpodctl serverless schedule apply -f schedule.yaml --once --dry-run
+
+  # crontab entry
+  */5 * * * * runpodctl serverless schedule apply -f /etc/runpod/schedule.yaml --once`,
+	Args: cobra.NoArgs,
+	RunE: runScheduleApply,
+}
+
+var (
+	scheduleFilePath string
+	scheduleOnce     bool
+	scheduleInterval time.Duration
+	scheduleDryRun   bool
+)
+
+// scheduleNow is the clock schedules are evaluated against
+var scheduleNow = time.Now
+
+func init() {
+	scheduleCmd.AddCommand(scheduleApplyCmd)
+
+	scheduleApplyCmd.Flags().StringVarP(&scheduleFilePath, "file", "f", "", "schedule file (yaml)")
+	scheduleApplyCmd.Flags().BoolVar(&scheduleOnce, "once", false, "evaluate the schedule once and exit")
+	scheduleApplyCmd.Flags().DurationVar(&scheduleInterval, "interval", time.Minute, "how often to evaluate the schedule")
+	scheduleApplyCmd.Flags().BoolVar(&scheduleDryRun, "dry-run", false, "show the changes without making them")
+	_ = scheduleApplyCmd.MarkFlagRequired("file")
+}
+
+// scheduleFile is a schedule as written in yaml
+type scheduleFile struct {
+	Timezone string         `yaml:"timezone"`
+	Rules    []scheduleRule `yaml:"rules"`
+
+	location *time.Location
+}
+
+// scheduleRule sets an endpoint's workers when its cron expression fires
+type scheduleRule struct {
+	Name       string `yaml:"name"`
+	Endpoint   string `yaml:"endpoint"`
+	Cron       string `yaml:"cron"`
+	WorkersMin *int   `yaml:"workersMin"`
+	WorkersMax *int   `yaml:"workersMax"`
+
+	schedule *cron.Schedule
+}
+
+// scheduleAction is the outcome of evaluating the schedule for one endpoint
+type scheduleAction struct {
+	EndpointID         string    `json:"endpointId"`
+	Rule               string    `json:"rule"`
+	Since              time.Time `json:"since"`
+	WorkersMin         *int      `json:"workersMin,omitempty"`
+	WorkersMax         *int      `json:"workersMax,omitempty"`
+	PreviousWorkersMin int       `json:"previousWorkersMin"`
+	PreviousWorkersMax int       `json:"previousWorkersMax"`
+	Changed            bool      `json:"changed"`
+	Skipped            string    `json:"skipped,omitempty"`
+	Error              string    `json:"error,omitempty"`
+}
+
+func runScheduleApply(cmd *cobra.Command, args []string) error {
+	if !scheduleOnce && scheduleInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	sched, err := loadSchedule(scheduleFilePath)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	if scheduleOnce {
+		actions, applyErr := applySchedule(client, sched, scheduleNow(), scheduleDryRun)
+		format := output.ParseFormat(cmd.Flag("output").Value.String())
+		if err := output.Print(actions, &output.Config{Format: format}); err != nil {
+			return err
+		}
+		return applyErr
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		actions, _ := applySchedule(client, sched, scheduleNow(), scheduleDryRun)
+		logScheduleActions(cmd, actions)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// pick up edits to the file; a broken edit keeps the last good schedule
+		reloaded, err := loadSchedule(scheduleFilePath)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %v; keeping the previous schedule\n", err)
+			continue
+		}
+		sched = reloaded
+	}
+}
+
+// loadSchedule reads and validates a schedule file
+func loadSchedule(path string) (*scheduleFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule: %w", err)
+	}
+	defer file.Close()
+
+	var sched scheduleFile
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&sched); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(sched.Rules) == 0 {
+		return nil, fmt.Errorf("%s has no rules", path)
+	}
+
+	sched.location = time.Local
+	if sched.Timezone != "" {
+		if sched.location, err = time.LoadLocation(sched.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", sched.Timezone, err)
+		}
+	}
+
+	for i := range sched.Rules {
+		rule := &sched.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if rule.Endpoint == "" || rule.Cron == "" {
+			return nil, fmt.Errorf("%s: endpoint and cron are required", rule.Name)
+		}
+		if rule.WorkersMin == nil && rule.WorkersMax == nil {
+			return nil, fmt.Errorf("%s: set workersMin, workersMax or both", rule.Name)
+		}
+		if rule.WorkersMin != nil && *rule.WorkersMin < 0 {
+			return nil, fmt.Errorf("%s: workersMin must not be negative", rule.Name)
+		}
+		if rule.WorkersMax != nil && *rule.WorkersMax < 1 {
+			return nil, fmt.Errorf("%s: workersMax must be at least 1", rule.Name)
+		}
+		if rule.WorkersMin != nil && rule.WorkersMax != nil && *rule.WorkersMin > *rule.WorkersMax {
+			return nil, fmt.Errorf("%s: workersMin is above workersMax", rule.Name)
+		}
+		if rule.schedule, err = cron.Parse(rule.Cron); err != nil {
+			return nil, fmt.Errorf("%s: %w", rule.Name, err)
+		}
+	}
+	return &sched, nil
+}
+
+// evaluateSchedule picks, for every endpoint, the rule that fired most
+// recently at now. on a tie the rule listed last wins.
+func evaluateSchedule(sched *scheduleFile, now time.Time) []scheduleAction {
+	now = now.In(sched.location)
+	var order []string
+	latest := map[string]*scheduleAction{}
+	for _, rule := range sched.Rules {
+		action, seen := latest[rule.Endpoint]
+		if !seen {
+			order = append(order, rule.Endpoint)
+			action = &scheduleAction{EndpointID: rule.Endpoint, Skipped: "no rule has fired yet"}
+			latest[rule.Endpoint] = action
+		}
+		fired, ok := rule.schedule.Prev(now)
+		if !ok || (action.Rule != "" && fired.Before(action.Since)) {
+			continue
+		}
+		*action = scheduleAction{
+			EndpointID: rule.Endpoint,
+			Rule:       rule.Name,
+			Since:      fired,
+			WorkersMin: rule.WorkersMin,
+			WorkersMax: rule.WorkersMax,
+		}
+	}
+
+	actions := make([]scheduleAction, 0, len(order))
+	for _, endpointID := range order {
+		actions = append(actions, *latest[endpointID])
+	}
+	return actions
+}
+
+// applySchedule brings every endpoint in the schedule to the state its
+// latest rule asks for. endpoints that fail are reported in their action and
+// don't stop the others.
+func applySchedule(client *api.Client, sched *scheduleFile, now time.Time, dryRun bool) ([]scheduleAction, error) {
+	actions := evaluateSchedule(sched, now)
+	records, err := loadWarmRecords()
+	if err != nil {
+		return actions, err
+	}
+
+	var failed []string
+	for i := range actions {
+		action := &actions[i]
+		if action.Rule == "" {
+			continue
+		}
+		record, warming := records[action.EndpointID]
+		if warming && now.Before(record.Until) {
+			action.Skipped = "held warm until " + record.Until.Format(time.RFC3339)
+			continue
+		}
+
+		if err := applyScheduleAction(client, action, dryRun); err != nil {
+			action.Error = err.Error()
+			failed = append(failed, action.EndpointID)
+			continue
+		}
+		if warming && !dryRun {
+			// the warm-up ended without restoring; the schedule owns
+			// workersMin again
+			delete(records, action.EndpointID)
+			if err := saveWarmRecords(records); err != nil {
+				return actions, err
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return actions, fmt.Errorf("failed to apply the schedule to %s", strings.Join(failed, ", "))
+	}
+	return actions, nil
+}
+
+func applyScheduleAction(client *api.Client, action *scheduleAction, dryRun bool) error {
+	endpoint, err := client.GetEndpoint(action.EndpointID, false, false)
+	if err != nil {
+		return fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	action.PreviousWorkersMin, action.PreviousWorkersMax = endpoint.WorkersMin, endpoint.WorkersMax
+
+	workersMin, workersMax := endpoint.WorkersMin, endpoint.WorkersMax
+	if action.WorkersMin != nil {
+		workersMin = *action.WorkersMin
+	}
+	if action.WorkersMax != nil {
+		workersMax = *action.WorkersMax
+	}
+	if workersMin == endpoint.WorkersMin && workersMax == endpoint.WorkersMax {
+		return nil
+	}
+	if workersMin > workersMax {
+		return fmt.Errorf("workersMin %d would be above workersMax %d", workersMin, workersMax)
+	}
+
+	action.Changed = true
+	if dryRun {
+		return nil
+	}
+	_, err = client.UpdateEndpointConfig(action.EndpointID, func(config *api.EndpointConfig) {
+		config.WorkersMin = workersMin
+		config.WorkersMax = workersMax
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update endpoint: %w", err)
+	}
+	return nil
+}
+
+// logScheduleActions reports the changes and failures of one evaluation
+func logScheduleActions(cmd *cobra.Command, actions []scheduleAction) {
+	stamp := scheduleNow().Format("15:04:05")
+	for _, action := range actions {
+		switch {
+		case action.Error != "":
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s %s (%s): %s\n", stamp, action.EndpointID, action.Rule, action.Error)
+		case action.Changed:
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s %s (%s): workers %d-%d -> %s\n", stamp, action.EndpointID, action.Rule,
+				action.PreviousWorkersMin, action.PreviousWorkersMax, formatWorkersRange(action))
+		}
+	}
+}
+
+func formatWorkersRange(action scheduleAction) string {
+	workersMin, workersMax := action.PreviousWorkersMin, action.PreviousWorkersMax
+	if action.WorkersMin != nil {
+		workersMin = *action.WorkersMin
+	}
+	if action.WorkersMax != nil {
+		workersMax = *action.WorkersMax
+	}
+	return fmt.Sprintf("%d-%d", workersMin, workersMax)
+}
@@ -0,0 +1,188 @@
+package serverless
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetScheduleVars(t *testing.T) {
+	t.Helper()
+	origFile, origOnce, origInterval, origDryRun, origNow := scheduleFilePath, scheduleOnce, scheduleInterval, scheduleDryRun, scheduleNow
+	t.Cleanup(func() {
+		scheduleFilePath, scheduleOnce, scheduleInterval, scheduleDryRun, scheduleNow = origFile, origOnce, origInterval, origDryRun, origNow
+	})
+	scheduleFilePath, scheduleOnce, scheduleInterval, scheduleDryRun = "", false, time.Minute, false
+	t.Setenv("HOME", t.TempDir())
+}
+
+const testSchedule = `timezone: UTC
+rules:
+  - name: business-hours
+    endpoint: ep-1
+    cron: "0 8 * * mon-fri"
+    workersMin: 2
+    workersMax: 10
+  - name: nights
+    endpoint: ep-1
+    cron: "0 19 * * mon-fri"
+    workersMin: 0
+  - name: launch
+    endpoint: ep-2
+    cron: "0 0 1 jan *"
+    workersMin: 1
+  - endpoint: ep-3
+    cron: "0 0 31 feb *"
+    workersMin: 1
+`
+
+func writeSchedule(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schedule.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEvaluateSchedule(t *testing.T) {
+	sched, err := loadSchedule(writeSchedule(t, testSchedule))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2026-03-02 is a monday
+	cases := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), "business-hours"},
+		{time.Date(2026, 3, 2, 7, 59, 0, 0, time.UTC), "nights"},
+		{time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), "nights"},
+		{time.Date(2026, 3, 2, 19, 0, 0, 0, time.UTC), "nights"},
+	}
+	for _, tc := range cases {
+		actions := evaluateSchedule(sched, tc.at)
+		if len(actions) != 3 || actions[0].EndpointID != "ep-1" || actions[0].Rule != tc.want {
+			t.Errorf("at %s: got %+v, want %s", tc.at, actions, tc.want)
+		}
+	}
+
+	actions := evaluateSchedule(sched, time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	if actions[1].Rule != "launch" || !actions[1].Since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected ep-2 action %+v", actions[1])
+	}
+	if actions[2].Rule != "" || actions[2].Skipped != "no rule has fired yet" {
+		t.Errorf("unexpected ep-3 action %+v", actions[2])
+	}
+}
+
+func TestLoadSchedule_Errors(t *testing.T) {
+	cases := map[string]string{
+		"rules: []": "has no rules",
+		"rules:\n  - endpoint: ep-1\n    cron: '* * * * *'\n    workersMn: 1":                       "field workersMn not found",
+		"rules:\n  - cron: '* * * * *'\n    workersMin: 1":                                          "rule 1: endpoint and cron are required",
+		"rules:\n  - endpoint: ep-1\n    cron: '* * * * *'":                                         "set workersMin, workersMax or both",
+		"rules:\n  - endpoint: ep-1\n    cron: '* * * * *'\n    workersMin: 3\n    workersMax: 2":   "workersMin is above workersMax",
+		"rules:\n  - name: x\n    endpoint: ep-1\n    cron: '* 25 * * *'\n    workersMin: 1":        "x: invalid value \"25\" in hour field",
+		"timezone: Mars/Base\nrules:\n  - endpoint: ep-1\n    cron: '* * * * *'\n    workersMin: 1": "invalid timezone",
+	}
+	for content, want := range cases {
+		if _, err := loadSchedule(writeSchedule(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestRunScheduleApply_Once(t *testing.T) {
+	resetScheduleVars(t)
+	fake := &fakeScalingAPI{workers: map[string][2]int{"ep-1": {0, 3}, "ep-2": {1, 3}, "ep-3": {0, 1}}}
+	startFakeScalingAPI(t, fake)
+
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	scheduleNow = func() time.Time { return now }
+	scheduleFilePath = writeSchedule(t, testSchedule)
+	scheduleOnce = true
+
+	// a dry run changes nothing
+	scheduleDryRun = true
+	stdout, _, err := runCaptured(t, runScheduleApply)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(fake.saved) != 0 || len(fake.patched) != 0 {
+		t.Fatalf("expected no changes on a dry run, got %v %v", fake.saved, fake.patched)
+	}
+	var actions []scheduleAction
+	if err := json.Unmarshal([]byte(stdout), &actions); err != nil {
+		t.Fatalf("parse %q: %v", stdout, err)
+	}
+	if len(actions) != 3 || !actions[0].Changed || actions[1].Changed || actions[2].Changed {
+		t.Fatalf("unexpected actions %+v", actions)
+	}
+
+	scheduleDryRun = false
+	if _, _, err := runCaptured(t, runScheduleApply); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(fake.saved) != 1 || fake.saved[0]["ep-1"] != 2 || fake.saved[0]["max"] != 10 {
+		t.Fatalf("expected ep-1 scaled to 2-10, got %v", fake.saved)
+	}
+
+	// at night ep-1 goes back to 0, unless it is held warm
+	now = time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	if err := saveWarmRecords(map[string]warmRecord{"ep-1": {EndpointID: "ep-1", WorkersMin: 2, Until: now.Add(time.Hour)}}); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = runCaptured(t, runScheduleApply)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(fake.saved) != 1 || !strings.Contains(stdout, "held warm until") {
+		t.Fatalf("expected the warm endpoint skipped, got %v %s", fake.saved, stdout)
+	}
+
+	// a warm-up that ended without restoring is taken over by the schedule
+	now = now.Add(2 * time.Hour)
+	if _, _, err := runCaptured(t, runScheduleApply); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(fake.saved) != 2 || fake.saved[1]["ep-1"] != 0 || fake.saved[1]["max"] != 10 {
+		t.Fatalf("expected ep-1 scaled to 0-10, got %v", fake.saved)
+	}
+	if records, _ := loadWarmRecords(); len(records) != 0 {
+		t.Fatalf("expected the stale warm-up dropped, got %v", records)
+	}
+}
+
+func TestRunScheduleApply_ReportsFailures(t *testing.T) {
+	resetScheduleVars(t)
+	fake := &fakeScalingAPI{workers: map[string][2]int{"ep-1": {0, 1}}}
+	startFakeScalingAPI(t, fake)
+
+	scheduleNow = func() time.Time { return time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC) }
+	scheduleFilePath = writeSchedule(t, `timezone: UTC
+rules:
+  - endpoint: ep-1
+    cron: "0 8 * * *"
+    workersMin: 2
+  - endpoint: ep-missing
+    cron: "0 8 * * *"
+    workersMin: 2
+`)
+	scheduleOnce = true
+
+	stdout, _, err := runCaptured(t, runScheduleApply)
+	if err == nil || !strings.Contains(err.Error(), "ep-1, ep-missing") {
+		t.Fatalf("expected both endpoints to fail, got %v", err)
+	}
+	if !strings.Contains(stdout, "workersMin 2 would be above workersMax 1") {
+		t.Fatalf("expected the failure in the output, got %s", stdout)
+	}
+	if len(fake.saved) != 0 {
+		t.Fatalf("expected no changes, got %v", fake.saved)
+	}
+}
@@ -18,4 +18,18 @@ func init() {
 	Cmd.AddCommand(createCmd)
 	Cmd.AddCommand(updateCmd)
 	Cmd.AddCommand(deleteCmd)
+	Cmd.AddCommand(devCmd)
+	Cmd.AddCommand(workersCmd)
+	Cmd.AddCommand(rolloutCmd)
+	Cmd.AddCommand(rollbackCmd)
+	Cmd.AddCommand(benchCmd)
+	Cmd.AddCommand(chatCmd)
+	Cmd.AddCommand(completeCmd)
+	Cmd.AddCommand(deployCmd)
+	Cmd.AddCommand(jobCmd)
+	Cmd.AddCommand(comfyCmd)
+	Cmd.AddCommand(modelsCmd)
+	Cmd.AddCommand(volumesCmd)
+	Cmd.AddCommand(warmCmd)
+	Cmd.AddCommand(scheduleCmd)
 }
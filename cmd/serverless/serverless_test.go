@@ -2,13 +2,36 @@ package serverless
 
 import (
 	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/registry/registrytest"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+// useFakeAPI points the rest, graphql and serverless clients at handler for
+// the rest of the test
+func useFakeAPI(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	t.Setenv("RUNPOD_SERVERLESS_URL", server.URL)
+	viper.Set("restApiUrl", server.URL)
+	viper.Set("apiUrl", server.URL)
+	t.Cleanup(func() {
+		viper.Set("restApiUrl", "")
+		viper.Set("apiUrl", "")
+	})
+	return server
+}
+
 func TestServerlessCmd_Structure(t *testing.T) {
 	if Cmd.Use != "serverless" {
 		t.Errorf("expected use 'serverless', got %s", Cmd.Use)
@@ -23,7 +46,7 @@ func TestServerlessCmd_Structure(t *testing.T) {
 	}
 
 	// check subcommands exist
-	expectedSubcommands := []string{"list", "get <endpoint-id>", "create", "update <endpoint-id>", "delete <endpoint-id>"}
+	expectedSubcommands := []string{"list", "get <endpoint-id>", "create", "update <endpoint-id>", "delete <endpoint-id>", "dev", "workers <endpoint-id>", "rollout <endpoint-id>", "rollback <endpoint-id>", "bench <endpoint-id>", "chat <endpoint-id>", "complete <endpoint-id>", "deploy", "job", "comfy", "models", "volumes", "warm <endpoint-id>", "schedule"}
 	for _, expected := range expectedSubcommands {
 		found := false
 		for _, cmd := range Cmd.Commands() {
@@ -92,16 +115,16 @@ func snapshotCreateFlags(t *testing.T) {
 		minCudaVersion, scaleBy                                 string
 		gpuCount, workersMin, workersMax                        int
 		scaleThreshold, idleTimeout, executionTimeout           int
-		flashBoot                                               bool
-		envVars, modelReferences                                []string
+		flashBoot, skipImageCheck                               bool
+		envVars, envSecrets, modelReferences                    []string
 	}{
 		createName, createTemplateID, createHubID, createComputeType, createGpuTypeID, createInstanceID,
 		createDataCenterIDs, createNetworkVolumeID, createNetworkVolumeIDs,
 		createMinCudaVersion, createScaleBy,
 		createGpuCount, createWorkersMin, createWorkersMax,
 		createScaleThreshold, createIdleTimeout, createExecutionTimeout,
-		createFlashBoot,
-		createEnvVars, createModelReferences,
+		createFlashBoot, createSkipImageCheck,
+		createEnvVars, createEnvSecrets, createModelReferences,
 	}
 	t.Cleanup(func() {
 		createName, createTemplateID, createHubID = old.name, old.templateID, old.hubID
@@ -110,8 +133,8 @@ func snapshotCreateFlags(t *testing.T) {
 		createMinCudaVersion, createScaleBy = old.minCudaVersion, old.scaleBy
 		createGpuCount, createWorkersMin, createWorkersMax = old.gpuCount, old.workersMin, old.workersMax
 		createScaleThreshold, createIdleTimeout, createExecutionTimeout = old.scaleThreshold, old.idleTimeout, old.executionTimeout
-		createFlashBoot = old.flashBoot
-		createEnvVars, createModelReferences = old.envVars, old.modelReferences
+		createFlashBoot, createSkipImageCheck = old.flashBoot, old.skipImageCheck
+		createEnvVars, createEnvSecrets, createModelReferences = old.envVars, old.envSecrets, old.modelReferences
 	})
 	// known-good baseline matching the flag defaults; tests override per case.
 	createName, createTemplateID, createHubID = "", "tpl-123", ""
@@ -120,16 +143,24 @@ func snapshotCreateFlags(t *testing.T) {
 	createMinCudaVersion, createScaleBy = "", ""
 	createGpuCount, createWorkersMin, createWorkersMax = 1, 0, 3
 	createScaleThreshold, createIdleTimeout, createExecutionTimeout = -1, -1, -1
-	createFlashBoot = true
-	createEnvVars, createModelReferences = nil, nil
+	createFlashBoot, createSkipImageCheck = true, false
+	createEnvVars, createEnvSecrets, createModelReferences = nil, nil, nil
 }
 
 type mockServerlessCreateClient struct {
 	listing       *api.Listing
+	template      *api.Template
 	getListingHit bool
 	createInput   *api.EndpointCreateGQLInput
 }
 
+func (c *mockServerlessCreateClient) GetTemplate(id string) (*api.Template, error) {
+	if c.template == nil {
+		return nil, fmt.Errorf("template %s not found", id)
+	}
+	return c.template, nil
+}
+
 func (c *mockServerlessCreateClient) GetListing(string) (*api.Listing, error) {
 	c.getListingHit = true
 	return c.listing, nil
@@ -320,6 +351,52 @@ func TestRunCreate_HubDeploymentConstraints(t *testing.T) {
 	}
 }
 
+func TestRunCreate_ChecksTemplateImage(t *testing.T) {
+	server := registrytest.NewServer(t)
+	server.PushIndex("team/worker", "v1", 1<<20, "linux/amd64")
+	server.PushIndex("team/worker", "arm", 1<<20, "linux/arm64")
+
+	cases := []struct {
+		name    string
+		image   string
+		skip    bool
+		wantErr string
+	}{
+		{name: "image exists", image: server.Image("team/worker", "v1")},
+		{name: "tag typo", image: server.Image("team/worker", "v11"), wantErr: "not found"},
+		{name: "wrong platform", image: server.Image("team/worker", "arm"), wantErr: "no linux/amd64 variant"},
+		{name: "skipped", image: server.Image("team/worker", "v11"), skip: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshotCreateFlags(t)
+			createSkipImageCheck = tc.skip
+
+			client := &mockServerlessCreateClient{template: &api.Template{ID: "tpl-123", ImageName: tc.image, ContainerDiskInGb: 10}}
+			oldFactory := newServerlessCreateClient
+			newServerlessCreateClient = func() (serverlessCreateClient, error) { return client, nil }
+			t.Cleanup(func() { newServerlessCreateClient = oldFactory })
+
+			err := runCreate(mockCreateCommand(), nil)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tc.wantErr)
+				}
+				if client.createInput != nil {
+					t.Fatal("endpoint was created despite the failed image check")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client.createInput == nil || client.createInput.TemplateID != "tpl-123" {
+				t.Fatalf("unexpected create input %+v", client.createInput)
+			}
+		})
+	}
+}
+
 func TestUpdateCmd_Flags(t *testing.T) {
 	flags := updateCmd.Flags()
 
@@ -381,3 +458,34 @@ func TestServerlessCmd_Help(t *testing.T) {
 		t.Error("expected help output")
 	}
 }
+
+func TestFilterWorkers(t *testing.T) {
+	workers := []api.Worker{
+		{ID: "w-1", Status: "RUNNING"},
+		{ID: "w-2", Status: "RUNNING", Throttled: true},
+		{ID: "w-3", Status: "EXITED"},
+	}
+
+	if got := filterWorkers(workers, "running", false); len(got) != 2 {
+		t.Fatalf("expected 2 running workers, got %+v", got)
+	}
+	if got := filterWorkers(workers, "", true); len(got) != 1 || got[0].ID != "w-2" {
+		t.Fatalf("expected only w-2, got %+v", got)
+	}
+	if got := filterWorkers(nil, "", false); got == nil {
+		t.Fatal("expected empty slice, not nil")
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	lines := []string{"a", "b", "c"}
+	if got := tailLines(lines, 2); len(got) != 2 || got[0] != "b" {
+		t.Fatalf("unexpected tail %v", got)
+	}
+	if got := tailLines(lines, 0); len(got) != 3 {
+		t.Fatalf("expected all lines, got %v", got)
+	}
+	if got := tailLines(nil, 5); got == nil {
+		t.Fatal("expected empty slice, not nil")
+	}
+}
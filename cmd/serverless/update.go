@@ -1,12 +1,18 @@
 package serverless
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
 	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
 
 	"github.com/spf13/cobra"
 )
@@ -27,7 +33,20 @@ examples:
   runpodctl serverless update <id> --model-reference <ref-a> --model-reference <ref-b>
 
   # clear all model references
-  runpodctl serverless update <id> --clear-models`,
+  runpodctl serverless update <id> --clear-models
+
+  # add env vars to the endpoint's template (merged into the existing env)
+  runpodctl serverless update <id> --env LOG_LEVEL=debug --env-file .env
+
+  # move to other gpus and datacenters without recreating the endpoint
+  runpodctl serverless update <id> --gpu-id "NVIDIA L40S,NVIDIA A40" --data-center-ids EU-RO-1,US-TX-3
+
+  # drop network volumes and cuda constraints
+  runpodctl serverless update <id> --clear-network-volumes --clear-cuda-versions
+
+the endpoint keeps its id and url. a before/after diff of the changed fields is
+printed to stderr. env, the rest fields, the template swap and the remaining
+config are separate writes; if one fails, the ones already applied are named.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runUpdate,
 }
@@ -42,6 +61,22 @@ var (
 	updateScaleThreshold int
 	updateModelRefs      []string
 	updateClearModels    bool
+	updateEnvVars        []string
+	updateEnvFile        string
+
+	updateGpuTypeID           string
+	updateGpuCount            int
+	updateInstanceIDs         string
+	updateDataCenterIDs       string
+	updateClearDataCenterIDs  bool
+	updateNetworkVolumeID     string
+	updateNetworkVolumeIDs    string
+	updateClearNetworkVolumes bool
+	updateExecutionTimeout    int
+	updateFlashBoot           bool
+	updateMinCudaVersion      string
+	updateCudaVersions        string
+	updateClearCudaVersions   bool
 )
 
 func init() {
@@ -54,6 +89,21 @@ func init() {
 	updateCmd.Flags().IntVar(&updateScaleThreshold, "scale-threshold", -1, "trigger point for autoscaler (delay: seconds, requests: count)")
 	updateCmd.Flags().StringArrayVar(&updateModelRefs, "model-reference", nil, "model reference to cache on the endpoint (repeatable); replaces existing model references")
 	updateCmd.Flags().BoolVar(&updateClearModels, "clear-models", false, "remove all model references from the endpoint")
+	updateCmd.Flags().StringArrayVar(&updateEnvVars, "env", nil, "env var in KEY=VALUE format merged into the endpoint template's env (repeatable)")
+	updateCmd.Flags().StringVar(&updateEnvFile, "env-file", "", "read env vars from a dotenv file, merged into the endpoint template's env (--env takes precedence)")
+	updateCmd.Flags().StringVar(&updateGpuTypeID, "gpu-id", "", "gpu ids or pool ids, comma-separated in order of preference (gpu endpoints)")
+	updateCmd.Flags().IntVar(&updateGpuCount, "gpu-count", -1, "new number of gpus per worker")
+	updateCmd.Flags().StringVar(&updateInstanceIDs, "instance-id", "", "cpu instance ids, comma-separated (cpu endpoints, e.g. cpu3g-4-16)")
+	updateCmd.Flags().StringVar(&updateDataCenterIDs, "data-center-ids", "", "comma-separated list of data center ids (replaces existing)")
+	updateCmd.Flags().BoolVar(&updateClearDataCenterIDs, "clear-data-center-ids", false, "allow workers in any data center")
+	updateCmd.Flags().StringVar(&updateNetworkVolumeID, "network-volume-id", "", "network volume id to attach (replaces existing)")
+	updateCmd.Flags().StringVar(&updateNetworkVolumeIDs, "network-volume-ids", "", "comma-separated network volume ids for multi-region (replaces existing)")
+	updateCmd.Flags().BoolVar(&updateClearNetworkVolumes, "clear-network-volumes", false, "detach all network volumes")
+	updateCmd.Flags().IntVar(&updateExecutionTimeout, "execution-timeout", -1, "new max seconds per request (0 for no limit)")
+	updateCmd.Flags().BoolVar(&updateFlashBoot, "flash-boot", true, "enable or disable flash boot (only applied when given)")
+	updateCmd.Flags().StringVar(&updateMinCudaVersion, "min-cuda-version", "", "new minimum cuda version (e.g., 12.6)")
+	updateCmd.Flags().StringVar(&updateCudaVersions, "cuda-versions", "", "comma-separated allowed cuda versions (replaces existing)")
+	updateCmd.Flags().BoolVar(&updateClearCudaVersions, "clear-cuda-versions", false, "remove the minimum and allowed cuda version constraints")
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
@@ -62,12 +112,32 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if updateClearModels && len(updateModelRefs) > 0 {
 		return fmt.Errorf("--clear-models and --model-reference are mutually exclusive")
 	}
+	if err := validateUpdateConfigFlags(); err != nil {
+		return err
+	}
+
+	var envUpdates map[string]string
+	if len(updateEnvVars) > 0 || updateEnvFile != "" {
+		assignments, err := envvars.ParseAssignments(updateEnvVars)
+		if err != nil {
+			return err
+		}
+		envUpdates, err = envvars.Load(updateEnvFile, assignments)
+		if err != nil {
+			return err
+		}
+	}
 
 	client, err := api.NewClient()
 	if err != nil {
 		return err
 	}
 
+	before, err := client.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		return fmt.Errorf("failed to get endpoint: %w", err)
+	}
+
 	req := &api.EndpointUpdateRequest{}
 	hasRESTUpdate := false
 
@@ -103,28 +173,47 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		hasRESTUpdate = true
 	}
 
+	// the saveEndpoint flags are checked before anything is written
+	apply, err := endpointConfigChanges(cmd, client, before)
+	if err != nil {
+		return err
+	}
+
+	// the steps below are separate api calls with no rollback. env goes to
+	// the template first, so a refused env change leaves the endpoint
+	// untouched, and a later failure says which steps already went through.
+	var applied []string
+	if len(envUpdates) > 0 {
+		templateID := before.TemplateID
+		if updateTemplateID != "" {
+			templateID = updateTemplateID
+		}
+		if err := mergeEndpointTemplateEnv(cmd.ErrOrStderr(), client, endpointID, templateID, envUpdates); err != nil {
+			return err
+		}
+		applied = append(applied, fmt.Sprintf("template %s env was updated", templateID))
+	}
+
 	if hasRESTUpdate {
 		if _, err := client.UpdateEndpoint(endpointID, req); err != nil {
+			warnPartialUpdate(applied, "endpoint update")
 			return fmt.Errorf("failed to update endpoint: %w", err)
 		}
+		applied = append(applied, "endpoint rest fields were updated")
 	}
 
 	if updateTemplateID != "" {
 		if err := client.UpdateEndpointTemplate(endpointID, updateTemplateID); err != nil {
-			if hasRESTUpdate {
-				fmt.Fprintln(os.Stderr, "warning: endpoint rest fields were updated, but template swap failed")
-			}
+			warnPartialUpdate(applied, "template swap")
 			return fmt.Errorf("failed to update endpoint template: %w", err)
 		}
+		applied = append(applied, "template was swapped to "+updateTemplateID)
 	}
 
-	if len(updateModelRefs) > 0 || updateClearModels {
-		var refs []string
-		if !updateClearModels {
-			refs = updateModelRefs
-		}
-		if _, err := client.UpdateEndpointModels(endpointID, refs); err != nil {
-			return fmt.Errorf("failed to update model references: %w", err)
+	if apply != nil {
+		if _, err := client.UpdateEndpointConfig(endpointID, apply); err != nil {
+			warnPartialUpdate(applied, "endpoint config update")
+			return fmt.Errorf("failed to update endpoint config: %w", err)
 		}
 	}
 
@@ -132,7 +221,265 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get updated endpoint: %w", err)
 	}
+	printEndpointDiff(cmd.ErrOrStderr(), diffEndpoints(before, endpoint))
 
 	format := output.ParseFormat(cmd.Flag("output").Value.String())
 	return output.Print(endpoint, &output.Config{Format: format})
 }
+
+// warnPartialUpdate says which update steps went through before step failed
+func warnPartialUpdate(applied []string, step string) {
+	if len(applied) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %s, but %s failed\n", strings.Join(applied, " and "), step)
+	}
+}
+
+// mergeEndpointTemplateEnv applies env updates to the template behind an
+// endpoint. endpoint env lives on its template, and the template patch
+// replaces the whole map, so the current env is read and merged first. a
+// template other endpoints or pods use is refused, since they would get the
+// change too. the change is recorded in the template's history.
+func mergeEndpointTemplateEnv(stderr io.Writer, client *api.Client, endpointID, templateID string, updates map[string]string) error {
+	if templateID == "" {
+		return fmt.Errorf("endpoint %s has no template to update env on", endpointID)
+	}
+
+	users, err := templateUsers(client, templateID, endpointID)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return fmt.Errorf("template %s is also used by %s; give this endpoint its own template (runpodctl template clone %s --name <name>, then --template-id) before changing its env", templateID, strings.Join(users, ", "), templateID)
+	}
+
+	template, err := client.GetTemplate(templateID)
+	if err != nil {
+		return fmt.Errorf("failed to get endpoint template: %w", err)
+	}
+
+	before := templatehistory.ReadState(stderr, templateID)
+	if _, err := client.UpdateTemplateEnv(templateID, envvars.Merge(template.Env, updates)); err != nil {
+		return fmt.Errorf("failed to update endpoint template env: %w", err)
+	}
+	templatehistory.Record(stderr, "env", templateID, before)
+	return nil
+}
+
+// templateUsers lists the endpoints and pods other than endpointID that run
+// on a template
+func templateUsers(client *api.Client, templateID, endpointID string) ([]string, error) {
+	endpoints, err := client.ListEndpoints(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	pods, err := client.ListPods(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var users []string
+	for _, endpoint := range endpoints {
+		if endpoint.TemplateID == templateID && endpoint.ID != endpointID {
+			users = append(users, "endpoint "+endpoint.ID)
+		}
+	}
+	for _, pod := range pods {
+		if pod.TemplateID == templateID {
+			users = append(users, "pod "+pod.ID)
+		}
+	}
+	return users, nil
+}
+
+func validateUpdateConfigFlags() error {
+	if updateClearDataCenterIDs && updateDataCenterIDs != "" {
+		return fmt.Errorf("--clear-data-center-ids and --data-center-ids are mutually exclusive")
+	}
+	if updateNetworkVolumeID != "" && updateNetworkVolumeIDs != "" {
+		return fmt.Errorf("--network-volume-id and --network-volume-ids are mutually exclusive")
+	}
+	if updateClearNetworkVolumes && (updateNetworkVolumeID != "" || updateNetworkVolumeIDs != "") {
+		return fmt.Errorf("--clear-network-volumes and --network-volume-id(s) are mutually exclusive")
+	}
+	if updateClearCudaVersions && (updateMinCudaVersion != "" || updateCudaVersions != "") {
+		return fmt.Errorf("--clear-cuda-versions and --min-cuda-version/--cuda-versions are mutually exclusive")
+	}
+	if updateGpuCount == 0 || updateGpuCount < -1 {
+		return fmt.Errorf("--gpu-count must be at least 1")
+	}
+	if updateExecutionTimeout < -1 {
+		return fmt.Errorf("--execution-timeout must be 0 or more seconds")
+	}
+	if updateGpuTypeID != "" && updateInstanceIDs != "" {
+		return fmt.Errorf("--gpu-id and --instance-id are mutually exclusive")
+	}
+	return nil
+}
+
+// endpointConfigChanges turns the saveEndpoint-only flags into a change to
+// apply on top of the current config. It returns nil when none were given.
+func endpointConfigChanges(cmd *cobra.Command, client *api.Client, endpoint *api.Endpoint) (func(*api.EndpointConfig), error) {
+	var changes []func(*api.EndpointConfig)
+	isCPU := strings.EqualFold(endpoint.ComputeType, "CPU") || len(endpoint.InstanceIDs) > 0
+
+	if updateGpuTypeID != "" {
+		if isCPU {
+			return nil, fmt.Errorf("--gpu-id is not supported on a cpu endpoint; use --instance-id")
+		}
+		poolIDs, err := client.ResolveServerlessGpuPoolID(updateGpuTypeID)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, func(c *api.EndpointConfig) { c.GpuIDs = poolIDs })
+	}
+	if updateGpuCount > 0 {
+		if isCPU {
+			return nil, fmt.Errorf("--gpu-count is not supported on a cpu endpoint")
+		}
+		count := updateGpuCount
+		changes = append(changes, func(c *api.EndpointConfig) { c.GpuCount = count })
+	}
+	if updateInstanceIDs != "" {
+		if !isCPU {
+			return nil, fmt.Errorf("--instance-id is only supported on cpu endpoints")
+		}
+		ids := splitList(updateInstanceIDs)
+		for _, id := range ids {
+			if !isCPUInstanceID(id) {
+				return nil, fmt.Errorf("invalid --instance-id %q; expected a cpu flavor id like cpu3g-4-16", id)
+			}
+		}
+		changes = append(changes, func(c *api.EndpointConfig) { c.InstanceIDs = ids })
+	}
+
+	if updateDataCenterIDs != "" || updateClearDataCenterIDs {
+		locations := strings.Join(splitList(updateDataCenterIDs), ",")
+		changes = append(changes, func(c *api.EndpointConfig) { c.Locations = locations })
+	}
+
+	if updateNetworkVolumeID != "" || updateNetworkVolumeIDs != "" || updateClearNetworkVolumes {
+		ids := splitList(updateNetworkVolumeIDs)
+		if updateNetworkVolumeID != "" {
+			ids = []string{strings.TrimSpace(updateNetworkVolumeID)}
+		}
+		changes = append(changes, func(c *api.EndpointConfig) { setNetworkVolumes(c, ids) })
+	}
+
+	if updateExecutionTimeout >= 0 {
+		timeoutMs := updateExecutionTimeout * 1000
+		changes = append(changes, func(c *api.EndpointConfig) { c.ExecutionTimeoutMs = timeoutMs })
+	}
+	if flagChanged(cmd, "flash-boot") {
+		flashBootType := "OFF"
+		if updateFlashBoot {
+			flashBootType = "FLASHBOOT"
+		}
+		changes = append(changes, func(c *api.EndpointConfig) { c.FlashBootType = flashBootType })
+	}
+
+	if updateClearCudaVersions {
+		changes = append(changes, func(c *api.EndpointConfig) {
+			c.MinCudaVersion = ""
+			c.AllowedCudaVersions = ""
+		})
+	}
+	if updateMinCudaVersion != "" {
+		version := strings.TrimSpace(updateMinCudaVersion)
+		changes = append(changes, func(c *api.EndpointConfig) { c.MinCudaVersion = version })
+	}
+	if updateCudaVersions != "" {
+		versions := strings.Join(splitList(updateCudaVersions), ",")
+		changes = append(changes, func(c *api.EndpointConfig) { c.AllowedCudaVersions = versions })
+	}
+
+	if len(updateModelRefs) > 0 || updateClearModels {
+		var refs []string
+		if !updateClearModels {
+			refs = updateModelRefs
+		}
+		changes = append(changes, func(c *api.EndpointConfig) { c.ModelReferences = refs })
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return func(c *api.EndpointConfig) {
+		for _, change := range changes {
+			change(c)
+		}
+	}, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// endpointChange is one changed field in the before/after diff.
+type endpointChange struct {
+	Field  string
+	Before interface{}
+	After  interface{}
+}
+
+// diffEndpoints compares the config fields of two endpoint reads. template
+// and workers are left out; they are not part of the endpoint config.
+func diffEndpoints(before, after *api.Endpoint) []endpointChange {
+	toMap := func(endpoint *api.Endpoint) map[string]interface{} {
+		fields := map[string]interface{}{}
+		data, err := json.Marshal(endpoint)
+		if err == nil {
+			_ = json.Unmarshal(data, &fields)
+		}
+		delete(fields, "template")
+		delete(fields, "workers")
+		return fields
+	}
+
+	beforeFields, afterFields := toMap(before), toMap(after)
+	keys := make([]string, 0, len(beforeFields)+len(afterFields))
+	for key := range beforeFields {
+		keys = append(keys, key)
+	}
+	for key := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []endpointChange
+	for _, key := range keys {
+		if !reflect.DeepEqual(beforeFields[key], afterFields[key]) {
+			changes = append(changes, endpointChange{Field: key, Before: beforeFields[key], After: afterFields[key]})
+		}
+	}
+	return changes
+}
+
+func printEndpointDiff(w io.Writer, changes []endpointChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no endpoint config changes")
+		return
+	}
+	fmt.Fprintln(w, "endpoint config changes:")
+	for _, change := range changes {
+		fmt.Fprintf(w, "  %s: %s -> %s\n", change.Field, diffValue(change.Before), diffValue(change.After))
+	}
+}
+
+func diffValue(value interface{}) string {
+	if value == nil {
+		return "(unset)"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/runpod/runpodctl/internal/templatehistory"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -66,6 +67,13 @@ func resetUpdateVars(t *testing.T) {
 	origScaleThreshold := updateScaleThreshold
 	origModelRefs := updateModelRefs
 	origClearModels := updateClearModels
+	origEnvVars := updateEnvVars
+	origEnvFile := updateEnvFile
+	origGpuTypeID, origGpuCount, origInstanceIDs := updateGpuTypeID, updateGpuCount, updateInstanceIDs
+	origDataCenterIDs, origClearDataCenterIDs := updateDataCenterIDs, updateClearDataCenterIDs
+	origNetworkVolumeID, origNetworkVolumeIDs, origClearNetworkVolumes := updateNetworkVolumeID, updateNetworkVolumeIDs, updateClearNetworkVolumes
+	origExecutionTimeout, origFlashBoot := updateExecutionTimeout, updateFlashBoot
+	origMinCudaVersion, origCudaVersions, origClearCudaVersions := updateMinCudaVersion, updateCudaVersions, updateClearCudaVersions
 	t.Cleanup(func() {
 		updateName = origName
 		updateTemplateID = origTemplateID
@@ -76,6 +84,13 @@ func resetUpdateVars(t *testing.T) {
 		updateScaleThreshold = origScaleThreshold
 		updateModelRefs = origModelRefs
 		updateClearModels = origClearModels
+		updateEnvVars = origEnvVars
+		updateEnvFile = origEnvFile
+		updateGpuTypeID, updateGpuCount, updateInstanceIDs = origGpuTypeID, origGpuCount, origInstanceIDs
+		updateDataCenterIDs, updateClearDataCenterIDs = origDataCenterIDs, origClearDataCenterIDs
+		updateNetworkVolumeID, updateNetworkVolumeIDs, updateClearNetworkVolumes = origNetworkVolumeID, origNetworkVolumeIDs, origClearNetworkVolumes
+		updateExecutionTimeout, updateFlashBoot = origExecutionTimeout, origFlashBoot
+		updateMinCudaVersion, updateCudaVersions, updateClearCudaVersions = origMinCudaVersion, origCudaVersions, origClearCudaVersions
 	})
 }
 
@@ -84,6 +99,8 @@ func TestRunUpdate_WarnsWhenTemplateSwapFailsAfterRESTUpdate(t *testing.T) {
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-123":
+			_, _ = w.Write([]byte(`{"id": "ep-123", "name": "old-name"}`))
 		case r.Method == http.MethodPatch && r.URL.Path == "/endpoints/ep-123":
 			var body map[string]interface{}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -249,3 +266,260 @@ func TestRunUpdate_ModelReferences(t *testing.T) {
 		t.Errorf("workersMax not round-tripped: got %v", input["workersMax"])
 	}
 }
+
+func TestRunUpdate_ConfigFlagsRoundTripAndDiff(t *testing.T) {
+	resetUpdateVars(t)
+
+	var gqlBody map[string]interface{}
+	saved := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-123":
+			if saved {
+				_, _ = w.Write([]byte(`{
+					"id": "ep-123", "name": "my-endpoint", "gpuIds": "ADA_24",
+					"locations": "EU-RO-1,US-TX-3", "executionTimeoutMs": 30000,
+					"allowedCudaVersions": "12.4,12.6", "workersMax": 5
+				}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{
+				"id": "ep-123", "name": "my-endpoint", "gpuIds": "ADA_24",
+				"networkVolumeId": "vol-1", "networkVolumeIds": ["vol-1"],
+				"executionTimeoutMs": 600000, "workersMax": 5
+			}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			if err := json.NewDecoder(r.Body).Decode(&gqlBody); err != nil {
+				t.Fatalf("decode gql request: %v", err)
+			}
+			saved = true
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"saveEndpoint": map[string]interface{}{"id": "ep-123"},
+				},
+			})
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	viper.Set("restApiUrl", server.URL)
+	viper.Set("apiUrl", server.URL)
+	t.Cleanup(func() {
+		viper.Set("restApiUrl", "")
+		viper.Set("apiUrl", "")
+	})
+
+	updateDataCenterIDs = "EU-RO-1, US-TX-3"
+	updateClearNetworkVolumes = true
+	updateExecutionTimeout = 30
+	updateCudaVersions = "12.4,12.6"
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	cmd.Flags().Bool("flash-boot", true, "")
+	var errOut strings.Builder
+	cmd.SetErr(&errOut)
+
+	if err := runUpdate(cmd, []string{"ep-123"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vars, _ := gqlBody["variables"].(map[string]interface{})
+	input, _ := vars["input"].(map[string]interface{})
+	if input["locations"] != "EU-RO-1,US-TX-3" {
+		t.Errorf("unexpected locations %v", input["locations"])
+	}
+	if input["executionTimeoutMs"] != float64(30000) {
+		t.Errorf("unexpected executionTimeoutMs %v", input["executionTimeoutMs"])
+	}
+	if input["allowedCudaVersions"] != "12.4,12.6" {
+		t.Errorf("unexpected allowedCudaVersions %v", input["allowedCudaVersions"])
+	}
+	if nvIDs, _ := input["networkVolumeIds"].([]interface{}); input["networkVolumeId"] != "" || len(nvIDs) != 0 {
+		t.Errorf("expected network volumes cleared, got %v / %v", input["networkVolumeId"], input["networkVolumeIds"])
+	}
+	// untouched fields are round-tripped
+	if input["gpuIds"] != "ADA_24" || input["workersMax"] != float64(5) {
+		t.Errorf("expected existing config kept, got gpuIds=%v workersMax=%v", input["gpuIds"], input["workersMax"])
+	}
+
+	diff := errOut.String()
+	for _, want := range []string{
+		`executionTimeoutMs: 600000 -> 30000`,
+		`locations: (unset) -> "EU-RO-1,US-TX-3"`,
+		`networkVolumeId: "vol-1" -> (unset)`,
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, "gpuIds") {
+		t.Errorf("unchanged field in diff:\n%s", diff)
+	}
+}
+
+func TestRunUpdate_ClearFlagsMutuallyExclusive(t *testing.T) {
+	resetUpdateVars(t)
+
+	updateDataCenterIDs = "EU-RO-1"
+	updateClearDataCenterIDs = true
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+
+	err := runUpdate(cmd, []string{"ep-123"})
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestRunUpdate_EnvOnSharedTemplateIsRefused(t *testing.T) {
+	resetUpdateVars(t)
+
+	patched := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-123":
+			_, _ = w.Write([]byte(`{"id": "ep-123", "name": "old-name", "templateId": "tpl-1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints":
+			_, _ = w.Write([]byte(`[{"id": "ep-123", "templateId": "tpl-1"}, {"id": "ep-456", "templateId": "tpl-1"}]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pods":
+			_, _ = w.Write([]byte(`[{"id": "pod-1", "templateId": "tpl-2"}]`))
+		case r.Method == http.MethodPatch:
+			patched = true
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	viper.Set("restApiUrl", server.URL)
+	t.Cleanup(func() { viper.Set("restApiUrl", "") })
+
+	updateName = "patched-name"
+	updateEnvVars = []string{"LOG_LEVEL=debug"}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+
+	err := runUpdate(cmd, []string{"ep-123"})
+	if err == nil || !strings.Contains(err.Error(), "also used by endpoint ep-456") {
+		t.Fatalf("expected a shared template error, got %v", err)
+	}
+	if patched {
+		t.Fatal("expected nothing to be updated")
+	}
+}
+
+func TestRunUpdate_EnvRecordsTemplateHistory(t *testing.T) {
+	resetUpdateVars(t)
+	t.Setenv("HOME", t.TempDir())
+
+	env := `[{"key": "MODEL", "value": "llama"}]`
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-123":
+			_, _ = w.Write([]byte(`{"id": "ep-123", "name": "old-name", "templateId": "tpl-1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints":
+			_, _ = w.Write([]byte(`[{"id": "ep-123", "templateId": "tpl-1"}]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pods":
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/templates/tpl-1":
+			_, _ = w.Write([]byte(`{"id": "tpl-1", "name": "worker", "env": {"MODEL": "llama"}}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/templates/tpl-1":
+			env = `[{"key": "LOG_LEVEL", "value": "debug"}, {"key": "MODEL", "value": "llama"}]`
+			_, _ = w.Write([]byte(`{"id": "tpl-1"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			_, _ = w.Write([]byte(`{"data": {"myself": {"podTemplates": [{"id": "tpl-1", "name": "worker", "imageName": "repo/worker:v1", "isServerless": true, "env": ` + env + `}]}}}`))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	updateEnvVars = []string{"LOG_LEVEL=debug"}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	cmd.SetOut(io.Discard)
+	var stderr strings.Builder
+	cmd.SetErr(&stderr)
+
+	var runErr error
+	captureStdout(t, func() {
+		runErr = runUpdate(cmd, []string{"ep-123"})
+	})
+	if runErr != nil {
+		t.Fatalf("update: %v", runErr)
+	}
+
+	revisions, err := templatehistory.Load("tpl-1")
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Action != "env" {
+		t.Fatalf("expected one env revision, got %+v (stderr %q)", revisions, stderr.String())
+	}
+	if revisions[0].Before == nil || len(revisions[0].Before.Env) != 1 || revisions[0].After == nil || revisions[0].After.Env["LOG_LEVEL"] != "debug" {
+		t.Fatalf("unexpected revision %+v", revisions[0])
+	}
+}
+
+func TestRunUpdate_ReportsAppliedStepsWhenConfigSaveFails(t *testing.T) {
+	resetUpdateVars(t)
+
+	var gqlBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-123":
+			_, _ = w.Write([]byte(`{"id": "ep-123", "name": "old-name", "allowedCudaVersions": "12.4"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/endpoints/ep-123":
+			_, _ = w.Write([]byte(`{"id": "ep-123", "name": "patched-name"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			if err := json.NewDecoder(r.Body).Decode(&gqlBody); err != nil {
+				t.Fatalf("decode gql request: %v", err)
+			}
+			_, _ = w.Write([]byte(`{"errors": [{"message": "save failed"}]}`))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	viper.Set("restApiUrl", server.URL)
+	viper.Set("apiUrl", server.URL)
+	t.Cleanup(func() {
+		viper.Set("restApiUrl", "")
+		viper.Set("apiUrl", "")
+	})
+
+	updateName = "patched-name"
+	updateClearCudaVersions = true
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	cmd.Flags().Bool("flash-boot", true, "")
+
+	var runErr error
+	stderr := captureStderr(t, func() {
+		runErr = runUpdate(cmd, []string{"ep-123"})
+	})
+	if runErr == nil || !strings.Contains(runErr.Error(), "failed to update endpoint config") {
+		t.Fatalf("expected a config save error, got %v", runErr)
+	}
+	if !strings.Contains(stderr, "warning: endpoint rest fields were updated, but endpoint config update failed") {
+		t.Fatalf("expected the applied steps to be reported, got %q", stderr)
+	}
+
+	vars, _ := gqlBody["variables"].(map[string]interface{})
+	input, _ := vars["input"].(map[string]interface{})
+	if value, ok := input["allowedCudaVersions"]; !ok || value != "" {
+		t.Fatalf("expected allowedCudaVersions to be sent empty, got %v (present %t)", value, ok)
+	}
+}
@@ -0,0 +1,332 @@
+package serverless
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var volumesCmd = &cobra.Command{
+	Use:   "volumes",
+	Short: "manage the network volumes of an endpoint",
+	Long: `list, attach and detach the network volumes of an endpoint. an endpoint can
+have one network volume per datacenter; its workers mount the volume of the
+datacenter they run in.`,
+}
+
+var volumesListCmd = &cobra.Command{
+	Use:   "list <endpoint-id>",
+	Short: "list the network volumes of an endpoint",
+	Long: `list the network volumes of an endpoint with their datacenter, whether the
+datacenter is in the endpoint's locations and the stock of the endpoint's gpus
+there.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVolumesList,
+}
+
+var volumesAttachCmd = &cobra.Command{
+	Use:   "attach <endpoint-id> [volume-id]...",
+	Short: "attach network volumes to an endpoint",
+	Long: `attach network volumes to an endpoint, keeping the ones it already has.
+
+each volume must be in a datacenter the endpoint has no volume in yet and, when
+the endpoint is limited to --data-center-ids, in one of those. a warning is
+printed when the endpoint's gpus are low or out of stock in a volume's
+datacenter.
+
+--replicate-from creates a volume with the name and size of an existing one in
+each of --data-center-ids and attaches it. the new volumes start empty.`,
+	Example: `  runpodctl serverless volumes attach <endpoint-id> <volume-id>
+  runpodctl serverless volumes attach <endpoint-id> --replicate-from <volume-id> --data-center-ids EU-RO-1,US-TX-3`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runVolumesAttach,
+}
+
+var volumesDetachCmd = &cobra.Command{
+	Use:   "detach <endpoint-id> <volume-id>...",
+	Short: "detach network volumes from an endpoint",
+	Long:  "detach network volumes from an endpoint, keeping the others. the volumes themselves are not deleted.",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runVolumesDetach,
+}
+
+var (
+	volumesReplicateFrom string
+	volumesDataCenterIDs string
+)
+
+func init() {
+	volumesAttachCmd.Flags().StringVar(&volumesReplicateFrom, "replicate-from", "", "create volumes like this one in --data-center-ids and attach them")
+	volumesAttachCmd.Flags().StringVar(&volumesDataCenterIDs, "data-center-ids", "", "comma-separated datacenter ids for --replicate-from")
+
+	volumesCmd.AddCommand(volumesListCmd)
+	volumesCmd.AddCommand(volumesAttachCmd)
+	volumesCmd.AddCommand(volumesDetachCmd)
+}
+
+// endpointVolume is an attached network volume as printed
+type endpointVolume struct {
+	ID           string `json:"id"`
+	Name         string `json:"name,omitempty"`
+	Size         int    `json:"size,omitempty"`
+	DataCenterID string `json:"dataCenterId,omitempty"`
+	InLocations  bool   `json:"inLocations"`
+	GpuStock     string `json:"gpuStock,omitempty"`
+}
+
+// volumeContext is what attach, detach and list need to know about an
+// endpoint and the account's volumes
+type volumeContext struct {
+	client   *api.Client
+	endpoint *api.Endpoint
+	volumes  map[string]api.NetworkVolume
+	// stock is the best stock of the endpoint's gpus per datacenter, nil when
+	// unknown or for cpu endpoints
+	stock map[string]string
+}
+
+func loadVolumeContext(cmd *cobra.Command, endpointID string) (*volumeContext, error) {
+	client, err := api.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := client.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := client.ListNetworkVolumes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network volumes: %w", err)
+	}
+	ctx := &volumeContext{client: client, endpoint: endpoint, volumes: map[string]api.NetworkVolume{}}
+	for _, volume := range volumes {
+		ctx.volumes[volume.ID] = volume
+	}
+
+	if gpuIDs := splitList(endpoint.GpuIDs); len(gpuIDs) > 0 && endpoint.ComputeType != "CPU" {
+		stock, err := client.ServerlessPoolStock(gpuIDs)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: failed to check gpu stock: %v\n", err)
+		} else {
+			ctx.stock = stock
+		}
+	}
+	return ctx, nil
+}
+
+// attached returns the ids of the endpoint's volumes
+func (v *volumeContext) attached() []string {
+	var ids []string
+	for _, volume := range v.endpoint.NetworkVolumeIDs {
+		ids = append(ids, volume.NetworkVolumeID)
+	}
+	if id := v.endpoint.NetworkVolumeID; id != "" && !containsFold(ids, id) {
+		ids = append([]string{id}, ids...)
+	}
+	return ids
+}
+
+// inLocations reports whether workers of the endpoint may run in dataCenterID
+func (v *volumeContext) inLocations(dataCenterID string) bool {
+	locations := splitList(v.endpoint.Locations)
+	return len(locations) == 0 || containsFold(locations, dataCenterID)
+}
+
+// checkDataCenter fails unless a new volume, described by label, may go into
+// dataCenterID and warns when the endpoint's gpus are short there. taken maps
+// the datacenters that already have a volume to a description of it.
+func (v *volumeContext) checkDataCenter(cmd *cobra.Command, label, dataCenterID string, taken map[string]string) error {
+	if other, ok := taken[strings.ToUpper(dataCenterID)]; ok {
+		return fmt.Errorf("%s: %s already has %s; an endpoint takes one volume per datacenter", label, dataCenterID, other)
+	}
+	if !v.inLocations(dataCenterID) {
+		return fmt.Errorf("%s: %s is not in the endpoint's locations (%s); add it with 'serverless update --data-center-ids'", label, dataCenterID, v.endpoint.Locations)
+	}
+	if v.stock != nil {
+		switch stock := v.stock[dataCenterID]; stock {
+		case "":
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: no %s gpus in stock in %s\n", v.endpoint.GpuIDs, dataCenterID)
+		case "Low":
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s gpus are low on stock in %s\n", v.endpoint.GpuIDs, dataCenterID)
+		}
+	}
+	taken[strings.ToUpper(dataCenterID)] = label
+	return nil
+}
+
+// describe returns the endpoint's volumes as printed
+func (v *volumeContext) describe(ids []string) []endpointVolume {
+	described := make([]endpointVolume, 0, len(ids))
+	for _, id := range ids {
+		entry := endpointVolume{ID: id}
+		if volume, ok := v.volumes[id]; ok {
+			entry.Name, entry.Size, entry.DataCenterID = volume.Name, volume.Size, volume.DataCenterID
+			entry.InLocations = v.inLocations(volume.DataCenterID)
+			if v.stock != nil {
+				entry.GpuStock = v.stock[volume.DataCenterID]
+				if entry.GpuStock == "" {
+					entry.GpuStock = "None"
+				}
+			}
+		}
+		described = append(described, entry)
+	}
+	return described
+}
+
+func runVolumesList(cmd *cobra.Command, args []string) error {
+	ctx, err := loadVolumeContext(cmd, args[0])
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	return printEndpointVolumes(cmd, ctx.describe(ctx.attached()))
+}
+
+func runVolumesAttach(cmd *cobra.Command, args []string) error {
+	endpointID, volumeIDs := args[0], args[1:]
+	replicaDCs := splitList(volumesDataCenterIDs)
+	if volumesReplicateFrom == "" && len(volumeIDs) == 0 {
+		return fmt.Errorf("pass volume ids to attach or --replicate-from with --data-center-ids")
+	}
+	if (volumesReplicateFrom != "") != (len(replicaDCs) > 0) {
+		return fmt.Errorf("--replicate-from and --data-center-ids go together")
+	}
+
+	ctx, err := loadVolumeContext(cmd, endpointID)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	current := ctx.attached()
+	taken := map[string]string{}
+	for _, id := range current {
+		if volume, ok := ctx.volumes[id]; ok && volume.DataCenterID != "" {
+			taken[strings.ToUpper(volume.DataCenterID)] = "volume " + id
+		}
+	}
+
+	// check everything before creating or attaching anything
+	updated := append([]string{}, current...)
+	for _, id := range volumeIDs {
+		if containsFold(current, id) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "volume %s is already attached to %s\n", id, endpointID)
+			continue
+		}
+		volume, ok := ctx.volumes[id]
+		if !ok {
+			return fmt.Errorf("network volume %s not found", id)
+		}
+		if err := ctx.checkDataCenter(cmd, "volume "+id, volume.DataCenterID, taken); err != nil {
+			return err
+		}
+		updated = append(updated, id)
+	}
+
+	var source api.NetworkVolume
+	if volumesReplicateFrom != "" {
+		var ok bool
+		if source, ok = ctx.volumes[volumesReplicateFrom]; !ok {
+			return fmt.Errorf("network volume %s not found", volumesReplicateFrom)
+		}
+		for _, dc := range replicaDCs {
+			if err := ctx.checkDataCenter(cmd, "replica of "+source.ID, dc, taken); err != nil {
+				return err
+			}
+		}
+	}
+
+	var created []string
+	for _, dc := range replicaDCs {
+		volume, err := ctx.client.CreateNetworkVolume(&api.NetworkVolumeCreateRequest{
+			Name:         source.Name + "-" + strings.ToLower(dc),
+			Size:         source.Size,
+			DataCenterID: dc,
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to create a volume in %s (created so far: %s): %w", dc, strings.Join(created, ", "), err)
+			output.Error(err)
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "created volume %s (%d gb) in %s\n", volume.ID, volume.Size, dc)
+		ctx.volumes[volume.ID] = *volume
+		created = append(created, volume.ID)
+		updated = append(updated, volume.ID)
+	}
+
+	if len(updated) == len(current) {
+		return printEndpointVolumes(cmd, ctx.describe(current))
+	}
+
+	if _, err := ctx.client.UpdateEndpointConfig(endpointID, func(c *api.EndpointConfig) { setNetworkVolumes(c, updated) }); err != nil {
+		if len(created) > 0 {
+			err = fmt.Errorf("%w (created volumes %s are not attached)", err, strings.Join(created, ", "))
+		}
+		output.Error(err)
+		return err
+	}
+	return printEndpointVolumes(cmd, ctx.describe(updated))
+}
+
+func runVolumesDetach(cmd *cobra.Command, args []string) error {
+	endpointID, volumeIDs := args[0], args[1:]
+
+	ctx, err := loadVolumeContext(cmd, endpointID)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	current := ctx.attached()
+	for _, id := range volumeIDs {
+		if !containsFold(current, id) {
+			return fmt.Errorf("volume %s is not attached to %s", id, endpointID)
+		}
+	}
+
+	kept := []string{}
+	for _, id := range current {
+		if !containsFold(volumeIDs, id) {
+			kept = append(kept, id)
+		}
+	}
+
+	if _, err := ctx.client.UpdateEndpointConfig(endpointID, func(c *api.EndpointConfig) { setNetworkVolumes(c, kept) }); err != nil {
+		output.Error(err)
+		return err
+	}
+	return printEndpointVolumes(cmd, ctx.describe(kept))
+}
+
+// setNetworkVolumes replaces the network volumes of an endpoint config
+func setNetworkVolumes(c *api.EndpointConfig, ids []string) {
+	c.NetworkVolumeID = ""
+	c.NetworkVolumeIDs = make([]api.NetworkVolumeIDInput, 0, len(ids))
+	for _, id := range ids {
+		c.NetworkVolumeIDs = append(c.NetworkVolumeIDs, api.NetworkVolumeIDInput{NetworkVolumeID: id})
+	}
+	if len(ids) > 0 {
+		c.NetworkVolumeID = ids[0]
+	}
+}
+
+func printEndpointVolumes(cmd *cobra.Command, volumes []endpointVolume) error {
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(volumes, &output.Config{Format: format})
+}
+
+func containsFold(items []string, item string) bool {
+	for _, candidate := range items {
+		if strings.EqualFold(candidate, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package serverless
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func resetVolumesVars(t *testing.T) {
+	t.Helper()
+	origReplicateFrom, origDataCenterIDs := volumesReplicateFrom, volumesDataCenterIDs
+	t.Cleanup(func() { volumesReplicateFrom, volumesDataCenterIDs = origReplicateFrom, origDataCenterIDs })
+	volumesReplicateFrom, volumesDataCenterIDs = "", ""
+}
+
+// fakeVolumesAPI serves an ADA_24 endpoint limited to three datacenters,
+// four volumes and gpu stock, and records created volumes and saved volume
+// ids
+type fakeVolumesAPI struct {
+	attached []string
+	created  []map[string]interface{}
+	saved    [][]string
+}
+
+func startFakeVolumesAPI(t *testing.T, fake *fakeVolumesAPI) {
+	t.Helper()
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/endpoints/ep-1":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": "ep-1", "gpuIds": "ADA_24", "locations": "EU-RO-1,US-TX-3,EU-SE-1", "networkVolumeIds": fake.attached,
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/networkvolumes":
+			_, _ = w.Write([]byte(`[
+				{"id":"vol-ro","name":"models","size":100,"dataCenterId":"EU-RO-1"},
+				{"id":"vol-ro-2","name":"other","size":10,"dataCenterId":"EU-RO-1"},
+				{"id":"vol-tx","name":"models-tx","size":100,"dataCenterId":"US-TX-3"},
+				{"id":"vol-ca","name":"models-ca","size":100,"dataCenterId":"CA-MTL-1"}
+			]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/networkvolumes":
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			fake.created = append(fake.created, body)
+			body["id"] = "vol-new"
+			_ = json.NewEncoder(w).Encode(body)
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			var body struct {
+				Query     string `json:"query"`
+				Variables struct {
+					Input struct {
+						NetworkVolumeIDs []struct {
+							NetworkVolumeID string `json:"networkVolumeId"`
+						} `json:"networkVolumeIds"`
+					} `json:"input"`
+				} `json:"variables"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			switch {
+			case strings.Contains(body.Query, "serverlessGpuPools"):
+				_, _ = w.Write([]byte(`{"data":{"serverlessGpuPools":[{"id":"ADA_24","gpuTypeIds":["NVIDIA GeForce RTX 4090"]}]}}`))
+			case strings.Contains(body.Query, "dataCenters"):
+				_, _ = w.Write([]byte(`{"data":{"dataCenters":[
+					{"id":"EU-RO-1","gpuAvailability":[{"gpuTypeId":"NVIDIA GeForce RTX 4090","stockStatus":"High"}]},
+					{"id":"US-TX-3","gpuAvailability":[{"gpuTypeId":"NVIDIA GeForce RTX 4090","stockStatus":"Low"}]},
+					{"id":"EU-SE-1","gpuAvailability":[{"gpuTypeId":"NVIDIA A40","stockStatus":"High"}]}
+				]}}`))
+			case strings.Contains(body.Query, "saveEndpoint"):
+				var ids []string
+				for _, volume := range body.Variables.Input.NetworkVolumeIDs {
+					ids = append(ids, volume.NetworkVolumeID)
+				}
+				fake.saved = append(fake.saved, ids)
+				_, _ = w.Write([]byte(`{"data":{"saveEndpoint":{"id":"ep-1"}}}`))
+			default:
+				t.Errorf("unexpected graphql query %s", body.Query)
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestRunVolumesAttach_WarnsOnLowStock(t *testing.T) {
+	resetVolumesVars(t)
+	fake := &fakeVolumesAPI{attached: []string{"vol-ro"}}
+	startFakeVolumesAPI(t, fake)
+
+	stdout, stderr, err := runCaptured(t, runVolumesAttach, "ep-1", "vol-tx")
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if len(fake.saved) != 1 || strings.Join(fake.saved[0], ",") != "vol-ro,vol-tx" {
+		t.Fatalf("unexpected saved volumes %v", fake.saved)
+	}
+	if !strings.Contains(stderr, "ADA_24 gpus are low on stock in US-TX-3") {
+		t.Fatalf("expected a low stock warning, got %q", stderr)
+	}
+
+	var volumes []endpointVolume
+	if err := json.Unmarshal([]byte(stdout), &volumes); err != nil {
+		t.Fatalf("parse %q: %v", stdout, err)
+	}
+	if len(volumes) != 2 || volumes[1].DataCenterID != "US-TX-3" || volumes[1].GpuStock != "Low" || !volumes[1].InLocations {
+		t.Fatalf("unexpected volumes %+v", volumes)
+	}
+}
+
+func TestRunVolumesAttach_Validates(t *testing.T) {
+	cases := map[string]string{
+		"vol-ro-2": "EU-RO-1 already has volume vol-ro",
+		"vol-ca":   "CA-MTL-1 is not in the endpoint's locations",
+		"vol-none": "network volume vol-none not found",
+	}
+	for volumeID, want := range cases {
+		resetVolumesVars(t)
+		fake := &fakeVolumesAPI{attached: []string{"vol-ro"}}
+		startFakeVolumesAPI(t, fake)
+		_, _, err := runCaptured(t, runVolumesAttach, "ep-1", volumeID)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("attach %s: got %v, want %q", volumeID, err, want)
+		}
+		if len(fake.saved) != 0 {
+			t.Errorf("attach %s: expected no change, got %v", volumeID, fake.saved)
+		}
+	}
+}
+
+func TestRunVolumesAttach_ReplicateFrom(t *testing.T) {
+	resetVolumesVars(t)
+	fake := &fakeVolumesAPI{attached: []string{"vol-ro"}}
+	startFakeVolumesAPI(t, fake)
+	volumesReplicateFrom = "vol-ro"
+	volumesDataCenterIDs = "EU-SE-1"
+
+	_, stderr, err := runCaptured(t, runVolumesAttach, "ep-1")
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if len(fake.created) != 1 || fake.created[0]["name"] != "models-eu-se-1" || fake.created[0]["size"] != float64(100) || fake.created[0]["dataCenterId"] != "EU-SE-1" {
+		t.Fatalf("unexpected created volumes %v", fake.created)
+	}
+	if len(fake.saved) != 1 || strings.Join(fake.saved[0], ",") != "vol-ro,vol-new" {
+		t.Fatalf("unexpected saved volumes %v", fake.saved)
+	}
+	if !strings.Contains(stderr, "no ADA_24 gpus in stock in EU-SE-1") {
+		t.Fatalf("expected an out of stock warning, got %q", stderr)
+	}
+
+	// a replica into a datacenter that already has a volume is refused
+	// before anything is created
+	fake.created, fake.saved = nil, nil
+	volumesDataCenterIDs = "US-TX-3,EU-RO-1"
+	if _, _, err := runCaptured(t, runVolumesAttach, "ep-1"); err == nil || !strings.Contains(err.Error(), "EU-RO-1 already has volume vol-ro") {
+		t.Fatalf("expected a datacenter conflict, got %v", err)
+	}
+	if len(fake.created) != 0 {
+		t.Fatalf("expected no volumes created, got %v", fake.created)
+	}
+}
+
+func TestRunVolumesDetach(t *testing.T) {
+	resetVolumesVars(t)
+	fake := &fakeVolumesAPI{attached: []string{"vol-ro", "vol-tx"}}
+	startFakeVolumesAPI(t, fake)
+
+	if _, _, err := runCaptured(t, runVolumesDetach, "ep-1", "vol-ro"); err != nil {
+		t.Fatalf("detach: %v", err)
+	}
+	if len(fake.saved) != 1 || strings.Join(fake.saved[0], ",") != "vol-tx" {
+		t.Fatalf("unexpected saved volumes %v", fake.saved)
+	}
+
+	if _, _, err := runCaptured(t, runVolumesDetach, "ep-1", "vol-ca"); err == nil || !strings.Contains(err.Error(), "not attached") {
+		t.Fatalf("expected not attached error, got %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package serverless
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/statefile"
+
+	"github.com/spf13/cobra"
+)
+
+var warmCmd = &cobra.Command{
+	Use:   "warm <endpoint-id>",
+	Short: "keep workers warm for a while",
+	Long: `raise an endpoint's minimum workers for a while, then put it back.
+
+workersMin is raised to --workers and held for --for; when the time is up, or
+on ctrl-c, it is restored to its previous value. the change is recorded in
+~/.runpod/serverless-warm.json before it is made, so if runpodctl dies while
+holding workers warm, "serverless warm <endpoint-id> --restore" puts the
+endpoint back. workersMin is left alone if it was changed by someone else
+during the warm-up.`,
+	Example: `  runpodctl serverless warm <endpoint-id> --workers 3 --for 2h
+  runpodctl serverless warm <endpoint-id> --restore`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWarm,
+}
+
+var (
+	warmWorkers  int
+	warmDuration time.Duration
+	warmRestore  bool
+)
+
+func init() {
+	warmCmd.Flags().IntVar(&warmWorkers, "workers", 0, "minimum workers to hold")
+	warmCmd.Flags().DurationVar(&warmDuration, "for", 0, "how long to hold them (e.g. 30m, 2h)")
+	warmCmd.Flags().BoolVar(&warmRestore, "restore", false, "restore a recorded warm-up now, e.g. after a crash")
+}
+
+// warmRecord is a warm-up in progress, as recorded in the warm state file
+type warmRecord struct {
+	EndpointID         string    `json:"endpointId"`
+	PreviousWorkersMin int       `json:"previousWorkersMin"`
+	WorkersMin         int       `json:"workersMin"`
+	StartedAt          time.Time `json:"startedAt"`
+	Until              time.Time `json:"until"`
+}
+
+// warmResult is a finished warm-up as printed
+type warmResult struct {
+	EndpointID string    `json:"endpointId"`
+	WorkersMin int       `json:"workersMin"`
+	RestoredTo int       `json:"restoredTo"`
+	Restored   bool      `json:"restored"`
+	StartedAt  time.Time `json:"startedAt"`
+	Until      time.Time `json:"until"`
+}
+
+func runWarm(cmd *cobra.Command, args []string) error {
+	endpointID := args[0]
+	if warmRestore {
+		if warmWorkers != 0 || warmDuration != 0 {
+			return fmt.Errorf("--restore cannot be combined with --workers or --for")
+		}
+	} else if warmWorkers <= 0 || warmDuration <= 0 {
+		return fmt.Errorf("--workers and --for must be positive")
+	}
+
+	records, err := loadWarmRecords()
+	if err != nil {
+		return err
+	}
+	record, recorded := records[endpointID]
+	if warmRestore && !recorded {
+		return fmt.Errorf("no warm-up recorded for endpoint %s", endpointID)
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	if !warmRestore {
+		endpoint, err := client.GetEndpoint(endpointID, false, false)
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to get endpoint: %w", err)
+		}
+		if endpoint.WorkersMax > 0 && warmWorkers > endpoint.WorkersMax {
+			return fmt.Errorf("--workers %d is above the endpoint's workersMax %d", warmWorkers, endpoint.WorkersMax)
+		}
+
+		previous := endpoint.WorkersMin
+		if recorded {
+			// an earlier warm-up is still recorded: keep what it will
+			// restore rather than the value it raised
+			previous = record.PreviousWorkersMin
+			fmt.Fprintf(cmd.ErrOrStderr(), "note: taking over an earlier warm-up; workersMin will be restored to %d\n", previous)
+		} else if endpoint.WorkersMin >= warmWorkers {
+			return fmt.Errorf("endpoint %s already has workersMin %d", endpointID, endpoint.WorkersMin)
+		}
+
+		now := time.Now()
+		record = warmRecord{
+			EndpointID:         endpointID,
+			PreviousWorkersMin: previous,
+			WorkersMin:         warmWorkers,
+			StartedAt:          now,
+			Until:              now.Add(warmDuration),
+		}
+		records[endpointID] = record
+		if err := saveWarmRecords(records); err != nil {
+			return err
+		}
+		if err := setWorkersMin(client, endpointID, warmWorkers); err != nil {
+			if !recorded {
+				delete(records, endpointID)
+				_ = saveWarmRecords(records)
+			}
+			output.Error(err)
+			return fmt.Errorf("failed to raise workersMin: %w", err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "workersMin raised from %d to %d until %s; ctrl-c restores it early\n",
+			endpoint.WorkersMin, warmWorkers, record.Until.Format("15:04:05"))
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		timer := time.NewTimer(warmDuration)
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(cmd.ErrOrStderr(), "interrupted, restoring workersMin")
+		case <-timer.C:
+		}
+		timer.Stop()
+		stop()
+	}
+
+	result, err := restoreWarm(cmd, client, record)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+// restoreWarm puts workersMin back to its value before the warm-up, unless
+// it was changed since, and drops the record. a warm-up that was taken over
+// by a later one is left to that one.
+func restoreWarm(cmd *cobra.Command, client *api.Client, record warmRecord) (*warmResult, error) {
+	endpoint, err := client.GetEndpoint(record.EndpointID, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+
+	result := &warmResult{
+		EndpointID: record.EndpointID,
+		WorkersMin: record.WorkersMin,
+		RestoredTo: endpoint.WorkersMin,
+		StartedAt:  record.StartedAt,
+		Until:      record.Until,
+	}
+	records, err := loadWarmRecords()
+	if err != nil {
+		return nil, err
+	}
+	current, ok := records[record.EndpointID]
+	if !ok || !current.StartedAt.Equal(record.StartedAt) || !current.Until.Equal(record.Until) {
+		fmt.Fprintln(cmd.ErrOrStderr(), "note: the warm-up was taken over or restored elsewhere; leaving workersMin to it")
+		return result, nil
+	}
+
+	if endpoint.WorkersMin == record.WorkersMin {
+		if err := setWorkersMin(client, record.EndpointID, record.PreviousWorkersMin); err != nil {
+			return nil, fmt.Errorf("failed to restore workersMin to %d: %w", record.PreviousWorkersMin, err)
+		}
+		result.RestoredTo = record.PreviousWorkersMin
+		result.Restored = true
+	} else {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: workersMin was changed to %d during the warm-up; leaving it\n", endpoint.WorkersMin)
+	}
+
+	delete(records, record.EndpointID)
+	if err := saveWarmRecords(records); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setWorkersMin sets an endpoint's minimum workers. the rest patch drops a
+// zero workersMin, so lowering it to 0 goes through the full endpoint config.
+func setWorkersMin(client *api.Client, endpointID string, workersMin int) error {
+	if workersMin > 0 {
+		_, err := client.UpdateEndpoint(endpointID, &api.EndpointUpdateRequest{WorkersMin: workersMin})
+		return err
+	}
+	_, err := client.UpdateEndpointConfig(endpointID, func(config *api.EndpointConfig) {
+		config.WorkersMin = 0
+	})
+	return err
+}
+
+const warmStateFile = "serverless-warm.json"
+
+// loadWarmRecords reads the recorded warm-ups by endpoint id
+func loadWarmRecords() (map[string]warmRecord, error) {
+	records := map[string]warmRecord{}
+	if err := statefile.Load(warmStateFile, &records); err != nil {
+		return nil, fmt.Errorf("failed to read warm-up state: %w", err)
+	}
+	return records, nil
+}
+
+func saveWarmRecords(records map[string]warmRecord) error {
+	var err error
+	if len(records) == 0 {
+		err = statefile.Remove(warmStateFile)
+	} else {
+		err = statefile.Save(warmStateFile, records)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write warm-up state: %w", err)
+	}
+	return nil
+}
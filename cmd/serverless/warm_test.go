@@ -0,0 +1,224 @@
+package serverless
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/statefile"
+)
+
+func resetWarmVars(t *testing.T) {
+	t.Helper()
+	origWorkers, origDuration, origRestore := warmWorkers, warmDuration, warmRestore
+	t.Cleanup(func() { warmWorkers, warmDuration, warmRestore = origWorkers, origDuration, origRestore })
+	warmWorkers, warmDuration, warmRestore = 0, 0, false
+	t.Setenv("HOME", t.TempDir())
+}
+
+// fakeScalingAPI serves endpoints with worker limits and records rest
+// patches and graphql saves of them
+type fakeScalingAPI struct {
+	workers map[string][2]int
+	patched []int
+	saved   []map[string]int
+	// onPatch runs before a patch is applied
+	onPatch func()
+}
+
+func startFakeScalingAPI(t *testing.T, fake *fakeScalingAPI) {
+	t.Helper()
+	useFakeAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpointID := strings.TrimPrefix(r.URL.Path, "/endpoints/")
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/endpoints/"):
+			limits, ok := fake.workers[endpointID]
+			if !ok {
+				http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": endpointID, "workersMin": limits[0], "workersMax": limits[1]})
+		case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/endpoints/"):
+			if fake.onPatch != nil {
+				fake.onPatch()
+			}
+			var body map[string]int
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			fake.patched = append(fake.patched, body["workersMin"])
+			limits := fake.workers[endpointID]
+			limits[0] = body["workersMin"]
+			fake.workers[endpointID] = limits
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": endpointID})
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			var body struct {
+				Query     string `json:"query"`
+				Variables struct {
+					Input struct {
+						ID         string `json:"id"`
+						WorkersMin int    `json:"workersMin"`
+						WorkersMax int    `json:"workersMax"`
+					} `json:"input"`
+				} `json:"variables"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if !strings.Contains(body.Query, "saveEndpoint") {
+				t.Errorf("unexpected graphql query %s", body.Query)
+				return
+			}
+			input := body.Variables.Input
+			fake.saved = append(fake.saved, map[string]int{input.ID: input.WorkersMin, "max": input.WorkersMax})
+			fake.workers[input.ID] = [2]int{input.WorkersMin, input.WorkersMax}
+			_, _ = w.Write([]byte(`{"data":{"saveEndpoint":{"id":"` + input.ID + `"}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestRunWarm_RaisesAndRestores(t *testing.T) {
+	resetWarmVars(t)
+	fake := &fakeScalingAPI{workers: map[string][2]int{"ep-1": {0, 5}}}
+	fake.onPatch = func() {
+		// the change is recorded before it is made
+		records, err := loadWarmRecords()
+		if err != nil || records["ep-1"].PreviousWorkersMin != 0 || records["ep-1"].WorkersMin != 3 {
+			t.Errorf("expected a recorded warm-up before raising, got %+v, %v", records, err)
+		}
+	}
+	startFakeScalingAPI(t, fake)
+
+	warmWorkers, warmDuration = 3, 20*time.Millisecond
+	stdout, stderr, err := runCaptured(t, runWarm, "ep-1")
+	if err != nil {
+		t.Fatalf("warm: %v", err)
+	}
+	if len(fake.patched) != 1 || fake.patched[0] != 3 {
+		t.Fatalf("expected workersMin raised to 3, got %v", fake.patched)
+	}
+	// a zero workersMin can't be sent as a patch, so it is saved instead
+	if len(fake.saved) != 1 || fake.saved[0]["ep-1"] != 0 || fake.saved[0]["max"] != 5 {
+		t.Fatalf("expected workersMin restored to 0, got %v", fake.saved)
+	}
+	if !strings.Contains(stderr, "workersMin raised from 0 to 3") {
+		t.Fatalf("unexpected stderr %q", stderr)
+	}
+
+	var result warmResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse %q: %v", stdout, err)
+	}
+	if !result.Restored || result.RestoredTo != 0 || result.WorkersMin != 3 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	path, _ := statefile.Path(warmStateFile)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the warm-up record removed, got %v", err)
+	}
+}
+
+func TestRunWarm_TakenOver(t *testing.T) {
+	resetWarmVars(t)
+	fake := &fakeScalingAPI{workers: map[string][2]int{"ep-1": {0, 5}}}
+	later := warmRecord{EndpointID: "ep-1", PreviousWorkersMin: 0, WorkersMin: 3, StartedAt: time.Now(), Until: time.Now().Add(4 * time.Hour)}
+	fake.onPatch = func() {
+		// a second warm-up to the same workersMin takes this one over
+		if err := saveWarmRecords(map[string]warmRecord{"ep-1": later}); err != nil {
+			t.Error(err)
+		}
+	}
+	startFakeScalingAPI(t, fake)
+
+	warmWorkers, warmDuration = 3, 20*time.Millisecond
+	stdout, stderr, err := runCaptured(t, runWarm, "ep-1")
+	if err != nil {
+		t.Fatalf("warm: %v", err)
+	}
+	if len(fake.patched) != 1 || len(fake.saved) != 0 || fake.workers["ep-1"][0] != 3 {
+		t.Fatalf("expected workersMin left at 3 for the later warm-up, got %v %v", fake.patched, fake.saved)
+	}
+	if !strings.Contains(stderr, "taken over") {
+		t.Fatalf("unexpected stderr %q", stderr)
+	}
+	var result warmResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil || result.Restored {
+		t.Fatalf("unexpected result %q (%v)", stdout, err)
+	}
+
+	records, err := loadWarmRecords()
+	if err != nil || !records["ep-1"].Until.Equal(later.Until) {
+		t.Fatalf("expected the later warm-up still recorded, got %v, %v", records, err)
+	}
+}
+
+func TestRunWarm_RestoreAfterCrash(t *testing.T) {
+	resetWarmVars(t)
+	fake := &fakeScalingAPI{workers: map[string][2]int{"ep-1": {4, 5}, "ep-2": {2, 5}}}
+	startFakeScalingAPI(t, fake)
+	if err := saveWarmRecords(map[string]warmRecord{
+		"ep-1": {EndpointID: "ep-1", PreviousWorkersMin: 1, WorkersMin: 4},
+		"ep-2": {EndpointID: "ep-2", PreviousWorkersMin: 0, WorkersMin: 3},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	warmRestore = true
+	if _, _, err := runCaptured(t, runWarm, "ep-1"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if len(fake.patched) != 1 || fake.patched[0] != 1 {
+		t.Fatalf("expected workersMin restored to 1, got %v", fake.patched)
+	}
+
+	// ep-2 was changed by hand during the warm-up and is left alone
+	_, stderr, err := runCaptured(t, runWarm, "ep-2")
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if len(fake.patched) != 1 || len(fake.saved) != 0 {
+		t.Fatalf("expected no change to ep-2, got %v %v", fake.patched, fake.saved)
+	}
+	if !strings.Contains(stderr, "workersMin was changed to 2 during the warm-up") {
+		t.Fatalf("unexpected stderr %q", stderr)
+	}
+
+	records, err := loadWarmRecords()
+	if err != nil || len(records) != 0 {
+		t.Fatalf("expected no records left, got %v, %v", records, err)
+	}
+	if _, _, err := runCaptured(t, runWarm, "ep-1"); err == nil || !strings.Contains(err.Error(), "no warm-up recorded") {
+		t.Fatalf("expected no recorded warm-up, got %v", err)
+	}
+}
+
+func TestRunWarm_Validates(t *testing.T) {
+	resetWarmVars(t)
+	fake := &fakeScalingAPI{workers: map[string][2]int{"ep-1": {3, 5}}}
+	startFakeScalingAPI(t, fake)
+
+	cases := []struct {
+		workers int
+		for_    time.Duration
+		want    string
+	}{
+		{0, time.Hour, "must be positive"},
+		{6, time.Hour, "above the endpoint's workersMax 5"},
+		{2, time.Hour, "already has workersMin 3"},
+	}
+	for _, tc := range cases {
+		warmWorkers, warmDuration = tc.workers, tc.for_
+		if _, _, err := runCaptured(t, runWarm, "ep-1"); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("--workers %d: got %v, want %q", tc.workers, err, tc.want)
+		}
+	}
+	if len(fake.patched) != 0 {
+		t.Fatalf("expected no changes, got %v", fake.patched)
+	}
+	if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".runpod", "serverless-warm.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing recorded, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package serverless
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/sshconnect"
+
+	"github.com/spf13/cobra"
+)
+
+var workersCmd = &cobra.Command{
+	Use:   "workers <endpoint-id>",
+	Short: "list and debug endpoint workers",
+	Long: `list the workers of an endpoint, with status, gpu, datacenter, uptime,
+image and whether the worker is throttled.
+
+use the subcommands to inspect or remove a single worker, e.g. one that keeps
+failing jobs. a terminated worker is replaced by the autoscaler if needed.`,
+	Example: `  runpodctl serverless workers <endpoint-id>
+  runpodctl serverless workers logs <worker-id>
+  runpodctl serverless workers terminate <worker-id>`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkers,
+}
+
+var workersSSHCmd = &cobra.Command{
+	Use:   "ssh <worker-id>",
+	Short: "show ssh info for a worker",
+	Long:  "show ssh info for a worker (command + key). does not connect. the worker image must run an ssh server.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkersSSH,
+}
+
+var workersLogsCmd = &cobra.Command{
+	Use:   "logs <worker-id>",
+	Short: "show worker logs",
+	Long:  "show the recent container logs of a worker",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkersLogs,
+}
+
+var workersTerminateCmd = &cobra.Command{
+	Use:   "terminate <worker-id>",
+	Short: "terminate a worker",
+	Long:  "terminate a single worker. in-flight jobs on the worker fail and are retried according to the endpoint's policy.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWorkersTerminate,
+}
+
+var (
+	workersStatus     string
+	workersThrottled  bool
+	workersLogsTail   int
+	workersLogsSystem bool
+)
+
+func init() {
+	workersCmd.Flags().StringVar(&workersStatus, "status", "", "only show workers with this status (e.g. RUNNING, EXITED)")
+	workersCmd.Flags().BoolVar(&workersThrottled, "throttled", false, "only show throttled workers")
+	workersLogsCmd.Flags().IntVar(&workersLogsTail, "tail", 100, "number of lines to show (0 for all)")
+	workersLogsCmd.Flags().BoolVar(&workersLogsSystem, "system", false, "include system logs")
+
+	workersCmd.AddCommand(workersSSHCmd)
+	workersCmd.AddCommand(workersLogsCmd)
+	workersCmd.AddCommand(workersTerminateCmd)
+}
+
+func runWorkers(cmd *cobra.Command, args []string) error {
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	workers, err := client.ListWorkers(args[0])
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to list workers: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(filterWorkers(workers, workersStatus, workersThrottled), &output.Config{Format: format})
+}
+
+func filterWorkers(workers []api.Worker, status string, throttledOnly bool) []api.Worker {
+	filtered := make([]api.Worker, 0, len(workers))
+	for _, worker := range workers {
+		if status != "" && !strings.EqualFold(worker.Status, status) {
+			continue
+		}
+		if throttledOnly && !worker.Throttled {
+			continue
+		}
+		filtered = append(filtered, worker)
+	}
+	return filtered
+}
+
+func runWorkersSSH(cmd *cobra.Command, args []string) error {
+	workerID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	worker, err := client.GetWorkerPod(workerID)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get worker: %w", err)
+	}
+
+	gqlClient, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	keyInfo := sshconnect.ResolveKeyInfo(gqlClient)
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	conn := sshconnect.BuildConnection(worker, keyInfo)
+	if conn == nil {
+		return output.Print(map[string]interface{}{
+			"error":  "worker has no public ssh port",
+			"id":     worker.ID,
+			"status": worker.DesiredStatus,
+		}, &output.Config{Format: format})
+	}
+	return output.Print(conn, &output.Config{Format: format})
+}
+
+func runWorkersLogs(cmd *cobra.Command, args []string) error {
+	workerID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	logs, err := client.GetPodLogs(workerID)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get worker logs: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"id":        workerID,
+		"container": tailLines(logs.Container, workersLogsTail),
+	}
+	if workersLogsSystem {
+		result["system"] = tailLines(logs.System, workersLogsTail)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+func tailLines(lines []string, n int) []string {
+	if lines == nil {
+		return []string{}
+	}
+	if n <= 0 || len(lines) <= n {
+		return lines
+	}
+	return lines[len(lines)-n:]
+}
+
+func runWorkersTerminate(cmd *cobra.Command, args []string) error {
+	workerID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	if err := client.TerminateWorker(workerID); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to terminate worker: %w", err)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"terminated": true,
+		"id":         workerID,
+	}, &output.Config{Format: format})
+}
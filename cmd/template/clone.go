@@ -0,0 +1,57 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <template-id>",
+	Short: "create a copy of a template",
+	Long: `create a new template with the image, ports, port labels, env, disks,
+readme, start command and registry auth of an existing one.`,
+	Example: `  runpodctl template clone <template-id> --name my-template-v2`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runClone,
+}
+
+var cloneName string
+
+func init() {
+	cloneCmd.Flags().StringVar(&cloneName, "name", "", "name of the new template (required)")
+
+	cloneCmd.MarkFlagRequired("name") //nolint:errcheck
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	source, err := client.GetTemplate(args[0])
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+
+	spec := specFromTemplate(source)
+	spec.Name = cloneName
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	template, err := createTemplate(client, spec.createRequest(source.ContainerRegistryAuthID), spec.PortLabels)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	templatehistory.Record(cmd.ErrOrStderr(), "clone", template.ID, nil)
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(template, &output.Config{Format: format})
+}
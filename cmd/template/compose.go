@@ -0,0 +1,539 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/runpod/runpodctl/internal/envvars"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeFile is the part of a docker compose file from-compose reads
+type composeFile struct {
+	Name     string                     `yaml:"name"`
+	Services map[string]*composeService `yaml:"services"`
+}
+
+// composeService keeps the fields that need more than one syntax as yaml
+// nodes; every other key lands in Other and is reported
+type composeService struct {
+	Image       string               `yaml:"image"`
+	Command     yaml.Node            `yaml:"command"`
+	Entrypoint  yaml.Node            `yaml:"entrypoint"`
+	Environment yaml.Node            `yaml:"environment"`
+	EnvFile     yaml.Node            `yaml:"env_file"`
+	Ports       []yaml.Node          `yaml:"ports"`
+	Volumes     []yaml.Node          `yaml:"volumes"`
+	Other       map[string]yaml.Node `yaml:",inline"`
+}
+
+// composeUnsupported explains the service keys a template can't represent
+var composeUnsupported = map[string]string{
+	"build":        "runpod pulls images and can't build them; push the image and set image",
+	"depends_on":   "a pod runs one container; create the other services separately",
+	"links":        "a pod runs one container; create the other services separately",
+	"healthcheck":  "runpod doesn't run container healthchecks",
+	"deploy":       "gpus and replicas are chosen when the pod is created",
+	"gpus":         "gpus are chosen when the pod is created",
+	"networks":     "each pod has its own network; reach other pods through their proxy urls",
+	"network_mode": "each pod has its own network; reach other pods through their proxy urls",
+	"extra_hosts":  "each pod has its own network; reach other pods through their proxy urls",
+	"secrets":      "set an env var to {{ RUNPOD_SECRET_<name> }} to use a runpod secret",
+	"configs":      "bake config files into the image or put them on the volume",
+	"working_dir":  "set WORKDIR in the image",
+	"user":         "set USER in the image",
+	"restart":      "runpod restarts the container itself",
+	"expose":       "only ports are exposed; add the port to ports to reach it",
+}
+
+// composeIgnored are service keys that mean nothing on runpod and aren't
+// worth a note
+var composeIgnored = map[string]bool{
+	"container_name": true,
+	"profiles":       true,
+	"stdin_open":     true,
+	"tty":            true,
+}
+
+// composeConversion is one compose service as a template, with everything
+// that was left out or changed on the way
+type composeConversion struct {
+	Service string
+	Spec    *templateSpec
+	Notes   []string
+}
+
+// composeConverter converts a service of the compose file in dir
+type composeConverter struct {
+	dir     string
+	service string
+	notes   []string
+}
+
+func (c *composeConverter) note(format string, args ...interface{}) {
+	c.notes = append(c.notes, fmt.Sprintf("service %s: ", c.service)+fmt.Sprintf(format, args...))
+}
+
+// loadCompose reads a compose file, filling in ${VAR} references from the
+// shell and a .env file next to it the way docker compose does
+func loadCompose(path string) (*composeFile, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	dotenv := map[string]string{}
+	if env, err := envvars.ParseFile(filepath.Join(filepath.Dir(path), ".env")); err == nil {
+		dotenv = env
+	}
+	lookup := func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := dotenv[name]
+		return value, ok
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	var (
+		notes    []string
+		required error
+		unset    = map[string]bool{}
+	)
+	expand := func(ref string) string {
+		value, set, err := interpolate(ref, lookup)
+		if err != nil && required == nil {
+			required = err
+		}
+		if !set && !unset[ref] {
+			unset[ref] = true
+			notes = append(notes, fmt.Sprintf("%s is not set; using an empty string", ref))
+		}
+		return value
+	}
+	// like docker compose, interpolate values after parsing, so a value
+	// can't change the structure of the file
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		for i, child := range node.Content {
+			isKey := node.Kind == yaml.MappingNode && i%2 == 0
+			if child.Kind == yaml.ScalarNode && !isKey {
+				child.Value = os.Expand(child.Value, expand)
+			}
+			walk(child)
+		}
+	}
+	walk(&root)
+	if required != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, required)
+	}
+
+	compose := &composeFile{}
+	if err := root.Decode(compose); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(compose.Services) == 0 {
+		return nil, nil, fmt.Errorf("%s has no services", path)
+	}
+	return compose, notes, nil
+}
+
+// interpolate resolves the inside of one ${...} reference: a name, or a
+// name with a :-, -, :? or ? modifier. set is false for a plain name that
+// has no value. "$$" is an escaped "$", and $1 style references are kept,
+// as compose does not expand them.
+func interpolate(ref string, lookup func(string) (string, bool)) (value string, set bool, err error) {
+	if ref == "$" {
+		return "$", true, nil
+	}
+	if ref == "" || !(ref[0] == '_' || unicode.IsLetter(rune(ref[0]))) {
+		return "$" + ref, true, nil
+	}
+	for _, op := range []string{":-", ":?", "-", "?"} {
+		name, arg, ok := strings.Cut(ref, op)
+		if !ok {
+			continue
+		}
+		value, set := lookup(name)
+		empty := !set || (strings.HasPrefix(op, ":") && value == "")
+		switch {
+		case !empty:
+			return value, true, nil
+		case strings.HasSuffix(op, "-"):
+			return arg, true, nil
+		default:
+			return "", true, fmt.Errorf("%s is required: %s", name, arg)
+		}
+	}
+	value, set = lookup(ref)
+	return value, set, nil
+}
+
+// selectComposeService picks the named service, or the only one
+func selectComposeService(compose *composeFile, name string) (string, *composeService, error) {
+	names := make([]string, 0, len(compose.Services))
+	for service := range compose.Services {
+		names = append(names, service)
+	}
+	sort.Strings(names)
+	if name == "" {
+		if len(names) > 1 {
+			return "", nil, fmt.Errorf("the compose file has %d services (%s); pick one with --service", len(names), strings.Join(names, ", "))
+		}
+		name = names[0]
+	}
+	service, ok := compose.Services[name]
+	if !ok || service == nil {
+		return "", nil, fmt.Errorf("no service %s in the compose file (services: %s)", name, strings.Join(names, ", "))
+	}
+	return name, service, nil
+}
+
+// convertComposeService maps a compose service onto a template spec
+func convertComposeService(dir, name string, service *composeService) (*composeConversion, error) {
+	c := &composeConverter{dir: dir, service: name}
+	if strings.TrimSpace(service.Image) == "" {
+		if _, ok := service.Other["build"]; ok {
+			return nil, fmt.Errorf("service %s has no image; runpod pulls images and can't build them, so push the image and set image", name)
+		}
+		return nil, fmt.Errorf("service %s has no image", name)
+	}
+
+	spec := &templateSpec{Name: name, ImageName: service.Image}
+	var err error
+	if spec.DockerEntrypoint, err = commandArgs(&service.Entrypoint); err != nil {
+		return nil, fmt.Errorf("service %s: entrypoint: %w", name, err)
+	}
+	if spec.DockerStartCmd, err = commandArgs(&service.Command); err != nil {
+		return nil, fmt.Errorf("service %s: command: %w", name, err)
+	}
+	if spec.Env, err = c.environment(service); err != nil {
+		return nil, err
+	}
+	if spec.Ports, err = c.ports(service.Ports); err != nil {
+		return nil, err
+	}
+	if spec.VolumeMountPath, err = c.volume(service.Volumes); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(service.Other))
+	for key := range service.Other {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if composeIgnored[key] || strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if reason, ok := composeUnsupported[key]; ok {
+			c.note("%s isn't supported; %s", key, reason)
+		} else {
+			c.note("%s isn't supported", key)
+		}
+	}
+	return &composeConversion{Service: name, Spec: spec, Notes: c.notes}, nil
+}
+
+// commandArgs reads a command or entrypoint, which compose allows as a list
+// or as a string split like a shell would
+func commandArgs(node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return nil, nil
+		}
+		return splitCommand(node.Value)
+	case yaml.SequenceNode:
+		var args []string
+		if err := node.Decode(&args); err != nil {
+			return nil, err
+		}
+		return args, nil
+	}
+	return nil, fmt.Errorf("expected a string or a list")
+}
+
+// splitCommand splits a command string into words, honouring quotes and
+// backslash escapes but not expanding anything
+func splitCommand(s string) ([]string, error) {
+	var (
+		args  []string
+		word  strings.Builder
+		quote rune
+		inArg bool
+	)
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == '\'':
+			word.WriteRune(r)
+		case r == '\\' && i+1 < len(runes) && (quote == 0 || strings.ContainsRune(`"\$`+"`", runes[i+1])):
+			i++
+			word.WriteRune(runes[i])
+			inArg = true
+		case quote == '"':
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, word.String())
+				word.Reset()
+				inArg = false
+			}
+		default:
+			word.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if inArg {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// environment merges env_file with environment, which wins. a variable
+// without a value takes the shell's, like docker compose.
+func (c *composeConverter) environment(service *composeService) (map[string]string, error) {
+	env := map[string]string{}
+	files, err := c.envFiles(&service.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		values, err := envvars.ParseFile(file.path)
+		if errors.Is(err, os.ErrNotExist) && !file.required {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("service %s: env_file %s: %w", c.service, file.path, err)
+		}
+		for key, value := range values {
+			env[key] = value
+		}
+	}
+
+	setFromShell := func(key string) {
+		if value, ok := os.LookupEnv(key); ok {
+			env[key] = value
+			return
+		}
+		delete(env, key)
+		c.note("environment %s has no value here or in your shell; left out", key)
+	}
+	switch service.Environment.Kind {
+	case 0:
+	case yaml.MappingNode:
+		content := service.Environment.Content
+		for i := 0; i+1 < len(content); i += 2 {
+			key, value := content[i].Value, content[i+1]
+			if value.Tag == "!!null" {
+				setFromShell(key)
+				continue
+			}
+			env[key] = value.Value
+		}
+	case yaml.SequenceNode:
+		for _, item := range service.Environment.Content {
+			key, value, ok := strings.Cut(item.Value, "=")
+			if !ok {
+				setFromShell(key)
+				continue
+			}
+			env[key] = value
+		}
+	default:
+		return nil, fmt.Errorf("service %s: environment must be a list or a mapping", c.service)
+	}
+	if len(env) == 0 {
+		return nil, nil
+	}
+	return env, nil
+}
+
+type composeEnvFile struct {
+	path     string
+	required bool
+}
+
+// envFiles reads env_file: a path, or a list of paths and {path, required}
+// entries. paths are relative to the compose file.
+func (c *composeConverter) envFiles(node *yaml.Node) ([]composeEnvFile, error) {
+	var items []*yaml.Node
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.ScalarNode:
+		items = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		items = node.Content
+	default:
+		return nil, fmt.Errorf("service %s: env_file must be a path or a list", c.service)
+	}
+
+	var files []composeEnvFile
+	for _, item := range items {
+		file := composeEnvFile{required: true}
+		if item.Kind == yaml.MappingNode {
+			var entry struct {
+				Path     string `yaml:"path"`
+				Required *bool  `yaml:"required"`
+			}
+			if err := item.Decode(&entry); err != nil {
+				return nil, fmt.Errorf("service %s: env_file: %w", c.service, err)
+			}
+			file.path = entry.Path
+			if entry.Required != nil {
+				file.required = *entry.Required
+			}
+		} else {
+			file.path = item.Value
+		}
+		if !filepath.IsAbs(file.path) {
+			file.path = filepath.Join(c.dir, file.path)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// tcpPorts are the well-known ports of protocols that aren't http, which
+// runpod's http proxy can't carry
+var tcpPorts = map[int]bool{
+	21: true, 22: true, 25: true, 1883: true, 3306: true, 5432: true,
+	5672: true, 6379: true, 9042: true, 11211: true, 27017: true,
+}
+
+// ports maps published ports onto the container ports runpod exposes: ssh,
+// the other well-known tcp protocols and udp as <port>/tcp, everything else
+// as <port>/http
+func (c *composeConverter) ports(nodes []yaml.Node) ([]string, error) {
+	var ports []string
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		var published, target, protocol string
+		if node.Kind == yaml.MappingNode {
+			var entry struct {
+				Target    string `yaml:"target"`
+				Published string `yaml:"published"`
+				Protocol  string `yaml:"protocol"`
+			}
+			if err := node.Decode(&entry); err != nil {
+				return nil, fmt.Errorf("service %s: ports: %w", c.service, err)
+			}
+			published, target, protocol = entry.Published, entry.Target, entry.Protocol
+		} else {
+			spec := node.Value
+			spec, protocol, _ = strings.Cut(spec, "/")
+			parts := strings.Split(spec, ":")
+			target = parts[len(parts)-1]
+			if len(parts) > 1 {
+				published = parts[len(parts)-2]
+			}
+		}
+		switch protocol {
+		case "", "tcp":
+		case "udp":
+			c.note("port %s/udp is exposed as %s/tcp; runpod doesn't forward udp", target, target)
+		default:
+			c.note("port %s/%s isn't supported; runpod only exposes tcp ports", target, protocol)
+			continue
+		}
+
+		first, last, err := portRange(target)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: ports: %w", c.service, err)
+		}
+		if published != "" && published != target {
+			c.note("port %s:%s is exposed as %s; runpod proxies container ports, not published ones", published, target, target)
+		}
+		for port := first; port <= last; port++ {
+			mapped := strconv.Itoa(port) + "/http"
+			if protocol == "udp" || tcpPorts[port] {
+				mapped = strconv.Itoa(port) + "/tcp"
+			}
+			if !seen[mapped] {
+				seen[mapped] = true
+				ports = append(ports, mapped)
+			}
+		}
+	}
+	return ports, nil
+}
+
+// portRange parses a container port or a start-end range of them
+func portRange(s string) (int, int, error) {
+	start, end, isRange := strings.Cut(s, "-")
+	first, err := strconv.Atoi(start)
+	if err != nil || first < 1 || first > 65535 {
+		return 0, 0, fmt.Errorf("invalid port %q", s)
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, err := strconv.Atoi(end)
+	if err != nil || last < first || last > 65535 {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	return first, last, nil
+}
+
+// volume returns the container path of the first volume, which becomes the
+// pod volume. a pod has a single volume, so the rest are reported.
+func (c *composeConverter) volume(nodes []yaml.Node) (string, error) {
+	var mountPath string
+	for _, node := range nodes {
+		var source, target, kind string
+		if node.Kind == yaml.MappingNode {
+			var entry struct {
+				Type   string `yaml:"type"`
+				Source string `yaml:"source"`
+				Target string `yaml:"target"`
+			}
+			if err := node.Decode(&entry); err != nil {
+				return "", fmt.Errorf("service %s: volumes: %w", c.service, err)
+			}
+			source, target, kind = entry.Source, entry.Target, entry.Type
+		} else {
+			parts := strings.Split(node.Value, ":")
+			target = parts[0]
+			if len(parts) > 1 {
+				source, target = parts[0], parts[1]
+			}
+		}
+		if target == "" {
+			return "", fmt.Errorf("service %s: volume %q has no container path", c.service, node.Value)
+		}
+		if kind == "tmpfs" {
+			c.note("tmpfs %s isn't supported; the path is part of the container disk", target)
+			continue
+		}
+		if mountPath != "" {
+			c.note("volume %s isn't mounted; a pod has a single volume, at %s", target, mountPath)
+			continue
+		}
+		mountPath = target
+		if kind == "bind" || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~") {
+			c.note("host path %s isn't copied; %s starts out as an empty volume", source, target)
+		}
+	}
+	return mountPath, nil
+}
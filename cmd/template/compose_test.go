@@ -0,0 +1,221 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testCompose = `name: shop
+services:
+  api:
+    image: ${REGISTRY:-ghcr.io/team}/api:${TAG}
+    entrypoint: ["/bin/sh", "-c"]
+    command: uvicorn app:main --host 0.0.0.0 --root-path '/api v1'
+    env_file:
+      - .env.api
+      - path: missing.env
+        required: false
+    environment:
+      - MODE=prod
+      - FROM_SHELL
+      - PRICE=$$5
+    ports:
+      - "8080:8000"
+      - 9000-9001
+      - "5353/udp"
+      - "2222:22"
+      - target: 7860
+    volumes:
+      - models:/models
+      - ./cache:/cache
+    depends_on: [db]
+    healthcheck:
+      test: ["CMD", "curl", "localhost:8000"]
+    container_name: shop-api
+  db:
+    image: postgres:16
+`
+
+func writeCompose(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "docker-compose.yml")
+}
+
+func resetFromComposeVars(t *testing.T) {
+	t.Helper()
+	service, name, apply, disk, volume := fromComposeService, fromComposeName, fromComposeApply, fromComposeContainerDiskInGb, fromComposeVolumeInGb
+	authID, skip, compute, gpu, gpus := fromComposeRegistryAuthID, fromComposeSkipImageCheck, fromComposeComputeType, fromComposeGpuTypeID, fromComposeGpuCount
+	cloudType := fromComposeCloudType
+	t.Cleanup(func() {
+		fromComposeService, fromComposeName, fromComposeApply, fromComposeContainerDiskInGb, fromComposeVolumeInGb = service, name, apply, disk, volume
+		fromComposeRegistryAuthID, fromComposeSkipImageCheck, fromComposeComputeType, fromComposeGpuTypeID, fromComposeGpuCount = authID, skip, compute, gpu, gpus
+		fromComposeCloudType = cloudType
+	})
+}
+
+func TestConvertComposeFile(t *testing.T) {
+	resetFromComposeVars(t)
+	t.Setenv("FROM_SHELL", "yes")
+	path := writeCompose(t, map[string]string{
+		"docker-compose.yml": testCompose,
+		".env":               "TAG=v2\n",
+		".env.api":           "MODE=dev\nSECRET={{ RUNPOD_SECRET_api }}\n",
+	})
+
+	conversion, err := convertComposeFile(path, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := conversion.Spec
+	if spec.Name != "shop-api" || spec.ImageName != "ghcr.io/team/api:v2" {
+		t.Fatalf("unexpected name or image %q %q", spec.Name, spec.ImageName)
+	}
+	if want := []string{"/bin/sh", "-c"}; !reflect.DeepEqual(spec.DockerEntrypoint, want) {
+		t.Errorf("entrypoint = %q, want %q", spec.DockerEntrypoint, want)
+	}
+	if want := []string{"uvicorn", "app:main", "--host", "0.0.0.0", "--root-path", "/api v1"}; !reflect.DeepEqual(spec.DockerStartCmd, want) {
+		t.Errorf("command = %q, want %q", spec.DockerStartCmd, want)
+	}
+	wantEnv := map[string]string{"MODE": "prod", "FROM_SHELL": "yes", "PRICE": "$5", "SECRET": "{{ RUNPOD_SECRET_api }}"}
+	if !reflect.DeepEqual(spec.Env, wantEnv) {
+		t.Errorf("env = %v, want %v", spec.Env, wantEnv)
+	}
+	if want := []string{"8000/http", "9000/http", "9001/http", "5353/tcp", "22/tcp", "7860/http"}; !reflect.DeepEqual(spec.Ports, want) {
+		t.Errorf("ports = %q, want %q", spec.Ports, want)
+	}
+	if spec.VolumeMountPath != "/models" || spec.VolumeInGb != 20 || spec.ContainerDiskInGb != 20 {
+		t.Errorf("unexpected disks %+v", spec)
+	}
+
+	notes := strings.Join(conversion.Notes, "\n")
+	for _, want := range []string{
+		"port 8080:8000 is exposed as 8000",
+		"port 5353/udp is exposed as 5353/tcp",
+		"volume /cache isn't mounted",
+		"depends_on isn't supported",
+		"healthcheck isn't supported",
+	} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("notes are missing %q:\n%s", want, notes)
+		}
+	}
+	if strings.Contains(notes, "container_name") || strings.Contains(notes, "is not set") {
+		t.Errorf("unexpected notes:\n%s", notes)
+	}
+}
+
+func TestConvertComposeFile_Errors(t *testing.T) {
+	resetFromComposeVars(t)
+	path := writeCompose(t, map[string]string{"docker-compose.yml": testCompose})
+	if _, err := convertComposeFile(path, ""); err == nil || !strings.Contains(err.Error(), "api, db") {
+		t.Errorf("expected a pick-a-service error, got %v", err)
+	}
+	if _, err := convertComposeFile(path, "web"); err == nil {
+		t.Error("expected an unknown service error")
+	}
+
+	path = writeCompose(t, map[string]string{"docker-compose.yml": "services:\n  app:\n    build: .\n"})
+	if _, err := convertComposeFile(path, ""); err == nil || !strings.Contains(err.Error(), "can't build") {
+		t.Errorf("expected a build error, got %v", err)
+	}
+
+	path = writeCompose(t, map[string]string{"docker-compose.yml": "services:\n  app:\n    image: app:${TAG:?set a tag}\n"})
+	if _, err := convertComposeFile(path, ""); err == nil || !strings.Contains(err.Error(), "set a tag") {
+		t.Errorf("expected a required variable error, got %v", err)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for input, want := range map[string][]string{
+		`python main.py --listen`:       {"python", "main.py", "--listen"},
+		`sh -c "echo $HOME && sleep 1"`: {"sh", "-c", "echo $HOME && sleep 1"},
+		`echo 'a "b"' c\ d ""`:          {"echo", `a "b"`, "c d", ""},
+		`  `:                            nil,
+	} {
+		got, err := splitCommand(input)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("splitCommand(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := splitCommand(`echo "oops`); err == nil {
+		t.Error("expected an unterminated quote error")
+	}
+}
+
+func TestRunFromCompose(t *testing.T) {
+	resetFromComposeVars(t)
+	resetTemplateSpecVars(t)
+	fake := startTemplateAPI(t, nil)
+	path := writeCompose(t, map[string]string{"docker-compose.yml": testCompose, ".env": "TAG=v2\n", ".env.api": "MODE=dev\n"})
+	fromComposeService = "api"
+	fromComposeSkipImageCheck = true
+
+	// the printed template is what template import reads
+	printed, err := runTemplateCmd(t, runFromCompose, path)
+	if err != nil {
+		t.Fatalf("from-compose: %v", err)
+	}
+	importFile = filepath.Join(t.TempDir(), "tpl.yaml")
+	if err := os.WriteFile(importFile, []byte(printed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if specs, err := loadTemplateSpecs(importFile); err != nil || specs[0].Name != "shop-api" {
+		t.Fatalf("printed template doesn't load: %v\n%s", err, printed)
+	}
+	if len(fake.created) != 0 {
+		t.Fatal("expected nothing to be created without --apply")
+	}
+
+	fromComposeApply = "template"
+	fromComposeName = "api-v2"
+	if _, err := runTemplateCmd(t, runFromCompose, path); err != nil {
+		t.Fatalf("apply template: %v", err)
+	}
+	if len(fake.created) != 1 || fake.created[0]["name"] != "api-v2" || fake.created[0]["volumeMountPath"] != "/models" {
+		t.Fatalf("unexpected template creates %v", fake.created)
+	}
+
+	fromComposeApply = "pod"
+	fromComposeGpuTypeID = "NVIDIA GeForce RTX 4090"
+	stdout, err := runTemplateCmd(t, runFromCompose, path)
+	if err != nil {
+		t.Fatalf("apply pod: %v", err)
+	}
+	if len(fake.pods) != 1 || !strings.Contains(stdout, "pod-1") {
+		t.Fatalf("unexpected pod creates %v\n%s", fake.pods, stdout)
+	}
+	pod := fake.pods[0]
+	var dockerArgs struct{ Cmd, Entrypoint []string }
+	if err := json.Unmarshal([]byte(pod["dockerArgs"].(string)), &dockerArgs); err != nil || dockerArgs.Entrypoint[0] != "/bin/sh" || dockerArgs.Cmd[0] != "uvicorn" {
+		t.Fatalf("unexpected dockerArgs %v (%v)", pod["dockerArgs"], err)
+	}
+	if pod["ports"] != "8000/http,9000/http,9001/http,5353/tcp,22/tcp,7860/http" || pod["gpuTypeId"] != "NVIDIA GeForce RTX 4090" || pod["cloudType"] != "SECURE" || pod["startSsh"] != true {
+		t.Fatalf("unexpected pod input %v", pod)
+	}
+	if env, _ := json.Marshal(pod["env"]); string(env) != `[{"key":"MODE","value":"prod"},{"key":"PRICE","value":"$5"}]` {
+		t.Fatalf("expected env sorted by key, got %s", env)
+	}
+
+	fromComposeCloudType = "community"
+	if _, err := runTemplateCmd(t, runFromCompose, path); err != nil {
+		t.Fatalf("apply community pod: %v", err)
+	}
+	if len(fake.pods) != 2 || fake.pods[1]["cloudType"] != "COMMUNITY" {
+		t.Fatalf("expected --cloud-type to be passed on, got %v", fake.pods)
+	}
+
+	fromComposeComputeType = "CPU"
+	if _, err := runTemplateCmd(t, runFromCompose, path); err == nil || !strings.Contains(err.Error(), "--gpu-id") {
+		t.Fatalf("expected a cpu/gpu flag error, got %v", err)
+	}
+}
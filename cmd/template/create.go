@@ -1,12 +1,16 @@
 package template
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
 	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/registry"
+	"github.com/runpod/runpodctl/internal/templatehistory"
 
 	"github.com/spf13/cobra"
 )
@@ -14,7 +18,12 @@ import (
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "create a new template",
-	Long:  "create a new template",
+	Long: `create a new template.
+
+the image is checked against its registry first: it must exist for
+linux/amd64, and its compressed size is compared with the container disk.
+private images are checked with your local docker credentials when
+--registry-auth-id is set. use --skip-image-check to skip this.`,
 	Example: `  runpodctl template create --name private-gpu --image registry.example.com/team/image:tag --registry-auth-id <registry-auth-id>
   runpodctl template create --name dev --image example/image:tag --ports "22/tcp,8888/http" --port-labels "22=ssh,8888=jupyter lab"`,
 	Args: cobra.NoArgs,
@@ -30,11 +39,15 @@ var (
 	createDockerEntrypoint  string
 	createDockerStartCmd    string
 	createEnv               string
+	createEnvFile           string
+	createEnvSecrets        []string
 	createContainerDiskInGb int
 	createVolumeInGb        int
 	createVolumeMountPath   string
 	createReadme            string
 	createRegistryAuthID    string
+	createSkipImageCheck    bool
+	createStrict            bool
 )
 
 func init() {
@@ -46,11 +59,16 @@ func init() {
 	createCmd.Flags().StringVar(&createDockerEntrypoint, "docker-entrypoint", "", "comma-separated docker entrypoint commands")
 	createCmd.Flags().StringVar(&createDockerStartCmd, "docker-start-cmd", "", "comma-separated docker start commands")
 	createCmd.Flags().StringVar(&createEnv, "env", "", "environment variables as json object")
+	createCmd.Flags().StringVar(&createEnvFile, "env-file", "", "read environment variables from a dotenv file (--env takes precedence)")
+	createCmd.Flags().StringArrayVar(&createEnvSecrets, "env-secret", nil, "set an env var to a runpod secret as KEY=secretName (repeatable)")
 	createCmd.Flags().IntVar(&createContainerDiskInGb, "container-disk-in-gb", 20, "container disk size in gb")
 	createCmd.Flags().IntVar(&createVolumeInGb, "volume-in-gb", 0, "volume size in gb")
 	createCmd.Flags().StringVar(&createVolumeMountPath, "volume-mount-path", "/workspace", "volume mount path")
 	createCmd.Flags().StringVar(&createReadme, "readme", "", "readme content")
 	createCmd.Flags().StringVar(&createRegistryAuthID, "registry-auth-id", "", "container registry auth id (from 'runpodctl registry list')")
+	createCmd.Flags().BoolVar(&createSkipImageCheck, "skip-image-check", false, "don't check the image against its registry before creating the template")
+
+	createCmd.Flags().BoolVar(&createStrict, "strict", false, "run the template lint checks first, and refuse errors and warnings")
 
 	createCmd.MarkFlagRequired("name")  //nolint:errcheck
 	createCmd.MarkFlagRequired("image") //nolint:errcheck
@@ -72,12 +90,6 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	client, err := api.NewClient()
-	if err != nil {
-		output.Error(err)
-		return err
-	}
-
 	req := &api.TemplateCreateRequest{
 		Name:                    createName,
 		ImageName:               createImageName,
@@ -102,18 +114,64 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	if createDockerStartCmd != "" {
 		req.DockerStartCmd = strings.Split(createDockerStartCmd, ",")
 	}
-	if createEnv != "" {
+	if createEnv != "" || createEnvFile != "" || len(createEnvSecrets) > 0 {
+		var err error
 		var env map[string]string
-		if err := json.Unmarshal([]byte(createEnv), &env); err != nil {
-			return fmt.Errorf("invalid env json: %w", err)
+		if createEnv != "" {
+			if err := json.Unmarshal([]byte(createEnv), &env); err != nil {
+				return fmt.Errorf("invalid env json: %w", err)
+			}
+		}
+		env, err = envvars.Load(createEnvFile, env)
+		if err != nil {
+			return err
+		}
+		req.Env, err = envvars.WithSecretRefs(env, createEnvSecrets)
+		if err != nil {
+			return err
 		}
-		req.Env = env
 	}
 
-	template, err := client.CreateTemplate(req)
+	if createStrict {
+		if err := lintStrict(cmd.ErrOrStderr(), specFromCreateRequest(req, portLabels)); err != nil {
+			output.Error(err)
+			return err
+		}
+	}
+
+	if !createSkipImageCheck {
+		_, err := registry.Preflight(context.Background(), cmd.ErrOrStderr(), createImageName, registry.PreflightOptions{
+			RegistryAuthID:    strings.TrimSpace(createRegistryAuthID),
+			ContainerDiskInGb: createContainerDiskInGb,
+		})
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	template, err := createTemplate(client, req, portLabels)
 	if err != nil {
 		output.Error(err)
-		return fmt.Errorf("failed to create template: %w", err)
+		return err
+	}
+	templatehistory.Record(cmd.ErrOrStderr(), "create", template.ID, nil)
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(template, &output.Config{Format: format})
+}
+
+// createTemplate creates a template and sets its port labels
+func createTemplate(client *api.Client, req *api.TemplateCreateRequest, portLabels []api.TemplatePortConfig) (*api.Template, error) {
+	template, err := client.CreateTemplate(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
 	}
 
 	// Port labels (portsConfig) are not part of the REST template schema, so
@@ -126,21 +184,16 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 		if labelErr != nil {
 			if cleanupErr := client.DeleteTemplate(template.ID); cleanupErr != nil {
-				labelErr = fmt.Errorf("failed to set port labels: %v; failed to clean up template %s: %w", labelErr, template.ID, cleanupErr)
-			} else {
-				labelErr = fmt.Errorf("failed to set port labels: %w", labelErr)
+				return nil, fmt.Errorf("failed to set port labels: %v; failed to clean up template %s: %w", labelErr, template.ID, cleanupErr)
 			}
-			output.Error(labelErr)
-			return labelErr
+			return nil, fmt.Errorf("failed to set port labels: %w", labelErr)
 		}
 		template.PortsConfig = portLabels
 	}
 	if req.ContainerRegistryAuthID != "" {
 		template.ContainerRegistryAuthID = req.ContainerRegistryAuthID
 	}
-
-	format := output.ParseFormat(cmd.Flag("output").Value.String())
-	return output.Print(template, &output.Config{Format: format})
+	return template, nil
 }
 
 func createPortLabelOverrides(req *api.TemplateCreateRequest) *api.TemplatePortLabelOverrides {
@@ -160,31 +213,8 @@ func createPortLabelOverrides(req *api.TemplateCreateRequest) *api.TemplatePortL
 		overrides.VolumeInGb = &req.VolumeInGb
 		overrides.VolumeMountPath = &req.VolumeMountPath
 	}
-	if dockerArgs := dockerArgsJSON(req.DockerEntrypoint, req.DockerStartCmd); dockerArgs != "" {
+	if dockerArgs := api.DockerArgsJSON(req.DockerEntrypoint, req.DockerStartCmd); dockerArgs != "" {
 		overrides.DockerArgs = &dockerArgs
 	}
 	return overrides
 }
-
-// dockerArgsJSON reconstructs the backend's canonical dockerArgs encoding
-// (`{"cmd":[...],"entrypoint":[...]}`) from the REST create fields. The port-label
-// write reads the template back over GraphQL and re-sends dockerArgs; if that
-// post-create read is briefly stale it returns an empty dockerArgs and the write
-// would blank the just-set start command. Carrying the reconstructed value through
-// the overrides makes the write re-assert the command regardless of read staleness.
-// Returns "" when neither field is set (so the override stays nil and a genuinely
-// command-less template is left untouched).
-func dockerArgsJSON(entrypoint, cmd []string) string {
-	if len(entrypoint) == 0 && len(cmd) == 0 {
-		return ""
-	}
-	payload := struct {
-		Cmd        []string `json:"cmd,omitempty"`
-		Entrypoint []string `json:"entrypoint,omitempty"`
-	}{Cmd: cmd, Entrypoint: entrypoint}
-	encoded, err := json.Marshal(payload)
-	if err != nil {
-		return ""
-	}
-	return string(encoded)
-}
@@ -1,9 +1,14 @@
 package template
 
 import (
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/registry/registrytest"
+
+	"github.com/spf13/cobra"
 )
 
 // TestCreatePortLabelOverridesCarriesStartCommand is the create-side half of the
@@ -59,3 +64,23 @@ func TestCreatePortLabelOverridesCarriesStartCommand(t *testing.T) {
 }
 
 func strPtr(s string) *string { return &s }
+
+func TestRunCreate_ChecksImageFirst(t *testing.T) {
+	server := registrytest.NewServer(t)
+	server.PushIndex("team/app", "v1", 1<<20, "linux/arm64")
+	image, skip := createImageName, createSkipImageCheck
+	t.Cleanup(func() { createImageName, createSkipImageCheck = image, skip })
+	t.Setenv("RUNPOD_API_KEY", "")
+
+	cmd := &cobra.Command{}
+	cmd.SetErr(io.Discard)
+	createImageName = server.Image("team/app", "v1")
+	if err := runCreate(cmd, nil); err == nil || !strings.Contains(err.Error(), "has no linux/amd64 variant (has linux/arm64)") {
+		t.Fatalf("expected the platform check to fail before any api call, got %v", err)
+	}
+
+	createSkipImageCheck = true
+	if err := runCreate(cmd, nil); err == nil || strings.Contains(err.Error(), "linux/amd64") {
+		t.Fatalf("expected --skip-image-check to go straight to the api, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/runpod/runpodctl/internal/api"
 	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
 
 	"github.com/spf13/cobra"
 )
@@ -27,10 +28,12 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	before := templatehistory.ReadState(cmd.ErrOrStderr(), templateID)
 	if err := client.DeleteTemplate(templateID); err != nil {
 		output.Error(err)
 		return fmt.Errorf("failed to delete template: %w", err)
 	}
+	templatehistory.Record(cmd.ErrOrStderr(), "delete", templateID, before)
 
 	format := output.ParseFormat(cmd.Flag("output").Value.String())
 	return output.Print(map[string]interface{}{
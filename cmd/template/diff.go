@@ -0,0 +1,137 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <template-id> [rev1] [rev2]",
+	Short: "compare recorded states of a template",
+	Long: `compare states of a template from template history, field by field.
+
+with no revisions, show what the latest recorded change did; with one, what
+that revision changed. with two, compare the template after rev1 with the
+template after rev2. "current" stands for the template as it is now, so
+"diff <template-id> 4 current" includes changes made outside this cli.
+
+env values are masked unless --show-values is set.`,
+	Example: `  runpodctl template diff <template-id>
+  runpodctl template diff <template-id> 3
+  runpodctl template diff <template-id> 2 current --show-values`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: runDiff,
+}
+
+var diffShowValues bool
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffShowValues, "show-values", false, "show env values instead of masking them")
+}
+
+type diffResult struct {
+	ID      string           `json:"id"`
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	Changes []templateChange `json:"changes"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	templateID := args[0]
+	revisions, err := templatehistory.Load(templateID)
+	if err != nil {
+		return err
+	}
+
+	result := &diffResult{ID: templateID}
+	var from, to *api.TemplateState
+	switch len(args) {
+	case 1, 2:
+		if len(revisions) == 0 {
+			return fmt.Errorf("no recorded changes to template %s", templateID)
+		}
+		revision := &revisions[len(revisions)-1]
+		if len(args) == 2 {
+			if revision, err = findTemplateRevision(revisions, args[1]); err != nil {
+				return err
+			}
+		}
+		from, to = revision.Before, revision.After
+		result.From = fmt.Sprintf("before rev %d", revision.Rev)
+		result.To = fmt.Sprintf("rev %d", revision.Rev)
+	default:
+		if from, result.From, err = templateStateAt(revisions, templateID, args[1]); err != nil {
+			return err
+		}
+		if to, result.To, err = templateStateAt(revisions, templateID, args[2]); err != nil {
+			return err
+		}
+	}
+
+	result.Changes = diffTemplateStates(from, to)
+	if result.Changes == nil {
+		result.Changes = []templateChange{}
+	}
+	if !diffShowValues {
+		masked := envvars.MaskedValue
+		for i, change := range result.Changes {
+			if !strings.HasPrefix(change.Field, "env.") {
+				continue
+			}
+			if change.From != nil {
+				result.Changes[i].From = &masked
+			}
+			if change.To != nil {
+				result.Changes[i].To = &masked
+			}
+		}
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+// findTemplateRevision finds a revision by its number
+func findTemplateRevision(revisions []templatehistory.Revision, rev string) (*templatehistory.Revision, error) {
+	number, err := strconv.Atoi(strings.TrimPrefix(rev, "rev"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q; use a number from template history", rev)
+	}
+	for i := range revisions {
+		if revisions[i].Rev == number {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no revision %d in the template history", number)
+}
+
+// templateStateAt returns the state after a revision, or the live state for
+// "current", with a label for output
+func templateStateAt(revisions []templatehistory.Revision, templateID, rev string) (*api.TemplateState, string, error) {
+	if rev == "current" {
+		client, err := api.NewGraphQLClient()
+		if err != nil {
+			output.Error(err)
+			return nil, "", err
+		}
+		state, err := client.GetTemplateState(templateID)
+		if err != nil {
+			output.Error(err)
+			return nil, "", fmt.Errorf("failed to get template: %w", err)
+		}
+		return state, "current", nil
+	}
+	revision, err := findTemplateRevision(revisions, rev)
+	if err != nil {
+		return nil, "", err
+	}
+	return revision.After, fmt.Sprintf("rev %d", revision.Rev), nil
+}
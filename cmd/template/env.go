@@ -0,0 +1,159 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "manage template environment variables",
+	Long: `list, set and unset environment variables on a template.
+
+set and unset read the template's current env, apply the change and write the
+full env back, so other variables are kept. values are masked unless
+--show-values is given.`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list <template-id>",
+	Short: "list template environment variables",
+	Long:  "list environment variables on a template",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEnvList,
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set <template-id> [KEY=VALUE...]",
+	Short: "set template environment variables",
+	Long:  "add or change environment variables on a template, keeping the others",
+	Example: `  runpodctl template env set <template-id> MAX_MODEL_LEN=8192
+  runpodctl template env set <template-id> --env-file .env`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEnvSet,
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset <template-id> KEY...",
+	Short: "unset template environment variables",
+	Long:  "remove environment variables from a template, keeping the others",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runEnvUnset,
+}
+
+var (
+	envShowValues bool
+	envSetFile    string
+)
+
+func init() {
+	envCmd.PersistentFlags().BoolVar(&envShowValues, "show-values", false, "show env values instead of masking them")
+	envSetCmd.Flags().StringVar(&envSetFile, "env-file", "", "read KEY=VALUE lines from a dotenv file")
+
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envUnsetCmd)
+}
+
+func runEnvList(cmd *cobra.Command, args []string) error {
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	template, err := client.GetTemplate(args[0])
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return printTemplateEnv(cmd, template.ID, template.Env)
+}
+
+func runEnvSet(cmd *cobra.Command, args []string) error {
+	templateID := args[0]
+
+	assignments, err := envvars.ParseAssignments(args[1:])
+	if err != nil {
+		return err
+	}
+	updates, err := envvars.Load(envSetFile, assignments)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return fmt.Errorf("nothing to set; pass KEY=VALUE arguments or --env-file")
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	template, err := client.GetTemplate(templateID)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get existing template env: %w", err)
+	}
+
+	env := envvars.Merge(template.Env, updates)
+	before := templatehistory.ReadState(cmd.ErrOrStderr(), templateID)
+	if _, err := client.UpdateTemplateEnv(templateID, env); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to update template env: %w", err)
+	}
+	templatehistory.Record(cmd.ErrOrStderr(), "env", templateID, before)
+
+	return printTemplateEnv(cmd, templateID, env)
+}
+
+func runEnvUnset(cmd *cobra.Command, args []string) error {
+	templateID := args[0]
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	template, err := client.GetTemplate(templateID)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get existing template env: %w", err)
+	}
+
+	env, missing := envvars.Unset(template.Env, args[1:])
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "note: not set on template %s: %s\n", templateID, strings.Join(missing, ", "))
+	}
+	if len(missing) == len(args[1:]) {
+		return printTemplateEnv(cmd, templateID, template.Env)
+	}
+
+	before := templatehistory.ReadState(cmd.ErrOrStderr(), templateID)
+	if _, err := client.UpdateTemplateEnv(templateID, env); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to update template env: %w", err)
+	}
+	templatehistory.Record(cmd.ErrOrStderr(), "env", templateID, before)
+
+	return printTemplateEnv(cmd, templateID, env)
+}
+
+func printTemplateEnv(cmd *cobra.Command, templateID string, env map[string]string) error {
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"id":  templateID,
+		"env": envvars.Mask(env, envShowValues),
+	}, &output.Config{Format: format})
+}
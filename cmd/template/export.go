@@ -0,0 +1,80 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <template-id>...",
+	Short: "export templates as yaml",
+	Long: `write templates as yaml documents that template import reads back, in
+this or another account. the image, ports, port labels, env, disks, readme
+and start command are carried over; the registry auth is written by name,
+since auth ids differ between accounts.`,
+	Example: `  runpodctl template export <template-id> > tpl.yaml
+  runpodctl template export <template-id> <template-id> > templates.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExport,
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	var authNames map[string]string
+	var specs []*templateSpec
+	for _, templateID := range args {
+		template, err := client.GetTemplate(templateID)
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to get template %s: %w", templateID, err)
+		}
+		spec := specFromTemplate(template)
+		if authID := template.ContainerRegistryAuthID; authID != "" {
+			if authNames == nil {
+				if authNames, _, err = registryAuthNames(client); err != nil {
+					return err
+				}
+			}
+			spec.RegistryAuth = authNames[authID]
+			if spec.RegistryAuth == "" {
+				return fmt.Errorf("template %s uses registry auth %s, which isn't in this account", templateID, authID)
+			}
+		}
+		specs = append(specs, spec)
+	}
+
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	for _, spec := range specs {
+		if err := encoder.Encode(spec); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+// registryAuthNames maps the account's registry auth ids to names and back
+func registryAuthNames(client *api.Client) (map[string]string, map[string]string, error) {
+	auths, err := client.ListContainerRegistryAuths()
+	if err != nil {
+		output.Error(err)
+		return nil, nil, fmt.Errorf("failed to list registry auths: %w", err)
+	}
+	names := make(map[string]string, len(auths))
+	ids := make(map[string]string, len(auths))
+	for _, auth := range auths {
+		names[auth.ID] = auth.Name
+		ids[auth.Name] = auth.ID
+	}
+	return names, ids, nil
+}
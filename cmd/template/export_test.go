@@ -0,0 +1,344 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/api"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// fakeTemplateAPI is one account's templates and registry auths, served
+// over the rest api and the graphql calls port labels use
+type fakeTemplateAPI struct {
+	mu        sync.Mutex
+	templates map[string]*api.Template
+	auths     []api.ContainerRegistryAuth
+	created   []map[string]interface{}
+	saved     []map[string]interface{}
+	pods      []map[string]interface{}
+}
+
+func startTemplateAPI(t *testing.T, templates []*api.Template, auths ...api.ContainerRegistryAuth) *fakeTemplateAPI {
+	t.Helper()
+	fake := &fakeTemplateAPI{templates: map[string]*api.Template{}, auths: auths}
+	for _, template := range templates {
+		fake.templates[template.ID] = template
+	}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	t.Setenv("HOME", t.TempDir())
+	viper.Set("restApiUrl", server.URL)
+	viper.Set("apiUrl", server.URL+"/graphql")
+	t.Cleanup(func() {
+		viper.Set("restApiUrl", "")
+		viper.Set("apiUrl", "")
+	})
+	return fake
+}
+
+func (f *fakeTemplateAPI) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.URL.Path == "/graphql":
+		f.serveGraphQL(w, body)
+	case r.Method == http.MethodGet && r.URL.Path == "/containerregistryauth":
+		_ = json.NewEncoder(w).Encode(f.auths)
+	case r.Method == http.MethodGet && r.URL.Path == "/templates":
+		list := []*api.Template{}
+		for _, template := range f.templates {
+			list = append(list, template)
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/templates/"):
+		template, ok := f.templates[strings.TrimPrefix(r.URL.Path, "/templates/")]
+		if !ok {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		// the rest schema has no port labels
+		rest := *template
+		rest.PortsConfig = nil
+		_ = json.NewEncoder(w).Encode(rest)
+	case r.Method == http.MethodPost && r.URL.Path == "/templates":
+		var req map[string]interface{}
+		_ = json.Unmarshal(body, &req)
+		f.created = append(f.created, req)
+		var template api.Template
+		_ = json.Unmarshal(body, &template)
+		template.ID = fmt.Sprintf("new-%d", len(f.created))
+		f.templates[template.ID] = &template
+		_ = json.NewEncoder(w).Encode(template)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeTemplateAPI) serveGraphQL(w http.ResponseWriter, body []byte) {
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	_ = json.Unmarshal(body, &req)
+
+	switch {
+	case strings.Contains(req.Query, "saveTemplate"):
+		input, _ := req.Variables["input"].(map[string]interface{})
+		f.saved = append(f.saved, input)
+		f.applySave(input)
+		_, _ = fmt.Fprintf(w, `{"data":{"saveTemplate":{"id":%q}}}`, input["id"])
+	case strings.Contains(req.Query, "podFindAndDeployOnDemand"):
+		input, _ := req.Variables["input"].(map[string]interface{})
+		f.pods = append(f.pods, input)
+		_, _ = fmt.Fprintf(w, `{"data":{"podFindAndDeployOnDemand":{"id":"pod-%d","name":%q}}}`, len(f.pods), input["name"])
+	case strings.Contains(req.Query, "podTemplates"):
+		states := []map[string]interface{}{}
+		for _, template := range f.templates {
+			states = append(states, map[string]interface{}{
+				"id":                      template.ID,
+				"name":                    template.Name,
+				"imageName":               template.ImageName,
+				"ports":                   strings.Join(template.Ports, ","),
+				"containerRegistryAuthId": template.ContainerRegistryAuthID,
+				"isServerless":            template.IsServerless,
+				"containerDiskInGb":       template.ContainerDiskInGb,
+				"portsConfig":             template.PortsConfig,
+				"env":                     envPairs(template.Env),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"myself": map[string]interface{}{"podTemplates": states}}})
+	case strings.Contains(req.Query, "podTemplate("):
+		template := f.templates[fmt.Sprint(req.Variables["id"])]
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"podTemplate": map[string]interface{}{
+			"id":          template.ID,
+			"ports":       template.Ports,
+			"portsConfig": template.PortsConfig,
+		}}})
+	default:
+		_, _ = w.Write([]byte(`{"errors":[{"message":"unexpected query"}]}`))
+	}
+}
+
+// applySave makes a saveTemplate input the template's new state
+func (f *fakeTemplateAPI) applySave(input map[string]interface{}) {
+	template, ok := f.templates[fmt.Sprint(input["id"])]
+	if !ok {
+		return
+	}
+	template.Name, _ = input["name"].(string)
+	template.ImageName, _ = input["imageName"].(string)
+	if ports, _ := input["ports"].(string); ports != "" {
+		template.Ports = strings.Split(ports, ",")
+	} else {
+		template.Ports = nil
+	}
+	if disk, ok := input["containerDiskInGb"].(float64); ok {
+		template.ContainerDiskInGb = int(disk)
+	}
+	template.Env = map[string]string{}
+	pairs, _ := input["env"].([]interface{})
+	for _, pair := range pairs {
+		pair, _ := pair.(map[string]interface{})
+		template.Env[fmt.Sprint(pair["key"])] = fmt.Sprint(pair["value"])
+	}
+	data, _ := json.Marshal(input["portsConfig"])
+	template.PortsConfig = nil
+	_ = json.Unmarshal(data, &template.PortsConfig)
+}
+
+func envPairs(env map[string]string) []map[string]string {
+	pairs := []map[string]string{}
+	for key, value := range env {
+		pairs = append(pairs, map[string]string{"key": key, "value": value})
+	}
+	return pairs
+}
+
+func resetTemplateSpecVars(t *testing.T) {
+	t.Helper()
+	file, upsert, name := importFile, importUpsertByName, cloneName
+	t.Cleanup(func() { importFile, importUpsertByName, cloneName = file, upsert, name })
+}
+
+func runTemplateCmd(t *testing.T, run func(*cobra.Command, []string) error, args ...string) (string, error) {
+	t.Helper()
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	var stdout strings.Builder
+	cmd.SetOut(&stdout)
+	cmd.SetErr(io.Discard)
+
+	origStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stdout: %v", err)
+	}
+	os.Stdout = w
+	runErr := run(cmd, args)
+	_ = w.Close()
+	os.Stdout = origStdout
+	printed, _ := io.ReadAll(r)
+	_ = r.Close()
+	return stdout.String() + string(printed), runErr
+}
+
+func sourceTemplate() *api.Template {
+	return &api.Template{
+		ID:                      "tpl-1",
+		Name:                    "comfy",
+		ImageName:               "ghcr.io/team/comfy:v3",
+		Ports:                   []string{"8188/http", "22/tcp"},
+		PortsConfig:             []api.TemplatePortConfig{{Port: "8188", Name: "comfyui"}},
+		DockerStartCmd:          []string{"python main.py --listen"},
+		Env:                     map[string]string{"HF_TOKEN": "{{ RUNPOD_SECRET_hf }}", "MODE": "prod"},
+		ContainerDiskInGb:       30,
+		VolumeInGb:              50,
+		VolumeMountPath:         "/workspace",
+		Readme:                  "# comfy\nline two",
+		ContainerRegistryAuthID: "auth-a",
+	}
+}
+
+func TestExportImport_AcrossAccounts(t *testing.T) {
+	resetTemplateSpecVars(t)
+
+	startTemplateAPI(t, []*api.Template{sourceTemplate()}, api.ContainerRegistryAuth{ID: "auth-a", Name: "ghcr"})
+	exported, err := runTemplateCmd(t, runExport, "tpl-1")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	for _, want := range []string{"name: comfy", "registryAuth: ghcr", "port: \"8188\"", "name: comfyui", "python main.py --listen", "RUNPOD_SECRET_hf", "volumeMountPath: /workspace"} {
+		if !strings.Contains(exported, want) {
+			t.Fatalf("export is missing %q:\n%s", want, exported)
+		}
+	}
+	if strings.Contains(exported, "auth-a") || strings.Contains(exported, "tpl-1") {
+		t.Fatalf("export should not carry account ids:\n%s", exported)
+	}
+	importFile = filepath.Join(t.TempDir(), "tpl.yaml")
+	if err := os.WriteFile(importFile, []byte(exported), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the other account has its own id for the ghcr auth
+	other := startTemplateAPI(t, nil, api.ContainerRegistryAuth{ID: "auth-b", Name: "ghcr"})
+	stdout, err := runTemplateCmd(t, runImport)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !strings.Contains(stdout, `"action": "created"`) || len(other.created) != 1 {
+		t.Fatalf("unexpected import %s (%d creates)", stdout, len(other.created))
+	}
+	created := other.created[0]
+	if created["containerRegistryAuthId"] != "auth-b" || created["volumeInGb"] != float64(50) || fmt.Sprint(created["dockerStartCmd"]) != "[python main.py --listen]" {
+		t.Fatalf("unexpected create request %v", created)
+	}
+	if len(other.saved) != 1 || fmt.Sprint(other.saved[0]["portsConfig"]) != "[map[name:comfyui port:8188]]" {
+		t.Fatalf("expected the port labels to be set, got %v", other.saved)
+	}
+
+	// a second import needs --upsert-by-name, and then keeps the id
+	if _, err := runTemplateCmd(t, runImport); err == nil || !strings.Contains(err.Error(), "--upsert-by-name") {
+		t.Fatalf("expected a name conflict, got %v", err)
+	}
+	importUpsertByName = true
+	if stdout, err = runTemplateCmd(t, runImport); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if !strings.Contains(stdout, `"id": "new-1"`) || !strings.Contains(stdout, `"action": "updated"`) || len(other.created) != 1 {
+		t.Fatalf("expected an update of new-1, got %s", stdout)
+	}
+	saved := other.saved[len(other.saved)-1]
+	if saved["id"] != "new-1" || saved["containerRegistryAuthId"] != "auth-b" || saved["dockerArgs"] != `{"cmd":["python main.py --listen"]}` || saved["readme"] != "# comfy\nline two" {
+		t.Fatalf("unexpected save %v", saved)
+	}
+}
+
+func TestImport_MissingRegistryAuth(t *testing.T) {
+	resetTemplateSpecVars(t)
+	fake := startTemplateAPI(t, []*api.Template{sourceTemplate()}, api.ContainerRegistryAuth{ID: "auth-b", Name: "dockerhub"})
+	importFile = filepath.Join(t.TempDir(), "tpl.yaml")
+	write := func(spec string) {
+		if err := os.WriteFile(importFile, []byte(spec), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the bad document comes last; nothing before it is written
+	write("name: other\nimageName: ubuntu\n---\nname: comfy-v3\nimageName: ghcr.io/team/comfy:v3\nregistryAuth: ghcr\n")
+	if _, err := runTemplateCmd(t, runImport); err == nil || !strings.Contains(err.Error(), `registry auth "ghcr"`) {
+		t.Fatalf("expected a missing registry auth error, got %v", err)
+	}
+	write("name: other\nimageName: ubuntu\n---\nname: comfy\nimageName: ghcr.io/team/comfy:v3\n")
+	if _, err := runTemplateCmd(t, runImport); err == nil || !strings.Contains(err.Error(), "template comfy already exists") {
+		t.Fatalf("expected a name conflict error, got %v", err)
+	}
+	if len(fake.created) != 0 || len(fake.saved) != 0 {
+		t.Fatalf("expected nothing to be written, got %v %v", fake.created, fake.saved)
+	}
+}
+
+func TestLoadTemplateSpecs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "tpl.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	specs, err := loadTemplateSpecs(write("name: a\nimageName: x\nports: [8888/http]\nportLabels: [{port: 8888/http, name: ' jupyter '}]\n---\n{\"name\": \"b\", \"imageName\": \"y\"}\n"))
+	if err != nil || len(specs) != 2 {
+		t.Fatalf("expected two templates, got %v (%v)", specs, err)
+	}
+	if specs[0].PortLabels[0] != (api.TemplatePortConfig{Port: "8888", Name: "jupyter"}) {
+		t.Fatalf("expected normalized labels, got %+v", specs[0].PortLabels)
+	}
+
+	for content, want := range map[string]string{
+		"name: a\n":                       "has no imageName",
+		"name: a\nimageName: x\nimage: y": "field image not found",
+		"name: a\nimageName: x\nportLabels: [{port: 22, name: ssh}]": "does not match any value in --ports",
+		"": "has no templates",
+	} {
+		if _, err := loadTemplateSpecs(write(content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loadTemplateSpecs(%q) = %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestRunClone(t *testing.T) {
+	resetTemplateSpecVars(t)
+	fake := startTemplateAPI(t, []*api.Template{sourceTemplate()})
+	cloneName = "comfy-v4"
+
+	stdout, err := runTemplateCmd(t, runClone, "tpl-1")
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if !strings.Contains(stdout, `"name": "comfy-v4"`) || len(fake.created) != 1 {
+		t.Fatalf("unexpected clone %s", stdout)
+	}
+	if fake.created[0]["containerRegistryAuthId"] != "auth-a" || fake.created[0]["readme"] != "# comfy\nline two" {
+		t.Fatalf("expected the registry auth id and readme to be kept, got %v", fake.created[0])
+	}
+	if len(fake.saved) != 1 || fake.saved[0]["name"] != "comfy-v4" {
+		t.Fatalf("expected the port labels to be set on the clone, got %v", fake.saved)
+	}
+}
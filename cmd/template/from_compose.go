@@ -0,0 +1,195 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/runpod/runpodctl/cmd/pod"
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/registry"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var fromComposeCmd = &cobra.Command{
+	Use:   "from-compose <compose-file>",
+	Short: "convert a docker compose service into a template or pod",
+	Long: `convert one service of a docker compose file: its image, entrypoint and
+command, environment and env_file, ports as <port>/http (or <port>/tcp for
+ssh, other well-known tcp protocols and udp), and its volume as the volume
+mount path. ${VAR} references are filled in from your shell and a
+.env file next to the compose file, like docker compose does.
+
+anything runpod can't represent, such as depends_on, healthchecks or a
+second volume, is listed on stderr.
+
+without --apply the template is written as yaml that template import reads.
+--apply creates the template, and --apply pod creates a pod from the service
+directly, with the same checks and defaults as pod create.`,
+	Example: `  runpodctl template from-compose docker-compose.yml --service api > tpl.yaml
+  runpodctl template from-compose compose.yaml --service api --apply
+  runpodctl template from-compose compose.yaml --service api --apply pod --gpu-id "NVIDIA GeForce RTX 4090"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFromCompose,
+}
+
+var (
+	fromComposeService           string
+	fromComposeName              string
+	fromComposeApply             string
+	fromComposeContainerDiskInGb int
+	fromComposeVolumeInGb        int
+	fromComposeRegistryAuthID    string
+	fromComposeSkipImageCheck    bool
+	fromComposeComputeType       string
+	fromComposeGpuTypeID         string
+	fromComposeGpuCount          int
+	fromComposeCloudType         string
+)
+
+func init() {
+	fromComposeCmd.Flags().StringVar(&fromComposeService, "service", "", "service to convert (required when the file has several)")
+	fromComposeCmd.Flags().StringVar(&fromComposeName, "name", "", "template or pod name (default <project>-<service>)")
+	fromComposeCmd.Flags().StringVar(&fromComposeApply, "apply", "", "create the template, or a pod with --apply pod, instead of printing it")
+	fromComposeCmd.Flags().Lookup("apply").NoOptDefVal = "template"
+	fromComposeCmd.Flags().IntVar(&fromComposeContainerDiskInGb, "container-disk-in-gb", 20, "container disk size in gb")
+	fromComposeCmd.Flags().IntVar(&fromComposeVolumeInGb, "volume-in-gb", 20, "volume size in gb, when the service has a volume")
+	fromComposeCmd.Flags().StringVar(&fromComposeRegistryAuthID, "registry-auth-id", "", "container registry auth id for --apply (from 'runpodctl registry list')")
+	fromComposeCmd.Flags().BoolVar(&fromComposeSkipImageCheck, "skip-image-check", false, "don't check the image against its registry before --apply")
+	fromComposeCmd.Flags().StringVar(&fromComposeComputeType, "compute-type", "GPU", "compute type for --apply pod (GPU or CPU)")
+	fromComposeCmd.Flags().StringVar(&fromComposeGpuTypeID, "gpu-id", "", "gpu id for --apply pod (from 'runpodctl gpu list')")
+	fromComposeCmd.Flags().IntVar(&fromComposeGpuCount, "gpu-count", 1, "number of gpus for --apply pod")
+	fromComposeCmd.Flags().StringVar(&fromComposeCloudType, "cloud-type", "SECURE", "cloud type for --apply pod (SECURE or COMMUNITY)")
+}
+
+func runFromCompose(cmd *cobra.Command, args []string) error {
+	apply := strings.ToLower(strings.TrimSpace(fromComposeApply))
+	switch apply {
+	case "", "template", "pod":
+	default:
+		return fmt.Errorf("invalid --apply %q (use template or pod)", fromComposeApply)
+	}
+	conversion, err := convertComposeFile(args[0], fromComposeService)
+	if err != nil {
+		return err
+	}
+	for _, note := range conversion.Notes {
+		fmt.Fprintf(cmd.ErrOrStderr(), "note: %s\n", note)
+	}
+	spec := conversion.Spec
+	if err := spec.validate(); err != nil {
+		return err
+	}
+
+	if apply == "" {
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		if err := encoder.Encode(spec); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	authID := strings.TrimSpace(fromComposeRegistryAuthID)
+	var podOpts *pod.CreateOptions
+	if apply == "pod" {
+		podOpts = composePodOptions(spec, authID)
+		if err := podOpts.Validate(cmd.ErrOrStderr()); err != nil {
+			return err
+		}
+	}
+	if !fromComposeSkipImageCheck {
+		_, err := registry.Preflight(context.Background(), cmd.ErrOrStderr(), spec.ImageName, registry.PreflightOptions{
+			RegistryAuthID:    authID,
+			ContainerDiskInGb: spec.ContainerDiskInGb,
+		})
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+	}
+
+	var result interface{}
+	if apply == "pod" {
+		result, err = pod.Create(podOpts)
+	} else {
+		var client *api.Client
+		var template *api.Template
+		client, err = api.NewClient()
+		if err == nil {
+			template, err = createTemplate(client, spec.createRequest(authID), nil)
+		}
+		if err == nil {
+			templatehistory.Record(cmd.ErrOrStderr(), "from-compose", template.ID, nil)
+			result = template
+		}
+	}
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+// convertComposeFile loads a compose file and converts one of its services,
+// named and sized from the flags
+func convertComposeFile(path, service string) (*composeConversion, error) {
+	compose, notes, err := loadCompose(path)
+	if err != nil {
+		return nil, err
+	}
+	name, selected, err := selectComposeService(compose, service)
+	if err != nil {
+		return nil, err
+	}
+	conversion, err := convertComposeService(filepath.Dir(path), name, selected)
+	if err != nil {
+		return nil, err
+	}
+	conversion.Notes = append(notes, conversion.Notes...)
+
+	spec := conversion.Spec
+	spec.Name = fromComposeName
+	if spec.Name == "" {
+		project := compose.Name
+		if project == "" {
+			if dir, err := filepath.Abs(filepath.Dir(path)); err == nil {
+				project = filepath.Base(dir)
+			}
+		}
+		spec.Name = project + "-" + name
+	}
+	spec.ContainerDiskInGb = fromComposeContainerDiskInGb
+	if spec.VolumeMountPath != "" {
+		spec.VolumeInGb = fromComposeVolumeInGb
+	}
+	return conversion, nil
+}
+
+// composePodOptions describes a pod running a converted service, for the
+// pod create builder
+func composePodOptions(spec *templateSpec, registryAuthID string) *pod.CreateOptions {
+	return &pod.CreateOptions{
+		Name:              spec.Name,
+		ImageName:         spec.ImageName,
+		ComputeType:       fromComposeComputeType,
+		GpuTypeID:         fromComposeGpuTypeID,
+		GpuCount:          fromComposeGpuCount,
+		CloudType:         fromComposeCloudType,
+		ContainerDiskInGb: spec.ContainerDiskInGb,
+		VolumeInGb:        spec.VolumeInGb,
+		VolumeMountPath:   spec.VolumeMountPath,
+		Ports:             spec.Ports,
+		Env:               spec.Env,
+		DockerArgs:        api.DockerArgsJSON(spec.DockerEntrypoint, spec.DockerStartCmd),
+		RegistryAuthID:    registryAuthID,
+		StartSSH:          true,
+	}
+}
@@ -0,0 +1,136 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <template-id>",
+	Short: "list the recorded changes to a template",
+	Long: `list the changes made to a template through this cli, newest last. every
+create, update, env change, import, delete and revert records the full
+template state before and after under ~/.runpod/history, which template diff
+and template revert read.
+
+changes made in the console or by other machines aren't recorded.`,
+	Example: `  runpodctl template history <template-id>`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runHistory,
+}
+
+type historyEntry struct {
+	Rev     int       `json:"rev"`
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Changed []string  `json:"changed"`
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	revisions, err := templatehistory.Load(args[0])
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		return fmt.Errorf("no recorded changes to template %s", args[0])
+	}
+	entries := make([]historyEntry, 0, len(revisions))
+	for _, revision := range revisions {
+		entry := historyEntry{Rev: revision.Rev, Time: revision.Time, Action: revision.Action, Changed: []string{}}
+		for _, change := range diffTemplateStates(revision.Before, revision.After) {
+			entry.Changed = append(entry.Changed, change.Field)
+		}
+		entries = append(entries, entry)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(entries, &output.Config{Format: format})
+}
+
+// templateChange is one field that differs between two template states
+type templateChange struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+// diffTemplateStates compares two states field by field, with env keys and
+// port labels as fields of their own. a missing state has no fields.
+func diffTemplateStates(from, to *api.TemplateState) []templateChange {
+	before, after := templateStateFields(from), templateStateFields(to)
+	fields := map[string]bool{}
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	var changes []templateChange
+	for _, field := range names {
+		old, hadOld := before[field]
+		value, hasNew := after[field]
+		if hadOld == hasNew && old == value {
+			continue
+		}
+		change := templateChange{Field: field}
+		if hadOld {
+			change.From = &old
+		}
+		if hasNew {
+			change.To = &value
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func templateStateFields(state *api.TemplateState) map[string]string {
+	fields := map[string]string{}
+	if state == nil {
+		return fields
+	}
+	set := func(field, value string) {
+		if value != "" {
+			fields[field] = value
+		}
+	}
+	set("name", state.Name)
+	set("imageName", state.ImageName)
+	set("dockerArgs", state.DockerArgs)
+	set("ports", strings.Join(state.Ports, ","))
+	for _, label := range state.PortsConfig {
+		set("portLabels."+label.Port, label.Name)
+	}
+	for _, key := range envvars.Keys(state.Env) {
+		fields["env."+key] = state.Env[key]
+	}
+	set("volumeMountPath", state.VolumeMountPath)
+	set("volumeInGb", strconv.Itoa(state.VolumeInGb))
+	set("containerDiskInGb", strconv.Itoa(state.ContainerDiskInGb))
+	set("containerRegistryAuthId", state.ContainerRegistryAuthID)
+	set("startJupyter", strconv.FormatBool(state.StartJupyter))
+	set("startSsh", strconv.FormatBool(state.StartSSH))
+	set("startScript", state.StartScript)
+	set("isServerless", strconv.FormatBool(state.IsServerless))
+	set("isPublic", strconv.FormatBool(state.IsPublic))
+	set("readme", state.Readme)
+	set("advancedStart", strconv.FormatBool(state.AdvancedStart))
+	set("category", state.Category)
+	return fields
+}
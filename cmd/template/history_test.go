@@ -0,0 +1,195 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+)
+
+func TestTemplateHistory_ImportDiffRevert(t *testing.T) {
+	resetTemplateSpecVars(t)
+	fake := startTemplateAPI(t, []*api.Template{sourceTemplate()})
+	t.Cleanup(func() { importUpsertByName, diffShowValues, revertTo = false, false, "" })
+
+	if _, err := runTemplateCmd(t, runHistory, "tpl-1"); err == nil || !strings.Contains(err.Error(), "no recorded changes") {
+		t.Fatalf("expected no history yet, got %v", err)
+	}
+
+	importFile = filepath.Join(t.TempDir(), "tpl.yaml")
+	importUpsertByName = true
+	for _, spec := range []string{
+		"name: comfy\nimageName: ghcr.io/team/comfy:v4\nports:\n  - 8188/http\nenv:\n  MODE: prod\n",
+		"name: comfy\nimageName: ghcr.io/team/comfy:v5\nports:\n  - 8188/http\nenv:\n  MODE: dev\n",
+	} {
+		if err := os.WriteFile(importFile, []byte(spec), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := runTemplateCmd(t, runImport); err != nil {
+			t.Fatalf("import: %v", err)
+		}
+	}
+
+	stdout, err := runTemplateCmd(t, runHistory, "tpl-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	var entries []historyEntry
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		t.Fatalf("parse history %s: %v", stdout, err)
+	}
+	if len(entries) != 2 || entries[0].Action != "import" || !strings.Contains(strings.Join(entries[0].Changed, ","), "imageName") {
+		t.Fatalf("unexpected history %+v", entries)
+	}
+
+	stdout, err = runTemplateCmd(t, runDiff, "tpl-1", "1", "2")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	var result diffResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse diff %s: %v", stdout, err)
+	}
+	changes := map[string]templateChange{}
+	for _, change := range result.Changes {
+		changes[change.Field] = change
+	}
+	if image := changes["imageName"]; image.From == nil || *image.From != "ghcr.io/team/comfy:v4" || *image.To != "ghcr.io/team/comfy:v5" {
+		t.Fatalf("expected the image change, got %+v", result.Changes)
+	}
+	if mode, ok := changes["env.MODE"]; !ok || strings.Contains(stdout, "dev") {
+		t.Fatalf("expected a masked env change, got %s", stdout)
+	} else if *mode.To == "dev" {
+		t.Fatalf("env value should be masked, got %q", *mode.To)
+	}
+
+	revertTo = "1"
+	if stdout, err = runTemplateCmd(t, runRevert, "tpl-1"); err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	if !strings.Contains(stdout, `"reverted": true`) {
+		t.Fatalf("unexpected revert output %s", stdout)
+	}
+	saved := fake.saved[len(fake.saved)-1]
+	if saved["id"] != "tpl-1" || saved["imageName"] != "ghcr.io/team/comfy:v4" {
+		t.Fatalf("unexpected revert save %v", saved)
+	}
+	revisions, err := templatehistory.Load("tpl-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 || revisions[2].Action != "revert" || revisions[2].After.ImageName != "ghcr.io/team/comfy:v4" || revisions[2].After.Env["MODE"] != "prod" {
+		t.Fatalf("expected the revert to be recorded, got %+v", revisions)
+	}
+}
+
+func TestRevert_Errors(t *testing.T) {
+	startTemplateAPI(t, []*api.Template{sourceTemplate()})
+	t.Cleanup(func() { revertTo = "" })
+	if err := templatehistory.Append("tpl-1", &templatehistory.Revision{Action: "delete", Before: &api.TemplateState{ID: "tpl-1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		to   string
+		want string
+	}{
+		{"one", "invalid revision"},
+		{"7", "no revision 7"},
+		{"rev1", "no state to restore"},
+	}
+	for _, tt := range tests {
+		revertTo = tt.to
+		if _, err := runTemplateCmd(t, runRevert, "tpl-1"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("--to %s: expected %q, got %v", tt.to, tt.want, err)
+		}
+	}
+}
+
+func TestRevert_RestoresEveryDiffedField(t *testing.T) {
+	fake := startTemplateAPI(t, []*api.Template{sourceTemplate()})
+	t.Cleanup(func() { revertTo = "" })
+	target := &api.TemplateState{
+		ID:            "tpl-1",
+		Name:          "comfy",
+		ImageName:     "ghcr.io/team/comfy:v2",
+		StartJupyter:  true,
+		StartSSH:      true,
+		IsPublic:      true,
+		AdvancedStart: true,
+		Category:      "NVIDIA",
+	}
+	if err := templatehistory.Append("tpl-1", &templatehistory.Revision{Action: "update", After: target}); err != nil {
+		t.Fatal(err)
+	}
+
+	revertTo = "1"
+	if _, err := runTemplateCmd(t, runRevert, "tpl-1"); err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	saved := fake.saved[len(fake.saved)-1]
+	for field, want := range map[string]interface{}{
+		"startJupyter":  true,
+		"startSsh":      true,
+		"isPublic":      true,
+		"advancedStart": true,
+		"category":      "NVIDIA",
+		"startScript":   "",
+	} {
+		if got, ok := saved[field]; !ok || got != want {
+			t.Errorf("saved %s = %v, want %v", field, got, want)
+		}
+	}
+}
+
+func TestDelete_UnknownTemplateRecordsNothing(t *testing.T) {
+	startTemplateAPI(t, nil)
+
+	start := time.Now()
+	if _, err := runTemplateCmd(t, runDelete, "tpl-missing"); err == nil {
+		t.Fatal("expected the delete to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the pre-read not to retry, took %s", elapsed)
+	}
+	if revisions, err := templatehistory.Load("tpl-missing"); err != nil || len(revisions) != 0 {
+		t.Fatalf("expected no revision, got %+v (%v)", revisions, err)
+	}
+}
+
+func TestDiffTemplateStates(t *testing.T) {
+	before := &api.TemplateState{
+		Name:        "comfy",
+		ImageName:   "comfy:v3",
+		Ports:       []string{"8188/http"},
+		PortsConfig: []api.TemplatePortConfig{{Port: "8188", Name: "comfyui"}},
+		Env:         map[string]string{"MODE": "prod", "OLD": "1"},
+	}
+	after := &api.TemplateState{
+		Name:      "comfy",
+		ImageName: "comfy:v4",
+		Ports:     []string{"8188/http"},
+		Env:       map[string]string{"MODE": "prod", "NEW": ""},
+	}
+
+	var fields []string
+	for _, change := range diffTemplateStates(before, after) {
+		fields = append(fields, change.Field)
+	}
+	if got := strings.Join(fields, ","); got != "env.NEW,env.OLD,imageName,portLabels.8188" {
+		t.Fatalf("unexpected changed fields %s", got)
+	}
+	if changes := diffTemplateStates(before, before); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+	for _, change := range diffTemplateStates(nil, after) {
+		if change.From != nil || change.To == nil {
+			t.Fatalf("expected every field to be added, got %+v", change)
+		}
+	}
+}
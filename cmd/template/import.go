@@ -0,0 +1,163 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "create templates from an exported yaml file",
+	Long: `create the templates in a file written by template export, one per yaml
+document. registry auths are looked up by name in this account, so create
+or import them first (runpodctl registry import).
+
+a template whose name already exists is an error, unless --upsert-by-name
+is given: then it is overwritten with the file's fields, keeping its id.
+registry auths and names are checked for every document before the first
+template is written.`,
+	Example: `  runpodctl template import -f tpl.yaml
+  runpodctl template import -f templates.yaml --upsert-by-name
+  RUNPOD_API_KEY=$OTHER_ACCOUNT_KEY runpodctl template import -f tpl.yaml`,
+	Args: cobra.NoArgs,
+	RunE: runImport,
+}
+
+var (
+	importFile         string
+	importUpsertByName bool
+)
+
+func init() {
+	importCmd.Flags().StringVarP(&importFile, "file", "f", "", "yaml file from template export, or - for stdin (required)")
+	importCmd.Flags().BoolVar(&importUpsertByName, "upsert-by-name", false, "update templates whose name already exists instead of failing")
+
+	importCmd.MarkFlagRequired("file") //nolint:errcheck
+}
+
+type importResult struct {
+	Name   string `json:"name"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	specs, err := loadTemplateSpecs(importFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	existing, err := client.ListTemplates()
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to list templates: %w", err)
+	}
+	byName := make(map[string]*api.Template, len(existing))
+	for i := range existing {
+		byName[existing[i].Name] = &existing[i]
+	}
+
+	// resolve every registry auth and name conflict before the first write,
+	// so a bad document leaves the account untouched
+	var authIDs map[string]string
+	specAuthIDs := make([]string, len(specs))
+	seen := map[string]bool{}
+	for i, spec := range specs {
+		if seen[spec.Name] {
+			return fmt.Errorf("template %s appears more than once in %s", spec.Name, importFile)
+		}
+		seen[spec.Name] = true
+
+		if spec.RegistryAuth != "" {
+			if authIDs == nil {
+				if _, authIDs, err = registryAuthNames(client); err != nil {
+					return err
+				}
+			}
+			if specAuthIDs[i] = authIDs[spec.RegistryAuth]; specAuthIDs[i] == "" {
+				return fmt.Errorf("template %s uses registry auth %q, which isn't in this account; create it with runpodctl registry create", spec.Name, spec.RegistryAuth)
+			}
+		}
+
+		if current, exists := byName[spec.Name]; exists {
+			if !importUpsertByName {
+				return fmt.Errorf("template %s already exists (%s); use --upsert-by-name to update it", spec.Name, current.ID)
+			}
+			if err := checkTemplateKind(current, spec); err != nil {
+				return err
+			}
+		}
+	}
+
+	results := []*importResult{}
+	for i, spec := range specs {
+		authID := specAuthIDs[i]
+		if current, exists := byName[spec.Name]; exists {
+			before := templatehistory.ReadState(cmd.ErrOrStderr(), current.ID)
+			if err := saveTemplateSpec(current, spec, authID); err != nil {
+				output.Error(err)
+				return err
+			}
+			templatehistory.Record(cmd.ErrOrStderr(), "import", current.ID, before)
+			results = append(results, &importResult{Name: spec.Name, ID: current.ID, Action: "updated"})
+			fmt.Fprintf(cmd.ErrOrStderr(), "updated %s (%s)\n", spec.Name, current.ID)
+			continue
+		}
+
+		template, err := createTemplate(client, spec.createRequest(authID), spec.PortLabels)
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("template %s: %w", spec.Name, err)
+		}
+		templatehistory.Record(cmd.ErrOrStderr(), "import", template.ID, nil)
+		results = append(results, &importResult{Name: spec.Name, ID: template.ID, Action: "created"})
+		fmt.Fprintf(cmd.ErrOrStderr(), "created %s (%s)\n", spec.Name, template.ID)
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(results, &output.Config{Format: format})
+}
+
+// saveTemplateSpec overwrites an existing template with a spec in one
+// graphql saveTemplate, the only write that covers every field, port
+// labels included
+func saveTemplateSpec(current *api.Template, spec *templateSpec, registryAuthID string) error {
+	if err := checkTemplateKind(current, spec); err != nil {
+		return err
+	}
+	graphqlClient, err := api.NewGraphQLClient()
+	if err != nil {
+		return err
+	}
+	labels := spec.PortLabels
+	if labels == nil {
+		labels = []api.TemplatePortConfig{}
+	}
+	if err := graphqlClient.UpdateTemplatePortLabels(current.ID, labels, spec.saveOverrides(registryAuthID)); err != nil {
+		return fmt.Errorf("failed to update template %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// checkTemplateKind refuses to overwrite a pod template with a serverless
+// one or the other way around
+func checkTemplateKind(current *api.Template, spec *templateSpec) error {
+	if current.IsServerless != spec.IsServerless {
+		kind := "pod"
+		if current.IsServerless {
+			kind = "serverless"
+		}
+		return fmt.Errorf("template %s (%s) is a %s template; delete it to import it as another kind", spec.Name, current.ID, kind)
+	}
+	return nil
+}
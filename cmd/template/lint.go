@@ -0,0 +1,249 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/registry"
+
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [<template-id>]",
+	Short: "check a template for mistakes",
+	Long: `check a template, from a file written by template export or by id, for
+mistakes that otherwise only show up after deploy: invalid or duplicate
+ports, http on port 22, port labels without a port, invalid env names,
+secrets in plain text, disk sizes, a volume without a mount path, and
+serverless templates without a start command.
+
+findings have a severity of error, warning or info. the command fails when
+there are errors. template create and update run the same checks with
+--strict, and refuse warnings too.`,
+	Example: `  runpodctl template lint -f tpl.yaml
+  runpodctl template lint <template-id>
+  runpodctl template from-compose compose.yaml --service api | runpodctl template lint -f -`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLint,
+}
+
+var lintFile string
+
+func init() {
+	lintCmd.Flags().StringVarP(&lintFile, "file", "f", "", "yaml file of templates to check, or - for stdin")
+}
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityInfo    = "info"
+)
+
+// lintFinding is one problem in a template. field uses the yaml names of
+// template export.
+type lintFinding struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+type lintResult struct {
+	Template string        `json:"template"`
+	ID       string        `json:"id,omitempty"`
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Findings []lintFinding `json:"findings"`
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if (lintFile == "") == (len(args) == 0) {
+		return fmt.Errorf("pass either a template id or -f <file>")
+	}
+
+	var results []*lintResult
+	if lintFile != "" {
+		specs, err := decodeTemplateSpecs(lintFile)
+		if err != nil {
+			return err
+		}
+		for _, spec := range specs {
+			results = append(results, newLintResult(spec, ""))
+		}
+	} else {
+		client, err := api.NewClient()
+		if err != nil {
+			output.Error(err)
+			return err
+		}
+		template, err := client.GetTemplate(args[0])
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to get template: %w", err)
+		}
+		results = append(results, newLintResult(specFromTemplate(template), template.ID))
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	if err := output.Print(results, &output.Config{Format: format}); err != nil {
+		return err
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Errors > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d templates have lint errors", failed, len(results))
+	}
+	return nil
+}
+
+func newLintResult(spec *templateSpec, id string) *lintResult {
+	result := &lintResult{Template: spec.Name, ID: id, Findings: lintTemplate(spec)}
+	for _, finding := range result.Findings {
+		switch finding.Severity {
+		case severityError:
+			result.Errors++
+		case severityWarning:
+			result.Warnings++
+		}
+	}
+	return result
+}
+
+// lintStrict runs the lint checks for create and update --strict. errors
+// and warnings are written to w and fail the command; info findings don't.
+func lintStrict(w io.Writer, spec *templateSpec) error {
+	failed := 0
+	for _, finding := range lintTemplate(spec) {
+		if finding.Severity == severityInfo {
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "%s: %s (%s)\n", finding.Severity, finding.Message, finding.Rule)
+	}
+	if failed > 0 {
+		return fmt.Errorf("template has %d lint findings; fix them or drop --strict", failed)
+	}
+	return nil
+}
+
+var (
+	// secretNamePattern matches env names that usually hold credentials
+	secretNamePattern = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|API_?KEY|PRIVATE_KEY|ACCESS_KEY|CREDENTIALS?)`)
+	// secretValuePattern matches well-known token formats under any name
+	secretValuePattern = regexp.MustCompile(`^(hf_[A-Za-z0-9]{20,}|sk-[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{20,}|AKIA[0-9A-Z]{16}|rpa_[A-Za-z0-9]{20,})`)
+)
+
+// lintTemplate checks a template and returns its findings, errors first in
+// field order
+func lintTemplate(spec *templateSpec) []lintFinding {
+	var findings []lintFinding
+	add := func(severity, rule, field, format string, args ...interface{}) {
+		findings = append(findings, lintFinding{Severity: severity, Rule: rule, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(spec.Name) == "" {
+		add(severityError, "name-required", "name", "the template has no name")
+	}
+	if strings.TrimSpace(spec.ImageName) == "" {
+		add(severityError, "image-required", "imageName", "the template has no image")
+	} else if ref, err := registry.ParseReference(spec.ImageName); err != nil {
+		add(severityError, "image-reference", "imageName", "%v", err)
+	} else if ref.Digest == "" && ref.Tag == "latest" {
+		add(severityWarning, "image-tag", "imageName", "%s uses latest, which can change under running pods and workers; pin a tag or digest", spec.ImageName)
+	}
+
+	portsValid := true
+	protocols := map[string]string{}
+	for i, raw := range spec.Ports {
+		field := fmt.Sprintf("ports[%d]", i)
+		port, err := api.NormalizePort(raw)
+		if err != nil {
+			add(severityError, "port-syntax", field, "%v", err)
+			portsValid = false
+			continue
+		}
+		_, protocol, hasProtocol := strings.Cut(strings.TrimSpace(raw), "/")
+		protocol = strings.ToLower(strings.TrimSpace(protocol))
+		if previous, seen := protocols[port]; seen {
+			add(severityError, "duplicate-port", field, "port %s is listed more than once (as %s and %s)", port, previous, raw)
+		}
+		protocols[port] = raw
+		if !hasProtocol {
+			add(severityWarning, "port-protocol", field, "port %s has no protocol; use %s/http or %s/tcp", port, port, port)
+		}
+		if port == "22" && protocol == "http" {
+			add(severityError, "ssh-over-http", field, "22/http goes through the http proxy, which ssh can't use; expose 22/tcp")
+		}
+	}
+
+	if len(spec.PortLabels) > 0 {
+		// labels go through the --port-labels parser, so lint agrees with it
+		encoded, _ := json.Marshal(spec.PortLabels)
+		labels, err := parsePortLabels(string(encoded))
+		if err != nil {
+			add(severityError, "port-label", "portLabels", "%v", err)
+		} else if portsValid {
+			if err := validatePortLabelsAgainstPorts(labels, strings.Join(spec.Ports, ",")); err != nil {
+				add(severityError, "port-label", "portLabels", "%s", strings.Replace(err.Error(), "--ports", "ports", 1))
+			}
+		}
+	}
+
+	for _, key := range envvars.Keys(spec.Env) {
+		field, value := "env."+key, spec.Env[key]
+		if !envvars.ValidKey(key) {
+			add(severityError, "env-name", field, "%q is not a valid env name; use letters, digits and _, not starting with a digit", key)
+		}
+		if strings.Contains(value, "RUNPOD_SECRET_") {
+			continue
+		}
+		if secretValuePattern.MatchString(value) || (secretNamePattern.MatchString(key) && len(value) >= 8) {
+			add(severityWarning, "plaintext-secret", field, "%s looks like a secret in plain text; store it with runpodctl secret create and set %s", key, envvars.SecretReference("<name>"))
+		}
+	}
+
+	if spec.ContainerDiskInGb < 1 {
+		add(severityError, "container-disk", "containerDiskInGb", "the container disk must be at least 1 gb")
+	}
+	if spec.VolumeInGb < 0 {
+		add(severityError, "volume", "volumeInGb", "the volume size can't be negative")
+	}
+
+	if spec.IsServerless {
+		if len(spec.DockerStartCmd) == 0 && len(spec.DockerEntrypoint) == 0 {
+			add(severityWarning, "serverless-start-cmd", "dockerStartCmd", "the serverless template has no start command; workers run the image's default command, which must start the handler")
+		}
+		if spec.VolumeInGb > 0 {
+			add(severityWarning, "serverless-volume", "volumeInGb", "serverless workers don't get a pod volume; attach a network volume to the endpoint instead")
+		}
+	} else {
+		mountPath := strings.TrimSpace(spec.VolumeMountPath)
+		switch {
+		case spec.VolumeInGb > 0 && mountPath == "":
+			add(severityError, "volume-mount-path", "volumeMountPath", "volumeInGb is set but volumeMountPath is empty, so the volume is never mounted")
+		case mountPath != "" && !strings.HasPrefix(mountPath, "/"):
+			add(severityError, "volume-mount-path", "volumeMountPath", "volumeMountPath %q must be an absolute path", mountPath)
+		case mountPath != "" && spec.VolumeInGb == 0:
+			add(severityInfo, "volume-mount-path", "volumeMountPath", "volumeMountPath is set but volumeInGb is 0, so only a network volume is mounted there")
+		}
+	}
+
+	// errors first, keeping field order within a severity
+	rank := map[string]int{severityError: 0, severityWarning: 1, severityInfo: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank[findings[i].Severity] < rank[findings[j].Severity]
+	})
+	return findings
+}
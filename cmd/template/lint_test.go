@@ -0,0 +1,141 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/api"
+)
+
+func lintRules(findings []lintFinding) string {
+	var rules []string
+	for _, finding := range findings {
+		rules = append(rules, finding.Severity+":"+finding.Rule)
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, ",")
+}
+
+func TestLintTemplate(t *testing.T) {
+	clean := func() *templateSpec {
+		return &templateSpec{
+			Name:              "api",
+			ImageName:         "ghcr.io/team/api:v2",
+			Ports:             []string{"8000/http", "22/tcp"},
+			PortLabels:        []api.TemplatePortConfig{{Port: "8000", Name: "api"}},
+			Env:               map[string]string{"HF_TOKEN": "{{ RUNPOD_SECRET_hf }}", "MODE": "prod"},
+			ContainerDiskInGb: 20,
+			VolumeInGb:        50,
+			VolumeMountPath:   "/workspace",
+		}
+	}
+	cases := []struct {
+		name   string
+		modify func(*templateSpec)
+		want   string
+	}{
+		{"clean", func(*templateSpec) {}, ""},
+		{"latest image", func(s *templateSpec) { s.ImageName = "team/api" }, "warning:image-tag"},
+		{"invalid image", func(s *templateSpec) { s.ImageName = "Team/API:v1" }, "error:image-reference"},
+		{"duplicate port", func(s *templateSpec) { s.Ports = append(s.Ports, "8000/tcp") }, "error:duplicate-port"},
+		{"ssh over http", func(s *templateSpec) { s.Ports = []string{"8000/http", "22/http"} }, "error:ssh-over-http"},
+		{"no protocol", func(s *templateSpec) { s.Ports = []string{"8000"} }, "warning:port-protocol"},
+		{"bad port", func(s *templateSpec) { s.Ports = []string{"8000/http", "70000/tcp"} }, "error:port-syntax"},
+		{"label without port", func(s *templateSpec) { s.Ports = []string{"22/tcp"} }, "error:port-label"},
+		{"env name", func(s *templateSpec) { s.Env["1BAD-NAME"] = "x" }, "error:env-name"},
+		{"secret by name", func(s *templateSpec) { s.Env["DB_PASSWORD"] = "hunter2hunter2" }, "warning:plaintext-secret"},
+		{"secret by value", func(s *templateSpec) { s.Env["X"] = "hf_abcdefghijklmnopqrstuvwx" }, "warning:plaintext-secret"},
+		{"flag not a secret", func(s *templateSpec) { s.Env["USE_AUTH_TOKEN"] = "true" }, ""},
+		{"no disk", func(s *templateSpec) { s.ContainerDiskInGb = 0 }, "error:container-disk"},
+		{"volume not mounted", func(s *templateSpec) { s.VolumeMountPath = "" }, "error:volume-mount-path"},
+		{"relative mount", func(s *templateSpec) { s.VolumeMountPath = "workspace" }, "error:volume-mount-path"},
+		{"mount without volume", func(s *templateSpec) { s.VolumeInGb = 0 }, "info:volume-mount-path"},
+		{"serverless", func(s *templateSpec) { s.IsServerless = true }, "warning:serverless-start-cmd,warning:serverless-volume"},
+		{"serverless with command", func(s *templateSpec) {
+			s.IsServerless, s.VolumeInGb, s.DockerStartCmd = true, 0, []string{"python -u handler.py"}
+		}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := clean()
+			tc.modify(spec)
+			if got := lintRules(lintTemplate(spec)); got != tc.want {
+				t.Fatalf("findings = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	findings := lintTemplate(&templateSpec{Name: "x", ImageName: "app", Ports: []string{"22/http"}})
+	if findings[0].Severity != severityError || findings[len(findings)-1].Severity != severityWarning {
+		t.Fatalf("expected errors before warnings, got %+v", findings)
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	original := lintFile
+	t.Cleanup(func() { lintFile = original })
+	lintFile = filepath.Join(t.TempDir(), "templates.yaml")
+	content := `name: good
+imageName: ghcr.io/team/api:v2
+ports: [8000/http]
+containerDiskInGb: 20
+---
+name: bad
+imageName: ghcr.io/team/worker:v1
+isServerless: true
+ports: [22/http]
+containerDiskInGb: 20
+`
+	if err := os.WriteFile(lintFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, err := runTemplateCmd(t, runLint)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 templates") {
+		t.Fatalf("expected a lint failure, got %v", err)
+	}
+	var results []lintResult
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if len(results) != 2 || results[0].Errors != 0 || results[1].Errors != 1 || results[1].Warnings != 1 {
+		t.Fatalf("unexpected results %s", stdout)
+	}
+	if finding := results[1].Findings[0]; finding.Rule != "ssh-over-http" || finding.Field != "ports[0]" {
+		t.Fatalf("unexpected finding %+v", finding)
+	}
+
+	startTemplateAPI(t, []*api.Template{sourceTemplate()})
+	lintFile = ""
+	stdout, err = runTemplateCmd(t, runLint, "tpl-1")
+	if err != nil || !strings.Contains(stdout, `"id": "tpl-1"`) {
+		t.Fatalf("lint by id: %v\n%s", err, stdout)
+	}
+}
+
+func TestRunCreate_Strict(t *testing.T) {
+	name, image, env, strict, skip := createName, createImageName, createEnv, createStrict, createSkipImageCheck
+	t.Cleanup(func() {
+		createName, createImageName, createEnv, createStrict, createSkipImageCheck = name, image, env, strict, skip
+	})
+	fake := startTemplateAPI(t, nil)
+	createName, createImageName, createSkipImageCheck = "api", "ghcr.io/team/api:v2", true
+	createEnv = `{"HF_TOKEN":"hf_abcdefghijklmnopqrstuvwx"}`
+
+	createStrict = true
+	if _, err := runTemplateCmd(t, runCreate); err == nil || !strings.Contains(err.Error(), "--strict") {
+		t.Fatalf("expected --strict to refuse a plaintext secret, got %v", err)
+	}
+	if len(fake.created) != 0 {
+		t.Fatal("expected nothing to be created")
+	}
+
+	createStrict = false
+	if _, err := runTemplateCmd(t, runCreate); err != nil || len(fake.created) != 1 {
+		t.Fatalf("expected the template to be created without --strict: %v", err)
+	}
+}
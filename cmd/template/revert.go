@@ -0,0 +1,80 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
+
+	"github.com/spf13/cobra"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert <template-id>",
+	Short: "restore a template to a recorded revision",
+	Long: `write a template back to its state after a revision from template history,
+in one graphql saveTemplate that includes port labels. to undo revision 5,
+revert to 4.
+
+the revert is recorded like any other change, so it can be reverted too.`,
+	Example: `  runpodctl template history <template-id>
+  runpodctl template revert <template-id> --to 4`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRevert,
+}
+
+var revertTo string
+
+func init() {
+	revertCmd.Flags().StringVar(&revertTo, "to", "", "revision to restore (required)")
+
+	revertCmd.MarkFlagRequired("to") //nolint:errcheck
+}
+
+func runRevert(cmd *cobra.Command, args []string) error {
+	templateID := args[0]
+	revisions, err := templatehistory.Load(templateID)
+	if err != nil {
+		return err
+	}
+	revision, err := findTemplateRevision(revisions, revertTo)
+	if err != nil {
+		return err
+	}
+	target := revision.After
+	if target == nil {
+		return fmt.Errorf("revision %d deleted template %s; there is no state to restore", revision.Rev, templateID)
+	}
+
+	client, err := api.NewGraphQLClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	current, err := client.GetTemplateState(templateID)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to get template: %w", err)
+	}
+	if current.IsServerless != target.IsServerless {
+		return fmt.Errorf("revision %d is a different kind of template (serverless %t); it can't be restored over this one", revision.Rev, target.IsServerless)
+	}
+	if err := client.RestoreTemplateState(templateID, target); err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to revert template: %w", err)
+	}
+	templatehistory.Record(cmd.ErrOrStderr(), "revert", templateID, current)
+
+	changed := []string{}
+	for _, change := range diffTemplateStates(current, target) {
+		changed = append(changed, change.Field)
+	}
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"reverted": true,
+		"id":       templateID,
+		"to":       revision.Rev,
+		"changed":  changed,
+	}, &output.Config{Format: format})
+}
@@ -0,0 +1,206 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/api"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateSpec is a template as written by template export and read by
+// template import. the registry auth is referenced by name, because auth
+// ids differ between accounts.
+type templateSpec struct {
+	Name              string                   `yaml:"name"`
+	ImageName         string                   `yaml:"imageName"`
+	IsServerless      bool                     `yaml:"isServerless,omitempty"`
+	Ports             []string                 `yaml:"ports,omitempty"`
+	PortLabels        []api.TemplatePortConfig `yaml:"portLabels,omitempty"`
+	DockerEntrypoint  []string                 `yaml:"dockerEntrypoint,omitempty"`
+	DockerStartCmd    []string                 `yaml:"dockerStartCmd,omitempty"`
+	Env               map[string]string        `yaml:"env,omitempty"`
+	ContainerDiskInGb int                      `yaml:"containerDiskInGb,omitempty"`
+	VolumeInGb        int                      `yaml:"volumeInGb,omitempty"`
+	VolumeMountPath   string                   `yaml:"volumeMountPath,omitempty"`
+	Readme            string                   `yaml:"readme,omitempty"`
+	RegistryAuth      string                   `yaml:"registryAuth,omitempty"`
+}
+
+// specFromTemplate copies the portable fields of a template. the registry
+// auth is left for the caller to resolve.
+func specFromTemplate(template *api.Template) *templateSpec {
+	spec := &templateSpec{
+		Name:              template.Name,
+		ImageName:         template.ImageName,
+		IsServerless:      template.IsServerless,
+		Ports:             template.Ports,
+		PortLabels:        template.PortsConfig,
+		DockerEntrypoint:  template.DockerEntrypoint,
+		DockerStartCmd:    template.DockerStartCmd,
+		Env:               template.Env,
+		ContainerDiskInGb: template.ContainerDiskInGb,
+		Readme:            template.Readme,
+	}
+	// serverless templates have no volume
+	if !template.IsServerless {
+		spec.VolumeInGb = template.VolumeInGb
+		spec.VolumeMountPath = template.VolumeMountPath
+	}
+	return spec
+}
+
+// specFromCreateRequest describes a template about to be created, for
+// create --strict to lint
+func specFromCreateRequest(req *api.TemplateCreateRequest, portLabels []api.TemplatePortConfig) *templateSpec {
+	return &templateSpec{
+		Name:              req.Name,
+		ImageName:         req.ImageName,
+		IsServerless:      req.IsServerless,
+		Ports:             req.Ports,
+		PortLabels:        portLabels,
+		DockerEntrypoint:  req.DockerEntrypoint,
+		DockerStartCmd:    req.DockerStartCmd,
+		Env:               req.Env,
+		ContainerDiskInGb: req.ContainerDiskInGb,
+		VolumeInGb:        req.VolumeInGb,
+		VolumeMountPath:   req.VolumeMountPath,
+		Readme:            req.Readme,
+	}
+}
+
+// validate checks the fields the api would reject, and normalizes the port
+// labels the way --port-labels does
+func (s *templateSpec) validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("template has no name")
+	}
+	if strings.TrimSpace(s.ImageName) == "" {
+		return fmt.Errorf("template %s has no imageName", s.Name)
+	}
+	seen := map[string]bool{}
+	for i, label := range s.PortLabels {
+		port, err := api.NormalizePort(label.Port)
+		if err != nil {
+			return fmt.Errorf("template %s: %w", s.Name, err)
+		}
+		name := strings.TrimSpace(label.Name)
+		if name == "" {
+			return fmt.Errorf("template %s: port label name is required for port %s", s.Name, port)
+		}
+		if seen[port] {
+			return fmt.Errorf("template %s: duplicate port label for %s", s.Name, port)
+		}
+		seen[port] = true
+		s.PortLabels[i] = api.TemplatePortConfig{Port: port, Name: name}
+	}
+	if err := validatePortLabelsAgainstPorts(s.PortLabels, strings.Join(s.Ports, ",")); err != nil {
+		return fmt.Errorf("template %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// createRequest builds the rest create request, with the registry auth id
+// already resolved for the target account
+func (s *templateSpec) createRequest(registryAuthID string) *api.TemplateCreateRequest {
+	req := &api.TemplateCreateRequest{
+		Name:                    s.Name,
+		ImageName:               s.ImageName,
+		IsServerless:            s.IsServerless,
+		Ports:                   s.Ports,
+		DockerEntrypoint:        s.DockerEntrypoint,
+		DockerStartCmd:          s.DockerStartCmd,
+		Env:                     s.Env,
+		ContainerDiskInGb:       s.ContainerDiskInGb,
+		ContainerRegistryAuthID: registryAuthID,
+		Readme:                  s.Readme,
+	}
+	if !s.IsServerless {
+		req.VolumeInGb = s.VolumeInGb
+		req.VolumeMountPath = s.VolumeMountPath
+	}
+	return req
+}
+
+// saveOverrides describes the whole spec as a graphql saveTemplate write,
+// so an existing template ends up exactly as the spec says: fields the spec
+// leaves out, like the start command, are cleared
+func (s *templateSpec) saveOverrides(registryAuthID string) *api.TemplatePortLabelOverrides {
+	req := s.createRequest(registryAuthID)
+	env := req.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	ports := req.Ports
+	if ports == nil {
+		ports = []string{}
+	}
+	dockerArgs := api.DockerArgsJSON(req.DockerEntrypoint, req.DockerStartCmd)
+	overrides := &api.TemplatePortLabelOverrides{
+		Name:                    &req.Name,
+		ImageName:               &req.ImageName,
+		Ports:                   &ports,
+		Env:                     &env,
+		ContainerDiskInGb:       &req.ContainerDiskInGb,
+		ContainerRegistryAuthID: &req.ContainerRegistryAuthID,
+		Readme:                  &req.Readme,
+		DockerArgs:              &dockerArgs,
+	}
+	if !req.IsServerless {
+		overrides.VolumeInGb = &req.VolumeInGb
+		overrides.VolumeMountPath = &req.VolumeMountPath
+	}
+	return overrides
+}
+
+// loadTemplateSpecs reads every yaml document of a file and validates them;
+// "-" is stdin. json is read too, being yaml.
+func loadTemplateSpecs(path string) ([]*templateSpec, error) {
+	specs, err := decodeTemplateSpecs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		if err := spec.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return specs, nil
+}
+
+// decodeTemplateSpecs reads every yaml document of a file without
+// validating them, for template lint to report on
+func decodeTemplateSpecs(path string) ([]*templateSpec, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var specs []*templateSpec
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	for {
+		spec := &templateSpec{}
+		err := decoder.Decode(spec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%s has no templates", path)
+	}
+	return specs, nil
+}
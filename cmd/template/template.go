@@ -19,4 +19,13 @@ func init() {
 	Cmd.AddCommand(createCmd)
 	Cmd.AddCommand(updateCmd)
 	Cmd.AddCommand(deleteCmd)
+	Cmd.AddCommand(envCmd)
+	Cmd.AddCommand(exportCmd)
+	Cmd.AddCommand(importCmd)
+	Cmd.AddCommand(cloneCmd)
+	Cmd.AddCommand(fromComposeCmd)
+	Cmd.AddCommand(lintCmd)
+	Cmd.AddCommand(historyCmd)
+	Cmd.AddCommand(diffCmd)
+	Cmd.AddCommand(revertCmd)
 }
@@ -28,7 +28,7 @@ func TestTemplateCmd_Structure(t *testing.T) {
 	}
 
 	// check subcommands
-	expectedSubcommands := []string{"list", "get <template-id>", "create", "update <template-id>", "delete <template-id>"}
+	expectedSubcommands := []string{"list", "get <template-id>", "create", "update <template-id>", "delete <template-id>", "export <template-id>...", "import", "clone <template-id>", "from-compose <compose-file>", "lint [<template-id>]", "history <template-id>", "diff <template-id> [rev1] [rev2]", "revert <template-id>"}
 	for _, expected := range expectedSubcommands {
 		found := false
 		for _, cmd := range Cmd.Commands() {
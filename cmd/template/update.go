@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/envvars"
 	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/templatehistory"
 
 	"github.com/spf13/cobra"
 )
@@ -27,9 +29,11 @@ var (
 	updatePorts             string
 	updatePortLabels        string
 	updateEnv               string
+	updateEnvFile           string
 	updateReadme            string
 	updateContainerDiskInGb int
 	updateRegistryAuthID    string
+	updateStrict            bool
 )
 
 func init() {
@@ -37,10 +41,12 @@ func init() {
 	updateCmd.Flags().StringVar(&updateImageName, "image", "", "new docker image name")
 	updateCmd.Flags().StringVar(&updatePorts, "ports", "", "new comma-separated list of ports")
 	updateCmd.Flags().StringVar(&updatePortLabels, "port-labels", "", "new port labels as comma-separated port=name pairs, or json when a name contains a comma; pass an empty value to clear")
-	updateCmd.Flags().StringVar(&updateEnv, "env", "", "new environment variables as json object")
+	updateCmd.Flags().StringVar(&updateEnv, "env", "", "environment variables as json object, merged into the existing env")
+	updateCmd.Flags().StringVar(&updateEnvFile, "env-file", "", "read environment variables from a dotenv file, merged into the existing env (--env takes precedence)")
 	updateCmd.Flags().StringVar(&updateReadme, "readme", "", "new readme content")
 	updateCmd.Flags().IntVar(&updateContainerDiskInGb, "container-disk-in-gb", -1, "new container disk size in gb")
 	updateCmd.Flags().StringVar(&updateRegistryAuthID, "registry-auth-id", "", "new container registry auth id; pass an empty value to clear")
+	updateCmd.Flags().BoolVar(&updateStrict, "strict", false, "run the template lint checks on the updated template first, and refuse errors and warnings")
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
@@ -77,12 +83,23 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if updatePorts != "" {
 		req.Ports = strings.Split(updatePorts, ",")
 	}
-	if updateEnv != "" {
+	if updateEnv != "" || updateEnvFile != "" {
 		var env map[string]string
-		if err := json.Unmarshal([]byte(updateEnv), &env); err != nil {
-			return fmt.Errorf("invalid env json: %w", err)
+		if updateEnv != "" {
+			if err := json.Unmarshal([]byte(updateEnv), &env); err != nil {
+				return fmt.Errorf("invalid env json: %w", err)
+			}
+		}
+		env, err = envvars.Load(updateEnvFile, env)
+		if err != nil {
+			return err
 		}
-		req.Env = env
+		// the rest patch replaces the whole env map; merge so other keys survive.
+		existing, err := client.GetTemplate(templateID)
+		if err != nil {
+			return fmt.Errorf("failed to get existing template env: %w", err)
+		}
+		req.Env = envvars.Merge(existing.Env, env)
 	}
 	if updateReadme != "" {
 		req.Readme = updateReadme
@@ -96,11 +113,29 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		req.ContainerRegistryAuthID = &value
 	}
 
+	if updateStrict {
+		current, err := client.GetTemplate(templateID)
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to get template: %w", err)
+		}
+		spec := specFromTemplate(current)
+		applyUpdateRequest(spec, req)
+		if portLabelsChanged {
+			spec.PortLabels = portLabels
+		}
+		if err := lintStrict(cmd.ErrOrStderr(), spec); err != nil {
+			output.Error(err)
+			return err
+		}
+	}
+
 	// If only --port-labels was given, there is no REST field to PATCH; just
 	// fetch the current template so the GraphQL save below has fresh state.
 	hasRESTUpdate := req.Name != "" || req.ImageName != "" || req.Ports != nil || req.Env != nil ||
 		req.Readme != "" || req.ContainerDiskInGb != nil || req.ContainerRegistryAuthID != nil
 
+	before := templatehistory.ReadState(cmd.ErrOrStderr(), templateID)
 	var template *api.Template
 	if portLabelsChanged && !hasRESTUpdate {
 		template, err = client.GetTemplate(templateID)
@@ -127,11 +162,35 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if req.ContainerRegistryAuthID != nil {
 		template.ContainerRegistryAuthID = *req.ContainerRegistryAuthID
 	}
+	templatehistory.Record(cmd.ErrOrStderr(), "update", templateID, before)
 
 	format := output.ParseFormat(cmd.Flag("output").Value.String())
 	return output.Print(template, &output.Config{Format: format})
 }
 
+// applyUpdateRequest sets the fields an update changes on a template spec,
+// so update --strict lints the template as it will be
+func applyUpdateRequest(spec *templateSpec, req *api.TemplateUpdateRequest) {
+	if req.Name != "" {
+		spec.Name = req.Name
+	}
+	if req.ImageName != "" {
+		spec.ImageName = req.ImageName
+	}
+	if req.Ports != nil {
+		spec.Ports = req.Ports
+	}
+	if req.Env != nil {
+		spec.Env = req.Env
+	}
+	if req.Readme != "" {
+		spec.Readme = req.Readme
+	}
+	if req.ContainerDiskInGb != nil {
+		spec.ContainerDiskInGb = *req.ContainerDiskInGb
+	}
+}
+
 func updatePortLabelOverrides(req *api.TemplateUpdateRequest) *api.TemplatePortLabelOverrides {
 	overrides := &api.TemplatePortLabelOverrides{
 		ContainerDiskInGb:       req.ContainerDiskInGb,
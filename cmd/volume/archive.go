@@ -0,0 +1,135 @@
+package volume
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/klauspost/compress/zstd"
+)
+
+// backupManifestName is the last entry of a backup archive
+const backupManifestName = ".runpod-backup.json"
+
+// backupManifest lists the files in a backup, with checksums to verify a
+// restore against
+type backupManifest struct {
+	VolumeID     string       `json:"volumeId"`
+	VolumeName   string       `json:"volumeName"`
+	DataCenterID string       `json:"dataCenterId"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	Files        []backupFile `json:"files"`
+}
+
+type backupFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Modified time.Time `json:"modified"`
+}
+
+// archiveCompression picks the compression of an archive from its name
+func archiveCompression(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return "zstd", nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "gzip", nil
+	case strings.HasSuffix(name, ".tar"):
+		return "", nil
+	}
+	return "", fmt.Errorf("unsupported archive %s; use .tar.zst, .tar.gz or .tar", name)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// compressWriter wraps w in the compression the archive name asks for
+func compressWriter(name string, w io.Writer) (io.WriteCloser, error) {
+	compression, err := archiveCompression(name)
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case "zstd":
+		return zstd.NewWriter(w)
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// decompressReader wraps r in the decompression the archive name asks for
+func decompressReader(name string, r io.Reader) (io.ReadCloser, error) {
+	compression, err := archiveCompression(name)
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case "zstd":
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case "gzip":
+		return gzip.NewReader(r)
+	}
+	return io.NopCloser(r), nil
+}
+
+// pathFilter selects files by include and exclude globs. a pattern matches
+// a file or any directory above it; * stays within a directory and **
+// crosses directories.
+type pathFilter struct {
+	include []glob.Glob
+	exclude []glob.Glob
+}
+
+func newPathFilter(include, exclude []string) (*pathFilter, error) {
+	var err error
+	filter := &pathFilter{}
+	if filter.include, err = compileGlobs(include); err != nil {
+		return nil, err
+	}
+	if filter.exclude, err = compileGlobs(exclude); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func compileGlobs(patterns []string) ([]glob.Glob, error) {
+	var globs []glob.Glob
+	for _, pattern := range patterns {
+		compiled, err := glob.Compile(strings.Trim(pattern, "/"), '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		globs = append(globs, compiled)
+	}
+	return globs, nil
+}
+
+// match reports whether a slash-separated relative path is selected
+func (f *pathFilter) match(name string) bool {
+	if len(f.include) > 0 && !matchesPathOrParent(f.include, name) {
+		return false
+	}
+	return !matchesPathOrParent(f.exclude, name)
+}
+
+func matchesPathOrParent(globs []glob.Glob, name string) bool {
+	for p := name; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		for _, g := range globs {
+			if g.Match(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
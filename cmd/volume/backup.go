@@ -0,0 +1,198 @@
+package volume
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupTo      string
+	backupInclude []string
+	backupExclude []string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <volume>[:/path]",
+	Short: "back up a network volume to a local archive",
+	Long: `stream the files of a network volume into a local tar archive, compressed
+by the archive's extension: .tar.zst, .tar.gz or .tar.
+
+the archive ends with a manifest of every file's size and sha256, which
+network-volume restore checks; a copy is written next to the archive as
+<archive>.manifest.json. --include and --exclude take globs relative to the
+backed up path, where * stays within a directory and ** crosses them.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume backup my-volume --to ./backup.tar.zst
+  runpodctl network-volume backup abc123:/models --to models.tar.gz --exclude '**/*.tmp'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBackup,
+}
+
+func init() {
+	backupCmd.Flags().StringVar(&backupTo, "to", "", "archive to write (required)")
+	backupCmd.Flags().StringArrayVar(&backupInclude, "include", nil, "only back up paths matching this glob (repeatable)")
+	backupCmd.Flags().StringArrayVar(&backupExclude, "exclude", nil, "skip paths matching this glob (repeatable)")
+
+	backupCmd.MarkFlagRequired("to") //nolint:errcheck
+}
+
+type backupResult struct {
+	VolumeID     string `json:"volumeId"`
+	Archive      string `json:"archive"`
+	Manifest     string `json:"manifest"`
+	Files        int    `json:"files"`
+	Bytes        int64  `json:"bytes"`
+	ArchiveBytes int64  `json:"archiveBytes"`
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	if _, err := archiveCompression(backupTo); err != nil {
+		return err
+	}
+	filter, err := newPathFilter(backupInclude, backupExclude)
+	if err != nil {
+		return err
+	}
+	target := parseVolumeArg(args[0])
+	files, err := openVolume(target.Volume)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	ctx, stop := filesContext()
+	defer stop()
+
+	objects, _, err := files.client.List(ctx, files.bucket(), target.prefix(), "")
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to list %s: %w", target, err)
+	}
+	var keys []string
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.Key, target.prefix())
+		if name != "" && !strings.HasSuffix(name, "/") && filter.match(name) {
+			keys = append(keys, object.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no files to back up in %s", target)
+	}
+
+	// write next to the archive and rename at the end, so an interrupted
+	// backup never leaves a truncated archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(backupTo), "."+filepath.Base(backupTo)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	compressed, err := compressWriter(backupTo, tmp)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	archive := tar.NewWriter(compressed)
+
+	manifest := &backupManifest{
+		VolumeID:     files.volume.ID,
+		VolumeName:   files.volume.Name,
+		DataCenterID: files.volume.DataCenterID,
+		CreatedAt:    time.Now().UTC(),
+		Files:        []backupFile{},
+	}
+	result := &backupResult{VolumeID: files.volume.ID, Archive: backupTo, Manifest: backupTo + ".manifest.json"}
+	for i, key := range keys {
+		file, err := backupObject(ctx, files, archive, key, strings.TrimPrefix(key, target.prefix()))
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		manifest.Files = append(manifest.Files, *file)
+		result.Files++
+		result.Bytes += file.Size
+		fmt.Fprintf(cmd.ErrOrStderr(), "[%d/%d] %s (%s)\n", i+1, len(keys), file.Path, output.Bytes(result.Bytes))
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = archive.WriteHeader(&tar.Header{
+		Name:     backupManifestName,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  manifest.CreatedAt,
+		Typeflag: tar.TypeReg,
+	})
+	if err == nil {
+		_, err = archive.Write(data)
+	}
+	for _, closer := range []io.Closer{archive, compressed, tmp} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", backupTo, err)
+	}
+	if err := os.Rename(tmp.Name(), backupTo); err != nil {
+		return err
+	}
+	if err := os.WriteFile(result.Manifest, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if info, err := os.Stat(backupTo); err == nil {
+		result.ArchiveBytes = info.Size()
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+// backupObject streams one file into the archive under name
+func backupObject(ctx context.Context, files *volumeFiles, archive *tar.Writer, key, name string) (*backupFile, error) {
+	body, object, err := files.client.Get(ctx, files.bucket(), key)
+	if err != nil {
+		output.Error(err)
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer body.Close()
+	if object.Size < 0 {
+		return nil, fmt.Errorf("failed to read %s: unknown size", key)
+	}
+
+	err = archive.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     object.Size,
+		ModTime:  object.LastModified,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(archive, io.TeeReader(body, hash)); err != nil {
+		return nil, fmt.Errorf("failed to back up %s: %w", key, err)
+	}
+	return &backupFile{
+		Path:     name,
+		Size:     object.Size,
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+		Modified: object.LastModified.UTC(),
+	}, nil
+}
@@ -0,0 +1,149 @@
+package volume
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func resetBackupVars(t *testing.T) {
+	t.Helper()
+	to, bInclude, bExclude := backupTo, backupInclude, backupExclude
+	from, rInclude, rExclude := restoreFrom, restoreInclude, restoreExclude
+	t.Cleanup(func() {
+		backupTo, backupInclude, backupExclude = to, bInclude, bExclude
+		restoreFrom, restoreInclude, restoreExclude = from, rInclude, rExclude
+	})
+}
+
+func TestPathFilter(t *testing.T) {
+	filter, err := newPathFilter([]string{"models/", "*.txt"}, []string{"**/*.tmp", "models/cache"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"readme.txt":          true,
+		"notes/readme.txt":    false,
+		"models/llama/w.bin":  true,
+		"models/llama/w.tmp":  false,
+		"models/cache/x.bin":  false,
+		"outputs/result.png":  false,
+		"models/cache-keep/x": true,
+	} {
+		if got := filter.match(name); got != want {
+			t.Errorf("match(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if _, err := newPathFilter([]string{"[oops"}, nil); err == nil {
+		t.Error("expected an invalid pattern error")
+	}
+}
+
+func TestRunBackupAndRestore(t *testing.T) {
+	for _, archive := range []string{"backup.tar.zst", "backup.tar.gz", "backup.tar"} {
+		t.Run(archive, func(t *testing.T) {
+			server := startVolumeFiles(t)
+			resetBackupVars(t)
+			server.Put("vol-1", "models/a.bin", []byte("weights"))
+			server.Put("vol-1", "models/sub/b.bin", []byte("more weights"))
+			server.Put("vol-1", "models/scratch.tmp", []byte("skip"))
+			server.Put("vol-1", "outputs/x.png", []byte("png"))
+
+			backupTo = filepath.Join(t.TempDir(), archive)
+			backupExclude = []string{"**/*.tmp"}
+			stdout, stderr, err := runFilesCmd(t, runBackup, "models")
+			if err != nil {
+				t.Fatalf("backup: %v\n%s", err, stderr)
+			}
+			var result backupResult
+			if err := json.Unmarshal([]byte(stdout), &result); err != nil || result.Files != 3 || result.Bytes != 22 {
+				t.Fatalf("unexpected result %s (%v)", stdout, err)
+			}
+			var manifest backupManifest
+			data, _ := os.ReadFile(backupTo + ".manifest.json")
+			if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Files) != 3 || manifest.VolumeID != "vol-1" {
+				t.Fatalf("unexpected manifest %s (%v)", data, err)
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(backupTo)); len(entries) != 2 {
+				t.Fatalf("expected the archive and manifest only, got %v", entries)
+			}
+
+			restoreFrom = backupTo
+			restoreInclude = []string{"models"}
+			stdout, stderr, err = runFilesCmd(t, runRestore, "vol-2:/restored")
+			if err != nil {
+				t.Fatalf("restore: %v\n%s", err, stderr)
+			}
+			if keys := strings.Join(server.Keys("vol-2"), ","); keys != "restored/models/a.bin,restored/models/sub/b.bin" {
+				t.Fatalf("unexpected restored keys %s", keys)
+			}
+			if data, _ := server.Get("vol-2", "restored/models/sub/b.bin"); string(data) != "more weights" {
+				t.Fatalf("unexpected restored content %q", data)
+			}
+			if !strings.Contains(stdout, `"verified": true`) {
+				t.Fatalf("expected a verified restore, got %s", stdout)
+			}
+		})
+	}
+}
+
+func TestRunRestore_ChecksManifest(t *testing.T) {
+	server := startVolumeFiles(t)
+	resetBackupVars(t)
+
+	restoreFrom = filepath.Join(t.TempDir(), "tampered.tar")
+	file, err := os.Create(restoreFrom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := tar.NewWriter(file)
+	manifest, _ := json.Marshal(backupManifest{Files: []backupFile{
+		{Path: "a.txt", Size: 5, SHA256: "not-the-checksum"},
+		{Path: "gone.txt", Size: 1, SHA256: "x"},
+	}})
+	for _, entry := range []struct{ name, content string }{{"a.txt", "hello"}, {backupManifestName, string(manifest)}} {
+		_ = archive.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg})
+		_, _ = archive.Write([]byte(entry.content))
+	}
+	archive.Close()
+	file.Close()
+
+	stdout, _, err := runFilesCmd(t, runRestore, "vol-1")
+	if err == nil || !strings.Contains(err.Error(), "2 files don't match") {
+		t.Fatalf("expected manifest mismatches, got %v", err)
+	}
+	if !strings.Contains(stdout, "a.txt: checksum differs") || !strings.Contains(stdout, "gone.txt: missing") {
+		t.Fatalf("unexpected output %s", stdout)
+	}
+	if data, _ := server.Get("vol-1", "a.txt"); string(data) != "hello" {
+		t.Fatalf("expected the file to be restored, got %q", data)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	server := startVolumeFiles(t)
+	server.Put("vol-1", "readme.md", make([]byte, 10))
+	server.Put("vol-1", "models/a.bin", make([]byte, 3000))
+	server.Put("vol-1", "outputs/b.png", make([]byte, 200))
+
+	stdout, _, err := runFilesCmd(t, runUsage, "models")
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	var usage volumeUsage
+	if err := json.Unmarshal([]byte(stdout), &usage); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if usage.ID != "vol-1" || usage.SizeGb != 10 || usage.UsedBytes != 3210 || usage.FreeBytes != 10<<30-3210 {
+		t.Fatalf("unexpected usage %s", stdout)
+	}
+	if usage.RootFiles == nil || usage.RootFiles.Bytes != 10 {
+		t.Fatalf("expected root files to be counted, got %+v", usage.RootFiles)
+	}
+	if len(usage.Directories) != 2 || usage.Directories[0].Path != "/models" || usage.Directories[1].Bytes != 200 {
+		t.Fatalf("unexpected directories %s", stdout)
+	}
+}
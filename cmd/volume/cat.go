@@ -0,0 +1,65 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var catCmd = &cobra.Command{
+	Use:   "cat <volume>:/path...",
+	Short: "print files from a network volume",
+	Long: `stream the contents of files on a network volume to stdout.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume cat my-volume:/logs/train.log | tail`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runCat,
+}
+
+func runCat(cmd *cobra.Command, args []string) error {
+	ctx, stop := filesContext()
+	defer stop()
+
+	opened := map[string]*volumeFiles{}
+	for _, arg := range args {
+		target := parseVolumeArg(arg)
+		if target.Dir {
+			return fmt.Errorf("%s is a directory", target)
+		}
+		files, ok := opened[target.Volume]
+		if !ok {
+			var err error
+			if files, err = openVolume(target.Volume); err != nil {
+				output.Error(err)
+				return err
+			}
+			opened[target.Volume] = files
+		}
+		if err := catFile(ctx, files, target, cmd.OutOrStdout()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func catFile(ctx context.Context, files *volumeFiles, target volumePath, w io.Writer) error {
+	body, _, err := files.client.Get(ctx, files.bucket(), target.Key)
+	if s3.IsNotFound(err) {
+		return fmt.Errorf("%s: no such file", target)
+	}
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	defer body.Close()
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	return nil
+}
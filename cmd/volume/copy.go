@@ -0,0 +1,407 @@
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/s3"
+	"github.com/runpod/runpodctl/internal/statefile"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	copyDataCenterID string
+	copyName         string
+	copySize         int
+	copyWorkers      int
+	copyManifest     string
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy <volume>",
+	Short: "copy a network volume to another datacenter",
+	Long: `create a network volume in another datacenter and copy every file of the
+source volume into it, through the s3 apis of both datacenters.
+
+the new volume is as large as the source, or larger if the files need it.
+an interrupted copy is resumed by running the same command again: files
+already in the destination with the same size and checksum are skipped.
+at the end both volumes are listed and every file is compared by size and,
+where the etags are comparable, by checksum.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume copy models --to-datacenter EU-RO-1
+  runpodctl network-volume copy abc123 --to-datacenter US-KS-2 --name models-ks --manifest manifest.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCopy,
+}
+
+func init() {
+	copyCmd.Flags().StringVar(&copyDataCenterID, "to-datacenter", "", "datacenter to copy the volume to (required)")
+	copyCmd.Flags().StringVar(&copyName, "name", "", "name of the new volume (default <source name>-<datacenter>)")
+	copyCmd.Flags().IntVar(&copySize, "size", 0, "size of the new volume in gb (default: fits the source)")
+	copyCmd.Flags().IntVar(&copyWorkers, "workers", 4, "number of files to copy in parallel")
+	copyCmd.Flags().StringVar(&copyManifest, "manifest", "", "write the checksum manifest to this file")
+
+	copyCmd.MarkFlagRequired("to-datacenter") //nolint:errcheck
+}
+
+// volumeCopyState is an unfinished copy, kept so it can be resumed
+type volumeCopyState struct {
+	SourceID      string    `json:"sourceId"`
+	DestinationID string    `json:"destinationId"`
+	DataCenterID  string    `json:"dataCenterId"`
+	StartedAt     time.Time `json:"startedAt"`
+}
+
+// manifestEntry is one file of a copy. Verified is "checksum" when the
+// etags of both sides could be compared and "size" otherwise.
+type manifestEntry struct {
+	Path            string `json:"path"`
+	Size            int64  `json:"size"`
+	SourceETag      string `json:"sourceEtag"`
+	DestinationETag string `json:"destinationEtag"`
+	Verified        string `json:"verified,omitempty"`
+	Problem         string `json:"problem,omitempty"`
+}
+
+type volumeCopyResult struct {
+	Source      *api.NetworkVolume `json:"source"`
+	Destination *api.NetworkVolume `json:"destination"`
+	Resumed     bool               `json:"resumed,omitempty"`
+	Files       int                `json:"files"`
+	Bytes       int64              `json:"bytes"`
+	Copied      int                `json:"copied"`
+	Skipped     int                `json:"skipped"`
+	Verified    int                `json:"verified"`
+	Mismatches  []manifestEntry    `json:"mismatches,omitempty"`
+	Manifest    string             `json:"manifest,omitempty"`
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	if copyWorkers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	if err := checkS3Key(); err != nil {
+		return err
+	}
+	dataCenterID := strings.ToUpper(copyDataCenterID)
+
+	client, err := api.NewClient()
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	source, err := findVolume(client, args[0])
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	if strings.EqualFold(source.DataCenterID, dataCenterID) {
+		err := fmt.Errorf("volume %s is already in %s; pick another --to-datacenter", source.ID, source.DataCenterID)
+		output.Error(err)
+		return err
+	}
+	src := newVolumeFiles(source)
+
+	ctx, stop := filesContext()
+	defer stop()
+
+	objects, err := listFiles(ctx, src)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, object := range objects {
+		total += object.Size
+	}
+
+	result := &volumeCopyResult{Source: source, Files: len(objects), Bytes: total}
+	destination, resumed, err := copyDestination(cmd, client, source, dataCenterID, total)
+	if err != nil {
+		return err
+	}
+	result.Destination, result.Resumed = destination, resumed
+	dst := newVolumeFiles(destination)
+
+	existing, err := listFiles(ctx, dst)
+	if err != nil {
+		return err
+	}
+	existingByKey := map[string]s3.Object{}
+	for _, object := range existing {
+		existingByKey[object.Key] = object
+	}
+
+	// objects are written whole, so a file already in the destination with
+	// the right size is complete
+	var pending []s3.Object
+	var pendingBytes int64
+	for _, object := range objects {
+		if done, ok := existingByKey[object.Key]; ok && done.Size == object.Size &&
+			(!etagsComparable(object.ETag, done.ETag) || object.ETag == done.ETag) {
+			result.Skipped++
+			continue
+		}
+		pending = append(pending, object)
+		pendingBytes += object.Size
+	}
+	if result.Skipped > 0 {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%d of %d files are already copied\n", result.Skipped, len(objects))
+	}
+
+	// expected holds the etag each copied file should have, from the bytes
+	// read from the source
+	expected := map[string]string{}
+	var mu sync.Mutex
+	var copiedBytes int64
+	err = forEach(ctx, copyWorkers, len(pending), func(ctx context.Context, i int) error {
+		object := pending[i]
+		etag, err := copyObject(ctx, src, dst, object)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		expected[object.Key] = etag
+		result.Copied++
+		copiedBytes += object.Size
+		fmt.Fprintf(cmd.ErrOrStderr(), "[%d/%d] %s (%s of %s)\n", result.Copied, len(pending), object.Key,
+			output.Bytes(copiedBytes), output.Bytes(pendingBytes))
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "interrupted; run the same command again to resume the copy into %s\n", destination.ID)
+		}
+		return err
+	}
+
+	manifest, err := verifyCopy(ctx, objects, dst, expected)
+	if err != nil {
+		return err
+	}
+	for _, entry := range manifest {
+		if entry.Problem != "" {
+			result.Mismatches = append(result.Mismatches, entry)
+		} else {
+			result.Verified++
+		}
+	}
+	if copyManifest != "" {
+		if err := writeManifest(copyManifest, manifest); err != nil {
+			return err
+		}
+		result.Manifest = copyManifest
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	if err := output.Print(result, &output.Config{Format: format}); err != nil {
+		return err
+	}
+	if len(result.Mismatches) > 0 {
+		return fmt.Errorf("%d files differ between %s and %s; run the same command again to copy them", len(result.Mismatches), source.ID, destination.ID)
+	}
+	return forgetVolumeCopy(source.ID, dataCenterID)
+}
+
+// copyDestination returns the volume an earlier run of the same copy
+// created, or creates one
+func copyDestination(cmd *cobra.Command, client *api.Client, source *api.NetworkVolume, dataCenterID string, used int64) (*api.NetworkVolume, bool, error) {
+	states, err := loadVolumeCopies()
+	if err != nil {
+		return nil, false, err
+	}
+	key := volumeCopyKey(source.ID, dataCenterID)
+	if state, ok := states[key]; ok {
+		destination, err := findVolume(client, state.DestinationID)
+		if err == nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "resuming the copy into %s started %s\n", destination.ID, state.StartedAt.Local().Format(time.RFC1123))
+			return destination, true, nil
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "volume %s from an earlier copy is gone; starting over\n", state.DestinationID)
+	}
+
+	// volumes are sized in whole gb; leave a tenth free on top of the files
+	needed := int((used + used/10 + 1<<30 - 1) >> 30)
+	size := max(source.Size, needed)
+	if copySize > 0 {
+		if copySize < needed {
+			return nil, false, fmt.Errorf("--size %d is too small for %s of files; use at least %d", copySize, output.Bytes(used), needed)
+		}
+		size = copySize
+	}
+	name := copyName
+	if name == "" {
+		name = source.Name + "-" + strings.ToLower(dataCenterID)
+	}
+
+	destination, err := client.CreateNetworkVolume(&api.NetworkVolumeCreateRequest{
+		Name:         name,
+		Size:         size,
+		DataCenterID: dataCenterID,
+	})
+	if err != nil {
+		output.Error(err)
+		return nil, false, fmt.Errorf("failed to create volume: %w", err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "created volume %s (%s, %d gb) in %s\n", destination.ID, name, size, dataCenterID)
+
+	states[key] = volumeCopyState{
+		SourceID:      source.ID,
+		DestinationID: destination.ID,
+		DataCenterID:  dataCenterID,
+		StartedAt:     time.Now().UTC(),
+	}
+	if err := saveVolumeCopies(states); err != nil {
+		return nil, false, err
+	}
+	return destination, false, nil
+}
+
+// copyObject streams one file between volumes and returns the etag of the
+// bytes it read, as the destination computes it
+func copyObject(ctx context.Context, src, dst *volumeFiles, object s3.Object) (string, error) {
+	body, _, err := src.client.Get(ctx, src.bucket(), object.Key)
+	if err != nil {
+		output.Error(err)
+		return "", fmt.Errorf("failed to read %s: %w", object.Key, err)
+	}
+	defer body.Close()
+
+	// the bytes sent are hashed as they stream past
+	reader, writer := io.Pipe()
+	etags := make(chan string, 1)
+	go func() {
+		etag, err := dst.client.ETagOf(reader, object.Size)
+		reader.CloseWithError(err)
+		etags <- etag
+	}()
+	err = dst.client.Put(ctx, dst.bucket(), object.Key, io.TeeReader(body, writer), object.Size)
+	writer.CloseWithError(err)
+	etag := <-etags
+	if err != nil {
+		output.Error(err)
+		return "", fmt.Errorf("failed to write %s: %w", object.Key, err)
+	}
+	return etag, nil
+}
+
+// verifyCopy lists the destination and compares it with the source files
+func verifyCopy(ctx context.Context, objects []s3.Object, dst *volumeFiles, expected map[string]string) ([]manifestEntry, error) {
+	copied, err := listFiles(ctx, dst)
+	if err != nil {
+		return nil, err
+	}
+	copiedByKey := map[string]s3.Object{}
+	for _, object := range copied {
+		copiedByKey[object.Key] = object
+	}
+
+	manifest := make([]manifestEntry, 0, len(objects))
+	for _, object := range objects {
+		entry := manifestEntry{Path: "/" + object.Key, Size: object.Size, SourceETag: object.ETag}
+		done, ok := copiedByKey[object.Key]
+		entry.DestinationETag = done.ETag
+		want, read := expected[object.Key]
+		switch {
+		case !ok:
+			entry.Problem = "missing"
+		case done.Size != object.Size:
+			entry.Problem = "size"
+		case read && done.ETag != want:
+			entry.Problem = "checksum"
+		case read && etagsComparable(object.ETag, want) && object.ETag != want:
+			// the bytes read differ from what the source says it holds
+			entry.Problem = "checksum"
+		case etagsComparable(object.ETag, done.ETag) && object.ETag != done.ETag:
+			entry.Problem = "checksum"
+		case read || etagsComparable(object.ETag, done.ETag):
+			entry.Verified = "checksum"
+		default:
+			entry.Verified = "size"
+		}
+		manifest = append(manifest, entry)
+	}
+	return manifest, nil
+}
+
+// etagsComparable reports whether two etags were computed the same way:
+// both md5s, or both multipart etags with as many parts
+func etagsComparable(a, b string) bool {
+	_, aParts, aMultipart := strings.Cut(a, "-")
+	_, bParts, bMultipart := strings.Cut(b, "-")
+	return a != "" && b != "" && aMultipart == bMultipart && aParts == bParts
+}
+
+// listFiles lists every file on a volume, without directory markers
+func listFiles(ctx context.Context, files *volumeFiles) ([]s3.Object, error) {
+	objects, _, err := files.client.List(ctx, files.bucket(), "", "")
+	if err != nil {
+		output.Error(err)
+		return nil, fmt.Errorf("failed to list %s: %w", files.bucket(), err)
+	}
+	found := objects[:0]
+	for _, object := range objects {
+		if !strings.HasSuffix(object.Key, "/") {
+			found = append(found, object)
+		}
+	}
+	return found, nil
+}
+
+func writeManifest(name string, manifest []manifestEntry) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+func volumeCopyKey(sourceID, dataCenterID string) string {
+	return sourceID + "/" + dataCenterID
+}
+
+const volumeCopyStateFile = "volume-copies.json"
+
+func loadVolumeCopies() (map[string]volumeCopyState, error) {
+	states := map[string]volumeCopyState{}
+	if err := statefile.Load(volumeCopyStateFile, &states); err != nil {
+		return nil, fmt.Errorf("failed to read copy state: %w", err)
+	}
+	return states, nil
+}
+
+func saveVolumeCopies(states map[string]volumeCopyState) error {
+	var err error
+	if len(states) == 0 {
+		err = statefile.Remove(volumeCopyStateFile)
+	} else {
+		err = statefile.Save(volumeCopyStateFile, states)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write copy state: %w", err)
+	}
+	return nil
+}
+
+func forgetVolumeCopy(sourceID, dataCenterID string) error {
+	states, err := loadVolumeCopies()
+	if err != nil {
+		return err
+	}
+	delete(states, volumeCopyKey(sourceID, dataCenterID))
+	return saveVolumeCopies(states)
+}
@@ -0,0 +1,198 @@
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/s3"
+	"github.com/runpod/runpodctl/internal/s3/s3test"
+
+	"github.com/spf13/viper"
+)
+
+// fakeVolumeAPI serves the network volume routes of the rest api
+type fakeVolumeAPI struct {
+	mu      sync.Mutex
+	volumes []api.NetworkVolume
+	created []api.NetworkVolumeCreateRequest
+}
+
+func (f *fakeVolumeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/networkvolumes":
+		_ = json.NewEncoder(w).Encode(f.volumes)
+	case r.Method == http.MethodPost && r.URL.Path == "/networkvolumes":
+		var req api.NetworkVolumeCreateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.created = append(f.created, req)
+		volume := api.NetworkVolume{ID: fmt.Sprintf("new-%d", len(f.created)), Name: req.Name, Size: req.Size, DataCenterID: req.DataCenterID}
+		f.volumes = append(f.volumes, volume)
+		_ = json.NewEncoder(w).Encode(volume)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// startVolumeCopy is startVolumeFiles with a volume api that can create
+// volumes, and a home for the copy state
+func startVolumeCopy(t *testing.T) (*s3test.Server, *fakeVolumeAPI) {
+	t.Helper()
+	server := startVolumeFiles(t)
+	resetCopyVars(t)
+	fake := &fakeVolumeAPI{volumes: []api.NetworkVolume{
+		{ID: "vol-1", Name: "models", Size: 10, DataCenterID: "EU-RO-1"},
+		{ID: "vol-2", Name: "scratch", Size: 5, DataCenterID: "US-KS-2"},
+	}}
+	apiServer := httptest.NewServer(fake)
+	t.Cleanup(apiServer.Close)
+	viper.Set("restApiUrl", apiServer.URL)
+	t.Setenv("HOME", t.TempDir())
+	return server, fake
+}
+
+func resetCopyVars(t *testing.T) {
+	t.Helper()
+	dc, name, size, workers, manifest := copyDataCenterID, copyName, copySize, copyWorkers, copyManifest
+	t.Cleanup(func() {
+		copyDataCenterID, copyName, copySize, copyWorkers, copyManifest = dc, name, size, workers, manifest
+	})
+}
+
+func TestRunCopy_CreatesVolumeAndVerifies(t *testing.T) {
+	server, fake := startVolumeCopy(t)
+	for _, key := range []string{"a.txt", "models/x.bin", "models/sub/y.bin"} {
+		server.Put("vol-1", key, []byte(key))
+	}
+
+	copyDataCenterID = "eu-cz-1"
+	copyManifest = filepath.Join(t.TempDir(), "manifest.json")
+	stdout, stderr, err := runFilesCmd(t, runCopy, "models")
+	if err != nil {
+		t.Fatalf("copy: %v\n%s", err, stderr)
+	}
+
+	if len(fake.created) != 1 || fake.created[0] != (api.NetworkVolumeCreateRequest{Name: "models-eu-cz-1", Size: 10, DataCenterID: "EU-CZ-1"}) {
+		t.Fatalf("unexpected volumes created %+v", fake.created)
+	}
+	if got := strings.Join(server.Keys("new-1"), ","); got != "a.txt,models/sub/y.bin,models/x.bin" {
+		t.Fatalf("unexpected copied keys %s", got)
+	}
+	var result volumeCopyResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if result.Copied != 3 || result.Verified != 3 || len(result.Mismatches) != 0 || result.Destination.ID != "new-1" {
+		t.Fatalf("unexpected result %s", stdout)
+	}
+	if !strings.Contains(stderr, "[3/3]") {
+		t.Fatalf("expected progress on stderr, got %q", stderr)
+	}
+
+	var manifest []manifestEntry
+	data, _ := os.ReadFile(copyManifest)
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest) != 3 || manifest[0].Verified != "checksum" {
+		t.Fatalf("unexpected manifest %s (%v)", data, err)
+	}
+	if states, _ := loadVolumeCopies(); len(states) != 0 {
+		t.Fatalf("expected the finished copy to be forgotten, got %v", states)
+	}
+}
+
+func TestRunCopy_Resumes(t *testing.T) {
+	server, fake := startVolumeCopy(t)
+	server.Put("vol-1", "done.bin", []byte("done"))
+	server.Put("vol-1", "partial.bin", []byte("complete"))
+	server.Put("vol-1", "todo.bin", []byte("todo"))
+	// an earlier run copied one file and an old version of another
+	server.Put("vol-2", "done.bin", []byte("done"))
+	server.Put("vol-2", "partial.bin", []byte("old"))
+	err := saveVolumeCopies(map[string]volumeCopyState{
+		"vol-1/US-KS-2": {SourceID: "vol-1", DestinationID: "vol-2", DataCenterID: "US-KS-2", StartedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copyDataCenterID = "US-KS-2"
+	stdout, stderr, err := runFilesCmd(t, runCopy, "vol-1")
+	if err != nil {
+		t.Fatalf("copy: %v\n%s", err, stderr)
+	}
+	if len(fake.created) != 0 {
+		t.Fatalf("expected the earlier volume to be reused, created %+v", fake.created)
+	}
+	var result volumeCopyResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if !result.Resumed || result.Skipped != 1 || result.Copied != 2 || result.Verified != 3 {
+		t.Fatalf("unexpected result %s", stdout)
+	}
+	if data, _ := server.Get("vol-2", "partial.bin"); string(data) != "complete" {
+		t.Fatalf("expected the stale file to be copied again, got %q", data)
+	}
+	if !strings.Contains(stderr, "resuming the copy into vol-2") {
+		t.Fatalf("expected a resume note, got %q", stderr)
+	}
+}
+
+func TestVerifyCopy(t *testing.T) {
+	server := startVolumeFiles(t)
+	files, err := openVolume("vol-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Put("vol-2", "same.bin", []byte("same"))
+	server.Put("vol-2", "corrupt.bin", []byte("bad!"))
+	server.Put("vol-2", "short.bin", []byte("sh"))
+
+	sameETag, _ := files.client.ETagOf(strings.NewReader("same"), 4)
+	goodETag, _ := files.client.ETagOf(strings.NewReader("good"), 4)
+	objects := []s3.Object{
+		{Key: "same.bin", Size: 4, ETag: sameETag},
+		{Key: "corrupt.bin", Size: 4, ETag: goodETag},
+		{Key: "short.bin", Size: 5, ETag: "x"},
+		{Key: "missing.bin", Size: 1, ETag: "y"},
+		// a multipart source etag can't be compared with a single part copy
+		{Key: "same.bin", Size: 4, ETag: "abc-2"},
+	}
+	manifest, err := verifyCopy(context.Background(), objects, files, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range manifest {
+		got = append(got, entry.Path+":"+entry.Verified+entry.Problem)
+	}
+	want := "/same.bin:checksum,/corrupt.bin:checksum,/short.bin:size,/missing.bin:missing,/same.bin:size"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected manifest\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+	if manifest[1].Problem != "checksum" {
+		t.Fatalf("expected a checksum problem, got %+v", manifest[1])
+	}
+}
+
+func TestRunCopy_RejectsSourceDataCenter(t *testing.T) {
+	_, fake := startVolumeCopy(t)
+	copyDataCenterID = "eu-ro-1"
+	if _, _, err := runFilesCmd(t, runCopy, "models"); err == nil || !strings.Contains(err.Error(), "already in EU-RO-1") {
+		t.Fatalf("expected the source datacenter to be rejected, got %v", err)
+	}
+	if len(fake.created) != 0 {
+		t.Fatalf("expected no volume to be created, got %+v", fake.created)
+	}
+}
@@ -0,0 +1,269 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var cpRecursive bool
+
+var cpCmd = &cobra.Command{
+	Use:   "cp <src> <dst>",
+	Short: "copy files to or from a network volume",
+	Long: `copy files between your machine and a network volume. one side is a local
+path and the other is <volume>:/path, where the volume is an id or a name.
+use - as the local path to read from stdin or write to stdout.
+
+with -r, the contents of the source directory are copied into the
+destination directory. files larger than 64 MiB are uploaded in parts.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume cp model.safetensors my-volume:/models/
+  runpodctl network-volume cp -r ./data abc123:/datasets/train
+  runpodctl network-volume cp abc123:/outputs/result.png .
+  tar c ./src | runpodctl network-volume cp - abc123:/src.tar`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCp,
+}
+
+func init() {
+	cpCmd.Flags().BoolVarP(&cpRecursive, "recursive", "r", false, "copy directories")
+}
+
+// copyResult summarizes a copy
+type copyResult struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Files       int    `json:"files"`
+	Bytes       int64  `json:"bytes"`
+}
+
+func runCp(cmd *cobra.Command, args []string) error {
+	src, srcRemote := parseVolumePath(args[0])
+	dst, dstRemote := parseVolumePath(args[1])
+	switch {
+	case srcRemote && dstRemote:
+		return fmt.Errorf("copying from one volume path to another is not supported")
+	case !srcRemote && !dstRemote:
+		return fmt.Errorf("one of source and destination must be a volume path (<volume>:/path)")
+	}
+
+	ref := dst.Volume
+	if srcRemote {
+		ref = src.Volume
+	}
+	files, err := openVolume(ref)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	ctx, stop := filesContext()
+	defer stop()
+
+	result := &copyResult{Source: args[0], Destination: args[1]}
+	if dstRemote {
+		err = upload(ctx, cmd, files, args[0], dst, result)
+	} else {
+		err = download(ctx, cmd, files, src, args[1], result)
+	}
+	if err != nil {
+		return err
+	}
+	if args[1] == "-" {
+		return nil
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+func upload(ctx context.Context, cmd *cobra.Command, files *volumeFiles, local string, dst volumePath, result *copyResult) error {
+	if local == "-" {
+		if dst.Dir {
+			return fmt.Errorf("%s is a directory; name the file to write stdin to", dst)
+		}
+		counter := &countingReader{r: cmd.InOrStdin()}
+		if err := files.client.Put(ctx, files.bucket(), dst.Key, counter, -1); err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to upload to %s: %w", dst, err)
+		}
+		result.Files, result.Bytes = 1, counter.n
+		return nil
+	}
+
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		key := dst.Key
+		isDir := dst.Dir
+		if !isDir {
+			if isDir, err = files.isDir(ctx, dst); err != nil {
+				output.Error(err)
+				return err
+			}
+		}
+		if isDir {
+			key = path.Join(dst.Key, filepath.Base(local))
+		}
+		return result.add(uploadFile(ctx, files, local, key))
+	}
+	if !cpRecursive {
+		return fmt.Errorf("%s is a directory (use -r)", local)
+	}
+
+	return filepath.WalkDir(local, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(local, name)
+		if err != nil {
+			return err
+		}
+		key := path.Join(dst.Key, filepath.ToSlash(rel))
+		if err := result.add(uploadFile(ctx, files, name, key)); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "uploaded %s\n", name)
+		return nil
+	})
+}
+
+// add counts a copied file
+func (r *copyResult) add(n int64, err error) error {
+	if err != nil {
+		return err
+	}
+	r.Files++
+	r.Bytes += n
+	return nil
+}
+
+// uploadFile uploads a local file and returns its size
+func uploadFile(ctx context.Context, files *volumeFiles, local, key string) (int64, error) {
+	file, err := os.Open(local)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := files.client.Put(ctx, files.bucket(), key, file, info.Size()); err != nil {
+		output.Error(err)
+		return 0, fmt.Errorf("failed to upload %s: %w", local, err)
+	}
+	return info.Size(), nil
+}
+
+func download(ctx context.Context, cmd *cobra.Command, files *volumeFiles, src volumePath, local string, result *copyResult) error {
+	if cpRecursive {
+		if local == "-" {
+			return fmt.Errorf("cannot copy a directory to stdout")
+		}
+		objects, _, err := files.client.List(ctx, files.bucket(), src.prefix(), "")
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to list %s: %w", src, err)
+		}
+		if len(objects) == 0 {
+			return fmt.Errorf("%s: no such directory", src)
+		}
+		for _, object := range objects {
+			rel := filepath.FromSlash(strings.TrimPrefix(object.Key, src.prefix()))
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			if !filepath.IsLocal(rel) {
+				return fmt.Errorf("refusing to write %s outside of %s", object.Key, local)
+			}
+			name := filepath.Join(local, rel)
+			if err := result.add(downloadFile(ctx, files, object.Key, name)); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "downloaded %s\n", name)
+		}
+		return nil
+	}
+
+	if src.Dir {
+		return fmt.Errorf("%s is a directory (use -r)", src)
+	}
+	if local == "-" {
+		return catFile(ctx, files, src, cmd.OutOrStdout())
+	}
+	if info, err := os.Stat(local); (err == nil && info.IsDir()) || strings.HasSuffix(local, string(filepath.Separator)) || strings.HasSuffix(local, "/") {
+		local = filepath.Join(local, path.Base(src.Key))
+	}
+	err := result.add(downloadFile(ctx, files, src.Key, local))
+	if s3.IsNotFound(err) {
+		if isDir, dirErr := files.isDir(ctx, src); dirErr == nil && isDir {
+			return fmt.Errorf("%s is a directory (use -r)", src)
+		}
+		return fmt.Errorf("%s: no such file", src)
+	}
+	return err
+}
+
+// downloadFile writes an object next to its destination and renames it into
+// place, so an interrupted download leaves no partial file behind. it
+// returns the number of bytes written.
+func downloadFile(ctx context.Context, files *volumeFiles, key, local string) (int64, error) {
+	body, object, err := files.client.Get(ctx, files.bucket(), key)
+	if err != nil {
+		if s3.IsNotFound(err) {
+			return 0, err
+		}
+		output.Error(err)
+		return 0, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(local), "."+filepath.Base(local)+".*.part")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	if object.Size >= 0 && n != object.Size {
+		return 0, fmt.Errorf("failed to download %s: got %d of %d bytes", key, n, object.Size)
+	}
+	if err := os.Rename(tmp.Name(), local); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -11,7 +11,7 @@ import (
 
 var deleteCmd = &cobra.Command{
 	Use:     "delete <volume-id>",
-	Aliases: []string{"rm", "remove"},
+	Aliases: []string{"remove"},
 	Short:   "delete a network volume",
 	Long:    "delete a network volume by id",
 	Args:    cobra.ExactArgs(1),
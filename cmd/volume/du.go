@@ -0,0 +1,111 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var duCmd = &cobra.Command{
+	Use:   "du <volume>[:/path]",
+	Short: "show disk usage on a network volume",
+	Long: `show the number of files and bytes under a path on a network volume, with a
+breakdown by subdirectory. at the volume root it also shows how full the
+volume is.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume du my-volume
+  runpodctl network-volume du abc123:/models`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDu,
+}
+
+// diskUsage is the size of the files under a path
+type diskUsage struct {
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+	Size  string `json:"size"`
+}
+
+type duResult struct {
+	diskUsage
+	VolumeSizeGb int          `json:"volumeSizeGb,omitempty"`
+	UsedPercent  *float64     `json:"usedPercent,omitempty"`
+	Directories  []*diskUsage `json:"directories"`
+}
+
+func runDu(cmd *cobra.Command, args []string) error {
+	target := parseVolumeArg(args[0])
+	files, err := openVolume(target.Volume)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	ctx, stop := filesContext()
+	defer stop()
+
+	result, err := diskUsageOf(ctx, files, target)
+	if err != nil {
+		return err
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+// diskUsageOf adds up the files under a path, by immediate subdirectory
+func diskUsageOf(ctx context.Context, files *volumeFiles, target volumePath) (*duResult, error) {
+	objects, _, err := files.client.List(ctx, files.bucket(), target.prefix(), "")
+	if err != nil {
+		output.Error(err)
+		return nil, fmt.Errorf("failed to list %s: %w", target, err)
+	}
+
+	result := &duResult{diskUsage: diskUsage{Path: "/" + target.Key}, Directories: []*diskUsage{}}
+	dirs := map[string]*diskUsage{}
+	for _, object := range objects {
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+		result.Files++
+		result.Bytes += object.Size
+
+		rel := strings.TrimPrefix(object.Key, target.prefix())
+		dir, _, nested := strings.Cut(rel, "/")
+		if !nested {
+			continue
+		}
+		usage, ok := dirs[dir]
+		if !ok {
+			usage = &diskUsage{Path: "/" + target.prefix() + dir}
+			dirs[dir] = usage
+			result.Directories = append(result.Directories, usage)
+		}
+		usage.Files++
+		usage.Bytes += object.Size
+	}
+	if result.Files == 0 && target.Key != "" {
+		return nil, fmt.Errorf("%s: no such directory", target)
+	}
+
+	result.Size = output.Bytes(result.Bytes)
+	for _, usage := range result.Directories {
+		usage.Size = output.Bytes(usage.Bytes)
+	}
+	sort.SliceStable(result.Directories, func(i, j int) bool {
+		return result.Directories[i].Bytes > result.Directories[j].Bytes
+	})
+	if target.Key == "" && files.volume.Size > 0 {
+		result.VolumeSizeGb = files.volume.Size
+		used := float64(result.Bytes) / float64(int64(files.volume.Size)<<30) * 100
+		used = float64(int(used*10+0.5)) / 10
+		result.UsedPercent = &used
+	}
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/configenv"
+	"github.com/runpod/runpodctl/internal/s3"
+)
+
+// volumePath is a location on a network volume, written <volume>:/path.
+// the volume is an id or a name.
+type volumePath struct {
+	Volume string
+	// Key is the path without the leading slash; empty is the volume root
+	Key string
+	// Dir is set when the path ends in a slash or is the root
+	Dir bool
+}
+
+func (p volumePath) String() string {
+	return p.Volume + ":/" + p.Key
+}
+
+// parseVolumePath splits a <volume>:/path argument. ok is false for local
+// paths, which have no colon or a path separator before it.
+func parseVolumePath(arg string) (volumePath, bool) {
+	volume, rest, found := strings.Cut(arg, ":")
+	if !found || volume == "" || strings.ContainsAny(volume, `/\`) {
+		return volumePath{}, false
+	}
+	// c:\data on windows is a drive letter, not a volume
+	if runtime.GOOS == "windows" && len(volume) == 1 {
+		return volumePath{}, false
+	}
+	return newVolumePath(volume, rest), true
+}
+
+// parseVolumeArg is parseVolumePath for commands that only take volume
+// paths; a bare volume is its root
+func parseVolumeArg(arg string) volumePath {
+	if p, ok := parseVolumePath(arg); ok {
+		return p
+	}
+	return newVolumePath(arg, "")
+}
+
+func newVolumePath(volume, rest string) volumePath {
+	key := strings.TrimPrefix(path.Clean("/"+rest), "/")
+	return volumePath{
+		Volume: volume,
+		Key:    key,
+		Dir:    key == "" || strings.HasSuffix(rest, "/"),
+	}
+}
+
+// prefix returns the key prefix of the files under the path
+func (p volumePath) prefix() string {
+	if p.Key == "" {
+		return ""
+	}
+	return p.Key + "/"
+}
+
+// volumeFiles reads and writes the files of one network volume
+type volumeFiles struct {
+	volume *api.NetworkVolume
+	client *s3.Client
+}
+
+// openVolume resolves a volume by id or name and connects to the s3 api of
+// its datacenter
+func openVolume(ref string) (*volumeFiles, error) {
+	if err := checkS3Key(); err != nil {
+		return nil, err
+	}
+	client, err := api.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	volume, err := findVolume(client, ref)
+	if err != nil {
+		return nil, err
+	}
+	return newVolumeFiles(volume), nil
+}
+
+func checkS3Key() error {
+	if configenv.S3AccessKey() == "" || configenv.S3SecretKey() == "" {
+		return fmt.Errorf("s3 api key not configured. create one in the runpod console under settings, then set %s and %s",
+			configenv.S3AccessKeyEnv, configenv.S3SecretKeyEnv)
+	}
+	return nil
+}
+
+func newVolumeFiles(volume *api.NetworkVolume) *volumeFiles {
+	endpoint := configenv.S3Endpoint()
+	if endpoint == "" {
+		endpoint = s3.DataCenterEndpoint(volume.DataCenterID)
+	}
+	return &volumeFiles{
+		volume: volume,
+		client: s3.New(endpoint, volume.DataCenterID, configenv.S3AccessKey(), configenv.S3SecretKey()),
+	}
+}
+
+func findVolume(client *api.Client, ref string) (*api.NetworkVolume, error) {
+	volumes, err := client.ListNetworkVolumes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	var named []api.NetworkVolume
+	for _, volume := range volumes {
+		if volume.ID == ref {
+			return &volume, nil
+		}
+		if volume.Name == ref {
+			named = append(named, volume)
+		}
+	}
+	switch len(named) {
+	case 0:
+		return nil, fmt.Errorf("network volume %s not found", ref)
+	case 1:
+		return &named[0], nil
+	default:
+		ids := make([]string, len(named))
+		for i, volume := range named {
+			ids[i] = volume.ID
+		}
+		return nil, fmt.Errorf("more than one network volume is named %s (%s); use the id", ref, strings.Join(ids, ", "))
+	}
+}
+
+func (f *volumeFiles) bucket() string {
+	return f.volume.ID
+}
+
+// isDir reports whether any file lives under the path
+func (f *volumeFiles) isDir(ctx context.Context, p volumePath) (bool, error) {
+	if p.Key == "" {
+		return true, nil
+	}
+	objects, prefixes, err := f.client.List(ctx, f.bucket(), p.prefix(), "/")
+	if err != nil {
+		return false, err
+	}
+	return len(objects) > 0 || len(prefixes) > 0, nil
+}
+
+// filesContext is cancelled on ctrl-c, so uploads in flight are aborted
+func filesContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,310 @@
+package volume
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/s3/s3test"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// startVolumeFiles serves a volume list and an s3 stand-in for its files
+func startVolumeFiles(t *testing.T) *s3test.Server {
+	t.Helper()
+	resetFilesVars(t)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/networkvolumes" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"id": "vol-1", "name": "models", "size": 10, "dataCenterId": "EU-RO-1"},
+			{"id": "vol-2", "name": "scratch", "size": 5, "dataCenterId": "US-KS-2"},
+			{"id": "vol-3", "name": "scratch", "size": 5, "dataCenterId": "US-KS-2"}
+		]`))
+	}))
+	t.Cleanup(api.Close)
+	s3Server := s3test.NewServer(t)
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	t.Setenv("RUNPOD_S3_ACCESS_KEY_ID", s3test.AccessKey)
+	t.Setenv("RUNPOD_S3_SECRET_ACCESS_KEY", s3test.SecretKey)
+	t.Setenv("RUNPOD_S3_ENDPOINT", s3Server.URL)
+	viper.Set("restApiUrl", api.URL)
+	t.Cleanup(func() { viper.Set("restApiUrl", "") })
+	return s3Server
+}
+
+func resetFilesVars(t *testing.T) {
+	t.Helper()
+	lsR, cpR, rmR := lsRecursive, cpRecursive, rmRecursive
+	t.Cleanup(func() {
+		lsRecursive, cpRecursive, rmRecursive = lsR, cpR, rmR
+	})
+}
+
+func runFilesCmd(t *testing.T, run func(*cobra.Command, []string) error, args ...string) (string, string, error) {
+	t.Helper()
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", "json", "")
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+
+	origStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stdout: %v", err)
+	}
+	os.Stdout = w
+	runErr := run(cmd, args)
+	_ = w.Close()
+	os.Stdout = origStdout
+	printed, _ := io.ReadAll(r)
+	_ = r.Close()
+
+	return stdout.String() + string(printed), stderr.String(), runErr
+}
+
+func TestParseVolumePath(t *testing.T) {
+	tests := []struct {
+		arg    string
+		remote bool
+		want   volumePath
+	}{
+		{"vol-1:/models/a.bin", true, volumePath{Volume: "vol-1", Key: "models/a.bin"}},
+		{"vol-1:models/", true, volumePath{Volume: "vol-1", Key: "models", Dir: true}},
+		{"vol-1:/", true, volumePath{Volume: "vol-1", Dir: true}},
+		{"vol-1:/a/../../b", true, volumePath{Volume: "vol-1", Key: "b"}},
+		{"./data:1", false, volumePath{}},
+		{"data.txt", false, volumePath{}},
+		{":/x", false, volumePath{}},
+	}
+	for _, tt := range tests {
+		got, remote := parseVolumePath(tt.arg)
+		if remote != tt.remote || got != tt.want {
+			t.Errorf("parseVolumePath(%q) = %+v, %v; want %+v, %v", tt.arg, got, remote, tt.want, tt.remote)
+		}
+	}
+	if got := parseVolumeArg("models"); got != (volumePath{Volume: "models", Dir: true}) {
+		t.Errorf("expected a bare volume to be its root, got %+v", got)
+	}
+}
+
+func TestRunCp_RecursiveRoundTrip(t *testing.T) {
+	server := startVolumeFiles(t)
+	local := t.TempDir()
+	writeFile(t, filepath.Join(local, "a.txt"), "alpha")
+	writeFile(t, filepath.Join(local, "sub", "b.txt"), "beta")
+
+	cpRecursive = true
+	stdout, stderr, err := runFilesCmd(t, runCp, local, "models:/data")
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if keys := strings.Join(server.Keys("vol-1"), ","); keys != "data/a.txt,data/sub/b.txt" {
+		t.Fatalf("unexpected keys %s", keys)
+	}
+	var result copyResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil || result.Files != 2 || result.Bytes != 9 {
+		t.Fatalf("unexpected result %s (%v)", stdout, err)
+	}
+	if !strings.Contains(stderr, "uploaded") {
+		t.Fatalf("expected progress on stderr, got %q", stderr)
+	}
+
+	dst := t.TempDir()
+	if _, _, err := runFilesCmd(t, runCp, "vol-1:/data", dst); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "b.txt")); string(data) != "beta" {
+		t.Fatalf("unexpected downloaded content %q", data)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 2 {
+		t.Fatalf("expected no leftover part files, got %v", entries)
+	}
+}
+
+func TestRunCp_SingleFiles(t *testing.T) {
+	server := startVolumeFiles(t)
+	server.Put("vol-1", "models/existing.bin", []byte("x"))
+	local := filepath.Join(t.TempDir(), "new.bin")
+	writeFile(t, local, "weights")
+
+	// an existing directory gets the file's name appended
+	if _, _, err := runFilesCmd(t, runCp, local, "models:/models"); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if _, ok := server.Get("vol-1", "models/new.bin"); !ok {
+		t.Fatalf("expected models/new.bin, got %v", server.Keys("vol-1"))
+	}
+	if _, _, err := runFilesCmd(t, runCp, local, "models:/renamed.bin"); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if _, ok := server.Get("vol-1", "renamed.bin"); !ok {
+		t.Fatalf("expected renamed.bin, got %v", server.Keys("vol-1"))
+	}
+
+	stdout, _, err := runFilesCmd(t, runCp, "models:/renamed.bin", "-")
+	if err != nil || stdout != "weights" {
+		t.Fatalf("expected the file on stdout, got %q (%v)", stdout, err)
+	}
+
+	_, _, err = runFilesCmd(t, runCp, "models:/models", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "is a directory (use -r)") {
+		t.Fatalf("expected a directory error, got %v", err)
+	}
+	_, _, err = runFilesCmd(t, runCp, "models:/a", "models:/b")
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Fatalf("expected volume to volume copy to fail, got %v", err)
+	}
+}
+
+func TestRunLs(t *testing.T) {
+	server := startVolumeFiles(t)
+	for _, key := range []string{"a.txt", "models/x.bin", "models/sub/y.bin"} {
+		server.Put("vol-1", key, []byte(key))
+	}
+
+	stdout, _, err := runFilesCmd(t, runLs, "vol-1")
+	if err != nil {
+		t.Fatalf("ls: %v", err)
+	}
+	var entries []fileEntry
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if len(entries) != 2 || entries[0].Path != "/models" || !entries[0].Dir || entries[1].Path != "/a.txt" || entries[1].Size != 5 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	lsRecursive = true
+	stdout, _, _ = runFilesCmd(t, runLs, "models:/models")
+	if !strings.Contains(stdout, "/models/sub/y.bin") {
+		t.Fatalf("expected a recursive listing, got %s", stdout)
+	}
+
+	lsRecursive = false
+	stdout, _, err = runFilesCmd(t, runLs, "models:/models/x.bin")
+	if err != nil || !strings.Contains(stdout, `"size": 12`) {
+		t.Fatalf("expected a single file, got %s (%v)", stdout, err)
+	}
+	if _, _, err := runFilesCmd(t, runLs, "models:/missing"); err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Fatalf("expected a missing path error, got %v", err)
+	}
+}
+
+func TestRunRm(t *testing.T) {
+	server := startVolumeFiles(t)
+	for _, key := range []string{"keep.txt", "old/1.bin", "old/2.bin"} {
+		server.Put("vol-1", key, []byte(key))
+	}
+
+	_, _, err := runFilesCmd(t, runRm, "vol-1")
+	if err == nil || !strings.Contains(err.Error(), "network-volume delete") {
+		t.Fatalf("expected a bare volume to be refused, got %v", err)
+	}
+	_, _, err = runFilesCmd(t, runRm, "vol-1:/old")
+	if err == nil || !strings.Contains(err.Error(), "use -r") {
+		t.Fatalf("expected a directory to need -r, got %v", err)
+	}
+
+	rmRecursive = true
+	stdout, _, err := runFilesCmd(t, runRm, "vol-1:/old")
+	if err != nil {
+		t.Fatalf("rm: %v", err)
+	}
+	if keys := strings.Join(server.Keys("vol-1"), ","); keys != "keep.txt" {
+		t.Fatalf("unexpected keys after rm %s", keys)
+	}
+	if !strings.Contains(stdout, `"deleted": 2`) {
+		t.Fatalf("unexpected output %s", stdout)
+	}
+}
+
+func TestRunCat(t *testing.T) {
+	server := startVolumeFiles(t)
+	server.Put("vol-1", "a.txt", []byte("one\n"))
+	server.Put("vol-1", "b.txt", []byte("two\n"))
+
+	stdout, _, err := runFilesCmd(t, runCat, "vol-1:/a.txt", "models:/b.txt")
+	if err != nil || stdout != "one\ntwo\n" {
+		t.Fatalf("unexpected output %q (%v)", stdout, err)
+	}
+	if _, _, err := runFilesCmd(t, runCat, "vol-1:/nope"); err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Fatalf("expected a missing file error, got %v", err)
+	}
+}
+
+func TestRunDu(t *testing.T) {
+	server := startVolumeFiles(t)
+	server.Put("vol-1", "a.txt", make([]byte, 100))
+	server.Put("vol-1", "models/x.bin", make([]byte, 2048))
+	server.Put("vol-1", "models/sub/y.bin", make([]byte, 1024))
+	server.Put("vol-1", "logs/z.log", make([]byte, 10))
+
+	stdout, _, err := runFilesCmd(t, runDu, "models")
+	if err != nil {
+		t.Fatalf("du: %v", err)
+	}
+	var result struct {
+		Files        int      `json:"files"`
+		Bytes        int64    `json:"bytes"`
+		VolumeSizeGb int      `json:"volumeSizeGb"`
+		UsedPercent  *float64 `json:"usedPercent"`
+		Directories  []diskUsage
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	if result.Files != 4 || result.Bytes != 3182 || result.VolumeSizeGb != 10 || result.UsedPercent == nil {
+		t.Fatalf("unexpected usage %s", stdout)
+	}
+	if len(result.Directories) != 2 || result.Directories[0].Path != "/models" || result.Directories[0].Size != "3.0 KiB" {
+		t.Fatalf("unexpected directories %+v", result.Directories)
+	}
+}
+
+func TestOpenVolume(t *testing.T) {
+	startVolumeFiles(t)
+
+	files, err := openVolume("models")
+	if err != nil || files.bucket() != "vol-1" || files.client.Region != "EU-RO-1" {
+		t.Fatalf("expected to resolve by name, got %+v (%v)", files, err)
+	}
+	if _, err := openVolume("scratch"); err == nil || !strings.Contains(err.Error(), "vol-2, vol-3") {
+		t.Fatalf("expected an ambiguous name error, got %v", err)
+	}
+
+	t.Setenv("RUNPOD_S3_ENDPOINT", "")
+	files, _ = openVolume("vol-2")
+	if files.client.Endpoint != "https://s3api-us-ks-2.runpod.io" {
+		t.Fatalf("expected the datacenter endpoint, got %s", files.client.Endpoint)
+	}
+
+	t.Setenv("RUNPOD_S3_SECRET_ACCESS_KEY", "")
+	if _, err := openVolume("vol-1"); err == nil || !strings.Contains(err.Error(), "RUNPOD_S3_SECRET_ACCESS_KEY") {
+		t.Fatalf("expected a missing key error, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
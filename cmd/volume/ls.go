@@ -0,0 +1,97 @@
+package volume
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var lsRecursive bool
+
+var lsCmd = &cobra.Command{
+	Use:   "ls <volume>[:/path]",
+	Short: "list files on a network volume",
+	Long: `list the files and directories at a path on a network volume, through the
+volume's s3 api. the volume is an id or a name.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume ls my-volume
+  runpodctl network-volume ls abc123:/models -r`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLs,
+}
+
+func init() {
+	lsCmd.Flags().BoolVarP(&lsRecursive, "recursive", "r", false, "list every file under the path")
+}
+
+// fileEntry is a file or directory in a listing
+type fileEntry struct {
+	Path     string     `json:"path"`
+	Size     int64      `json:"size"`
+	Modified *time.Time `json:"modified,omitempty"`
+	Dir      bool       `json:"dir,omitempty"`
+}
+
+func runLs(cmd *cobra.Command, args []string) error {
+	target := parseVolumeArg(args[0])
+	files, err := openVolume(target.Volume)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	ctx, stop := filesContext()
+	defer stop()
+
+	delimiter := "/"
+	if lsRecursive {
+		delimiter = ""
+	}
+	objects, prefixes, err := files.client.List(ctx, files.bucket(), target.prefix(), delimiter)
+	if err != nil {
+		output.Error(err)
+		return fmt.Errorf("failed to list %s: %w", target, err)
+	}
+
+	entries := []fileEntry{}
+	for _, prefix := range prefixes {
+		entries = append(entries, fileEntry{Path: "/" + strings.TrimSuffix(prefix, "/"), Dir: true})
+	}
+	for _, object := range objects {
+		// keys ending in a slash are directory markers
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+		entries = append(entries, objectEntry(object))
+	}
+
+	// a path that isn't a directory may be a single file
+	if len(entries) == 0 && target.Key != "" && !target.Dir {
+		object, err := files.client.Head(ctx, files.bucket(), target.Key)
+		if s3.IsNotFound(err) {
+			return fmt.Errorf("%s: no such file or directory", target)
+		}
+		if err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to get %s: %w", target, err)
+		}
+		entries = append(entries, objectEntry(*object))
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(entries, &output.Config{Format: format})
+}
+
+func objectEntry(object s3.Object) fileEntry {
+	entry := fileEntry{Path: "/" + object.Key, Size: object.Size}
+	if !object.LastModified.IsZero() {
+		modified := object.LastModified.UTC()
+		entry.Modified = &modified
+	}
+	return entry
+}
@@ -0,0 +1,153 @@
+package volume
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreFrom    string
+	restoreInclude []string
+	restoreExclude []string
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <volume>[:/path]",
+	Short: "restore a network volume from a local archive",
+	Long: `upload the files of an archive written by network-volume backup to a network
+volume, then check them against the archive's manifest. existing files with
+the same paths are overwritten; other files are left alone.
+
+--include and --exclude take globs relative to the archive root, where *
+stays within a directory and ** crosses them.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume restore my-volume --from ./backup.tar.zst
+  runpodctl network-volume restore abc123:/models --from models.tar.gz --include 'llama/**'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreFrom, "from", "", "archive to restore (required)")
+	restoreCmd.Flags().StringArrayVar(&restoreInclude, "include", nil, "only restore paths matching this glob (repeatable)")
+	restoreCmd.Flags().StringArrayVar(&restoreExclude, "exclude", nil, "skip paths matching this glob (repeatable)")
+
+	restoreCmd.MarkFlagRequired("from") //nolint:errcheck
+}
+
+type restoreResult struct {
+	VolumeID   string   `json:"volumeId"`
+	Archive    string   `json:"archive"`
+	Files      int      `json:"files"`
+	Bytes      int64    `json:"bytes"`
+	Verified   bool     `json:"verified"`
+	Mismatches []string `json:"mismatches,omitempty"`
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	filter, err := newPathFilter(restoreInclude, restoreExclude)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(restoreFrom)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decompressed, err := decompressReader(restoreFrom, file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", restoreFrom, err)
+	}
+	defer decompressed.Close()
+
+	target := parseVolumeArg(args[0])
+	files, err := openVolume(target.Volume)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	ctx, stop := filesContext()
+	defer stop()
+
+	result := &restoreResult{VolumeID: files.volume.ID, Archive: restoreFrom}
+	restored := map[string]string{}
+	var manifest *backupManifest
+	archive := tar.NewReader(decompressed)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", restoreFrom, err)
+		}
+		if header.Name == backupManifestName {
+			manifest = &backupManifest{}
+			if err := json.NewDecoder(archive).Decode(manifest); err != nil {
+				return fmt.Errorf("failed to parse the manifest in %s: %w", restoreFrom, err)
+			}
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("refusing to restore %s outside of %s", header.Name, target)
+		}
+		if !filter.match(name) {
+			continue
+		}
+
+		key := path.Join(target.Key, name)
+		hash := sha256.New()
+		if err := files.client.Put(ctx, files.bucket(), key, io.TeeReader(archive, hash), header.Size); err != nil {
+			output.Error(err)
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		restored[name] = hex.EncodeToString(hash.Sum(nil))
+		result.Files++
+		result.Bytes += header.Size
+		fmt.Fprintf(cmd.ErrOrStderr(), "restored %s (%s)\n", name, output.Bytes(result.Bytes))
+	}
+
+	if manifest == nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s has no manifest; the restored files were not verified\n", restoreFrom)
+	} else {
+		for _, entry := range manifest.Files {
+			if !filter.match(entry.Path) {
+				continue
+			}
+			sum, ok := restored[entry.Path]
+			switch {
+			case !ok:
+				result.Mismatches = append(result.Mismatches, entry.Path+": missing from the archive")
+			case sum != entry.SHA256:
+				result.Mismatches = append(result.Mismatches, entry.Path+": checksum differs from the manifest")
+			}
+		}
+		result.Verified = len(result.Mismatches) == 0
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	if err := output.Print(result, &output.Config{Format: format}); err != nil {
+		return err
+	}
+	if len(result.Mismatches) > 0 {
+		return fmt.Errorf("%d files don't match the archive manifest", len(result.Mismatches))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package volume
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/output"
+	"github.com/runpod/runpodctl/internal/s3"
+
+	"github.com/spf13/cobra"
+)
+
+var rmRecursive bool
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <volume>:/path...",
+	Short: "delete files from a network volume",
+	Long: `delete files on a network volume. directories need -r.
+
+this removes files, not the volume; to delete a volume use
+network-volume delete <volume-id>.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume rm my-volume:/tmp/old.bin
+  runpodctl network-volume rm -r abc123:/checkpoints/run-1`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRm,
+}
+
+func init() {
+	rmCmd.Flags().BoolVarP(&rmRecursive, "recursive", "r", false, "delete directories and their contents")
+}
+
+func runRm(cmd *cobra.Command, args []string) error {
+	var targets []volumePath
+	for _, arg := range args {
+		target, ok := parseVolumePath(arg)
+		if !ok {
+			return fmt.Errorf("%s is not a volume path (<volume>:/path); to delete the volume itself use network-volume delete <volume-id>", arg)
+		}
+		targets = append(targets, target)
+	}
+
+	ctx, stop := filesContext()
+	defer stop()
+
+	opened := map[string]*volumeFiles{}
+	var deleted []string
+	for _, target := range targets {
+		files, ok := opened[target.Volume]
+		if !ok {
+			var err error
+			if files, err = openVolume(target.Volume); err != nil {
+				output.Error(err)
+				return err
+			}
+			opened[target.Volume] = files
+		}
+
+		var keys []string
+		if !target.Dir {
+			_, err := files.client.Head(ctx, files.bucket(), target.Key)
+			if err == nil {
+				keys = append(keys, target.Key)
+			} else if !s3.IsNotFound(err) {
+				output.Error(err)
+				return fmt.Errorf("failed to get %s: %w", target, err)
+			}
+		}
+		if len(keys) == 0 {
+			objects, _, err := files.client.List(ctx, files.bucket(), target.prefix(), "")
+			if err != nil {
+				output.Error(err)
+				return fmt.Errorf("failed to list %s: %w", target, err)
+			}
+			if len(objects) == 0 {
+				if target.Key == "" {
+					continue
+				}
+				return fmt.Errorf("%s: no such file or directory", target)
+			}
+			if !rmRecursive {
+				return fmt.Errorf("%s is a directory (use -r)", target)
+			}
+			for _, object := range objects {
+				keys = append(keys, object.Key)
+			}
+		}
+
+		for _, key := range keys {
+			if err := files.client.Delete(ctx, files.bucket(), key); err != nil {
+				output.Error(err)
+				return fmt.Errorf("failed to delete %s:/%s: %w", target.Volume, key, err)
+			}
+			deleted = append(deleted, target.Volume+":/"+strings.TrimSuffix(key, "/"))
+		}
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(map[string]interface{}{
+		"deleted": len(deleted),
+		"paths":   deleted,
+	}, &output.Config{Format: format})
+}
@@ -0,0 +1,342 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/runpod/runpodctl/cmd/project"
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncDelete  bool
+	syncDryRun  bool
+	syncWorkers int
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <src> <dst>",
+	Short: "sync a local directory with a network volume",
+	Long: `make the destination directory match the source, copying only files that are
+new or changed. one side is a local directory and the other is <volume>:/path,
+so sync pushes to a volume or pulls from it.
+
+files are compared by size, then by checksum against the volume's etags.
+patterns in .runpodignore in the current directory, and the default project
+excludes, are skipped in both directions and never deleted.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume sync ./data my-volume:/datasets
+  runpodctl network-volume sync ./data abc123:/datasets --delete --dry-run
+  runpodctl network-volume sync abc123:/outputs ./results`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSync,
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncDelete, "delete", false, "delete destination files that are not in the source")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "show what would change without changing anything")
+	syncCmd.Flags().IntVar(&syncWorkers, "workers", 4, "number of files to transfer in parallel")
+}
+
+// syncFile is a file on one side of a sync, by its slash-separated path
+// relative to the synced directory
+type syncFile struct {
+	size int64
+	etag string
+}
+
+// syncAction is a change a sync makes
+type syncAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type syncResult struct {
+	Source      string       `json:"source"`
+	Destination string       `json:"destination"`
+	DryRun      bool         `json:"dryRun,omitempty"`
+	Transferred int          `json:"transferred"`
+	Deleted     int          `json:"deleted"`
+	Unchanged   int          `json:"unchanged"`
+	Bytes       int64        `json:"bytes"`
+	Actions     []syncAction `json:"actions"`
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	if syncWorkers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	src, srcRemote := parseVolumePath(args[0])
+	dst, dstRemote := parseVolumePath(args[1])
+	switch {
+	case srcRemote && dstRemote:
+		return fmt.Errorf("syncing from one volume path to another is not supported")
+	case !srcRemote && !dstRemote:
+		return fmt.Errorf("one of source and destination must be a volume path (<volume>:/path)")
+	}
+
+	upload := dstRemote
+	remote, local := dst, args[0]
+	if !upload {
+		remote, local = src, args[1]
+	}
+	if info, err := os.Stat(local); err == nil && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", local)
+	} else if err != nil && (upload || !os.IsNotExist(err)) {
+		return err
+	}
+
+	ignoreList, err := project.GetIgnoreList()
+	if err != nil {
+		return fmt.Errorf("failed to read .runpodignore: %w", err)
+	}
+	// ShouldIgnore matches paths relative to the current directory
+	absLocal, err := filepath.Abs(local)
+	if err != nil {
+		return err
+	}
+	ignored := func(rel string) (bool, error) {
+		return project.ShouldIgnore(filepath.Join(absLocal, filepath.FromSlash(rel)), ignoreList)
+	}
+
+	files, err := openVolume(remote.Volume)
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	ctx, stop := filesContext()
+	defer stop()
+
+	remoteFiles, err := listRemoteFiles(ctx, files, remote, ignored)
+	if err != nil {
+		return err
+	}
+	if !upload && len(remoteFiles) == 0 && remote.Key != "" {
+		return fmt.Errorf("%s: no such directory", remote)
+	}
+	localFiles, err := listLocalFiles(local, ignored)
+	if err != nil {
+		return err
+	}
+
+	from, to := localFiles, remoteFiles
+	transfer := "upload"
+	if !upload {
+		from, to = remoteFiles, localFiles
+		transfer = "download"
+	}
+
+	// sizes decide most files; same-size files are checksummed in parallel
+	result := &syncResult{Source: args[0], Destination: args[1], DryRun: syncDryRun, Actions: []syncAction{}}
+	var candidates []string
+	for _, rel := range sortedKeys(from) {
+		existing, ok := to[rel]
+		switch {
+		case !ok:
+			result.Actions = append(result.Actions, syncAction{Action: transfer, Path: rel, Size: from[rel].size, Reason: "new"})
+		case existing.size != from[rel].size:
+			result.Actions = append(result.Actions, syncAction{Action: transfer, Path: rel, Size: from[rel].size, Reason: "size"})
+		default:
+			candidates = append(candidates, rel)
+		}
+	}
+	changed := make([]bool, len(candidates))
+	err = forEach(ctx, syncWorkers, len(candidates), func(ctx context.Context, i int) error {
+		rel := candidates[i]
+		name := filepath.Join(local, filepath.FromSlash(rel))
+		etag, err := localETag(files, name, localFiles[rel].size)
+		if err != nil {
+			return err
+		}
+		changed[i] = etag != remoteFiles[rel].etag
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, rel := range candidates {
+		if changed[i] {
+			result.Actions = append(result.Actions, syncAction{Action: transfer, Path: rel, Size: from[rel].size, Reason: "checksum"})
+		} else {
+			result.Unchanged++
+		}
+	}
+	if syncDelete {
+		for _, rel := range sortedKeys(to) {
+			if _, ok := from[rel]; !ok {
+				result.Actions = append(result.Actions, syncAction{Action: "delete", Path: rel, Size: to[rel].size})
+			}
+		}
+	}
+
+	if !syncDryRun {
+		var mu sync.Mutex
+		err = forEach(ctx, syncWorkers, len(result.Actions), func(ctx context.Context, i int) error {
+			action := result.Actions[i]
+			key := path.Join(remote.Key, action.Path)
+			name := filepath.Join(local, filepath.FromSlash(action.Path))
+
+			var n int64
+			var err error
+			switch {
+			case action.Action == "upload":
+				n, err = uploadFile(ctx, files, name, key)
+			case action.Action == "download":
+				n, err = downloadFile(ctx, files, key, name)
+			case upload:
+				if err = files.client.Delete(ctx, files.bucket(), key); err != nil {
+					output.Error(err)
+					err = fmt.Errorf("failed to delete %s:/%s: %w", remote.Volume, key, err)
+				}
+			default:
+				err = os.Remove(name)
+			}
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if action.Action == "delete" {
+				result.Deleted++
+			} else {
+				result.Transferred++
+				result.Bytes += n
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s %s\n", action.Action, action.Path)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(result, &output.Config{Format: format})
+}
+
+func listRemoteFiles(ctx context.Context, files *volumeFiles, remote volumePath, ignored func(string) (bool, error)) (map[string]syncFile, error) {
+	objects, _, err := files.client.List(ctx, files.bucket(), remote.prefix(), "")
+	if err != nil {
+		output.Error(err)
+		return nil, fmt.Errorf("failed to list %s: %w", remote, err)
+	}
+	found := map[string]syncFile{}
+	for _, object := range objects {
+		rel := strings.TrimPrefix(object.Key, remote.prefix())
+		if strings.HasSuffix(rel, "/") || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			continue
+		}
+		skip, err := ignored(rel)
+		if err != nil {
+			return nil, err
+		}
+		if !skip {
+			found[rel] = syncFile{size: object.Size, etag: object.ETag}
+		}
+	}
+	return found, nil
+}
+
+func listLocalFiles(root string, ignored func(string) (bool, error)) (map[string]syncFile, error) {
+	found := map[string]syncFile{}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return found, nil
+	}
+	err := filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		skip, err := ignored(rel)
+		if err != nil || skip {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		found[rel] = syncFile{size: info.Size()}
+		return nil
+	})
+	return found, err
+}
+
+// localETag checksums a local file the way the volume computes etags
+func localETag(files *volumeFiles, name string, size int64) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	etag, err := files.client.ETagOf(file, size)
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum %s: %w", name, err)
+	}
+	return etag, nil
+}
+
+// forEach calls fn for 0 to n-1 on up to workers goroutines. it stops
+// starting work at the first error, which it returns.
+func forEach(ctx context.Context, workers, n int, fn func(ctx context.Context, i int) error) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Go(func() {
+			for i := range jobs {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		})
+	}
+send:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = parent.Err()
+	}
+	return firstErr
+}
+
+func sortedKeys(files map[string]syncFile) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,120 @@
+package volume
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseSyncResult(t *testing.T, stdout string) syncResult {
+	t.Helper()
+	var result syncResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("parse: %v\n%s", err, stdout)
+	}
+	return result
+}
+
+func TestRunSync_Upload(t *testing.T) {
+	server := startVolumeFiles(t)
+	resetSyncVars(t)
+	t.Chdir(t.TempDir())
+	writeFile(t, filepath.Join("data", "same.txt"), "same")
+	writeFile(t, filepath.Join("data", "sub", "edited.txt"), "new!")
+	writeFile(t, filepath.Join("data", "added.txt"), "added")
+	writeFile(t, filepath.Join("data", "debug.log"), "ignored by default")
+	server.Put("vol-1", "ds/same.txt", []byte("same"))
+	server.Put("vol-1", "ds/sub/edited.txt", []byte("old!"))
+	server.Put("vol-1", "ds/stale.txt", []byte("stale"))
+	server.Put("vol-1", "ds/remote.log", []byte("kept"))
+
+	syncDelete, syncDryRun = true, true
+	stdout, _, err := runFilesCmd(t, runSync, "data", "models:/ds")
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	plan := parseSyncResult(t, stdout)
+	want := "upload added.txt new,upload sub/edited.txt checksum,delete stale.txt "
+	if got := actionsString(plan.Actions); got != want || plan.Unchanged != 1 || plan.Transferred != 0 {
+		t.Fatalf("unexpected plan %q, %+v", got, plan)
+	}
+	if data, _ := server.Get("vol-1", "ds/sub/edited.txt"); string(data) != "old!" {
+		t.Fatal("dry run changed the volume")
+	}
+
+	syncDryRun = false
+	stdout, stderr, err := runFilesCmd(t, runSync, "data", "models:/ds")
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	result := parseSyncResult(t, stdout)
+	if result.Transferred != 2 || result.Deleted != 1 || result.Bytes != 9 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if keys := strings.Join(server.Keys("vol-1"), ","); keys != "ds/added.txt,ds/remote.log,ds/same.txt,ds/sub/edited.txt" {
+		t.Fatalf("unexpected keys %s", keys)
+	}
+	if !strings.Contains(stderr, "delete stale.txt") {
+		t.Fatalf("expected progress on stderr, got %q", stderr)
+	}
+
+	stdout, _, _ = runFilesCmd(t, runSync, "data", "models:/ds")
+	if result := parseSyncResult(t, stdout); len(result.Actions) != 0 || result.Unchanged != 3 {
+		t.Fatalf("expected nothing to do, got %+v", result)
+	}
+}
+
+func TestRunSync_Download(t *testing.T) {
+	server := startVolumeFiles(t)
+	resetSyncVars(t)
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(".runpodignore", []byte("# scratch space\nresults/tmp/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server.Put("vol-1", "outputs/a.png", []byte("png"))
+	server.Put("vol-1", "outputs/tmp/scratch.bin", []byte("skip"))
+	writeFile(t, filepath.Join("results", "local-only.txt"), "mine")
+
+	syncWorkers = 1
+	syncDelete = true
+	stdout, _, err := runFilesCmd(t, runSync, "vol-1:/outputs", "results")
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if got := actionsString(parseSyncResult(t, stdout).Actions); got != "download a.png new,delete local-only.txt " {
+		t.Fatalf("unexpected actions %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join("results", "a.png")); string(data) != "png" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	for _, name := range []string{"local-only.txt", filepath.Join("tmp", "scratch.bin")} {
+		if _, err := os.Stat(filepath.Join("results", name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be gone or skipped, got %v", name, err)
+		}
+	}
+
+	if _, _, err := runFilesCmd(t, runSync, "vol-1:/missing", "results"); err == nil || !strings.Contains(err.Error(), "no such directory") {
+		t.Fatalf("expected a missing directory error, got %v", err)
+	}
+}
+
+func resetSyncVars(t *testing.T) {
+	t.Helper()
+	del, dry, workers := syncDelete, syncDryRun, syncWorkers
+	t.Cleanup(func() {
+		syncDelete, syncDryRun, syncWorkers = del, dry, workers
+	})
+}
+
+func actionsString(actions []syncAction) string {
+	var b strings.Builder
+	for i, action := range actions {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(action.Action + " " + action.Path + " " + action.Reason)
+	}
+	return b.String()
+}
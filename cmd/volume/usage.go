@@ -0,0 +1,81 @@
+package volume
+
+import (
+	"github.com/runpod/runpodctl/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage <volume>",
+	Short: "show how much of a network volume is used",
+	Long: `show the space used on a network volume next to its provisioned size, with
+a breakdown by top-level directory, largest first. usage is the sum of the
+file sizes, listed through the volume's s3 api.
+
+requires an s3 api key in RUNPOD_S3_ACCESS_KEY_ID and RUNPOD_S3_SECRET_ACCESS_KEY.`,
+	Example: `  runpodctl network-volume usage my-volume`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runUsage,
+}
+
+type volumeUsage struct {
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	DataCenterID string       `json:"dataCenterId"`
+	SizeGb       int          `json:"sizeGb"`
+	Files        int          `json:"files"`
+	UsedBytes    int64        `json:"usedBytes"`
+	Used         string       `json:"used"`
+	FreeBytes    int64        `json:"freeBytes"`
+	Free         string       `json:"free"`
+	UsedPercent  float64      `json:"usedPercent"`
+	RootFiles    *diskUsage   `json:"rootFiles,omitempty"`
+	Directories  []*diskUsage `json:"directories"`
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	files, err := openVolume(args[0])
+	if err != nil {
+		output.Error(err)
+		return err
+	}
+	ctx, stop := filesContext()
+	defer stop()
+
+	du, err := diskUsageOf(ctx, files, volumePath{Volume: args[0], Dir: true})
+	if err != nil {
+		return err
+	}
+
+	volume := files.volume
+	usage := &volumeUsage{
+		ID:           volume.ID,
+		Name:         volume.Name,
+		DataCenterID: volume.DataCenterID,
+		SizeGb:       volume.Size,
+		Files:        du.Files,
+		UsedBytes:    du.Bytes,
+		Used:         du.Size,
+		FreeBytes:    max(int64(volume.Size)<<30-du.Bytes, 0),
+		Directories:  du.Directories,
+	}
+	usage.Free = output.Bytes(usage.FreeBytes)
+	if du.UsedPercent != nil {
+		usage.UsedPercent = *du.UsedPercent
+	}
+
+	// files directly in the root don't belong to any directory
+	root := &diskUsage{Path: "/", Files: du.Files, Bytes: du.Bytes}
+	for _, dir := range du.Directories {
+		root.Files -= dir.Files
+		root.Bytes -= dir.Bytes
+	}
+	if root.Files > 0 {
+		root.Size = output.Bytes(root.Bytes)
+		usage.RootFiles = root
+	}
+
+	format := output.ParseFormat(cmd.Flag("output").Value.String())
+	return output.Print(usage, &output.Config{Format: format})
+}
@@ -18,4 +18,14 @@ func init() {
 	Cmd.AddCommand(createCmd)
 	Cmd.AddCommand(updateCmd)
 	Cmd.AddCommand(deleteCmd)
+	Cmd.AddCommand(lsCmd)
+	Cmd.AddCommand(cpCmd)
+	Cmd.AddCommand(rmCmd)
+	Cmd.AddCommand(catCmd)
+	Cmd.AddCommand(duCmd)
+	Cmd.AddCommand(syncCmd)
+	Cmd.AddCommand(copyCmd)
+	Cmd.AddCommand(usageCmd)
+	Cmd.AddCommand(backupCmd)
+	Cmd.AddCommand(restoreCmd)
 }
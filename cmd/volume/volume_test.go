@@ -21,7 +21,9 @@ func TestVolumeCmd_Structure(t *testing.T) {
 	}
 
 	// check subcommands
-	expectedSubcommands := []string{"list", "get <volume-id>", "create", "update <volume-id>", "delete <volume-id>"}
+	expectedSubcommands := []string{"list", "get <volume-id>", "create", "update <volume-id>", "delete <volume-id>",
+		"ls <volume>[:/path]", "cp <src> <dst>", "rm <volume>:/path...", "cat <volume>:/path...", "du <volume>[:/path]", "sync <src> <dst>", "copy <volume>",
+		"usage <volume>", "backup <volume>[:/path]", "restore <volume>[:/path]"}
 	for _, expected := range expectedSubcommands {
 		found := false
 		for _, cmd := range Cmd.Commands() {
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -63,6 +64,11 @@ func NewClient() (*Client, error) {
 
 // request makes an HTTP request to the API
 func (c *Client) request(method, endpoint string, params url.Values, body interface{}) ([]byte, error) {
+	return c.requestContext(context.Background(), method, endpoint, params, body)
+}
+
+// requestContext makes an HTTP request to the API that ends when ctx does
+func (c *Client) requestContext(ctx context.Context, method, endpoint string, params url.Values, body interface{}) ([]byte, error) {
 	u := c.baseURL + endpoint
 	if params != nil && len(params) > 0 {
 		u += "?" + params.Encode()
@@ -77,7 +83,7 @@ func (c *Client) request(method, endpoint string, params url.Values, body interf
 		reqBody = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, u, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -124,6 +130,11 @@ func (c *Client) Delete(endpoint string) ([]byte, error) {
 	return c.request(http.MethodDelete, endpoint, nil, nil)
 }
 
+// Request makes a request with any method, for passing paths through as is
+func (c *Client) Request(method, endpoint string, params url.Values, body interface{}) ([]byte, error) {
+	return c.request(method, endpoint, params, body)
+}
+
 // APIError represents an error response from the API
 type APIError struct {
 	Error string `json:"error"`
@@ -3,8 +3,10 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -149,6 +151,36 @@ func TestClient_Delete(t *testing.T) {
 	}
 }
 
+func TestClient_Request(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/test/123" || r.URL.Query().Get("dryRun") != "true" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"x"}` {
+			t.Errorf("unexpected body %s", body)
+		}
+		w.Write([]byte(`{"id":"123"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.baseURL = server.URL
+
+	data, err := client.Request(http.MethodPut, "/test/123", url.Values{"dryRun": {"true"}}, json.RawMessage(`{"name":"x"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"id":"123"}` {
+		t.Errorf("unexpected response %s", data)
+	}
+}
+
 func TestClient_ErrorResponse(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/configenv"
+)
+
+// DefaultServerlessURL is the base url of the queue-based endpoint api
+// (/run, /runsync, /status, /health, ...)
+const DefaultServerlessURL = "https://api.runpod.ai/v2"
+
+// EndpointHealth is the job and worker summary returned by /health
+type EndpointHealth struct {
+	Jobs struct {
+		Completed  int `json:"completed"`
+		Failed     int `json:"failed"`
+		InProgress int `json:"inProgress"`
+		InQueue    int `json:"inQueue"`
+		Retried    int `json:"retried"`
+	} `json:"jobs"`
+	Workers struct {
+		Idle         int `json:"idle"`
+		Initializing int `json:"initializing"`
+		Ready        int `json:"ready"`
+		Running      int `json:"running"`
+		Throttled    int `json:"throttled"`
+		Unhealthy    int `json:"unhealthy"`
+	} `json:"workers"`
+}
+
+// Job status values reported by the endpoint api
+const (
+	JobStatusInQueue    = "IN_QUEUE"
+	JobStatusInProgress = "IN_PROGRESS"
+	JobStatusCompleted  = "COMPLETED"
+	JobStatusFailed     = "FAILED"
+	JobStatusCancelled  = "CANCELLED"
+	JobStatusTimedOut   = "TIMED_OUT"
+)
+
+// Job is the status payload of a queued endpoint job. DelayTime and
+// ExecutionTime are in milliseconds.
+type Job struct {
+	ID            string          `json:"id"`
+	Status        string          `json:"status"`
+	DelayTime     int64           `json:"delayTime,omitempty"`
+	ExecutionTime int64           `json:"executionTime,omitempty"`
+	WorkerID      string          `json:"workerId,omitempty"`
+	Output        json.RawMessage `json:"output,omitempty"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// Done reports whether the job reached a final status
+func (j *Job) Done() bool {
+	switch j.Status {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled, JobStatusTimedOut:
+		return true
+	}
+	return false
+}
+
+// serverlessRequest calls the endpoint api instead of the rest api
+func (c *Client) serverlessRequest(method, endpoint string, params url.Values, body interface{}) ([]byte, error) {
+	return c.serverlessRequestContext(context.Background(), method, endpoint, params, body)
+}
+
+// serverlessRequestContext is serverlessRequest cancelled with ctx. it works
+// on a copy of the client rather than swapping baseURL, so jobs can be
+// submitted and polled from several goroutines at once.
+func (c *Client) serverlessRequestContext(ctx context.Context, method, endpoint string, params url.Values, body interface{}) ([]byte, error) {
+	serverlessURL := configenv.ServerlessURL()
+	if serverlessURL == "" {
+		serverlessURL = DefaultServerlessURL
+	}
+
+	serverless := *c
+	serverless.baseURL = serverlessURL
+	return serverless.requestContext(ctx, method, endpoint, params, body)
+}
+
+// GetEndpointHealth returns the job counters and worker states of an endpoint
+func (c *Client) GetEndpointHealth(endpointID string) (*EndpointHealth, error) {
+	data, err := c.serverlessRequest(http.MethodGet, "/"+url.PathEscape(endpointID)+"/health", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var health EndpointHealth
+	if err := json.Unmarshal(data, &health); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &health, nil
+}
+
+// RunJobSync submits a job and waits up to wait for it to finish. a job that
+// is still queued or running when wait ends is returned as is; poll it with
+// GetJob. ctx cancels the wait.
+func (c *Client) RunJobSync(ctx context.Context, endpointID string, input json.RawMessage, wait time.Duration) (*Job, error) {
+	params := url.Values{}
+	if wait > 0 {
+		params.Set("wait", strconv.FormatInt(wait.Milliseconds(), 10))
+	}
+	data, err := c.serverlessRequestContext(ctx, http.MethodPost, "/"+url.PathEscape(endpointID)+"/runsync", params, map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, err
+	}
+	return parseJob(data)
+}
+
+// GetJob returns the current status of a job
+func (c *Client) GetJob(ctx context.Context, endpointID, jobID string) (*Job, error) {
+	data, err := c.serverlessRequestContext(ctx, http.MethodGet, "/"+url.PathEscape(endpointID)+"/status/"+url.PathEscape(jobID), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseJob(data)
+}
+
+// CancelJob cancels a queued or running job
+func (c *Client) CancelJob(ctx context.Context, endpointID, jobID string) (*Job, error) {
+	data, err := c.serverlessRequestContext(ctx, http.MethodPost, "/"+url.PathEscape(endpointID)+"/cancel/"+url.PathEscape(jobID), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseJob(data)
+}
+
+func parseJob(data []byte) (*Job, error) {
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &job, nil
+}
@@ -4,32 +4,34 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strings"
 )
 
 // Endpoint represents a serverless endpoint
 type Endpoint struct {
-	ID                 string                  `json:"id"`
-	Name               string                  `json:"name"`
-	TemplateID         string                  `json:"templateId,omitempty"`
-	GpuIDs             string                  `json:"gpuIds,omitempty"`
-	InstanceIDs        []string                `json:"instanceIds,omitempty"`
-	NetworkVolumeID    string                  `json:"networkVolumeId,omitempty"`
-	NetworkVolumeIDs   []EndpointNetworkVolume `json:"networkVolumeIds,omitempty"`
-	Locations          string                  `json:"locations,omitempty"`
-	IdleTimeout        int                     `json:"idleTimeout,omitempty"`
-	ScalerType         string                  `json:"scalerType,omitempty"`
-	ScalerValue        int                     `json:"scalerValue,omitempty"`
-	WorkersMin         int                     `json:"workersMin,omitempty"`
-	WorkersMax         int                     `json:"workersMax,omitempty"`
-	GpuCount           int                     `json:"gpuCount,omitempty"`
-	MinCudaVersion     string                  `json:"minCudaVersion,omitempty"`
-	Flashboot          *bool                   `json:"flashboot,omitempty"`
-	FlashBootType      string                  `json:"flashBootType,omitempty"`
-	ComputeType        string                  `json:"computeType,omitempty"`
-	ExecutionTimeoutMs int                     `json:"executionTimeoutMs,omitempty"`
-	ModelReferences    []string                `json:"modelReferences,omitempty"`
-	Template           map[string]interface{}  `json:"template,omitempty"`
-	Workers            []interface{}           `json:"workers,omitempty"`
+	ID                  string                  `json:"id"`
+	Name                string                  `json:"name"`
+	TemplateID          string                  `json:"templateId,omitempty"`
+	GpuIDs              string                  `json:"gpuIds,omitempty"`
+	InstanceIDs         []string                `json:"instanceIds,omitempty"`
+	NetworkVolumeID     string                  `json:"networkVolumeId,omitempty"`
+	NetworkVolumeIDs    []EndpointNetworkVolume `json:"networkVolumeIds,omitempty"`
+	Locations           string                  `json:"locations,omitempty"`
+	IdleTimeout         int                     `json:"idleTimeout,omitempty"`
+	ScalerType          string                  `json:"scalerType,omitempty"`
+	ScalerValue         int                     `json:"scalerValue,omitempty"`
+	WorkersMin          int                     `json:"workersMin,omitempty"`
+	WorkersMax          int                     `json:"workersMax,omitempty"`
+	GpuCount            int                     `json:"gpuCount,omitempty"`
+	MinCudaVersion      string                  `json:"minCudaVersion,omitempty"`
+	AllowedCudaVersions CudaVersions            `json:"allowedCudaVersions,omitempty"`
+	Flashboot           *bool                   `json:"flashboot,omitempty"`
+	FlashBootType       string                  `json:"flashBootType,omitempty"`
+	ComputeType         string                  `json:"computeType,omitempty"`
+	ExecutionTimeoutMs  int                     `json:"executionTimeoutMs,omitempty"`
+	ModelReferences     []string                `json:"modelReferences,omitempty"`
+	Template            map[string]interface{}  `json:"template,omitempty"`
+	Workers             []Worker                `json:"workers,omitempty"`
 }
 
 // EndpointNetworkVolume is a multi-region network volume attached to an endpoint.
@@ -57,6 +59,31 @@ func (v *EndpointNetworkVolume) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// CudaVersions is a list of allowed cuda versions. The api returns it either
+// as a comma-separated string or as a list.
+type CudaVersions []string
+
+// UnmarshalJSON accepts both shapes of allowedCudaVersions.
+func (v *CudaVersions) UnmarshalJSON(data []byte) error {
+	var csv string
+	if err := json.Unmarshal(data, &csv); err == nil {
+		*v = nil
+		for _, version := range strings.Split(csv, ",") {
+			if version = strings.TrimSpace(version); version != "" {
+				*v = append(*v, version)
+			}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*v = list
+	return nil
+}
+
 // EndpointListResponse is the response from listing endpoints
 type EndpointListResponse struct {
 	Endpoints []Endpoint `json:"endpoints"`
@@ -188,21 +215,33 @@ func (c *Client) DeleteEndpoint(endpointID string) error {
 	return err
 }
 
-// UpdateEndpointModels sets the model references on an existing endpoint via
-// saveEndpoint. The full current config is round-tripped so that only
-// modelReferences changes — saveEndpoint is a full replace and omitting fields
-// would reset them to server defaults. Pass nil or an empty slice to clear all
-// model references.
-func (c *Client) UpdateEndpointModels(endpointID string, modelRefs []string) (*Endpoint, error) {
-	endpoint, err := c.GetEndpoint(endpointID, false, false)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch endpoint: %w", err)
-	}
-
-	if modelRefs == nil {
-		modelRefs = []string{}
-	}
+// EndpointConfig is the full saveEndpoint input for an existing endpoint.
+// saveEndpoint is a full replace, so every field is always sent; fields left
+// out would be reset to server defaults.
+type EndpointConfig struct {
+	ID                  string                 `json:"id"`
+	Name                string                 `json:"name"`
+	TemplateID          string                 `json:"templateId"`
+	GpuIDs              string                 `json:"gpuIds"`
+	GpuCount            int                    `json:"gpuCount"`
+	InstanceIDs         []string               `json:"instanceIds"`
+	WorkersMin          int                    `json:"workersMin"`
+	WorkersMax          int                    `json:"workersMax"`
+	Locations           string                 `json:"locations"`
+	NetworkVolumeID     string                 `json:"networkVolumeId"`
+	NetworkVolumeIDs    []NetworkVolumeIDInput `json:"networkVolumeIds"`
+	IdleTimeout         int                    `json:"idleTimeout"`
+	ScalerType          string                 `json:"scalerType"`
+	ScalerValue         int                    `json:"scalerValue"`
+	ExecutionTimeoutMs  int                    `json:"executionTimeoutMs"`
+	MinCudaVersion      string                 `json:"minCudaVersion"`
+	AllowedCudaVersions string                 `json:"allowedCudaVersions"`
+	FlashBootType       string                 `json:"flashBootType"`
+	ModelReferences     []string               `json:"modelReferences"`
+}
 
+// EndpointConfigFrom returns the saveEndpoint input that reproduces endpoint.
+func EndpointConfigFrom(endpoint *Endpoint) *EndpointConfig {
 	// saveEndpoint expects networkVolumeIds as [{networkVolumeId}] objects; the
 	// REST read shape uses bare id strings which UnmarshalJSON normalises into
 	// EndpointNetworkVolume — convert to the GraphQL write shape here.
@@ -211,6 +250,53 @@ func (c *Client) UpdateEndpointModels(endpointID string, modelRefs []string) (*E
 		nvIDs[i] = NetworkVolumeIDInput{NetworkVolumeID: nv.NetworkVolumeID}
 	}
 
+	modelRefs := endpoint.ModelReferences
+	if modelRefs == nil {
+		modelRefs = []string{}
+	}
+
+	return &EndpointConfig{
+		ID:                  endpoint.ID,
+		Name:                endpoint.Name,
+		TemplateID:          endpoint.TemplateID,
+		GpuIDs:              endpoint.GpuIDs,
+		GpuCount:            endpoint.GpuCount,
+		InstanceIDs:         endpoint.InstanceIDs,
+		WorkersMin:          endpoint.WorkersMin,
+		WorkersMax:          endpoint.WorkersMax,
+		Locations:           endpoint.Locations,
+		NetworkVolumeID:     endpoint.NetworkVolumeID,
+		NetworkVolumeIDs:    nvIDs,
+		IdleTimeout:         endpoint.IdleTimeout,
+		ScalerType:          endpoint.ScalerType,
+		ScalerValue:         endpoint.ScalerValue,
+		ExecutionTimeoutMs:  endpoint.ExecutionTimeoutMs,
+		MinCudaVersion:      endpoint.MinCudaVersion,
+		AllowedCudaVersions: strings.Join(endpoint.AllowedCudaVersions, ","),
+		FlashBootType:       endpoint.FlashBootType,
+		ModelReferences:     modelRefs,
+	}
+}
+
+// UpdateEndpointConfig reads the current endpoint config, lets apply change
+// it and saves the result via saveEndpoint, so only the fields apply touches
+// change.
+func (c *Client) UpdateEndpointConfig(endpointID string, apply func(*EndpointConfig)) (*Endpoint, error) {
+	endpoint, err := c.GetEndpoint(endpointID, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch endpoint: %w", err)
+	}
+
+	config := EndpointConfigFrom(endpoint)
+	config.ID = endpointID
+	apply(config)
+	if config.ModelReferences == nil {
+		config.ModelReferences = []string{}
+	}
+	if config.NetworkVolumeIDs == nil {
+		config.NetworkVolumeIDs = []NetworkVolumeIDInput{}
+	}
+
 	query := `
 		mutation SaveEndpoint($input: EndpointInput!) {
 			saveEndpoint(input: $input) {
@@ -236,30 +322,7 @@ func (c *Client) UpdateEndpointModels(endpointID string, modelRefs []string) (*E
 		}
 	`
 
-	variables := map[string]interface{}{
-		"input": map[string]interface{}{
-			"id":                 endpointID,
-			"name":               endpoint.Name,
-			"templateId":         endpoint.TemplateID,
-			"gpuIds":             endpoint.GpuIDs,
-			"gpuCount":           endpoint.GpuCount,
-			"instanceIds":        endpoint.InstanceIDs,
-			"workersMin":         endpoint.WorkersMin,
-			"workersMax":         endpoint.WorkersMax,
-			"locations":          endpoint.Locations,
-			"networkVolumeId":    endpoint.NetworkVolumeID,
-			"networkVolumeIds":   nvIDs,
-			"idleTimeout":        endpoint.IdleTimeout,
-			"scalerType":         endpoint.ScalerType,
-			"scalerValue":        endpoint.ScalerValue,
-			"executionTimeoutMs": endpoint.ExecutionTimeoutMs,
-			"minCudaVersion":     endpoint.MinCudaVersion,
-			"flashBootType":      endpoint.FlashBootType,
-			"modelReferences":    modelRefs,
-		},
-	}
-
-	data, err := c.graphqlRequest(query, variables)
+	data, err := c.graphqlRequest(query, map[string]interface{}{"input": config})
 	if err != nil {
 		return nil, err
 	}
@@ -288,6 +351,15 @@ func (c *Client) UpdateEndpointModels(endpointID string, modelRefs []string) (*E
 	return resp.Data.SaveEndpoint, nil
 }
 
+// UpdateEndpointModels sets the model references on an existing endpoint,
+// keeping the rest of its config. Pass nil or an empty slice to clear all
+// model references.
+func (c *Client) UpdateEndpointModels(endpointID string, modelRefs []string) (*Endpoint, error) {
+	return c.UpdateEndpointConfig(endpointID, func(config *EndpointConfig) {
+		config.ModelReferences = modelRefs
+	})
+}
+
 // NetworkVolumeIDInput is a single multi-region network volume entry for the
 // graphql saveEndpoint mutation (rest uses a flat []string instead).
 type NetworkVolumeIDInput struct {
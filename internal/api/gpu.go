@@ -160,6 +160,59 @@ func (c *Client) ListGpuTypes(includeUnavailable bool) ([]GpuTypeWithAvailabilit
 	return result, nil
 }
 
+// GpuHourlyPrices returns the lowest on-demand secure cloud price per hour
+// of one gpu of each type, by gpu type id. gpu types without a price are
+// left out.
+func (c *Client) GpuHourlyPrices() (map[string]float64, error) {
+	query := `
+		query LowestPrice($input: GpuLowestPriceInput!) {
+			gpuTypes {
+				id
+				lowestPrice(input: $input) {
+					uninterruptablePrice
+				}
+			}
+		}
+	`
+
+	data, err := c.graphqlRequest(query, map[string]interface{}{
+		"input": map[string]interface{}{"gpuCount": 1, "secureCloud": true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			GpuTypes []struct {
+				ID          string `json:"id"`
+				LowestPrice *struct {
+					UninterruptablePrice float64 `json:"uninterruptablePrice"`
+				} `json:"lowestPrice"`
+			} `json:"gpuTypes"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %s", resp.Errors[0].Message)
+	}
+
+	prices := make(map[string]float64)
+	for _, gpu := range resp.Data.GpuTypes {
+		if gpu.LowestPrice != nil && gpu.LowestPrice.UninterruptablePrice > 0 {
+			prices[gpu.ID] = gpu.LowestPrice.UninterruptablePrice
+		}
+	}
+	return prices, nil
+}
+
 func betterStock(a, b string) bool {
 	order := map[string]int{"High": 3, "Medium": 2, "Low": 1, "": 0}
 	return order[a] > order[b]
@@ -210,6 +263,46 @@ func (c *Client) ListServerlessGpuPools() ([]ServerlessGpuPool, error) {
 	return resp.Data.ServerlessGpuPools, nil
 }
 
+// ServerlessPoolStock returns the best stock status of the given gpu pools
+// (or gpu type ids) in each data center. data centers without any of their
+// gpus are left out.
+func (c *Client) ServerlessPoolStock(gpuIDs []string) (map[string]string, error) {
+	pools, err := c.ListServerlessGpuPools()
+	if err != nil {
+		return nil, err
+	}
+
+	gpuTypes := make(map[string]bool)
+	for _, id := range gpuIDs {
+		gpuTypes[strings.ToLower(id)] = true
+		for _, p := range pools {
+			if strings.EqualFold(p.ID, id) {
+				for _, t := range p.GpuTypeIDs {
+					gpuTypes[strings.ToLower(t)] = true
+				}
+			}
+		}
+	}
+
+	dataCenters, err := c.ListDataCenters()
+	if err != nil {
+		return nil, err
+	}
+
+	stock := make(map[string]string)
+	for _, dc := range dataCenters {
+		for _, avail := range dc.GpuAvailability {
+			if !gpuTypes[strings.ToLower(avail.GpuTypeID)] || avail.StockStatus == "" {
+				continue
+			}
+			if current, ok := stock[dc.ID]; !ok || betterStock(avail.StockStatus, current) {
+				stock[dc.ID] = avail.StockStatus
+			}
+		}
+	}
+	return stock, nil
+}
+
 // ResolveServerlessGpuPoolID maps a --gpu-id value to the gpu pool id(s) that
 // saveEndpoint expects. it accepts a pool id (returned as-is), a gpu type id
 // (translated to its pool id), or a comma-separated mix of those (e.g. a hub
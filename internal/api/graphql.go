@@ -317,6 +317,28 @@ type CreatePodGQLInput struct {
 	Compliance              []string     `json:"compliance,omitempty"`
 }
 
+// DockerArgsJSON reconstructs the backend's canonical dockerArgs encoding
+// (`{"cmd":[...],"entrypoint":[...]}`) from the REST entrypoint and start
+// command fields, so each argument keeps its quoting. Template port-label
+// writes re-send it so a briefly stale post-create read can't blank the
+// start command, and pod clone uses it to start the same process as the
+// source. Returns "" when neither field is set (so the override stays nil
+// and a genuinely command-less template is left untouched).
+func DockerArgsJSON(entrypoint, cmd []string) string {
+	if len(entrypoint) == 0 && len(cmd) == 0 {
+		return ""
+	}
+	payload := struct {
+		Cmd        []string `json:"cmd,omitempty"`
+		Entrypoint []string `json:"entrypoint,omitempty"`
+	}{Cmd: cmd, Entrypoint: entrypoint}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
 // CreatePod creates a pod via GraphQL (podFindAndDeployOnDemand)
 func (c *GraphQLClient) CreatePod(input *CreatePodGQLInput) (map[string]interface{}, error) {
 	gqlInput := GraphQLInput{
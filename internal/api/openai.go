@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/runpod/runpodctl/internal/configenv"
+)
+
+// ChatMessage is one message of an openai-compatible chat
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionRequest is the body of /chat/completions. Stream and
+// StreamOptions are set by StreamChatCompletion.
+type ChatCompletionRequest struct {
+	Model         string             `json:"model"`
+	Messages      []ChatMessage      `json:"messages"`
+	MaxTokens     int                `json:"max_tokens,omitempty"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	Stream        bool               `json:"stream"`
+	StreamOptions *chatStreamOptions `json:"stream_options,omitempty"`
+}
+
+type chatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ChatUsage is the token usage of a completion
+type ChatUsage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// ChatCompletion is a streamed completion put back together
+type ChatCompletion struct {
+	ID           string     `json:"id,omitempty"`
+	Model        string     `json:"model"`
+	Content      string     `json:"content"`
+	FinishReason string     `json:"finishReason,omitempty"`
+	Usage        *ChatUsage `json:"usage,omitempty"`
+}
+
+// chatCompletionChunk is one server-sent event of a streamed completion
+type chatCompletionChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// OpenAIURL returns the openai-compatible base url of an endpoint
+func OpenAIURL(endpointID string) string {
+	serverlessURL := configenv.ServerlessURL()
+	if serverlessURL == "" {
+		serverlessURL = DefaultServerlessURL
+	}
+	return strings.TrimRight(serverlessURL, "/") + "/" + url.PathEscape(endpointID) + "/openai/v1"
+}
+
+// ListOpenAIModels returns the model ids served by an openai-compatible endpoint
+func (c *Client) ListOpenAIModels(endpointID string) ([]string, error) {
+	data, err := c.serverlessRequest(http.MethodGet, "/"+url.PathEscape(endpointID)+"/openai/v1/models", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	models := make([]string, 0, len(resp.Data))
+	for _, model := range resp.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
+// StreamChatCompletion sends a streaming chat completion and calls onToken
+// with every content delta as it arrives. the returned completion holds the
+// whole reply and, when the server reports it, the token usage.
+func (c *Client) StreamChatCompletion(ctx context.Context, endpointID string, req *ChatCompletionRequest, onToken func(string)) (*ChatCompletion, error) {
+	body := *req
+	body.Stream = true
+	body.StreamOptions = &chatStreamOptions{IncludeUsage: true}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, OpenAIURL(endpointID)+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+
+	// a long generation can outlive the client timeout, which also covers
+	// reading the body, so streams are bounded by ctx only
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("api error: %s (status %d)", string(respBody), resp.StatusCode)
+	}
+
+	completion := &ChatCompletion{Model: req.Model}
+	var content strings.Builder
+	err = readServerSentEvents(resp.Body, func(data string) error {
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse stream event: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("api error: %s", chunk.Error.Message)
+		}
+		if chunk.ID != "" {
+			completion.ID = chunk.ID
+		}
+		if chunk.Model != "" {
+			completion.Model = chunk.Model
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				if onToken != nil {
+					onToken(choice.Delta.Content)
+				}
+			}
+			if choice.FinishReason != nil {
+				completion.FinishReason = *choice.FinishReason
+			}
+		}
+		if chunk.Usage != nil {
+			completion.Usage = &ChatUsage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+		return nil
+	})
+	completion.Content = content.String()
+	return completion, err
+}
+
+// readServerSentEvents calls fn with the data of every event until the
+// stream ends or sends [DONE]. multi-line data is joined with newlines.
+func readServerSentEvents(r io.Reader, fn func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var data []string
+	flush := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		event := strings.Join(data, "\n")
+		data = data[:0]
+		if event == "[DONE]" {
+			return io.EOF
+		}
+		return fn(event)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// comments (":") and other fields (event, id, retry) carry nothing we use
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	if err := flush(); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseServer streams events the way vllm's openai server does
+func sseServer(t *testing.T, events []string, check func(*http.Request)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			check(r)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+			flusher.Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	t.Setenv("RUNPOD_SERVERLESS_URL", server.URL)
+	return server
+}
+
+func TestStreamChatCompletion_TokensAndUsage(t *testing.T) {
+	sseServer(t, []string{
+		`{"id":"c-1","model":"llama","choices":[{"delta":{"role":"assistant"}}]}`,
+		`{"id":"c-1","choices":[{"delta":{"content":"Hel"}}]}`,
+		`{"id":"c-1","choices":[{"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+		`{"id":"c-1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+		`[DONE]`,
+		`{"id":"c-1","choices":[{"delta":{"content":"ignored"}}]}`,
+	}, func(r *http.Request) {
+		if r.URL.Path != "/ep-1/openai/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true || body["model"] != "llama" {
+			t.Errorf("unexpected body %v", body)
+		}
+		if options, _ := body["stream_options"].(map[string]interface{}); options["include_usage"] != true {
+			t.Errorf("expected include_usage, got %v", body["stream_options"])
+		}
+	})
+
+	client, _ := NewClient()
+	var tokens []string
+	completion, err := client.StreamChatCompletion(context.Background(), "ep-1", &ChatCompletionRequest{
+		Model:    "llama",
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+	}, func(token string) { tokens = append(tokens, token) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(tokens, "|") != "Hel|lo" || completion.Content != "Hello" || completion.FinishReason != "stop" {
+		t.Fatalf("unexpected completion %+v (tokens %v)", completion, tokens)
+	}
+	if completion.Usage == nil || completion.Usage.TotalTokens != 7 || completion.Usage.CompletionTokens != 2 {
+		t.Fatalf("unexpected usage %+v", completion.Usage)
+	}
+}
+
+func TestStreamChatCompletion_ErrorEvent(t *testing.T) {
+	sseServer(t, []string{
+		`{"choices":[{"delta":{"content":"par"}}]}`,
+		`{"error":{"message":"context length exceeded"}}`,
+	}, nil)
+
+	client, _ := NewClient()
+	completion, err := client.StreamChatCompletion(context.Background(), "ep-1", &ChatCompletionRequest{Model: "llama"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "context length exceeded") {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+	if completion.Content != "par" {
+		t.Fatalf("expected partial content, got %q", completion.Content)
+	}
+}
+
+func TestReadServerSentEvents_MultilineAndComments(t *testing.T) {
+	stream := ": keep-alive\n\nevent: message\ndata: line one\ndata: line two\n\ndata: last"
+	var events []string
+	err := readServerSentEvents(strings.NewReader(stream), func(data string) error {
+		events = append(events, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0] != "line one\nline two" || events[1] != "last" {
+		t.Fatalf("unexpected events %q", events)
+	}
+}
+
+func TestListOpenAIModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ep-1/openai/v1/models" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"llama"},{"id":"lora"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	t.Setenv("RUNPOD_SERVERLESS_URL", server.URL)
+
+	client, _ := NewClient()
+	models, err := client.ListOpenAIModels("ep-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0] != "llama" {
+		t.Fatalf("unexpected models %v", models)
+	}
+}
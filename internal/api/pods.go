@@ -27,6 +27,15 @@ type Pod struct {
 	Machine           map[string]interface{} `json:"machine,omitempty"`
 	Runtime           map[string]interface{} `json:"runtime,omitempty"`
 	Env               map[string]string      `json:"env,omitempty"`
+	// Fields below are only read by commands that recreate a pod (clone,
+	// migrate); the rest read shape returns them alongside the basics.
+	TemplateID              string         `json:"templateId,omitempty"`
+	DockerEntrypoint        []string       `json:"dockerEntrypoint,omitempty"`
+	DockerStartCmd          []string       `json:"dockerStartCmd,omitempty"`
+	ContainerRegistryAuthID string         `json:"containerRegistryAuthId,omitempty"`
+	Interruptible           bool           `json:"interruptible,omitempty"`
+	LastStartedAt           string         `json:"lastStartedAt,omitempty"`
+	NetworkVolume           *NetworkVolume `json:"networkVolume,omitempty"`
 }
 
 // PodListResponse is the response from listing pods
@@ -36,24 +45,26 @@ type PodListResponse struct {
 
 // PodCreateRequest is the request to create a pod
 type PodCreateRequest struct {
-	Name              string            `json:"name,omitempty"`
-	ImageName         string            `json:"imageName,omitempty"`
-	TemplateID        string            `json:"templateId,omitempty"`
-	ComputeType       string            `json:"computeType,omitempty"`
-	GlobalNetworking  bool              `json:"globalNetworking,omitempty"`
-	SupportPublicIp   bool              `json:"supportPublicIp,omitempty"`
-	GpuTypeIDs        []string          `json:"gpuTypeIds,omitempty"`
-	GpuCount          int               `json:"gpuCount,omitempty"`
-	VolumeInGb        int               `json:"volumeInGb,omitempty"`
-	ContainerDiskInGb int               `json:"containerDiskInGb,omitempty"`
-	VolumeMountPath   string            `json:"volumeMountPath,omitempty"`
-	Ports             []string          `json:"ports,omitempty"`
-	Env               map[string]string `json:"env,omitempty"`
-	CloudType         string            `json:"cloudType,omitempty"`
-	DataCenterIDs     []string          `json:"dataCenterIds,omitempty"`
-	NetworkVolumeID   string            `json:"networkVolumeId,omitempty"`
-	MinCudaVersion    string            `json:"minCudaVersion,omitempty"`
-	DockerArgs        string            `json:"dockerArgs,omitempty"`
+	Name                    string            `json:"name,omitempty"`
+	ImageName               string            `json:"imageName,omitempty"`
+	TemplateID              string            `json:"templateId,omitempty"`
+	ComputeType             string            `json:"computeType,omitempty"`
+	GlobalNetworking        bool              `json:"globalNetworking,omitempty"`
+	SupportPublicIp         bool              `json:"supportPublicIp,omitempty"`
+	GpuTypeIDs              []string          `json:"gpuTypeIds,omitempty"`
+	GpuCount                int               `json:"gpuCount,omitempty"`
+	VolumeInGb              int               `json:"volumeInGb,omitempty"`
+	ContainerDiskInGb       int               `json:"containerDiskInGb,omitempty"`
+	VolumeMountPath         string            `json:"volumeMountPath,omitempty"`
+	Ports                   []string          `json:"ports,omitempty"`
+	Env                     map[string]string `json:"env,omitempty"`
+	CloudType               string            `json:"cloudType,omitempty"`
+	DataCenterIDs           []string          `json:"dataCenterIds,omitempty"`
+	NetworkVolumeID         string            `json:"networkVolumeId,omitempty"`
+	MinCudaVersion          string            `json:"minCudaVersion,omitempty"`
+	DockerArgs              string            `json:"dockerArgs,omitempty"`
+	ContainerRegistryAuthID string            `json:"containerRegistryAuthId,omitempty"`
+	Interruptible           bool              `json:"interruptible,omitempty"`
 }
 
 // PodUpdateRequest is the request to update a pod
@@ -159,6 +170,25 @@ func (c *Client) UpdatePod(podID string, req *PodUpdateRequest) (*Pod, error) {
 	return &pod, nil
 }
 
+// UpdatePodEnv replaces a pod's env with env. Unlike UpdatePod, the env field
+// is always sent, so an empty map clears every variable.
+func (c *Client) UpdatePodEnv(podID string, env map[string]string) (*Pod, error) {
+	if env == nil {
+		env = map[string]string{}
+	}
+	data, err := c.Patch("/pods/"+podID, map[string]interface{}{"env": env})
+	if err != nil {
+		return nil, err
+	}
+
+	var pod Pod
+	if err := json.Unmarshal(data, &pod); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &pod, nil
+}
+
 // StartPod starts a stopped pod
 func (c *Client) StartPod(podID string) (*Pod, error) {
 	data, err := c.Post("/pods/"+podID+"/start", nil)
@@ -206,3 +206,30 @@ func TestDeletePod(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestUpdatePodEnv_SendsEmptyMap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/pods/pod-1" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		env, ok := body["env"].(map[string]interface{})
+		if !ok || len(env) != 0 {
+			t.Errorf("expected empty env object, got %#v", body["env"])
+		}
+		json.NewEncoder(w).Encode(Pod{ID: "pod-1"})
+	}))
+	defer server.Close()
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+
+	client, _ := NewClient()
+	client.baseURL = server.URL
+
+	if _, err := client.UpdatePodEnv("pod-1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -74,3 +74,50 @@ func (c *Client) DeleteContainerRegistryAuth(authID string) error {
 	_, err := c.Delete("/containerregistryauth/" + authID)
 	return err
 }
+
+// ContainerRegistryAuthUpdateInput replaces the credentials of a container
+// registry auth, keeping its id
+type ContainerRegistryAuthUpdateInput struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// UpdateContainerRegistryAuth replaces a container registry auth's
+// credentials via GraphQL; the REST API can't update them
+func (c *GraphQLClient) UpdateContainerRegistryAuth(input *ContainerRegistryAuthUpdateInput) (*ContainerRegistryAuth, error) {
+	body, err := c.Query(GraphQLInput{
+		Query: `
+		mutation updateRegistryAuth($input: UpdateRegistryAuthInput!) {
+			updateRegistryAuth(input: $input) {
+				id
+				name
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": input},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Data struct {
+			Auth *ContainerRegistryAuth `json:"updateRegistryAuth"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(data.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %s", data.Errors[0].Message)
+	}
+	if data.Data.Auth == nil {
+		return nil, fmt.Errorf("registry auth update returned nil response")
+	}
+
+	return data.Data.Auth, nil
+}
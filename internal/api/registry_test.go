@@ -109,3 +109,33 @@ func TestDeleteContainerRegistryAuth(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestUpdateContainerRegistryAuth(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input GraphQLInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		got, _ = input.Variables["input"].(map[string]interface{})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"updateRegistryAuth": map[string]interface{}{"id": "reg-123", "name": "ghcr"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := &GraphQLClient{url: server.URL, apiKey: "test-key", httpClient: server.Client(), userAgent: "test"}
+
+	auth, err := client.UpdateContainerRegistryAuth(&ContainerRegistryAuthUpdateInput{ID: "reg-123", Username: "me", Password: "new"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth.ID != "reg-123" {
+		t.Errorf("expected reg-123, got %s", auth.ID)
+	}
+	if got["id"] != "reg-123" || got["username"] != "me" || got["password"] != "new" {
+		t.Errorf("unexpected input %v", got)
+	}
+}
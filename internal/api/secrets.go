@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Secret is the metadata of a runpod secret. The value is write-only and is
+// never returned by the api.
+type Secret struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	CreatedAt   string `json:"createdAt,omitempty"`
+	LastUsedAt  string `json:"lastUsedAt,omitempty"`
+}
+
+// SecretCreateInput is the input for the secretCreate mutation
+type SecretCreateInput struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecretUpdateInput is the input for the secretUpdate mutation. Nil fields are
+// left unchanged.
+type SecretUpdateInput struct {
+	ID          string  `json:"id"`
+	Name        *string `json:"name,omitempty"`
+	Value       *string `json:"value,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ListSecrets returns the metadata of all secrets in the account
+func (c *GraphQLClient) ListSecrets() ([]Secret, error) {
+	body, err := c.Query(GraphQLInput{
+		Query: `
+		query secrets {
+			myself {
+				secrets {
+					id
+					name
+					description
+					createdAt
+					lastUsedAt
+				}
+			}
+		}
+		`,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Data struct {
+			Myself struct {
+				Secrets []Secret `json:"secrets"`
+			} `json:"myself"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(data.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %s", data.Errors[0].Message)
+	}
+
+	return data.Data.Myself.Secrets, nil
+}
+
+// GetSecret returns the metadata of a secret by id or name
+func (c *GraphQLClient) GetSecret(idOrName string) (*Secret, error) {
+	secrets, err := c.ListSecrets()
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets {
+		if secrets[i].ID == idOrName || secrets[i].Name == idOrName {
+			return &secrets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("secret not found: %s", idOrName)
+}
+
+// CreateSecret creates a secret via GraphQL
+func (c *GraphQLClient) CreateSecret(input *SecretCreateInput) (*Secret, error) {
+	body, err := c.Query(GraphQLInput{
+		Query: `
+		mutation secretCreate($input: SecretCreateInput!) {
+			secretCreate(input: $input) {
+				id
+				name
+				description
+				createdAt
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": input},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Data struct {
+			Secret *Secret `json:"secretCreate"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(data.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %s", data.Errors[0].Message)
+	}
+	if data.Data.Secret == nil {
+		return nil, fmt.Errorf("secret creation returned nil response")
+	}
+
+	return data.Data.Secret, nil
+}
+
+// UpdateSecret updates a secret's name, description or value via GraphQL
+func (c *GraphQLClient) UpdateSecret(input *SecretUpdateInput) (*Secret, error) {
+	body, err := c.Query(GraphQLInput{
+		Query: `
+		mutation secretUpdate($input: SecretUpdateInput!) {
+			secretUpdate(input: $input) {
+				id
+				name
+				description
+				createdAt
+				lastUsedAt
+			}
+		}
+		`,
+		Variables: map[string]interface{}{"input": input},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Data struct {
+			Secret *Secret `json:"secretUpdate"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(data.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %s", data.Errors[0].Message)
+	}
+	if data.Data.Secret == nil {
+		return nil, fmt.Errorf("secret update returned nil response")
+	}
+
+	return data.Data.Secret, nil
+}
+
+// DeleteSecret deletes a secret via GraphQL
+func (c *GraphQLClient) DeleteSecret(secretID string) error {
+	body, err := c.Query(GraphQLInput{
+		Query: `
+		mutation secretDelete($id: String!) {
+			secretDelete(id: $id)
+		}
+		`,
+		Variables: map[string]interface{}{"id": secretID},
+	})
+	if err != nil {
+		return err
+	}
+
+	var data struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(data.Errors) > 0 {
+		return fmt.Errorf("graphql error: %s", data.Errors[0].Message)
+	}
+
+	return nil
+}
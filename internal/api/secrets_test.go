@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecrets_GetByNameAndCreate(t *testing.T) {
+	var createdValue string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input GraphQLInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+
+		switch {
+		case strings.Contains(input.Query, "query secrets"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"myself": map[string]interface{}{
+						"secrets": []map[string]interface{}{
+							{"id": "sec-1", "name": "hf_token"},
+							{"id": "sec-2", "name": "openai_key"},
+						},
+					},
+				},
+			})
+		case strings.Contains(input.Query, "mutation secretCreate"):
+			createdValue, _ = input.Variables["input"].(map[string]interface{})["value"].(string)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"secretCreate": map[string]interface{}{"id": "sec-3", "name": "new_secret"},
+				},
+			})
+		default:
+			t.Fatalf("unexpected query: %s", input.Query)
+		}
+	}))
+	defer server.Close()
+
+	client := &GraphQLClient{
+		url:        server.URL,
+		apiKey:     "test-key",
+		httpClient: server.Client(),
+		userAgent:  "test",
+	}
+
+	secret, err := client.GetSecret("openai_key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.ID != "sec-2" {
+		t.Fatalf("expected sec-2, got %q", secret.ID)
+	}
+	if _, err := client.GetSecret("missing"); err == nil {
+		t.Fatal("expected not found error")
+	}
+
+	created, err := client.CreateSecret(&SecretCreateInput{Name: "new_secret", Value: "s3cr3t"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.ID != "sec-3" || createdValue != "s3cr3t" {
+		t.Fatalf("unexpected create: %+v value=%q", created, createdValue)
+	}
+}
+
+func TestDeleteSecret_GraphQLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errors": []map[string]interface{}{{"message": "secret not found"}},
+		})
+	}))
+	defer server.Close()
+
+	client := &GraphQLClient{
+		url:        server.URL,
+		apiKey:     "test-key",
+		httpClient: server.Client(),
+		userAgent:  "test",
+	}
+
+	err := client.DeleteSecret("sec-1")
+	if err == nil || !strings.Contains(err.Error(), "secret not found") {
+		t.Fatalf("expected graphql error, got %v", err)
+	}
+}
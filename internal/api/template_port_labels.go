@@ -28,7 +28,13 @@ type TemplatePortLabelOverrides struct {
 	// {"cmd":[...],"entrypoint":[...]} dockerArgs encoding) through the label
 	// write. Without it, a briefly-stale post-create GraphQL read can return an
 	// empty dockerArgs and the write would blank the command (see NEW-1).
-	DockerArgs *string
+	DockerArgs    *string
+	IsPublic      *bool
+	StartJupyter  *bool
+	StartSSH      *bool
+	StartScript   *string
+	AdvancedStart *bool
+	Category      *string
 }
 
 var errTemplateForPortLabelsNotFound = errors.New("template not found")
@@ -39,47 +45,32 @@ const (
 )
 
 type templateSaveState struct {
-	ID                      string            `json:"id"`
-	Name                    string            `json:"name"`
-	ImageName               string            `json:"imageName"`
-	DockerArgs              string            `json:"dockerArgs"`
-	Env                     []templateEnvPair `json:"env"`
-	Ports                   templatePorts     `json:"ports"`
-	VolumeMountPath         string            `json:"volumeMountPath"`
-	VolumeInGb              int               `json:"volumeInGb"`
-	ContainerDiskInGb       int               `json:"containerDiskInGb"`
-	ContainerRegistryAuthID string            `json:"containerRegistryAuthId"`
-	StartJupyter            bool              `json:"startJupyter"`
-	StartSSH                bool              `json:"startSsh"`
-	StartScript             string            `json:"startScript"`
-	IsServerless            bool              `json:"isServerless"`
-	IsPublic                bool              `json:"isPublic"`
-	Readme                  string            `json:"readme"`
-	AdvancedStart           bool              `json:"advancedStart"`
-	Category                string            `json:"category"`
+	ID                      string               `json:"id"`
+	Name                    string               `json:"name"`
+	ImageName               string               `json:"imageName"`
+	DockerArgs              string               `json:"dockerArgs"`
+	Env                     []templateEnvPair    `json:"env"`
+	Ports                   templatePorts        `json:"ports"`
+	PortsConfig             []TemplatePortConfig `json:"portsConfig"`
+	VolumeMountPath         string               `json:"volumeMountPath"`
+	VolumeInGb              int                  `json:"volumeInGb"`
+	ContainerDiskInGb       int                  `json:"containerDiskInGb"`
+	ContainerRegistryAuthID string               `json:"containerRegistryAuthId"`
+	StartJupyter            bool                 `json:"startJupyter"`
+	StartSSH                bool                 `json:"startSsh"`
+	StartScript             string               `json:"startScript"`
+	IsServerless            bool                 `json:"isServerless"`
+	IsPublic                bool                 `json:"isPublic"`
+	Readme                  string               `json:"readme"`
+	AdvancedStart           bool                 `json:"advancedStart"`
+	Category                string               `json:"category"`
 }
 
 // UpdateTemplatePortLabels updates the dashboard labels for a template's
 // exposed ports. Port labels are available through GraphQL's portsConfig
 // field, but not through the public REST template schema.
 func (c *GraphQLClient) UpdateTemplatePortLabels(templateID string, labels []TemplatePortConfig, overrides *TemplatePortLabelOverrides) error {
-	var (
-		state *templateSaveState
-		err   error
-	)
-
-	for attempt := 0; attempt < templatePortLabelFetchAttempts; attempt++ {
-		state, err = c.getTemplateSaveState(templateID)
-		if err == nil {
-			break
-		}
-		if !errors.Is(err, errTemplateForPortLabelsNotFound) {
-			return err
-		}
-		if attempt+1 < templatePortLabelFetchAttempts {
-			time.Sleep(templatePortLabelRetryDelay)
-		}
-	}
+	state, err := c.waitForTemplateSaveState(templateID)
 	if err != nil {
 		return err
 	}
@@ -117,10 +108,11 @@ func (c *GraphQLClient) UpdateTemplatePortLabels(templateID string, labels []Tem
 	if state.VolumeMountPath != "" {
 		input["volumeMountPath"] = state.VolumeMountPath
 	}
-	if state.StartScript != "" {
+	// empty values are only sent to clear a field on purpose
+	if state.StartScript != "" || (overrides != nil && overrides.StartScript != nil) {
 		input["startScript"] = state.StartScript
 	}
-	if state.Category != "" {
+	if state.Category != "" || (overrides != nil && overrides.Category != nil) {
 		input["category"] = state.Category
 	}
 
@@ -161,6 +153,25 @@ func (c *GraphQLClient) UpdateTemplatePortLabels(templateID string, labels []Tem
 	return nil
 }
 
+// waitForTemplateSaveState reads a template's save state, retrying while a
+// template created moments ago isn't visible to graphql yet
+func (c *GraphQLClient) waitForTemplateSaveState(templateID string) (*templateSaveState, error) {
+	var (
+		state *templateSaveState
+		err   error
+	)
+	for attempt := 0; attempt < templatePortLabelFetchAttempts; attempt++ {
+		state, err = c.getTemplateSaveState(templateID)
+		if err == nil || !errors.Is(err, errTemplateForPortLabelsNotFound) {
+			return state, err
+		}
+		if attempt+1 < templatePortLabelFetchAttempts {
+			time.Sleep(templatePortLabelRetryDelay)
+		}
+	}
+	return nil, err
+}
+
 func (c *GraphQLClient) getTemplateSaveState(templateID string) (*templateSaveState, error) {
 	body, err := c.Query(GraphQLInput{
 		Query: `
@@ -176,6 +187,10 @@ func (c *GraphQLClient) getTemplateSaveState(templateID string) (*templateSaveSt
 						value
 					}
 					ports
+					portsConfig {
+						port
+						name
+					}
 					volumeMountPath
 					volumeInGb
 					containerDiskInGb
@@ -260,6 +275,24 @@ func applyTemplatePortLabelOverrides(state *templateSaveState, overrides *Templa
 	if overrides.DockerArgs != nil {
 		state.DockerArgs = *overrides.DockerArgs
 	}
+	if overrides.IsPublic != nil {
+		state.IsPublic = *overrides.IsPublic
+	}
+	if overrides.StartJupyter != nil {
+		state.StartJupyter = *overrides.StartJupyter
+	}
+	if overrides.StartSSH != nil {
+		state.StartSSH = *overrides.StartSSH
+	}
+	if overrides.StartScript != nil {
+		state.StartScript = *overrides.StartScript
+	}
+	if overrides.AdvancedStart != nil {
+		state.AdvancedStart = *overrides.AdvancedStart
+	}
+	if overrides.Category != nil {
+		state.Category = *overrides.Category
+	}
 }
 
 func templateEnvPairs(env map[string]string) []templateEnvPair {
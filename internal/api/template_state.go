@@ -0,0 +1,117 @@
+package api
+
+import (
+	"strings"
+)
+
+// TemplateState is the full state of a template, every field the graphql
+// saveTemplate mutation writes, as template history records it
+type TemplateState struct {
+	ID                      string               `json:"id"`
+	Name                    string               `json:"name"`
+	ImageName               string               `json:"imageName"`
+	DockerArgs              string               `json:"dockerArgs,omitempty"`
+	Env                     map[string]string    `json:"env,omitempty"`
+	Ports                   []string             `json:"ports,omitempty"`
+	PortsConfig             []TemplatePortConfig `json:"portsConfig,omitempty"`
+	VolumeMountPath         string               `json:"volumeMountPath,omitempty"`
+	VolumeInGb              int                  `json:"volumeInGb"`
+	ContainerDiskInGb       int                  `json:"containerDiskInGb"`
+	ContainerRegistryAuthID string               `json:"containerRegistryAuthId,omitempty"`
+	StartJupyter            bool                 `json:"startJupyter"`
+	StartSSH                bool                 `json:"startSsh"`
+	StartScript             string               `json:"startScript,omitempty"`
+	IsServerless            bool                 `json:"isServerless"`
+	IsPublic                bool                 `json:"isPublic"`
+	Readme                  string               `json:"readme,omitempty"`
+	AdvancedStart           bool                 `json:"advancedStart"`
+	Category                string               `json:"category,omitempty"`
+}
+
+// GetTemplateState returns the full state of a template
+func (c *GraphQLClient) GetTemplateState(templateID string) (*TemplateState, error) {
+	state, err := c.getTemplateSaveState(templateID)
+	if err != nil {
+		return nil, err
+	}
+	return templateStateOf(state), nil
+}
+
+// WaitForTemplateState is GetTemplateState for a template that may have been
+// created moments ago, retrying briefly while graphql can't see it yet
+func (c *GraphQLClient) WaitForTemplateState(templateID string) (*TemplateState, error) {
+	state, err := c.waitForTemplateSaveState(templateID)
+	if err != nil {
+		return nil, err
+	}
+	return templateStateOf(state), nil
+}
+
+func templateStateOf(state *templateSaveState) *TemplateState {
+	result := &TemplateState{
+		ID:                      state.ID,
+		Name:                    state.Name,
+		ImageName:               state.ImageName,
+		DockerArgs:              state.DockerArgs,
+		Ports:                   []string(state.Ports),
+		PortsConfig:             state.PortsConfig,
+		VolumeMountPath:         state.VolumeMountPath,
+		VolumeInGb:              state.VolumeInGb,
+		ContainerDiskInGb:       state.ContainerDiskInGb,
+		ContainerRegistryAuthID: state.ContainerRegistryAuthID,
+		StartJupyter:            state.StartJupyter,
+		StartSSH:                state.StartSSH,
+		StartScript:             state.StartScript,
+		IsServerless:            state.IsServerless,
+		IsPublic:                state.IsPublic,
+		Readme:                  state.Readme,
+		AdvancedStart:           state.AdvancedStart,
+		Category:                state.Category,
+	}
+	for _, pair := range state.Env {
+		if strings.TrimSpace(pair.Key) == "" {
+			continue
+		}
+		if result.Env == nil {
+			result.Env = map[string]string{}
+		}
+		result.Env[pair.Key] = pair.Value
+	}
+	return result
+}
+
+// RestoreTemplateState writes a recorded state back over a template in one
+// saveTemplate, every field of the state and port labels included
+func (c *GraphQLClient) RestoreTemplateState(templateID string, state *TemplateState) error {
+	env := state.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	ports := state.Ports
+	if ports == nil {
+		ports = []string{}
+	}
+	labels := state.PortsConfig
+	if labels == nil {
+		labels = []TemplatePortConfig{}
+	}
+	return c.UpdateTemplatePortLabels(templateID, labels, &TemplatePortLabelOverrides{
+		Name:                    &state.Name,
+		ImageName:               &state.ImageName,
+		Ports:                   &ports,
+		Env:                     &env,
+		ContainerDiskInGb:       &state.ContainerDiskInGb,
+		ContainerRegistryAuthID: &state.ContainerRegistryAuthID,
+		VolumeInGb:              &state.VolumeInGb,
+		VolumeMountPath:         &state.VolumeMountPath,
+		Readme:                  &state.Readme,
+		DockerArgs:              &state.DockerArgs,
+		IsServerless:            &state.IsServerless,
+		IsPublic:                &state.IsPublic,
+		StartJupyter:            &state.StartJupyter,
+		StartSSH:                &state.StartSSH,
+		StartScript:             &state.StartScript,
+		AdvancedStart:           &state.AdvancedStart,
+		Category:                &state.Category,
+	})
+}
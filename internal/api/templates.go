@@ -421,6 +421,25 @@ func (c *Client) UpdateTemplate(templateID string, req *TemplateUpdateRequest) (
 	return &template, nil
 }
 
+// UpdateTemplateEnv replaces a template's env with env. Unlike UpdateTemplate,
+// the env field is always sent, so an empty map clears every variable.
+func (c *Client) UpdateTemplateEnv(templateID string, env map[string]string) (*Template, error) {
+	if env == nil {
+		env = map[string]string{}
+	}
+	data, err := c.Patch("/templates/"+templateID, map[string]interface{}{"env": env})
+	if err != nil {
+		return nil, err
+	}
+
+	var template Template
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &template, nil
+}
+
 // DeleteTemplate deletes a template
 func (c *Client) DeleteTemplate(templateID string) error {
 	_, err := c.Delete("/templates/" + templateID)
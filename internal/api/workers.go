@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/configenv"
+)
+
+// DefaultLogsURL is the base url of the pod log service
+const DefaultLogsURL = "https://hapi.runpod.net/v1"
+
+// Worker is a serverless endpoint worker. The api returns workers in the pod
+// shape; UnmarshalJSON flattens the fields that matter when debugging one.
+type Worker struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	GpuTypeID     string `json:"gpuTypeId,omitempty"`
+	GpuCount      int    `json:"gpuCount,omitempty"`
+	DataCenterID  string `json:"dataCenterId,omitempty"`
+	MachineID     string `json:"machineId,omitempty"`
+	Image         string `json:"image,omitempty"`
+	LastStartedAt string `json:"lastStartedAt,omitempty"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+	Throttled     bool   `json:"throttled"`
+}
+
+// UnmarshalJSON accepts both the pod shape returned by the api and the flat
+// shape Worker marshals to.
+func (w *Worker) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID            string `json:"id"`
+		Status        string `json:"status"`
+		DesiredStatus string `json:"desiredStatus"`
+		GpuTypeID     string `json:"gpuTypeId"`
+		GpuCount      int    `json:"gpuCount"`
+		Gpu           *struct {
+			ID    string `json:"id"`
+			Count int    `json:"count"`
+		} `json:"gpu"`
+		DataCenterID string `json:"dataCenterId"`
+		MachineID    string `json:"machineId"`
+		Machine      *struct {
+			GpuTypeID    string `json:"gpuTypeId"`
+			DataCenterID string `json:"dataCenterId"`
+			Location     string `json:"location"`
+		} `json:"machine"`
+		Image            string      `json:"image"`
+		ImageName        string      `json:"imageName"`
+		LastStartedAt    string      `json:"lastStartedAt"`
+		LastStatusChange interface{} `json:"lastStatusChange"`
+		UptimeSeconds    *float64    `json:"uptimeSeconds"`
+		Runtime          *struct {
+			UptimeInSeconds float64 `json:"uptimeInSeconds"`
+		} `json:"runtime"`
+		Throttled *bool `json:"throttled"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*w = Worker{
+		ID:            raw.ID,
+		Status:        firstNonEmpty(raw.Status, raw.DesiredStatus),
+		GpuTypeID:     raw.GpuTypeID,
+		GpuCount:      raw.GpuCount,
+		DataCenterID:  raw.DataCenterID,
+		MachineID:     raw.MachineID,
+		Image:         firstNonEmpty(raw.Image, raw.ImageName),
+		LastStartedAt: raw.LastStartedAt,
+	}
+	if raw.Gpu != nil {
+		w.GpuTypeID = firstNonEmpty(w.GpuTypeID, raw.Gpu.ID)
+		if w.GpuCount == 0 {
+			w.GpuCount = raw.Gpu.Count
+		}
+	}
+	if raw.Machine != nil {
+		w.GpuTypeID = firstNonEmpty(w.GpuTypeID, raw.Machine.GpuTypeID)
+		w.DataCenterID = firstNonEmpty(w.DataCenterID, raw.Machine.DataCenterID, raw.Machine.Location)
+	}
+
+	switch {
+	case raw.UptimeSeconds != nil:
+		w.UptimeSeconds = int64(*raw.UptimeSeconds)
+	case raw.Runtime != nil:
+		w.UptimeSeconds = int64(raw.Runtime.UptimeInSeconds)
+	case raw.LastStartedAt != "" && strings.EqualFold(w.Status, "RUNNING"):
+		if started, err := time.Parse(time.RFC3339, raw.LastStartedAt); err == nil {
+			w.UptimeSeconds = int64(time.Since(started).Seconds())
+		}
+	}
+
+	// the api has no dedicated flag everywhere; a throttled worker reports it
+	// in its last status change instead
+	if raw.Throttled != nil {
+		w.Throttled = *raw.Throttled
+	} else if change, ok := raw.LastStatusChange.(string); ok {
+		w.Throttled = strings.Contains(strings.ToLower(change), "throttl")
+	}
+
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ListWorkers returns the current workers of an endpoint
+func (c *Client) ListWorkers(endpointID string) ([]Worker, error) {
+	endpoint, err := c.GetEndpoint(endpointID, false, true)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Workers == nil {
+		return []Worker{}, nil
+	}
+	return endpoint.Workers, nil
+}
+
+// GetWorkerPod returns a worker in the legacy pod shape, which carries the
+// runtime ports needed to build an ssh command
+func (c *Client) GetWorkerPod(workerID string) (*LegacyPod, error) {
+	query := `
+		query pod($input: PodFilter!) {
+			pod(input: $input) {
+				id
+				name
+				desiredStatus
+				imageName
+				gpuCount
+				machine {
+					gpuDisplayName
+					location
+				}
+				runtime {
+					ports {
+						ip
+						isIpPublic
+						privatePort
+						publicPort
+						type
+					}
+				}
+			}
+		}
+	`
+
+	data, err := c.graphqlRequest(query, map[string]interface{}{
+		"input": map[string]interface{}{"podId": workerID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Pod *LegacyPod `json:"pod"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %s", resp.Errors[0].Message)
+	}
+	if resp.Data.Pod == nil {
+		return nil, fmt.Errorf("worker not found: %s", workerID)
+	}
+
+	return resp.Data.Pod, nil
+}
+
+// TerminateWorker terminates a single worker; the endpoint's autoscaler
+// starts a replacement if one is still needed
+func (c *Client) TerminateWorker(workerID string) error {
+	query := `
+		mutation podTerminate($input: PodTerminateInput!) {
+			podTerminate(input: $input)
+		}
+	`
+
+	data, err := c.graphqlRequest(query, map[string]interface{}{
+		"input": map[string]interface{}{"podId": workerID},
+	})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("graphql error: %s", resp.Errors[0].Message)
+	}
+
+	return nil
+}
+
+// PodLogs are the recent log lines of a pod or worker
+type PodLogs struct {
+	Container []string `json:"container"`
+	System    []string `json:"system,omitempty"`
+}
+
+// GetPodLogs returns the recent container and system logs of a pod or worker
+func (c *Client) GetPodLogs(podID string) (*PodLogs, error) {
+	logsURL := configenv.LogsURL()
+	if logsURL == "" {
+		logsURL = DefaultLogsURL
+	}
+
+	// temporarily swap base URL for the log service
+	origBaseURL := c.baseURL
+	c.baseURL = logsURL
+	defer func() { c.baseURL = origBaseURL }()
+
+	data, err := c.Get("/pod/"+url.PathEscape(podID)+"/logs", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs PodLogs
+	if err := json.Unmarshal(data, &logs); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if logs.Container == nil {
+		logs.Container = []string{}
+	}
+
+	return &logs, nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListWorkers_PodShape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/endpoints/ep-1" || r.URL.Query().Get("includeWorkers") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"id":"ep-1","workers":[
+			{"id":"w-1","desiredStatus":"RUNNING","image":"img:1","gpu":{"id":"NVIDIA A40","count":1},"machine":{"dataCenterId":"EU-RO-1"},"machineId":"m-1","runtime":{"uptimeInSeconds":120}},
+			{"id":"w-2","desiredStatus":"EXITED","imageName":"img:2","lastStatusChange":"Throttled: waiting for gpu"}
+		]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	client, _ := NewClient()
+	client.baseURL = server.URL
+
+	workers, err := client.ListWorkers("ep-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(workers) != 2 {
+		t.Fatalf("expected 2 workers, got %d", len(workers))
+	}
+
+	first := workers[0]
+	if first.Status != "RUNNING" || first.GpuTypeID != "NVIDIA A40" || first.GpuCount != 1 ||
+		first.DataCenterID != "EU-RO-1" || first.Image != "img:1" || first.UptimeSeconds != 120 || first.Throttled {
+		t.Fatalf("unexpected first worker %+v", first)
+	}
+	if second := workers[1]; second.Image != "img:2" || !second.Throttled {
+		t.Fatalf("unexpected second worker %+v", second)
+	}
+}
+
+func TestWorker_RoundTrip(t *testing.T) {
+	in := Worker{ID: "w-1", Status: "RUNNING", GpuTypeID: "NVIDIA A40", DataCenterID: "EU-RO-1", UptimeSeconds: 5, Throttled: true}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Worker
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Fatalf("round trip mismatch: %+v != %+v", out, in)
+	}
+}
+
+func TestGetPodLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pod/w-1/logs" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("missing auth header")
+		}
+		w.Write([]byte(`{"container":["a","b"],"system":["s"]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("RUNPOD_API_KEY", "test-key")
+	t.Setenv("RUNPOD_LOGS_URL", server.URL)
+	client, _ := NewClient()
+
+	logs, err := client.GetPodLogs("w-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logs.Container) != 2 || len(logs.System) != 1 {
+		t.Fatalf("unexpected logs %+v", logs)
+	}
+}
@@ -7,9 +7,14 @@ import (
 )
 
 const (
-	APIKeyEnv     = "RUNPOD_API_KEY"
-	RESTURLEnv    = "RUNPOD_API_URL"
-	GraphQLURLEnv = "RUNPOD_GRAPHQL_URL"
+	APIKeyEnv        = "RUNPOD_API_KEY"
+	RESTURLEnv       = "RUNPOD_API_URL"
+	GraphQLURLEnv    = "RUNPOD_GRAPHQL_URL"
+	LogsURLEnv       = "RUNPOD_LOGS_URL"
+	ServerlessURLEnv = "RUNPOD_SERVERLESS_URL"
+	S3AccessKeyEnv   = "RUNPOD_S3_ACCESS_KEY_ID"
+	S3SecretKeyEnv   = "RUNPOD_S3_SECRET_ACCESS_KEY"
+	S3EndpointEnv    = "RUNPOD_S3_ENDPOINT"
 )
 
 func APIKey() string {
@@ -24,6 +29,27 @@ func GraphQLURL() string {
 	return envOrConfig(GraphQLURLEnv, "apiUrl")
 }
 
+func LogsURL() string {
+	return envOrConfig(LogsURLEnv, "logsApiUrl")
+}
+
+func ServerlessURL() string {
+	return envOrConfig(ServerlessURLEnv, "serverlessApiUrl")
+}
+
+func S3AccessKey() string {
+	return envOrConfig(S3AccessKeyEnv, "s3AccessKeyId")
+}
+
+func S3SecretKey() string {
+	return envOrConfig(S3SecretKeyEnv, "s3SecretAccessKey")
+}
+
+// S3Endpoint overrides the per-datacenter network volume s3 endpoint
+func S3Endpoint() string {
+	return envOrConfig(S3EndpointEnv, "s3EndpointUrl")
+}
+
 func envOrConfig(envKey, configKey string) string {
 	if value := os.Getenv(envKey); value != "" {
 		return value
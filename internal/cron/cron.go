@@ -0,0 +1,172 @@
+// Package cron parses standard five-field cron expressions (minute, hour,
+// day of month, month, day of week) and finds the times they match.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minutes, hours, days, months, weekdays []bool
+	// anyDay and anyWeekday record a "*" field: when both day fields are
+	// restricted a time matches if either does, as in cron(8)
+	anyDay, anyWeekday bool
+}
+
+// field describes the range of one cron field
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField  = field{name: "minute", min: 0, max: 59}
+	hourField    = field{name: "hour", min: 0, max: 23}
+	dayField     = field{name: "day of month", min: 1, max: 31}
+	monthField   = field{name: "month", min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+// maxLookback bounds the search for a previous match, enough for any
+// expression that matches at least once a year
+const maxLookback = 366 * 4
+
+// Parse parses a five-field cron expression such as "0 8 * * mon-fri".
+// fields accept *, numbers, ranges (1-5), lists (1,3), steps (*/15, 8-18/2)
+// and, for months and weekdays, three-letter names. weekday 7 is sunday.
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day month weekday)", expr)
+	}
+
+	s := &Schedule{anyDay: parts[2] == "*", anyWeekday: parts[4] == "*"}
+	var err error
+	if s.minutes, err = parseField(parts[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hours, err = parseField(parts[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.days, err = parseField(parts[2], dayField); err != nil {
+		return nil, err
+	}
+	if s.months, err = parseField(parts[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.weekdays, err = parseField(parts[4], weekdayField); err != nil {
+		return nil, err
+	}
+	if s.weekdays[7] {
+		s.weekdays[0] = true
+	}
+	return s, nil
+}
+
+func parseField(value string, f field) ([]bool, error) {
+	set := make([]bool, f.max+1)
+	for _, item := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = f.value(from); err != nil {
+				return nil, err
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(to); err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				high = f.max
+			}
+			if low > high {
+				return nil, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		}
+
+		for i := low; i <= high; i += step {
+			set[i] = true
+		}
+	}
+	return set, nil
+}
+
+func (f field) value(text string) (int, error) {
+	if n, ok := f.names[strings.ToLower(text)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field (%d-%d)", text, f.name, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Matches reports whether the minute of t matches the schedule
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minutes[t.Minute()] && s.hours[t.Hour()] && s.matchesDay(t)
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	if !s.months[int(t.Month())] {
+		return false
+	}
+	day, weekday := s.days[t.Day()], s.weekdays[int(t.Weekday())]
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// Prev returns the latest matching minute at or before t, in t's location.
+// ok is false when nothing matched in the past four years.
+func (s *Schedule) Prev(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	for back := 0; back <= maxLookback; back++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()-back, 0, 0, 0, 0, loc)
+		if !s.matchesDay(day) {
+			continue
+		}
+		lastHour := 23
+		if back == 0 {
+			lastHour = t.Hour()
+		}
+		for hour := lastHour; hour >= 0; hour-- {
+			if !s.hours[hour] {
+				continue
+			}
+			lastMinute := 59
+			if back == 0 && hour == t.Hour() {
+				lastMinute = t.Minute()
+			}
+			for minute := lastMinute; minute >= 0; minute-- {
+				if s.minutes[minute] {
+					return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc), true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
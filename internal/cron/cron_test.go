@@ -0,0 +1,91 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		"* * * *":       "must have 5 fields",
+		"60 * * * *":    "invalid value \"60\" in minute field",
+		"* * * * 8":     "invalid value \"8\" in day of week field",
+		"*/0 * * * *":   "invalid step",
+		"* 18-8 * * *":  "invalid range",
+		"* * * foo * *": "must have 5 fields",
+	}
+	for expr, want := range cases {
+		if _, err := Parse(expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) = %v, want %q", expr, err, want)
+		}
+	}
+}
+
+func TestSchedule_Matches(t *testing.T) {
+	// 2026-03-02 is a monday
+	monday := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	cases := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"0 8 * * mon-fri", monday, true},
+		{"0 8 * * mon-fri", monday.AddDate(0, 0, 5), false},
+		{"*/15 8-18 * * *", monday.Add(45 * time.Minute), true},
+		{"*/15 8-18 * * *", monday.Add(50 * time.Minute), false},
+		{"0 8 * * 7", monday.AddDate(0, 0, 6), true},
+		{"0 8 1 * *", monday.AddDate(0, 0, -1), true},
+		{"0 8 1 * *", monday.AddDate(0, 0, -2), false},
+		// both day fields restricted: either matches
+		{"0 8 15 * 1", monday, true},
+		{"0 8 2 mar 0", monday, true},
+		{"0 8 2 apr *", monday, false},
+	}
+	for _, tc := range cases {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := s.Matches(tc.at); got != tc.want {
+			t.Errorf("%q matches %s = %v, want %v", tc.expr, tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestSchedule_Prev(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	cases := []struct {
+		expr string
+		at   time.Time
+		want time.Time
+	}{
+		// monday 10:30 -> this morning
+		{"0 8 * * mon-fri", time.Date(2026, 3, 2, 10, 30, 0, 0, berlin), time.Date(2026, 3, 2, 8, 0, 0, 0, berlin)},
+		// monday 07:59 -> friday evening rule
+		{"0 19 * * mon-fri", time.Date(2026, 3, 2, 7, 59, 0, 0, berlin), time.Date(2026, 2, 27, 19, 0, 0, 0, berlin)},
+		// exactly on a match
+		{"30 9 * * *", time.Date(2026, 3, 2, 9, 30, 0, 0, berlin), time.Date(2026, 3, 2, 9, 30, 0, 0, berlin)},
+		{"*/20 * * * *", time.Date(2026, 3, 2, 9, 59, 30, 0, berlin), time.Date(2026, 3, 2, 9, 40, 0, 0, berlin)},
+		// yearly
+		{"0 0 1 jan *", time.Date(2026, 3, 2, 9, 0, 0, 0, berlin), time.Date(2026, 1, 1, 0, 0, 0, 0, berlin)},
+	}
+	for _, tc := range cases {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := s.Prev(tc.at)
+		if !ok || !got.Equal(tc.want) {
+			t.Errorf("%q prev of %s = %s, %v; want %s", tc.expr, tc.at, got, ok, tc.want)
+		}
+	}
+
+	never, _ := Parse("0 0 31 feb *")
+	if _, ok := never.Prev(time.Now()); ok {
+		t.Error("expected no match for february 31st")
+	}
+}
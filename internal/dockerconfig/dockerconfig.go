@@ -0,0 +1,213 @@
+// Package dockerconfig reads registry credentials the way the docker cli
+// stores them: inline in ~/.docker/config.json or behind a credential helper.
+package dockerconfig
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultPath is the docker config location shown to users; Path expands it
+const DefaultPath = "~/.docker/config.json"
+
+// ErrNoCredentials means the config has no usable credentials for a registry
+var ErrNoCredentials = errors.New("no username and password stored")
+
+// Config is the part of a docker config that holds credentials
+type Config struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// Credentials are a username and password for one registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// RunCredentialHelper runs a docker credential helper; tests replace it
+var RunCredentialHelper = func(helper, action, input string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, action)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String() + string(out)); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// Path expands the default config path the way docker does, honouring
+// $DOCKER_CONFIG, and a leading ~ in any other path
+func Path(path string) (string, error) {
+	if path == "" || path == DefaultPath {
+		if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+			return filepath.Join(dir, "config.json"), nil
+		}
+		path = DefaultPath
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
+
+// Load reads a docker config; an empty path means the default location
+func Load(path string) (*Config, error) {
+	path, err := Path(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Servers lists every server the config has credentials for, one per
+// normalized registry host, in a stable order
+func (c *Config) Servers() []string {
+	seen := map[string]bool{}
+	var servers []string
+	add := func(server string) {
+		if registry := NormalizeRegistry(server); !seen[registry] {
+			seen[registry] = true
+			servers = append(servers, server)
+		}
+	}
+	for _, server := range sortedKeys(c.CredHelpers) {
+		add(server)
+	}
+	for _, server := range sortedKeys(c.Auths) {
+		add(server)
+	}
+	return servers
+}
+
+// Lookup finds the credentials for a registry host, matching the config's
+// server keys after normalization
+func (c *Config) Lookup(registry string) (*Credentials, error) {
+	registry = NormalizeRegistry(registry)
+	for _, server := range c.Servers() {
+		if NormalizeRegistry(server) == registry {
+			return c.Credentials(server)
+		}
+	}
+	// docker cli falls back to the default store for any registry
+	if c.CredsStore != "" {
+		server := registry
+		if registry == "docker.io" {
+			server = "https://index.docker.io/v1/"
+		}
+		return c.helperCredentials(c.CredsStore, server)
+	}
+	return nil, ErrNoCredentials
+}
+
+// Credentials returns the username and password stored for a server key,
+// from its credential helper if it has one and from the inline auth otherwise
+func (c *Config) Credentials(server string) (*Credentials, error) {
+	entry, inline := c.Auths[server]
+	inline = inline && (entry.Auth != "" || entry.Password != "" || entry.IdentityToken != "")
+	if !inline {
+		helper := c.CredHelpers[server]
+		if helper == "" {
+			helper = c.CredsStore
+		}
+		if helper != "" {
+			return c.helperCredentials(helper, server)
+		}
+	}
+
+	if entry.IdentityToken != "" {
+		return nil, fmt.Errorf("identity tokens are not supported")
+	}
+	cred := &Credentials{Username: entry.Username, Password: entry.Password}
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth: %w", err)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid auth: expected username:password")
+		}
+		cred.Username, cred.Password = username, password
+	}
+	if cred.Username == "" || cred.Password == "" {
+		return nil, ErrNoCredentials
+	}
+	return cred, nil
+}
+
+func (c *Config) helperCredentials(helper, server string) (*Credentials, error) {
+	out, err := RunCredentialHelper(helper, "get", server)
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s failed: %w", helper, err)
+	}
+	var helperCred struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &helperCred); err != nil {
+		return nil, fmt.Errorf("credential helper %s returned invalid output: %w", helper, err)
+	}
+	// helpers store identity tokens under this username; they can't be used
+	// as a registry password
+	if helperCred.Username == "<token>" {
+		return nil, fmt.Errorf("identity tokens are not supported")
+	}
+	if helperCred.Username == "" || helperCred.Secret == "" {
+		return nil, ErrNoCredentials
+	}
+	return &Credentials{Username: helperCred.Username, Password: helperCred.Secret}, nil
+}
+
+// NormalizeRegistry reduces a docker config server key to its host, so
+// https://index.docker.io/v1/ and docker.io name the same registry
+func NormalizeRegistry(server string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	host = strings.ToLower(host)
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "docker.io"
+	}
+	return host
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
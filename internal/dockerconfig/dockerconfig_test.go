@@ -0,0 +1,82 @@
+package dockerconfig
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeRegistry(t *testing.T) {
+	for server, want := range map[string]string{
+		"https://index.docker.io/v1/": "docker.io",
+		"registry-1.docker.io":        "docker.io",
+		"ghcr.io":                     "ghcr.io",
+		"https://GHCR.io/v2/":         "ghcr.io",
+		"localhost:5000":              "localhost:5000",
+	} {
+		if got := NormalizeRegistry(server); got != want {
+			t.Errorf("NormalizeRegistry(%q) = %q, want %q", server, got, want)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("DOCKER_CONFIG", "")
+	if path, _ := Path(DefaultPath); path != filepath.Join(home, ".docker", "config.json") {
+		t.Errorf("unexpected default path %s", path)
+	}
+	t.Setenv("DOCKER_CONFIG", "/etc/docker-ci")
+	if path, _ := Path(""); path != "/etc/docker-ci/config.json" {
+		t.Errorf("expected $DOCKER_CONFIG to be honoured, got %s", path)
+	}
+	if path, _ := Path("./config.json"); path != "./config.json" {
+		t.Errorf("expected an explicit path to be kept, got %s", path)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("me:ghcr-token"))
+	config := `{
+		"auths": {"https://ghcr.io/": {"auth": "` + auth + `"}, "quay.io": {"identitytoken": "abc"}},
+		"credsStore": "desktop"
+	}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	helper := RunCredentialHelper
+	t.Cleanup(func() { RunCredentialHelper = helper })
+	RunCredentialHelper = func(helper, action, server string) ([]byte, error) {
+		if server == "https://index.docker.io/v1/" {
+			return []byte(`{"Username":"hub-user","Secret":"hub-token"}`), nil
+		}
+		return nil, fmt.Errorf("credentials not found in native keychain")
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred, err := c.Lookup("ghcr.io"); err != nil || cred.Username != "me" || cred.Password != "ghcr-token" {
+		t.Fatalf("unexpected ghcr.io credentials %+v (%v)", cred, err)
+	}
+	if cred, err := c.Lookup("docker.io"); err != nil || cred.Username != "hub-user" {
+		t.Fatalf("expected the default store to be asked for docker.io, got %+v (%v)", cred, err)
+	}
+	if _, err := c.Lookup("quay.io"); err == nil {
+		t.Fatal("expected identity tokens to be refused")
+	}
+	if _, err := c.Lookup("nvcr.io"); err == nil {
+		t.Fatal("expected the helper error for an unknown registry")
+	}
+
+	c.CredsStore = ""
+	if _, err := c.Lookup("nvcr.io"); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+}
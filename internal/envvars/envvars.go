@@ -0,0 +1,234 @@
+// Package envvars parses and edits the KEY=VALUE environment maps used by
+// pods, templates and serverless endpoints.
+package envvars
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaskedValue replaces env values in output unless values are requested.
+const MaskedValue = "********"
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidKey reports whether name is a valid environment variable name.
+func ValidKey(name string) bool {
+	return keyPattern.MatchString(name)
+}
+
+// ParseAssignments parses KEY=VALUE arguments. Only the first "=" splits the
+// pair, so values may contain "=".
+func ParseAssignments(items []string) (map[string]string, error) {
+	env := make(map[string]string, len(items))
+	for _, item := range items {
+		key, value, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("invalid env %q; expected KEY=VALUE", item)
+		}
+		if !ValidKey(key) {
+			return nil, fmt.Errorf("invalid env name %q", key)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// ParseFile reads a dotenv file. Blank lines and # comments are skipped, an
+// optional "export " prefix is allowed, and single- or double-quoted values
+// are unquoted (double quotes also process escapes like \n). A # comment may
+// follow an unquoted value or a closing quote.
+func ParseFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !ValidKey(key) {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+
+		value, err = parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return env, nil
+}
+
+func parseValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	switch raw[0] {
+	case '"', '\'':
+		quoted, rest := splitQuoted(raw)
+		// only a comment may follow the closing quote
+		if rest = strings.TrimSpace(rest); quoted == "" || (rest != "" && !strings.HasPrefix(rest, "#")) {
+			return "", fmt.Errorf("invalid quoted value %s", raw)
+		}
+		if quoted[0] == '\'' {
+			return quoted[1 : len(quoted)-1], nil
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", raw)
+		}
+		return value, nil
+	}
+	// strip an inline comment from an unquoted value
+	if idx := strings.Index(raw, " #"); idx >= 0 {
+		raw = strings.TrimSpace(raw[:idx])
+	}
+	return raw, nil
+}
+
+// splitQuoted splits a value that starts with a quote after its closing
+// quote. quoted is empty when the quote is never closed. backslash escapes
+// only count inside double quotes.
+func splitQuoted(raw string) (quoted, rest string) {
+	quote := raw[0]
+	for i := 1; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && quote == '"':
+			i++
+		case raw[i] == quote:
+			return raw[:i+1], raw[i+1:]
+		}
+	}
+	return "", ""
+}
+
+// Load combines an optional env file with explicit overrides; overrides win.
+// It returns nil when neither source provides anything.
+func Load(path string, overrides map[string]string) (map[string]string, error) {
+	var env map[string]string
+	if path != "" {
+		var err error
+		env, err = ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return Merge(env, overrides), nil
+}
+
+// Merge returns a new map with updates applied on top of existing. It returns
+// nil when both are empty.
+func Merge(existing, updates map[string]string) map[string]string {
+	if len(existing) == 0 && len(updates) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(existing)+len(updates))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range updates {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Unset returns a copy of existing without keys, plus the keys that were not
+// present.
+func Unset(existing map[string]string, keys []string) (map[string]string, []string) {
+	result := make(map[string]string, len(existing))
+	for k, v := range existing {
+		result[k] = v
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := result[key]; !ok {
+			missing = append(missing, key)
+			continue
+		}
+		delete(result, key)
+	}
+	return result, missing
+}
+
+// Mask returns a copy of env with every value replaced by MaskedValue unless
+// show is set. The map is never nil so output is always an object.
+func Mask(env map[string]string, show bool) map[string]string {
+	result := make(map[string]string, len(env))
+	for k, v := range env {
+		if show {
+			result[k] = v
+		} else {
+			result[k] = MaskedValue
+		}
+	}
+	return result
+}
+
+// Keys returns the sorted keys of env.
+func Keys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SecretReference returns the env value runpod resolves to the named secret
+// when the pod or worker starts.
+func SecretReference(name string) string {
+	return "{{ RUNPOD_SECRET_" + name + " }}"
+}
+
+// WithSecretRefs adds KEY=secretName pairs to env as secret references. A key
+// that is already set in env is an error rather than a silent override.
+func WithSecretRefs(env map[string]string, refs []string) (map[string]string, error) {
+	if len(refs) == 0 {
+		return env, nil
+	}
+
+	pairs, err := ParseAssignments(refs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --env-secret: %w", err)
+	}
+
+	result := Merge(env, nil)
+	if result == nil {
+		result = make(map[string]string, len(pairs))
+	}
+	for _, key := range Keys(pairs) {
+		name := strings.TrimSpace(pairs[key])
+		if name == "" {
+			return nil, fmt.Errorf("invalid --env-secret %s=; expected KEY=secretName", key)
+		}
+		if _, exists := result[key]; exists {
+			return nil, fmt.Errorf("%s is set both as a plain env var and with --env-secret", key)
+		}
+		result[key] = SecretReference(name)
+	}
+	return result, nil
+}
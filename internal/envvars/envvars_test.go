@@ -0,0 +1,146 @@
+package envvars
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAssignments(t *testing.T) {
+	env, err := ParseAssignments([]string{"A=1", "B=x=y", "EMPTY="})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["A"] != "1" || env["B"] != "x=y" || env["EMPTY"] != "" {
+		t.Fatalf("unexpected env: %#v", env)
+	}
+
+	if _, err := ParseAssignments([]string{"NOVALUE"}); err == nil {
+		t.Fatal("expected error for missing =")
+	}
+	if _, err := ParseAssignments([]string{"1BAD=x"}); err == nil {
+		t.Fatal("expected error for invalid name")
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# comment
+export TOKEN=abc
+QUOTED="line1\nline2"
+SINGLE='raw $value'
+INLINE=value # trailing comment
+COMMENTED="quoted # kept" # trailing comment
+SINGLE_COMMENTED='raw' # trailing comment
+
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write env file: %v", err)
+	}
+
+	env, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"TOKEN":            "abc",
+		"QUOTED":           "line1\nline2",
+		"SINGLE":           "raw $value",
+		"INLINE":           "value",
+		"COMMENTED":        "quoted # kept",
+		"SINGLE_COMMENTED": "raw",
+		"EMPTY":            "",
+	}
+	if len(env) != len(want) {
+		t.Fatalf("expected %d keys, got %#v", len(want), env)
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, env[k])
+		}
+	}
+}
+
+func TestParseFile_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	for _, content := range []string{"OK=1\nnot a pair\n", "OK=\"unclosed\n", "OK=\"a\" b\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write env file: %v", err)
+		}
+		if _, err := ParseFile(path); err == nil {
+			t.Fatalf("expected error for %q", content)
+		}
+	}
+}
+
+func TestLoad_OverridesWin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=file\nB=file\n"), 0o600); err != nil {
+		t.Fatalf("write env file: %v", err)
+	}
+
+	env, err := Load(path, map[string]string{"B": "flag"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["A"] != "file" || env["B"] != "flag" {
+		t.Fatalf("unexpected env: %#v", env)
+	}
+
+	if env, err := Load("", nil); err != nil || env != nil {
+		t.Fatalf("expected nil env, got %#v (%v)", env, err)
+	}
+}
+
+func TestUnsetAndMask(t *testing.T) {
+	existing := map[string]string{"A": "1", "B": "2"}
+
+	env, missing := Unset(existing, []string{"A", "C"})
+	if _, ok := env["A"]; ok {
+		t.Fatal("expected A to be removed")
+	}
+	if env["B"] != "2" {
+		t.Fatal("expected B to be kept")
+	}
+	if len(missing) != 1 || missing[0] != "C" {
+		t.Fatalf("expected C to be reported missing, got %v", missing)
+	}
+	if existing["A"] != "1" {
+		t.Fatal("Unset must not modify its input")
+	}
+
+	masked := Mask(existing, false)
+	if masked["A"] != MaskedValue {
+		t.Fatalf("expected masked value, got %q", masked["A"])
+	}
+	if shown := Mask(existing, true); shown["A"] != "1" {
+		t.Fatalf("expected real value, got %q", shown["A"])
+	}
+	if Mask(nil, false) == nil {
+		t.Fatal("expected non-nil map")
+	}
+}
+
+func TestWithSecretRefs(t *testing.T) {
+	env, err := WithSecretRefs(map[string]string{"A": "1"}, []string{"HF_TOKEN=hf_token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["HF_TOKEN"] != "{{ RUNPOD_SECRET_hf_token }}" {
+		t.Fatalf("unexpected reference %q", env["HF_TOKEN"])
+	}
+	if env["A"] != "1" {
+		t.Fatal("expected existing env to be kept")
+	}
+
+	if _, err := WithSecretRefs(map[string]string{"A": "1"}, []string{"A=other"}); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if _, err := WithSecretRefs(nil, []string{"A="}); err == nil {
+		t.Fatal("expected error for empty secret name")
+	}
+	if env, err := WithSecretRefs(nil, nil); err != nil || env != nil {
+		t.Fatalf("expected nil env, got %#v (%v)", env, err)
+	}
+}
@@ -0,0 +1,153 @@
+// Package hf is a minimal client for the hugging face hub model api, used to
+// validate model references and size gpus from a model's parameter count.
+package hf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultURL is the hugging face hub
+	DefaultURL = "https://huggingface.co"
+	// URLEnv points the client at a mirror, as in the hugging face tools
+	URLEnv = "HF_ENDPOINT"
+	// TokenEnv holds a token for gated and private models
+	TokenEnv = "HF_TOKEN"
+)
+
+// ErrNotFound is returned for a model or revision that does not exist
+var ErrNotFound = errors.New("model not found")
+
+// ModelInfo is the subset of the hub's model info used by the cli
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Sha     string `json:"sha,omitempty"`
+	Private bool   `json:"private"`
+	Gated   bool   `json:"gated"`
+	// Parameters is the safetensors parameter count, 0 when unknown
+	Parameters int64 `json:"parameters,omitempty"`
+	// ParametersByDtype is the safetensors parameter count per dtype, e.g.
+	// BF16. quantized checkpoints count their packed tensors as stored.
+	ParametersByDtype map[string]int64 `json:"parametersByDtype,omitempty"`
+}
+
+// Client talks to the hugging face hub
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for HF_ENDPOINT (or the public hub) that sends
+// HF_TOKEN when set
+func NewClient() *Client {
+	baseURL := os.Getenv(URLEnv)
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      os.Getenv(TokenEnv),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// ModelURL returns the hub url of a model, as used in endpoint model references
+func ModelURL(repo string) string {
+	return DefaultURL + "/" + repo
+}
+
+// Model returns info about a model at revision ("" for the default branch)
+func (c *Client) Model(repo, revision string) (*ModelInfo, error) {
+	path := "/api/models/" + escapeRepo(repo)
+	if revision != "" {
+		path += "/revision/" + url.PathEscape(revision)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, modelRef(repo, revision))
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%s is private or gated; set %s to a token with access", modelRef(repo, revision), TokenEnv)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("hugging face error: %s (status %d)", string(body), resp.StatusCode)
+	}
+
+	var raw struct {
+		ID          string      `json:"id"`
+		Sha         string      `json:"sha"`
+		Private     bool        `json:"private"`
+		Gated       interface{} `json:"gated"`
+		Safetensors *struct {
+			Parameters map[string]int64 `json:"parameters"`
+			Total      int64            `json:"total"`
+		} `json:"safetensors"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	info := &ModelInfo{ID: raw.ID, Sha: raw.Sha, Private: raw.Private}
+	// gated is false, or the gating mode ("auto", "manual")
+	switch gated := raw.Gated.(type) {
+	case bool:
+		info.Gated = gated
+	case string:
+		info.Gated = gated != ""
+	}
+	if raw.Safetensors != nil {
+		var sum int64
+		for _, count := range raw.Safetensors.Parameters {
+			sum += count
+		}
+		info.ParametersByDtype = raw.Safetensors.Parameters
+		info.Parameters = raw.Safetensors.Total
+		if info.Parameters == 0 {
+			info.Parameters = sum
+		}
+	}
+	return info, nil
+}
+
+// escapeRepo escapes the parts of an org/name repo id but keeps the slash
+func escapeRepo(repo string) string {
+	parts := strings.Split(repo, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func modelRef(repo, revision string) string {
+	if revision == "" {
+		return repo
+	}
+	return repo + ":" + revision
+}
@@ -0,0 +1,51 @@
+package hf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestModel_ParsesSafetensors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/models/Qwen/Qwen2.5-7B-Instruct/revision/v1.0" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer hf-token" {
+			t.Errorf("expected token, got %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"id":"Qwen/Qwen2.5-7B-Instruct","sha":"abc","gated":"manual","safetensors":{"parameters":{"BF16":7000,"F32":10}}}`))
+	}))
+	defer server.Close()
+	t.Setenv(URLEnv, server.URL)
+	t.Setenv(TokenEnv, "hf-token")
+
+	info, err := NewClient().Model("Qwen/Qwen2.5-7B-Instruct", "v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Parameters != 7010 || info.ParametersByDtype["BF16"] != 7000 || info.ParametersByDtype["F32"] != 10 || !info.Gated || info.Sha != "abc" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+}
+
+func TestModel_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/models/private/") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	t.Setenv(URLEnv, server.URL)
+
+	if _, err := NewClient().Model("nobody/missing", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := NewClient().Model("private/model", "main"); err == nil || !strings.Contains(err.Error(), TokenEnv) {
+		t.Fatalf("expected a token hint, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package output
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -18,6 +19,8 @@ const (
 // Config holds output configuration
 type Config struct {
 	Format Format
+	// Raw prints data as the api returned it, without renaming gpu keys
+	Raw bool
 }
 
 // DefaultConfig returns the default output config (JSON for agents)
@@ -28,7 +31,9 @@ func Print(data interface{}, cfg *Config) error {
 	if cfg == nil {
 		cfg = DefaultConfig
 	}
-	data = normalizeGPUKeys(data)
+	if !cfg.Raw {
+		data = normalizeGPUKeys(data)
+	}
 
 	switch cfg.Format {
 	case FormatYAML:
@@ -67,6 +72,20 @@ func ParseFormat(s string) Format {
 	}
 }
 
+// Bytes formats a size with binary units
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func normalizeGPUKeys(data interface{}) interface{} {
 	if data == nil {
 		return data
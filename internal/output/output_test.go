@@ -104,6 +104,34 @@ func TestPrint_DefaultConfig(t *testing.T) {
 	}
 }
 
+func TestPrint_RawKeepsAPIKeys(t *testing.T) {
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	data := map[string]interface{}{"gpuTypeId": "NVIDIA A40"}
+	if err := Print(data, &Config{Format: FormatJSON, Raw: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	if !strings.Contains(buf.String(), `"gpuTypeId"`) {
+		t.Errorf("raw output should keep gpuTypeId, got %s", buf.String())
+	}
+}
+
+func TestBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := Bytes(n); got != want {
+			t.Errorf("Bytes(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
 func TestError(t *testing.T) {
 	old := os.Stderr
 	r, w, _ := os.Pipe()
@@ -0,0 +1,32 @@
+// Package prompt reads values that must not be echoed, such as passwords
+// and secrets.
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ReadHidden reads a value from in. An interactive terminal gets label as a
+// prompt on out and the typed value is not echoed; anything else is read to
+// eof. One trailing newline is stripped so `echo value |` works as expected.
+func ReadHidden(in io.Reader, out io.Writer, label string) (string, error) {
+	var raw []byte
+	var err error
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		fmt.Fprintf(out, "%s: ", label)
+		raw, err = term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(out)
+	} else {
+		raw, err = io.ReadAll(in)
+	}
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(raw), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
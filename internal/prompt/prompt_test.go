@@ -0,0 +1,21 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadHidden_Piped(t *testing.T) {
+	var out bytes.Buffer
+	value, err := ReadHidden(strings.NewReader("line1\nline2\r\n"), &out, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "line1\nline2" {
+		t.Fatalf("unexpected value %q", value)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no prompt for piped input, got %q", out.String())
+	}
+}
@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/dockerconfig"
+	"github.com/runpod/runpodctl/internal/output"
+)
+
+// PreflightTimeout bounds a preflight check, so a slow registry doesn't hold
+// up a create
+var PreflightTimeout = 30 * time.Second
+
+// PreflightOptions describe what the image will be pulled with
+type PreflightOptions struct {
+	// RegistryAuthID is the registry auth the pod or endpoint pulls with.
+	// runpod never returns its password, so the check logs in with the
+	// local docker credentials for the image's registry instead.
+	RegistryAuthID string
+	// ContainerDiskInGb is checked against the compressed image size
+	ContainerDiskInGb int
+}
+
+// Preflight checks that image exists for linux/amd64 before anything is
+// created with it, and reports its compressed size on w. it fails only on
+// a definite answer from the registry: an unreachable registry, or a private
+// image without local credentials to check it with, is noted and allowed.
+func Preflight(ctx context.Context, w io.Writer, image string, opts PreflightOptions) (*Image, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds *Credentials
+	if opts.RegistryAuthID != "" {
+		creds = localCredentials(ref.Registry)
+		if creds == nil {
+			fmt.Fprintf(w, "note: no local docker credentials for %s; checking %s anonymously\n", ref.Registry, ref)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, PreflightTimeout)
+	defer cancel()
+	img, err := New().Inspect(ctx, ref, creds)
+	var platformErr *PlatformError
+	switch {
+	case err == nil:
+	case IsNotFound(err):
+		return nil, fmt.Errorf("image %s not found; check the name and tag (or use --skip-image-check): %w", ref, err)
+	case IsUnauthorized(err) && opts.RegistryAuthID == "":
+		return nil, fmt.Errorf("image %s doesn't exist or is private; for a private image pass --registry-auth-id (or use --skip-image-check): %w", ref, err)
+	case IsUnauthorized(err) && creds != nil:
+		return nil, fmt.Errorf("%s refused the local docker credentials for %s; the image may not exist (or use --skip-image-check): %w", ref.Registry, ref, err)
+	case IsUnauthorized(err):
+		fmt.Fprintf(w, "note: %s is private; it will be pulled with registry auth %s, so it can't be checked here\n", ref, opts.RegistryAuthID)
+		return nil, nil
+	case errors.As(err, &platformErr):
+		return nil, fmt.Errorf("image %s can't run on runpod: %w", ref, err)
+	default:
+		fmt.Fprintf(w, "note: couldn't check image %s: %v\n", ref, err)
+		return nil, nil
+	}
+
+	fmt.Fprintf(w, "image %s: %s, %s compressed\n", ref, img.Platform, output.Bytes(img.CompressedSize))
+	if disk := int64(opts.ContainerDiskInGb) << 30; disk > 0 {
+		switch {
+		case img.CompressedSize >= disk:
+			return img, fmt.Errorf("image %s is %s compressed, more than the %d gb container disk; raise --container-disk-in-gb", ref, output.Bytes(img.CompressedSize), opts.ContainerDiskInGb)
+		case img.CompressedSize*2 >= disk:
+			// layers usually unpack to two or three times their compressed size
+			fmt.Fprintf(w, "note: image %s usually unpacks to 2-3x its compressed size; a %d gb container disk may be too small\n", ref, opts.ContainerDiskInGb)
+		}
+	}
+	return img, nil
+}
+
+// localCredentials returns the docker cli's credentials for a registry, or
+// nil when there are none
+func localCredentials(registry string) *Credentials {
+	config, err := dockerconfig.Load("")
+	if err != nil {
+		return nil
+	}
+	cred, err := config.Lookup(registry)
+	if err != nil {
+		return nil
+	}
+	return &Credentials{Username: cred.Username, Password: cred.Password}
+}
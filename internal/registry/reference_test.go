@@ -0,0 +1,44 @@
+package registry
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+		repo  string
+	}{
+		{"ubuntu", "docker.io/library/ubuntu:latest", "library/ubuntu"},
+		{"runpod/pytorch:2.1.0-py3.10", "docker.io/runpod/pytorch:2.1.0-py3.10", "runpod/pytorch"},
+		{"ghcr.io/org/app/worker:v1", "ghcr.io/org/app/worker:v1", "org/app/worker"},
+		{"localhost:5000/app", "localhost:5000/app:latest", "app"},
+		{"index.docker.io/library/redis:7", "docker.io/library/redis:7", "library/redis"},
+		{"nginx@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", "docker.io/library/nginx@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", "library/nginx"},
+	}
+	for _, tt := range tests {
+		ref, err := ParseReference(tt.image)
+		if err != nil {
+			t.Errorf("ParseReference(%q): %v", tt.image, err)
+			continue
+		}
+		if ref.String() != tt.want || ref.Repository != tt.repo {
+			t.Errorf("ParseReference(%q) = %s (%s), want %s (%s)", tt.image, ref, ref.Repository, tt.want, tt.repo)
+		}
+	}
+
+	for _, image := range []string{"", "Runpod/PyTorch:latest", "app:bad tag", "app@sha256:short", "app//x"} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("ParseReference(%q): expected an error", image)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/ubuntu:pull"`)
+	if scheme != "bearer" || params["realm"] != "https://auth.docker.io/token" || params["service"] != "registry.docker.io" || params["scope"] != "repository:library/ubuntu:pull" {
+		t.Fatalf("unexpected challenge %s %v", scheme, params)
+	}
+	if scheme, params := parseChallenge(`Basic realm=registry`); scheme != "basic" || params["realm"] != "registry" {
+		t.Fatalf("unexpected challenge %s %v", scheme, params)
+	}
+}
@@ -0,0 +1,405 @@
+// Package registry inspects container images through the registry v2 api,
+// so an image can be checked before a pod or endpoint tries to pull it.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Platform is the only platform runpod runs images on
+const Platform = "linux/amd64"
+
+const (
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+
+	// maxManifestSize bounds manifest and config reads
+	maxManifestSize = 4 << 20
+)
+
+var manifestMediaTypes = []string{mediaTypeOCIIndex, mediaTypeDockerList, mediaTypeOCIManifest, mediaTypeDockerManifest}
+
+// Credentials log in to a registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Image describes the linux/amd64 variant of an image
+type Image struct {
+	Reference      string `json:"reference"`
+	Digest         string `json:"digest"`
+	Platform       string `json:"platform"`
+	Layers         int    `json:"layers"`
+	CompressedSize int64  `json:"compressedSize"`
+}
+
+// Error is an error response from a registry
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("registry error: status %d", e.StatusCode)
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("registry error: %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("registry error: %s: %s (status %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// IsNotFound reports whether err is a missing repository, tag or digest
+func IsNotFound(err error) bool {
+	var regErr *Error
+	if !errors.As(err, &regErr) {
+		return false
+	}
+	return regErr.StatusCode == http.StatusNotFound || regErr.Code == "MANIFEST_UNKNOWN" || regErr.Code == "NAME_UNKNOWN"
+}
+
+// IsUnauthorized reports whether the registry refused access. registries
+// answer this for private images and, like docker hub, often for
+// repositories that don't exist.
+func IsUnauthorized(err error) bool {
+	var regErr *Error
+	if !errors.As(err, &regErr) {
+		return false
+	}
+	return regErr.StatusCode == http.StatusUnauthorized || regErr.StatusCode == http.StatusForbidden ||
+		regErr.Code == "UNAUTHORIZED" || regErr.Code == "DENIED"
+}
+
+// PlatformError means an image exists but not for the wanted platform
+type PlatformError struct {
+	Platform  string
+	Available []string
+}
+
+func (e *PlatformError) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("image has no %s variant", e.Platform)
+	}
+	return fmt.Sprintf("image has no %s variant (has %s)", e.Platform, strings.Join(e.Available, ", "))
+}
+
+// Client talks to registries. anonymous pulls and token logins are
+// negotiated per request, the way docker does.
+type Client struct {
+	HTTPClient *http.Client
+	UserAgent  string
+}
+
+// New returns a client
+func New() *Client {
+	return &Client{HTTPClient: &http.Client{}}
+}
+
+// Inspect resolves ref to its linux/amd64 manifest and sums the layer
+// sizes. creds may be nil for an anonymous pull.
+func (c *Client) Inspect(ctx context.Context, ref *Reference, creds *Credentials) (*Image, error) {
+	s := &session{client: c, ref: ref, creds: creds}
+
+	body, mediaType, digest, err := s.manifest(ctx, ref.manifestRef())
+	if err != nil {
+		return nil, err
+	}
+
+	fromIndex := mediaType == mediaTypeOCIIndex || mediaType == mediaTypeDockerList
+	if fromIndex {
+		var index struct {
+			Manifests []struct {
+				Digest   string `json:"digest"`
+				Platform struct {
+					OS           string `json:"os"`
+					Architecture string `json:"architecture"`
+				} `json:"platform"`
+			} `json:"manifests"`
+		}
+		if err := json.Unmarshal(body, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest list: %w", err)
+		}
+		platformErr := &PlatformError{Platform: Platform}
+		digest = ""
+		for _, m := range index.Manifests {
+			platform := m.Platform.OS + "/" + m.Platform.Architecture
+			if platform == Platform {
+				digest = m.Digest
+				break
+			}
+			// attestation manifests are listed as unknown/unknown
+			if m.Platform.OS != "unknown" && m.Platform.OS != "" {
+				platformErr.Available = append(platformErr.Available, platform)
+			}
+		}
+		if digest == "" {
+			return nil, platformErr
+		}
+		if body, mediaType, _, err = s.manifest(ctx, digest); err != nil {
+			return nil, err
+		}
+	}
+
+	if mediaType != mediaTypeOCIManifest && mediaType != mediaTypeDockerManifest {
+		return nil, fmt.Errorf("unsupported manifest type %q", mediaType)
+	}
+	var manifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+		Layers []struct {
+			Size int64 `json:"size"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	// a single manifest names its platform in the image config
+	if !fromIndex {
+		config, err := s.blob(ctx, manifest.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+		var platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+		}
+		if err := json.Unmarshal(config, &platform); err != nil {
+			return nil, fmt.Errorf("failed to parse image config: %w", err)
+		}
+		if got := platform.OS + "/" + platform.Architecture; got != Platform {
+			return nil, &PlatformError{Platform: Platform, Available: []string{got}}
+		}
+	}
+
+	image := &Image{Reference: ref.String(), Digest: digest, Platform: Platform, Layers: len(manifest.Layers)}
+	for _, layer := range manifest.Layers {
+		image.CompressedSize += layer.Size
+	}
+	return image, nil
+}
+
+// session holds the authorization negotiated for one repository
+type session struct {
+	client        *Client
+	ref           *Reference
+	creds         *Credentials
+	authorization string
+}
+
+// endpoint is the base url of the registry api. docker hub is served from
+// a different host than its name, and local registries are plain http.
+func (s *session) endpoint() string {
+	host := s.ref.Registry
+	if host == DockerHub {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if ip := net.ParseIP(hostname); hostname == "localhost" || (ip != nil && ip.IsLoopback()) {
+		scheme = "http"
+	}
+	return scheme + "://" + host
+}
+
+func (s *session) manifest(ctx context.Context, reference string) ([]byte, string, string, error) {
+	resp, err := s.get(ctx, "/v2/"+s.ref.Repository+"/manifests/"+reference, strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" || mediaType == "application/json" {
+		var probe struct {
+			MediaType string `json:"mediaType"`
+		}
+		_ = json.Unmarshal(body, &probe)
+		mediaType = probe.MediaType
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		sum := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return body, mediaType, digest, nil
+}
+
+func (s *session) blob(ctx context.Context, digest string) ([]byte, error) {
+	resp, err := s.get(ctx, "/v2/"+s.ref.Repository+"/blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+}
+
+// get sends a GET and, when the registry asks for it, logs in and retries
+// once
+func (s *session) get(ctx context.Context, path, accept string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint()+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if s.client.UserAgent != "" {
+			req.Header.Set("User-Agent", s.client.UserAgent)
+		}
+		if s.authorization != "" {
+			req.Header.Set("Authorization", s.authorization)
+		}
+		resp, err := s.client.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if err := s.authorize(ctx, challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		return nil, readError(resp)
+	}
+}
+
+// authorize answers a WWW-Authenticate challenge: basic auth with the
+// credentials, or a bearer token from the registry's token service
+func (s *session) authorize(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if s.creds == nil {
+			return &Error{StatusCode: http.StatusUnauthorized, Code: "UNAUTHORIZED", Message: "authentication required"}
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(s.creds.Username, s.creds.Password)
+		s.authorization = req.Header.Get("Authorization")
+		return nil
+	case "bearer":
+	default:
+		return &Error{StatusCode: http.StatusUnauthorized, Code: "UNAUTHORIZED", Message: "unsupported authentication " + challenge}
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", "repository:"+s.ref.Repository+":pull")
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if s.creds != nil {
+		req.SetBasicAuth(s.creds.Username, s.creds.Password)
+	}
+	if s.client.UserAgent != "" {
+		req.Header.Set("User-Agent", s.client.UserAgent)
+	}
+	resp, err := s.client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return readError(resp)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&token); err != nil {
+		return fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("registry token service returned no token")
+	}
+	s.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallenge splits `Bearer realm="...",service="..."` into a lower-case
+// scheme and its parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = strings.TrimPrefix(strings.TrimSpace(value[end+2:]), ",")
+		} else {
+			v, next, _ := strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(v)
+			rest = next
+		}
+		rest = strings.TrimSpace(rest)
+	}
+	return strings.ToLower(scheme), params
+}
+
+func readError(resp *http.Response) error {
+	regErr := &Error{StatusCode: resp.StatusCode}
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && len(body.Errors) > 0 {
+		regErr.Code = body.Errors[0].Code
+		regErr.Message = body.Errors[0].Message
+	}
+	return regErr
+}
@@ -0,0 +1,124 @@
+package registry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/registry"
+	"github.com/runpod/runpodctl/internal/registry/registrytest"
+)
+
+func inspect(t *testing.T, image string, creds *registry.Credentials) (*registry.Image, error) {
+	t.Helper()
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry.New().Inspect(context.Background(), ref, creds)
+}
+
+func TestInspect(t *testing.T) {
+	server := registrytest.NewServer(t)
+	server.Push("team/app", "v1", "linux/amd64", 100, 200)
+	server.PushIndex("team/multi", "v2", 1000, "linux/arm64", "linux/amd64")
+	server.PushIndex("team/arm", "latest", 1000, "linux/arm64")
+	server.Push("team/mac", "latest", "linux/arm64", 10)
+
+	image, err := inspect(t, server.Image("team/app", "v1"), nil)
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if image.CompressedSize != 300 || image.Layers != 2 || image.Platform != "linux/amd64" || !strings.HasPrefix(image.Digest, "sha256:") {
+		t.Fatalf("unexpected image %+v", image)
+	}
+
+	image, err = inspect(t, server.Image("team/multi", "v2"), nil)
+	if err != nil || image.CompressedSize != 1000 {
+		t.Fatalf("expected the amd64 manifest of the index, got %+v (%v)", image, err)
+	}
+
+	var platformErr *registry.PlatformError
+	if _, err := inspect(t, server.Image("team/arm", "latest"), nil); !errors.As(err, &platformErr) || strings.Join(platformErr.Available, ",") != "linux/arm64" {
+		t.Fatalf("expected a platform error listing linux/arm64, got %v", err)
+	}
+	if _, err := inspect(t, server.Image("team/mac", "latest"), nil); !errors.As(err, &platformErr) {
+		t.Fatalf("expected a platform error from the image config, got %v", err)
+	}
+
+	if _, err := inspect(t, server.Image("team/app", "v2"), nil); !registry.IsNotFound(err) {
+		t.Fatalf("expected a missing tag, got %v", err)
+	}
+	if _, err := inspect(t, server.Image("team/typo", "v1"), nil); !registry.IsNotFound(err) {
+		t.Fatalf("expected a missing repository, got %v", err)
+	}
+}
+
+func TestInspect_Private(t *testing.T) {
+	server := registrytest.NewServer(t)
+	server.Push("team/private", "v1", "linux/amd64", 42)
+	server.MakePrivate("team/private", "me", "token")
+
+	if _, err := inspect(t, server.Image("team/private", "v1"), nil); !registry.IsUnauthorized(err) {
+		t.Fatalf("expected an anonymous pull to be refused, got %v", err)
+	}
+	if _, err := inspect(t, server.Image("team/private", "v1"), &registry.Credentials{Username: "me", Password: "wrong"}); !registry.IsUnauthorized(err) {
+		t.Fatalf("expected wrong credentials to be refused, got %v", err)
+	}
+	image, err := inspect(t, server.Image("team/private", "v1"), &registry.Credentials{Username: "me", Password: "token"})
+	if err != nil || image.CompressedSize != 42 {
+		t.Fatalf("expected the private image, got %+v (%v)", image, err)
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DOCKER_CONFIG", "")
+	server := registrytest.NewServer(t)
+	server.Push("team/app", "v1", "linux/amd64", 3<<30)
+	server.Push("team/private", "v1", "linux/amd64", 1)
+	server.MakePrivate("team/private", "me", "token")
+
+	var notes bytes.Buffer
+	image, err := registry.Preflight(context.Background(), &notes, server.Image("team/app", "v1"), registry.PreflightOptions{ContainerDiskInGb: 5})
+	if err != nil || image == nil {
+		t.Fatalf("preflight: %v", err)
+	}
+	if !strings.Contains(notes.String(), "linux/amd64, 3.0 GiB compressed") || !strings.Contains(notes.String(), "may be too small") {
+		t.Fatalf("unexpected notes %q", notes.String())
+	}
+
+	if _, err := registry.Preflight(context.Background(), &notes, server.Image("team/app", "v1"), registry.PreflightOptions{ContainerDiskInGb: 2}); err == nil || !strings.Contains(err.Error(), "raise --container-disk-in-gb") {
+		t.Fatalf("expected the container disk to be too small, got %v", err)
+	}
+	if _, err := registry.Preflight(context.Background(), &notes, server.Image("team/app", "v1.0"), registry.PreflightOptions{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a missing tag, got %v", err)
+	}
+	if _, err := registry.Preflight(context.Background(), &notes, server.Image("team/private", "v1"), registry.PreflightOptions{}); err == nil || !strings.Contains(err.Error(), "--registry-auth-id") {
+		t.Fatalf("expected a private image without registry auth to fail, got %v", err)
+	}
+
+	// with a registry auth but no local credentials the image can't be checked
+	notes.Reset()
+	image, err = registry.Preflight(context.Background(), &notes, server.Image("team/private", "v1"), registry.PreflightOptions{RegistryAuthID: "auth-1"})
+	if err != nil || image != nil || !strings.Contains(notes.String(), "can't be checked") {
+		t.Fatalf("expected the check to be skipped, got %v (%v) %q", image, err, notes.String())
+	}
+
+	// and with local docker credentials it can
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	auth := base64.StdEncoding.EncodeToString([]byte("me:token"))
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths":{"`+server.Host()+`":{"auth":"`+auth+`"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	image, err = registry.Preflight(context.Background(), &notes, server.Image("team/private", "v1"), registry.PreflightOptions{RegistryAuthID: "auth-1"})
+	if err != nil || image == nil || image.CompressedSize != 1 {
+		t.Fatalf("expected the local credentials to be used, got %+v (%v)", image, err)
+	}
+}
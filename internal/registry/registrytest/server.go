@@ -0,0 +1,246 @@
+// Package registrytest provides an in-memory stand-in for a container
+// registry's v2 api, for tests.
+package registrytest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	mediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeIndex    = "application/vnd.oci.image.index.v1+json"
+)
+
+// Server is a registry stand-in. it serves pushed manifests and image
+// configs, and hands out bearer tokens the way docker hub and ghcr do.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	manifests map[string]map[string]*blob
+	blobs     map[string]*blob
+	private   map[string]Credentials
+	// Requests counts requests by path
+	Requests map[string]int
+}
+
+// Credentials log in to a private repository
+type Credentials struct {
+	Username string
+	Password string
+}
+
+type blob struct {
+	mediaType string
+	data      []byte
+}
+
+// NewServer starts a server that is closed when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		manifests: map[string]map[string]*blob{},
+		blobs:     map[string]*blob{},
+		private:   map[string]Credentials{},
+		Requests:  map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Host is the registry host to use in image references
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// Image returns a reference to repo:tag on this registry
+func (s *Server) Image(repo, tag string) string {
+	return s.Host() + "/" + repo + ":" + tag
+}
+
+// Push stores a single-platform image with layers of the given sizes and
+// returns its manifest digest. platform is os/architecture.
+func (s *Server) Push(repo, tag, platform string, layerSizes ...int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	digest := s.pushManifest(repo, platform, layerSizes)
+	s.tag(repo, tag, s.blobs[digest])
+	return digest
+}
+
+// PushIndex stores a multi-platform image, each platform with one layer of
+// layerSize bytes, plus an attestation manifest like buildx adds
+func (s *Server) PushIndex(repo, tag string, layerSize int64, platforms ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type descriptor struct {
+		MediaType string            `json:"mediaType"`
+		Digest    string            `json:"digest"`
+		Size      int               `json:"size"`
+		Platform  map[string]string `json:"platform"`
+	}
+	var manifests []descriptor
+	for _, platform := range append(platforms, "unknown/unknown") {
+		digest := s.pushManifest(repo, platform, []int64{layerSize})
+		os, arch, _ := strings.Cut(platform, "/")
+		manifests = append(manifests, descriptor{
+			MediaType: mediaTypeManifest,
+			Digest:    digest,
+			Size:      len(s.blobs[digest].data),
+			Platform:  map[string]string{"os": os, "architecture": arch},
+		})
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeIndex,
+		"manifests":     manifests,
+	})
+	s.tag(repo, tag, &blob{mediaType: mediaTypeIndex, data: data})
+}
+
+// MakePrivate requires a token issued for these credentials to pull repo
+func (s *Server) MakePrivate(repo, username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.private[repo] = Credentials{Username: username, Password: password}
+}
+
+func (s *Server) pushManifest(repo, platform string, layerSizes []int64) string {
+	os, arch, _ := strings.Cut(platform, "/")
+	config, _ := json.Marshal(map[string]string{"os": os, "architecture": arch})
+	configDigest := s.store(&blob{mediaType: "application/vnd.oci.image.config.v1+json", data: config})
+
+	layers := []map[string]interface{}{}
+	for i, size := range layerSizes {
+		layers = append(layers, map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+			"digest":    digestOf([]byte(fmt.Sprintf("%s/%s/%d", repo, platform, i))),
+			"size":      size,
+		})
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeManifest,
+		"config":        map[string]interface{}{"digest": configDigest, "size": len(config)},
+		"layers":        layers,
+	})
+	digest := s.store(&blob{mediaType: mediaTypeManifest, data: data})
+	s.tag(repo, digest, s.blobs[digest])
+	return digest
+}
+
+func (s *Server) store(b *blob) string {
+	digest := digestOf(b.data)
+	s.blobs[digest] = b
+	return digest
+}
+
+func (s *Server) tag(repo, ref string, b *blob) {
+	if s.manifests[repo] == nil {
+		s.manifests[repo] = map[string]*blob{}
+	}
+	s.manifests[repo][ref] = b
+	s.store(b)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Requests[r.URL.Path]++
+
+	if r.URL.Path == "/token" {
+		s.serveToken(w, r)
+		return
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/v2/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	repo, ref, kind := "", "", ""
+	for _, k := range []string{"/manifests/", "/blobs/"} {
+		if i := strings.LastIndex(path, k); i >= 0 {
+			repo, ref, kind = path[:i], path[i+len(k):], k
+			break
+		}
+	}
+	if kind == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// like docker hub, every pull needs a token, anonymous ones included
+	authorization := r.Header.Get("Authorization")
+	creds, private := s.private[repo]
+	if !strings.HasPrefix(authorization, "Bearer ") || (private && authorization != "Bearer "+token(repo, creds)) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registrytest"`, s.URL))
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required")
+		return
+	}
+	if _, exists := s.manifests[repo]; !exists {
+		writeError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository name not known to registry")
+		return
+	}
+
+	var b *blob
+	if kind == "/manifests/" {
+		b = s.manifests[repo][ref]
+	} else {
+		b = s.blobs[ref]
+	}
+	if b == nil {
+		writeError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown")
+		return
+	}
+	w.Header().Set("Content-Type", b.mediaType)
+	w.Header().Set("Docker-Content-Digest", digestOf(b.data))
+	_, _ = w.Write(b.data)
+}
+
+// serveToken issues a token for the requested repository: anonymous for a
+// public one, and only with the right credentials for a private one
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	scope := strings.TrimPrefix(r.URL.Query().Get("scope"), "repository:")
+	repo := strings.TrimSuffix(scope, ":pull")
+	username, password, _ := r.BasicAuth()
+
+	issued := "anonymous"
+	if creds, private := s.private[repo]; private {
+		if username != creds.Username || password != creds.Password {
+			// real registries hand out a token without access instead
+			issued = "no-access"
+		} else {
+			issued = token(repo, creds)
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": issued})
+}
+
+func token(repo string, creds Credentials) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(repo + ":" + creds.Username + ":" + creds.Password))
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
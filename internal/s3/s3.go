@@ -0,0 +1,391 @@
+// Package s3 is a small client for the s3-compatible api of runpod network
+// volumes. each network volume is a bucket named after its id, served by an
+// endpoint in the volume's datacenter.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPartSize is the multipart upload part size; files up to this
+	// size are uploaded with a single request
+	DefaultPartSize = 64 << 20
+
+	// maxParts is the most parts a multipart upload may have
+	maxParts = 10000
+)
+
+// DataCenterEndpoint returns the s3 endpoint of a runpod datacenter
+func DataCenterEndpoint(dataCenterID string) string {
+	return "https://s3api-" + strings.ToLower(dataCenterID) + ".runpod.io"
+}
+
+// Client makes signed requests to an s3-compatible endpoint
+type Client struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// PartSize overrides DefaultPartSize
+	PartSize   int64
+	HTTPClient *http.Client
+}
+
+// New returns a client for endpoint. runpod uses the datacenter id as the
+// region.
+func New(endpoint, region, accessKey, secretKey string) *Client {
+	return &Client{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		Region:     region,
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		HTTPClient: &http.Client{},
+	}
+}
+
+// Object is a stored object
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// Error is an error response from the s3 api
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("s3 error: %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("s3 error: %s: %s (status %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// IsNotFound reports whether err is a missing object or bucket
+func IsNotFound(err error) bool {
+	var s3Err *Error
+	return errors.As(err, &s3Err) && s3Err.StatusCode == http.StatusNotFound
+}
+
+// do sends a signed request and returns the response when it succeeded
+func (c *Client) do(ctx context.Context, method, bucket, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + bucket
+	if key != "" {
+		path += "/" + key
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: %w", c.Endpoint, err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = uriEncode(u.Path, true)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = int64(len(body))
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		payloadHash = hashHex(body)
+	}
+	c.sign(req, payloadHash, time.Now())
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, parseError(resp)
+	}
+	return resp, nil
+}
+
+func parseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	s3Err := &Error{StatusCode: resp.StatusCode}
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &body) == nil && body.Code != "" {
+		s3Err.Code, s3Err.Message = body.Code, body.Message
+		return s3Err
+	}
+	s3Err.Code = http.StatusText(resp.StatusCode)
+	if resp.StatusCode == http.StatusNotFound {
+		s3Err.Code = "NotFound"
+	}
+	s3Err.Message = strings.TrimSpace(string(data))
+	return s3Err
+}
+
+// List returns the objects under prefix. with a delimiter, keys that
+// continue past it are grouped into the returned common prefixes.
+func (c *Client) List(ctx context.Context, bucket, prefix, delimiter string) ([]Object, []string, error) {
+	var objects []Object
+	var prefixes []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, bucket, "", query, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			CommonPrefixes []struct {
+				Prefix string `xml:"Prefix"`
+			} `xml:"CommonPrefixes"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse object list: %w", err)
+		}
+
+		for _, item := range result.Contents {
+			objects = append(objects, Object{Key: item.Key, Size: item.Size, ETag: trimETag(item.ETag), LastModified: item.LastModified})
+		}
+		for _, item := range result.CommonPrefixes {
+			prefixes = append(prefixes, item.Prefix)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, prefixes, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Head returns an object's metadata
+func (c *Client) Head(ctx context.Context, bucket, key string) (*Object, error) {
+	resp, err := c.do(ctx, http.MethodHead, bucket, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return objectFromHeader(key, resp), nil
+}
+
+// Get opens an object for reading; the caller closes the body
+func (c *Client) Get(ctx context.Context, bucket, key string) (io.ReadCloser, *Object, error) {
+	resp, err := c.do(ctx, http.MethodGet, bucket, key, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, objectFromHeader(key, resp), nil
+}
+
+func objectFromHeader(key string, resp *http.Response) *Object {
+	object := &Object{Key: key, Size: resp.ContentLength, ETag: trimETag(resp.Header.Get("ETag"))}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.LastModified = modified
+	}
+	return object
+}
+
+// Delete removes an object
+func (c *Client) Delete(ctx context.Context, bucket, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, bucket, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Put uploads r as an object. size is used to pick the part size and may be
+// -1 when unknown; anything larger than one part is sent as a multipart
+// upload, which is aborted if it fails.
+func (c *Client) Put(ctx context.Context, bucket, key string, r io.Reader, size int64) error {
+	partSize := c.partSize(size)
+	// read the first part without allocating a whole part for small files
+	first, err := io.ReadAll(io.LimitReader(r, partSize))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if int64(len(first)) < partSize {
+		return c.putObject(ctx, bucket, key, first)
+	}
+
+	uploadID, err := c.createMultipartUpload(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := c.uploadParts(ctx, bucket, key, uploadID, r, first); err != nil {
+		// the upload is dropped even when ctx was cancelled
+		query := url.Values{"uploadId": {uploadID}}
+		if resp, abortErr := c.do(context.WithoutCancel(ctx), http.MethodDelete, bucket, key, query, nil); abortErr == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) partSize(size int64) int64 {
+	partSize := c.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	if size > 0 && (size+partSize-1)/partSize > maxParts {
+		partSize = (size + maxParts - 1) / maxParts
+	}
+	return partSize
+}
+
+// ETagOf returns the etag Put gives an object with r's content: the md5 of
+// the content, or for multipart uploads the md5 of the part md5s followed by
+// the number of parts. size must be exact.
+func (c *Client) ETagOf(r io.Reader, size int64) (string, error) {
+	partSize := c.partSize(size)
+	if size < partSize {
+		hash := md5.New()
+		if _, err := io.Copy(hash, r); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	var sums []byte
+	parts := 0
+	for {
+		hash := md5.New()
+		n, err := io.CopyN(hash, r, partSize)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if n == 0 {
+			break
+		}
+		sums = hash.Sum(sums)
+		parts++
+		if n < partSize {
+			break
+		}
+	}
+	sum := md5.Sum(sums)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), parts), nil
+}
+
+func (c *Client) putObject(ctx context.Context, bucket, key string, data []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, bucket, key, nil, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) createMultipartUpload(ctx context.Context, bucket, key string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPost, bucket, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("failed to start multipart upload of %s", key)
+	}
+	return result.UploadID, nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadParts sends the first part and the rest of r in parts of the same
+// size
+func (c *Client) uploadParts(ctx context.Context, bucket, key, uploadID string, r io.Reader, first []byte) error {
+	var parts []completedPart
+	buf := first
+	n := len(buf)
+	for partNumber := 1; ; partNumber++ {
+		query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+		resp, err := c.do(ctx, http.MethodPut, bucket, key, query, buf[:n])
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d of %s: %w", partNumber, key, err)
+		}
+		resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+
+		var readErr error
+		n, readErr = io.ReadFull(r, buf)
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read %s: %w", key, readErr)
+		}
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, bucket, key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return fmt.Errorf("failed to complete upload of %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	// s3 can report a failed completion in a 200 response
+	data, _ := io.ReadAll(resp.Body)
+	var failure struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &failure) == nil && failure.XMLName.Local == "Error" {
+		return &Error{StatusCode: resp.StatusCode, Code: failure.Code, Message: failure.Message}
+	}
+	return nil
+}
+
+func trimETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
@@ -0,0 +1,150 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/s3"
+	"github.com/runpod/runpodctl/internal/s3/s3test"
+)
+
+func newTestClient(t *testing.T) (*s3.Client, *s3test.Server) {
+	t.Helper()
+	server := s3test.NewServer(t)
+	return s3.New(server.URL, "EU-RO-1", s3test.AccessKey, s3test.SecretKey), server
+}
+
+func TestDataCenterEndpoint(t *testing.T) {
+	if got := s3.DataCenterEndpoint("EU-RO-1"); got != "https://s3api-eu-ro-1.runpod.io" {
+		t.Errorf("unexpected endpoint %s", got)
+	}
+}
+
+func TestClient_PutGetHeadDelete(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	key := "models/my model ünicode+v1.bin"
+	if err := client.Put(ctx, "vol-1", key, strings.NewReader("hello"), 5); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if data, ok := server.Get("vol-1", key); !ok || string(data) != "hello" {
+		t.Fatalf("unexpected stored object %q, %v", data, ok)
+	}
+
+	object, err := client.Head(ctx, "vol-1", key)
+	if err != nil {
+		t.Fatalf("head: %v", err)
+	}
+	if object.Size != 5 || object.ETag != "5d41402abc4b2a76b9719d911017c592" || object.LastModified.IsZero() {
+		t.Fatalf("unexpected object %+v", object)
+	}
+
+	body, _, err := client.Get(ctx, "vol-1", key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "hello" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	if err := client.Delete(ctx, "vol-1", key); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := client.Head(ctx, "vol-1", key); !s3.IsNotFound(err) {
+		t.Fatalf("expected not found after delete, got %v", err)
+	}
+	_, _, err = client.Get(ctx, "vol-1", key)
+	if !s3.IsNotFound(err) || !strings.Contains(err.Error(), "NoSuchKey") {
+		t.Fatalf("expected NoSuchKey, got %v", err)
+	}
+}
+
+func TestClient_PutMultipart(t *testing.T) {
+	client, server := newTestClient(t)
+	client.PartSize = 4
+
+	content := []byte("0123456789")
+	if err := client.Put(context.Background(), "vol-1", "big.bin", bytes.NewReader(content), -1); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if data, _ := server.Get("vol-1", "big.bin"); !bytes.Equal(data, content) {
+		t.Fatalf("unexpected content %q", data)
+	}
+	if server.MultipartUploads != 1 {
+		t.Fatalf("expected a multipart upload, got %d", server.MultipartUploads)
+	}
+	object, _ := client.Head(context.Background(), "vol-1", "big.bin")
+	if !strings.HasSuffix(object.ETag, "-3") {
+		t.Fatalf("expected a 3 part etag, got %s", object.ETag)
+	}
+
+	// smaller than a part goes in one request
+	if err := client.Put(context.Background(), "vol-1", "small.bin", strings.NewReader("abc"), 3); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if server.MultipartUploads != 1 {
+		t.Fatalf("expected a single put, got %d multipart uploads", server.MultipartUploads)
+	}
+}
+
+func TestClient_ETagOf(t *testing.T) {
+	client, server := newTestClient(t)
+	client.PartSize = 4
+
+	for _, content := range []string{"abc", "0123", "0123456789"} {
+		key := "file-" + content
+		if err := client.Put(context.Background(), "vol-1", key, strings.NewReader(content), int64(len(content))); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+		object, _ := client.Head(context.Background(), "vol-1", key)
+		etag, err := client.ETagOf(strings.NewReader(content), int64(len(content)))
+		if err != nil || etag != object.ETag {
+			t.Errorf("ETagOf(%q) = %s (%v), stored %s", content, etag, err, object.ETag)
+		}
+	}
+	if len(server.Keys("vol-1")) != 3 {
+		t.Fatalf("unexpected keys %v", server.Keys("vol-1"))
+	}
+}
+
+func TestClient_List(t *testing.T) {
+	client, server := newTestClient(t)
+	server.PageSize = 2
+	for _, key := range []string{"a.txt", "data/1.bin", "data/2.bin", "data/sub/3.bin", "logs/x.log", "z.txt"} {
+		server.Put("vol-1", key, []byte(key))
+	}
+
+	objects, prefixes, err := client.List(context.Background(), "vol-1", "", "/")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if keysOf(objects) != "a.txt,z.txt" || strings.Join(prefixes, ",") != "data/,logs/" {
+		t.Fatalf("unexpected listing %s %v", keysOf(objects), prefixes)
+	}
+
+	objects, _, err = client.List(context.Background(), "vol-1", "data/", "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if keysOf(objects) != "data/1.bin,data/2.bin,data/sub/3.bin" || objects[0].Size != 10 {
+		t.Fatalf("unexpected recursive listing %+v", objects)
+	}
+	if server.Requests[http.MethodGet] < 4 {
+		t.Fatalf("expected paged listings, got %d requests", server.Requests[http.MethodGet])
+	}
+}
+
+func keysOf(objects []s3.Object) string {
+	keys := make([]string, len(objects))
+	for i, object := range objects {
+		keys[i] = object.Key
+	}
+	return strings.Join(keys, ",")
+}
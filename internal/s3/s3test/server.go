@@ -0,0 +1,308 @@
+// Package s3test provides an in-memory stand-in for the s3-compatible
+// network volume api, for tests.
+package s3test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// AccessKey and SecretKey are the credentials the server expects
+const (
+	AccessKey = "user_test"
+	SecretKey = "rps_test"
+)
+
+// Server is an s3 stand-in holding objects in memory. any bucket exists.
+type Server struct {
+	*httptest.Server
+
+	// PageSize is the most keys returned by one list request
+	PageSize int
+
+	mu      sync.Mutex
+	objects map[string]map[string]*object
+	uploads map[string]map[int][]byte
+	// MultipartUploads counts completed multipart uploads
+	MultipartUploads int
+	// Requests counts requests by method
+	Requests map[string]int
+}
+
+type object struct {
+	data     []byte
+	etag     string
+	modified time.Time
+}
+
+// NewServer starts a server that is closed when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		PageSize: 1000,
+		objects:  map[string]map[string]*object{},
+		uploads:  map[string]map[int][]byte{},
+		Requests: map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.checkSignature(r); err != "" {
+			t.Errorf("s3test: %s %s: %s", r.Method, r.URL, err)
+			writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err)
+			return
+		}
+		s.serve(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Put stores an object
+func (s *Server) Put(bucket, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(bucket, key, data, md5Hex(data))
+}
+
+// Get returns an object's content
+func (s *Server) Get(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[bucket][key]
+	if !ok {
+		return nil, false
+	}
+	return obj.data, true
+}
+
+// Keys returns the keys in a bucket, sorted
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects[bucket]))
+	for key := range s.objects[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) put(bucket, key string, data []byte, etag string) {
+	if s.objects[bucket] == nil {
+		s.objects[bucket] = map[string]*object{}
+	}
+	s.objects[bucket][key] = &object{data: data, etag: etag, modified: time.Now().UTC()}
+}
+
+// checkSignature checks the parts of a sigv4 request the server can see
+// without recomputing the signature
+func (s *Server) checkSignature(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+AccessKey+"/") || !strings.Contains(auth, "Signature=") {
+		return "missing or unexpected authorization " + auth
+	}
+	if r.Header.Get("X-Amz-Date") == "" {
+		return "missing x-amz-date"
+	}
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+	sum := sha256.Sum256(body)
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(sum[:]) {
+		return "payload hash mismatch"
+	}
+	return ""
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Requests[r.Method]++
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, bucket, query.Get("prefix"), query.Get("delimiter"), query.Get("continuation-token"))
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID := fmt.Sprintf("upload-%d", len(s.uploads)+1)
+		s.uploads[uploadID] = map[int][]byte{}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			UploadID string   `xml:"UploadId"`
+		}{UploadID: uploadID})
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, ok := s.uploads[query.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload", "")
+			return
+		}
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		parts[partNumber] = body
+		w.Header().Set("ETag", `"`+md5Hex(body)+`"`)
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts, ok := s.uploads[query.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload", "")
+			return
+		}
+		var complete struct {
+			Parts []struct {
+				PartNumber int    `xml:"PartNumber"`
+				ETag       string `xml:"ETag"`
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil || len(complete.Parts) == 0 {
+			writeError(w, http.StatusBadRequest, "MalformedXML", "")
+			return
+		}
+		var data, sums []byte
+		for i, part := range complete.Parts {
+			content, ok := parts[part.PartNumber]
+			if !ok || part.PartNumber != i+1 || strings.Trim(part.ETag, `"`) != md5Hex(content) {
+				writeError(w, http.StatusBadRequest, "InvalidPart", "")
+				return
+			}
+			data = append(data, content...)
+			sum := md5.Sum(content)
+			sums = append(sums, sum[:]...)
+		}
+		delete(s.uploads, query.Get("uploadId"))
+		s.put(bucket, key, data, fmt.Sprintf("%s-%d", md5Hex(sums), len(complete.Parts)))
+		s.MultipartUploads++
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Key     string   `xml:"Key"`
+		}{Key: key})
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		s.put(bucket, key, body, md5Hex(body))
+		w.Header().Set("ETag", `"`+md5Hex(body)+`"`)
+
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		obj, ok := s.objects[bucket][key]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("ETag", `"`+obj.etag+`"`)
+		w.Header().Set("Last-Modified", obj.modified.Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.data)
+		}
+
+	case r.Method == http.MethodDelete:
+		delete(s.objects[bucket], key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented", r.Method+" "+r.URL.String())
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, bucket, prefix, delimiter, token string) {
+	type content struct {
+		Key          string `xml:"Key"`
+		Size         int    `xml:"Size"`
+		ETag         string `xml:"ETag"`
+		LastModified string `xml:"LastModified"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	}{}
+
+	keys := make([]string, 0, len(s.objects[bucket]))
+	for key := range s.objects[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := map[string]bool{}
+	entries := 0
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= token {
+			continue
+		}
+		common := ""
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				common = key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if common != "" && seen[common] {
+			result.NextContinuationToken = key
+			continue
+		}
+		if entries == s.PageSize {
+			result.IsTruncated = true
+			break
+		}
+		entries++
+		result.NextContinuationToken = key
+		if common != "" {
+			seen[common] = true
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: common})
+			continue
+		}
+		obj := s.objects[bucket][key]
+		result.Contents = append(result.Contents, content{
+			Key: key, Size: len(obj.data), ETag: `"` + obj.etag + `"`, LastModified: obj.modified.Format(time.RFC3339Nano),
+		})
+	}
+	if !result.IsTruncated {
+		result.NextContinuationToken = ""
+	}
+	writeXML(w, result)
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
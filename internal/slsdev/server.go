@@ -0,0 +1,501 @@
+// Package slsdev emulates the queue-based serverless endpoint api locally, so a
+// worker handler can be exercised against the same http contract as production
+// (/run, /runsync, /status, /stream, /cancel, /health, /purge-queue and
+// webhooks) before it is deployed.
+package slsdev
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job statuses, as reported by the endpoint api.
+const (
+	StatusInQueue    = "IN_QUEUE"
+	StatusInProgress = "IN_PROGRESS"
+	StatusCompleted  = "COMPLETED"
+	StatusFailed     = "FAILED"
+	StatusCancelled  = "CANCELLED"
+	StatusTimedOut   = "TIMED_OUT"
+)
+
+const (
+	maxQueuedJobs      = 4096
+	defaultRunsyncWait = 90 * time.Second
+	streamPollWait     = 10 * time.Second
+	webhookAttempts    = 3
+)
+
+// Worker runs a single job. Stream chunks are passed to emit as the handler
+// produces them; the returned output is the final result.
+type Worker interface {
+	Run(ctx context.Context, jobID string, input json.RawMessage, emit func(json.RawMessage)) (json.RawMessage, error)
+}
+
+// Config configures a Server.
+type Config struct {
+	Worker      Worker
+	Concurrency int
+	// Logf receives server events such as status changes and webhook
+	// failures. It may be nil.
+	Logf func(format string, args ...interface{})
+	// HTTPClient sends webhooks. It defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// JobState is a job as returned by /run, /runsync, /status and /cancel.
+type JobState struct {
+	ID            string          `json:"id"`
+	Status        string          `json:"status"`
+	Output        json.RawMessage `json:"output,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	DelayTime     int64           `json:"delayTime,omitempty"`
+	ExecutionTime int64           `json:"executionTime,omitempty"`
+}
+
+type job struct {
+	id      string
+	input   json.RawMessage
+	webhook string
+	timeout time.Duration
+
+	status     string
+	output     json.RawMessage
+	err        string
+	stream     []json.RawMessage
+	streamRead int
+
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+
+	cancel  context.CancelFunc
+	done    chan struct{}
+	changed chan struct{}
+}
+
+func (j *job) terminal() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// notify wakes everyone waiting on the job; callers hold the server lock.
+func (j *job) notify() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+func (j *job) state() JobState {
+	state := JobState{
+		ID:     j.id,
+		Status: j.status,
+		Output: j.output,
+		Error:  j.err,
+	}
+	if !j.startedAt.IsZero() {
+		state.DelayTime = j.startedAt.Sub(j.createdAt).Milliseconds()
+		if !j.finishedAt.IsZero() {
+			state.ExecutionTime = j.finishedAt.Sub(j.startedAt).Milliseconds()
+		}
+	}
+	return state
+}
+
+// Server is a local endpoint. Use Handler for the http api and Start to run
+// the workers.
+type Server struct {
+	cfg   Config
+	queue chan *job
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// New returns a server for cfg.
+func New(cfg Config) *Server {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.Logf == nil {
+		cfg.Logf = func(string, ...interface{}) {}
+	}
+	return &Server{
+		cfg:   cfg,
+		queue: make(chan *job, maxQueuedJobs),
+		jobs:  make(map[string]*job),
+	}
+}
+
+// Start runs cfg.Concurrency workers until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-s.queue:
+					s.runJob(ctx, j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Handler returns the endpoint api. Any endpoint id is accepted in the path.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v2/{endpoint}/run", s.handleRun)
+	mux.HandleFunc("POST /v2/{endpoint}/runsync", s.handleRunsync)
+	mux.HandleFunc("/v2/{endpoint}/status/{job}", s.handleStatus)
+	mux.HandleFunc("/v2/{endpoint}/stream/{job}", s.handleStream)
+	mux.HandleFunc("POST /v2/{endpoint}/cancel/{job}", s.handleCancel)
+	mux.HandleFunc("GET /v2/{endpoint}/health", s.handleHealth)
+	mux.HandleFunc("POST /v2/{endpoint}/purge-queue", s.handlePurge)
+	return mux
+}
+
+type runRequest struct {
+	Input   json.RawMessage `json:"input"`
+	Webhook string          `json:"webhook,omitempty"`
+	Policy  struct {
+		ExecutionTimeout int64 `json:"executionTimeout,omitempty"`
+	} `json:"policy"`
+}
+
+func (s *Server) enqueue(r *http.Request) (*job, int, error) {
+	var req runRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err)
+	}
+	if len(req.Input) == 0 || string(req.Input) == "null" {
+		return nil, http.StatusBadRequest, errors.New("request body must contain an input field")
+	}
+
+	j := &job{
+		id:        uuid.NewString() + "-dev",
+		input:     req.Input,
+		webhook:   req.Webhook,
+		timeout:   time.Duration(req.Policy.ExecutionTimeout) * time.Millisecond,
+		status:    StatusInQueue,
+		createdAt: time.Now(),
+		done:      make(chan struct{}),
+		changed:   make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	select {
+	case s.queue <- j:
+		s.jobs[j.id] = j
+	default:
+		s.mu.Unlock()
+		return nil, http.StatusTooManyRequests, errors.New("queue is full")
+	}
+	s.mu.Unlock()
+
+	s.cfg.Logf("job %s queued", j.id)
+	return j, http.StatusOK, nil
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	j, code, err := s.enqueue(r)
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, JobState{ID: j.id, Status: StatusInQueue})
+}
+
+func (s *Server) handleRunsync(w http.ResponseWriter, r *http.Request) {
+	wait := defaultRunsyncWait
+	if raw := r.URL.Query().Get("wait"); raw != "" {
+		ms, err := strconv.Atoi(raw)
+		if err != nil || ms < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid wait %q", raw))
+			return
+		}
+		wait = time.Duration(ms) * time.Millisecond
+	}
+
+	j, code, err := s.enqueue(r)
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-j.done:
+	case <-timer.C:
+	case <-r.Context().Done():
+		return
+	}
+
+	s.mu.Lock()
+	state := j.state()
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+	s.mu.Lock()
+	state := j.state()
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, state)
+}
+
+type streamChunk struct {
+	Output json.RawMessage `json:"output"`
+}
+
+// handleStream returns the chunks produced since the previous call, waiting
+// briefly for new ones while the job is still running.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+
+	deadline := time.NewTimer(streamPollWait)
+	defer deadline.Stop()
+
+	s.mu.Lock()
+	for len(j.stream) == j.streamRead && !j.terminal() {
+		changed := j.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+			s.mu.Lock()
+			continue
+		case <-deadline.C:
+		case <-r.Context().Done():
+			return
+		}
+		s.mu.Lock()
+		break
+	}
+
+	chunks := make([]streamChunk, 0, len(j.stream)-j.streamRead)
+	for _, chunk := range j.stream[j.streamRead:] {
+		chunks = append(chunks, streamChunk{Output: chunk})
+	}
+	j.streamRead = len(j.stream)
+	state := j.state()
+	s.mu.Unlock()
+
+	resp := map[string]interface{}{
+		"id":     state.ID,
+		"status": state.Status,
+		"stream": chunks,
+	}
+	if state.Error != "" {
+		resp["error"] = state.Error
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if !j.terminal() {
+		if j.cancel != nil {
+			j.cancel()
+		}
+		s.finish(j, StatusCancelled, nil, "")
+	}
+	state := j.state()
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	var completed, failed, inProgress, inQueue int
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		switch j.status {
+		case StatusCompleted:
+			completed++
+		case StatusFailed, StatusTimedOut:
+			failed++
+		case StatusInProgress:
+			inProgress++
+		case StatusInQueue:
+			inQueue++
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs": map[string]int{
+			"completed":  completed,
+			"failed":     failed,
+			"inProgress": inProgress,
+			"inQueue":    inQueue,
+			"retried":    0,
+		},
+		"workers": map[string]int{
+			"idle":    s.cfg.Concurrency - inProgress,
+			"running": inProgress,
+		},
+	})
+}
+
+func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
+	removed := 0
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if j.status == StatusInQueue {
+			s.finish(j, StatusCancelled, nil, "")
+			removed++
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"removed": removed,
+		"status":  "completed",
+	})
+}
+
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) *job {
+	id := r.PathValue("job")
+	s.mu.Lock()
+	j := s.jobs[id]
+	s.mu.Unlock()
+	if j == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", id))
+	}
+	return j
+}
+
+func (s *Server) runJob(ctx context.Context, j *job) {
+	s.mu.Lock()
+	if j.status != StatusInQueue {
+		// cancelled or purged while queued
+		s.mu.Unlock()
+		return
+	}
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if j.timeout > 0 {
+		jobCtx, cancel = context.WithTimeout(ctx, j.timeout)
+	} else {
+		jobCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	j.cancel = cancel
+	j.status = StatusInProgress
+	j.startedAt = time.Now()
+	j.notify()
+	s.mu.Unlock()
+	s.cfg.Logf("job %s in progress", j.id)
+
+	output, err := s.cfg.Worker.Run(jobCtx, j.id, j.input, func(chunk json.RawMessage) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !j.terminal() {
+			j.stream = append(j.stream, chunk)
+			j.notify()
+		}
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.terminal() {
+		return
+	}
+	switch {
+	case errors.Is(jobCtx.Err(), context.DeadlineExceeded):
+		s.finish(j, StatusTimedOut, nil, fmt.Sprintf("execution timeout of %s exceeded", j.timeout))
+	case err != nil:
+		s.finish(j, StatusFailed, nil, err.Error())
+	default:
+		if output == nil && len(j.stream) > 0 {
+			output, _ = json.Marshal(j.stream)
+		}
+		s.finish(j, StatusCompleted, output, "")
+	}
+}
+
+// finish moves a job to a terminal status and fires its webhook. Callers hold
+// the server lock.
+func (s *Server) finish(j *job, status string, output json.RawMessage, errMsg string) {
+	j.status = status
+	j.output = output
+	j.err = errMsg
+	j.finishedAt = time.Now()
+	close(j.done)
+	j.notify()
+
+	state := j.state()
+	if errMsg != "" {
+		s.cfg.Logf("job %s %s: %s", j.id, status, errMsg)
+	} else {
+		s.cfg.Logf("job %s %s", j.id, status)
+	}
+	if j.webhook != "" {
+		go s.sendWebhook(j.webhook, state)
+	}
+}
+
+func (s *Server) sendWebhook(url string, state JobState) {
+	body, err := json.Marshal(state)
+	if err != nil {
+		s.cfg.Logf("webhook for job %s: %v", state.ID, err)
+		return
+	}
+
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		resp, err := s.cfg.HTTPClient.Post(url, "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return
+			}
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+		s.cfg.Logf("webhook for job %s failed (attempt %d/%d): %v", state.ID, attempt, webhookAttempts, err)
+		if attempt < webhookAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,245 @@
+package slsdev
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeWorker echoes the input, streams the "chunks" field, fails when the
+// input has an "error" field and blocks on "sleep" (in ms) until cancelled.
+type fakeWorker struct{}
+
+func (fakeWorker) Run(ctx context.Context, jobID string, input json.RawMessage, emit func(json.RawMessage)) (json.RawMessage, error) {
+	var in struct {
+		Chunks []string `json:"chunks"`
+		Error  string   `json:"error"`
+		Sleep  int      `json:"sleep"`
+	}
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, err
+	}
+	if in.Sleep > 0 {
+		select {
+		case <-time.After(time.Duration(in.Sleep) * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if in.Error != "" {
+		return nil, errors.New(in.Error)
+	}
+	if len(in.Chunks) > 0 {
+		for _, chunk := range in.Chunks {
+			raw, _ := json.Marshal(chunk)
+			emit(raw)
+		}
+		return nil, nil
+	}
+	return input, nil
+}
+
+func startTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := New(Config{Worker: fakeWorker{}, Concurrency: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	go server.Start(ctx)
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		cancel()
+	})
+	return ts
+}
+
+func postJSON(t *testing.T, url, body string, out interface{}) int {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func waitForStatus(t *testing.T, baseURL, jobID, want string) JobState {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var state JobState
+		postJSON(t, baseURL+"/v2/local/status/"+jobID, "", &state)
+		if state.Status == want {
+			return state
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s status %s, want %s", jobID, state.Status, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_RunsyncCompletes(t *testing.T) {
+	ts := startTestServer(t)
+
+	var state JobState
+	postJSON(t, ts.URL+"/v2/abc123/runsync", `{"input": {"prompt": "hi"}}`, &state)
+	if state.Status != StatusCompleted {
+		t.Fatalf("expected COMPLETED, got %s", state.Status)
+	}
+	if string(state.Output) != `{"prompt":"hi"}` {
+		t.Fatalf("unexpected output %s", state.Output)
+	}
+}
+
+func TestServer_RunThenStatus(t *testing.T) {
+	ts := startTestServer(t)
+
+	var queued JobState
+	postJSON(t, ts.URL+"/v2/local/run", `{"input": {"error": "boom"}}`, &queued)
+	if queued.ID == "" || queued.Status != StatusInQueue {
+		t.Fatalf("unexpected run response %+v", queued)
+	}
+
+	state := waitForStatus(t, ts.URL, queued.ID, StatusFailed)
+	if state.Error != "boom" {
+		t.Fatalf("expected error boom, got %q", state.Error)
+	}
+}
+
+func TestServer_RejectsMissingInput(t *testing.T) {
+	ts := startTestServer(t)
+
+	if code := postJSON(t, ts.URL+"/v2/local/run", `{}`, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", code)
+	}
+	if code := postJSON(t, ts.URL+"/v2/local/status/missing", "", nil); code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+}
+
+func TestServer_StreamReturnsNewChunks(t *testing.T) {
+	ts := startTestServer(t)
+
+	var queued JobState
+	postJSON(t, ts.URL+"/v2/local/run", `{"input": {"chunks": ["a", "b"]}}`, &queued)
+	waitForStatus(t, ts.URL, queued.ID, StatusCompleted)
+
+	var stream struct {
+		Status string `json:"status"`
+		Stream []struct {
+			Output string `json:"output"`
+		} `json:"stream"`
+	}
+	postJSON(t, ts.URL+"/v2/local/stream/"+queued.ID, "", &stream)
+	if stream.Status != StatusCompleted || len(stream.Stream) != 2 || stream.Stream[1].Output != "b" {
+		t.Fatalf("unexpected stream response %+v", stream)
+	}
+
+	// chunks are only returned once
+	postJSON(t, ts.URL+"/v2/local/stream/"+queued.ID, "", &stream)
+	if len(stream.Stream) != 0 {
+		t.Fatalf("expected no new chunks, got %+v", stream.Stream)
+	}
+
+	state := waitForStatus(t, ts.URL, queued.ID, StatusCompleted)
+	if string(state.Output) != `["a","b"]` {
+		t.Fatalf("expected aggregated stream output, got %s", state.Output)
+	}
+}
+
+func TestServer_CancelInProgress(t *testing.T) {
+	ts := startTestServer(t)
+
+	var queued JobState
+	postJSON(t, ts.URL+"/v2/local/run", `{"input": {"sleep": 10000}}`, &queued)
+	waitForStatus(t, ts.URL, queued.ID, StatusInProgress)
+
+	var state JobState
+	postJSON(t, ts.URL+"/v2/local/cancel/"+queued.ID, "", &state)
+	if state.Status != StatusCancelled {
+		t.Fatalf("expected CANCELLED, got %s", state.Status)
+	}
+
+	// the worker is free again
+	postJSON(t, ts.URL+"/v2/local/runsync", `{"input": {"ok": true}}`, &state)
+	if state.Status != StatusCompleted {
+		t.Fatalf("expected next job to complete, got %s", state.Status)
+	}
+}
+
+func TestServer_ExecutionTimeout(t *testing.T) {
+	ts := startTestServer(t)
+
+	var state JobState
+	postJSON(t, ts.URL+"/v2/local/runsync", `{"input": {"sleep": 10000}, "policy": {"executionTimeout": 50}}`, &state)
+	if state.Status != StatusTimedOut {
+		t.Fatalf("expected TIMED_OUT, got %s", state.Status)
+	}
+}
+
+func TestServer_HealthAndPurge(t *testing.T) {
+	ts := startTestServer(t)
+
+	var running JobState
+	postJSON(t, ts.URL+"/v2/local/run", `{"input": {"sleep": 10000}}`, &running)
+	waitForStatus(t, ts.URL, running.ID, StatusInProgress)
+	var queued JobState
+	postJSON(t, ts.URL+"/v2/local/run", `{"input": {"ok": true}}`, &queued)
+
+	var health struct {
+		Jobs    map[string]int `json:"jobs"`
+		Workers map[string]int `json:"workers"`
+	}
+	resp, err := http.Get(ts.URL + "/v2/local/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&health)
+	resp.Body.Close()
+	if health.Jobs["inProgress"] != 1 || health.Jobs["inQueue"] != 1 || health.Workers["running"] != 1 || health.Workers["idle"] != 0 {
+		t.Fatalf("unexpected health %+v", health)
+	}
+
+	var purge struct {
+		Removed int `json:"removed"`
+	}
+	postJSON(t, ts.URL+"/v2/local/purge-queue", "", &purge)
+	if purge.Removed != 1 {
+		t.Fatalf("expected 1 removed, got %d", purge.Removed)
+	}
+	waitForStatus(t, ts.URL, queued.ID, StatusCancelled)
+	postJSON(t, ts.URL+"/v2/local/cancel/"+running.ID, "", nil)
+}
+
+func TestServer_Webhook(t *testing.T) {
+	received := make(chan JobState, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var state JobState
+		_ = json.NewDecoder(r.Body).Decode(&state)
+		received <- state
+	}))
+	defer hook.Close()
+
+	ts := startTestServer(t)
+	var queued JobState
+	postJSON(t, ts.URL+"/v2/local/run", `{"input": {"ok": true}, "webhook": "`+hook.URL+`"}`, &queued)
+
+	select {
+	case state := <-received:
+		if state.ID != queued.ID || state.Status != StatusCompleted {
+			t.Fatalf("unexpected webhook payload %+v", state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
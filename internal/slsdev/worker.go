@@ -0,0 +1,150 @@
+package slsdev
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed worker_shim.py
+var workerShim []byte
+
+// shimMarker prefixes the json lines the shim writes for handler events.
+const shimMarker = "__RUNPODCTL_DEV__ "
+
+// PythonWorker runs each job in a fresh `python handler.py --test_input <job>`
+// process, the runpod-python local test mode. A small shim wraps the handler
+// registered with runpod.serverless.start and reports its output, stream
+// chunks and errors on stdout. Everything else the handler prints is copied to
+// Logs, prefixed with the job id.
+type PythonWorker struct {
+	Python  string
+	Handler string
+	Env     []string
+	Logs    io.Writer
+
+	shimPath string
+}
+
+// NewPythonWorker writes the shim to a temp file. Call Close to remove it.
+func NewPythonWorker(python, handler string, env []string, logs io.Writer) (*PythonWorker, error) {
+	file, err := os.CreateTemp("", "runpodctl-dev-*.py")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker shim: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(workerShim); err != nil {
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to write worker shim: %w", err)
+	}
+
+	return &PythonWorker{
+		Python:   python,
+		Handler:  handler,
+		Env:      env,
+		Logs:     logs,
+		shimPath: file.Name(),
+	}, nil
+}
+
+// Close removes the shim.
+func (w *PythonWorker) Close() error {
+	return os.Remove(w.shimPath)
+}
+
+// Run implements Worker.
+func (w *PythonWorker) Run(ctx context.Context, jobID string, input json.RawMessage, emit func(json.RawMessage)) (json.RawMessage, error) {
+	testInput, err := json.Marshal(map[string]interface{}{"id": jobID, "input": input})
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, w.Python, w.shimPath, w.Handler, "--test_input", string(testInput))
+	cmd.Env = append(os.Environ(), w.Env...)
+	cmd.WaitDelay = 5 * time.Second
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start worker: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.copyLogs(jobID, stderr)
+	}()
+
+	var output json.RawMessage
+	var handlerErr string
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, shimMarker) {
+			w.logLine(jobID, line)
+			continue
+		}
+
+		var event struct {
+			Output json.RawMessage `json:"output"`
+			Stream json.RawMessage `json:"stream"`
+			Error  *string         `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, shimMarker)), &event); err != nil {
+			w.logLine(jobID, line)
+			continue
+		}
+		switch {
+		case event.Error != nil:
+			handlerErr = *event.Error
+		case event.Stream != nil:
+			emit(event.Stream)
+		case event.Output != nil:
+			output = event.Output
+		}
+	}
+	wg.Wait()
+	waitErr := cmd.Wait()
+
+	if handlerErr != "" {
+		return nil, errors.New(handlerErr)
+	}
+	if waitErr != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("worker exited: %w", waitErr)
+	}
+	return output, nil
+}
+
+func (w *PythonWorker) copyLogs(jobID string, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		w.logLine(jobID, scanner.Text())
+	}
+}
+
+func (w *PythonWorker) logLine(jobID, line string) {
+	if w.Logs != nil {
+		fmt.Fprintf(w.Logs, "[%s] %s\n", jobID, line)
+	}
+}
@@ -0,0 +1,81 @@
+# Runs a runpod-python handler file in local test mode (--test_input) and
+# reports what the handler produces on stdout, one marked json line per event,
+# so runpodctl can serve it through the endpoint api.
+import inspect
+import json
+import os
+import runpy
+import sys
+
+import runpod.serverless
+
+MARKER = "__RUNPODCTL_DEV__ "
+
+
+def emit(kind, value):
+    sys.stdout.write(MARKER + json.dumps({kind: value}, default=str) + "\n")
+    sys.stdout.flush()
+
+
+def report(result):
+    if isinstance(result, dict) and "error" in result:
+        emit("error", str(result["error"]))
+    else:
+        emit("output", result)
+
+
+def wrap(handler):
+    if inspect.isasyncgenfunction(handler):
+        async def wrapped(job):
+            try:
+                async for chunk in handler(job):
+                    emit("stream", chunk)
+                    yield chunk
+            except Exception as err:
+                emit("error", repr(err))
+                raise
+    elif inspect.isgeneratorfunction(handler):
+        def wrapped(job):
+            try:
+                for chunk in handler(job):
+                    emit("stream", chunk)
+                    yield chunk
+            except Exception as err:
+                emit("error", repr(err))
+                raise
+    elif inspect.iscoroutinefunction(handler):
+        async def wrapped(job):
+            try:
+                result = await handler(job)
+            except Exception as err:
+                emit("error", repr(err))
+                raise
+            report(result)
+            return result
+    else:
+        def wrapped(job):
+            try:
+                result = handler(job)
+            except Exception as err:
+                emit("error", repr(err))
+                raise
+            report(result)
+            return result
+    return wrapped
+
+
+original_start = runpod.serverless.start
+
+
+def start(config):
+    config = dict(config)
+    config["handler"] = wrap(config["handler"])
+    return original_start(config)
+
+
+runpod.serverless.start = start
+
+handler_path = sys.argv[1]
+sys.argv = [handler_path] + sys.argv[2:]
+sys.path.insert(0, os.path.dirname(os.path.abspath(handler_path)))
+runpy.run_path(handler_path, run_name="__main__")
@@ -0,0 +1,120 @@
+package slsdev
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubRunpod is a minimal runpod package whose serverless.start runs the
+// handler once on --test_input, like runpod-python's local test mode.
+const stubRunpod = `
+import inspect, json, sys
+
+def start(config):
+    job = json.loads(sys.argv[sys.argv.index("--test_input") + 1])
+    handler = config["handler"]
+    if inspect.isgeneratorfunction(handler):
+        for _ in handler(job):
+            pass
+    else:
+        handler(job)
+`
+
+const testHandler = `
+import runpod
+
+def handler(job):
+    if job["input"].get("fail"):
+        raise ValueError("bad input")
+    print("handling", job["id"])
+    return {"echo": job["input"]["value"]}
+
+runpod.serverless.start({"handler": handler})
+`
+
+func newTestPythonWorker(t *testing.T, handlerSource string) (*PythonWorker, *bytes.Buffer) {
+	t.Helper()
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "runpod"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"runpod/__init__.py":   "from . import serverless\n",
+		"runpod/serverless.py": stubRunpod,
+		"handler.py":           handlerSource,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logs := &bytes.Buffer{}
+	worker, err := NewPythonWorker(python, filepath.Join(dir, "handler.py"), []string{"PYTHONPATH=" + dir}, logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { worker.Close() })
+	return worker, logs
+}
+
+func TestPythonWorker_Output(t *testing.T) {
+	worker, logs := newTestPythonWorker(t, testHandler)
+
+	output, err := worker.Run(context.Background(), "job-1", json.RawMessage(`{"value": 42}`), func(json.RawMessage) {})
+	if err != nil {
+		t.Fatalf("unexpected error: %v (logs: %s)", err, logs)
+	}
+	if string(output) != `{"echo": 42}` {
+		t.Fatalf("unexpected output %s", output)
+	}
+	if !strings.Contains(logs.String(), "[job-1] handling job-1") {
+		t.Fatalf("expected handler output in logs, got %q", logs)
+	}
+}
+
+func TestPythonWorker_Error(t *testing.T) {
+	worker, _ := newTestPythonWorker(t, testHandler)
+
+	_, err := worker.Run(context.Background(), "job-2", json.RawMessage(`{"fail": true}`), func(json.RawMessage) {})
+	if err == nil || !strings.Contains(err.Error(), "bad input") {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+}
+
+func TestPythonWorker_Stream(t *testing.T) {
+	worker, _ := newTestPythonWorker(t, `
+import runpod
+
+def handler(job):
+    for i in range(job["input"]["n"]):
+        yield {"i": i}
+
+runpod.serverless.start({"handler": handler})
+`)
+
+	var chunks []string
+	output, err := worker.Run(context.Background(), "job-3", json.RawMessage(`{"n": 3}`), func(chunk json.RawMessage) {
+		chunks = append(chunks, string(chunk))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output != nil {
+		t.Fatalf("expected no final output for a generator, got %s", output)
+	}
+	if len(chunks) != 3 || chunks[2] != `{"i": 2}` {
+		t.Fatalf("unexpected chunks %v", chunks)
+	}
+}
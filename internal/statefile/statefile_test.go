@@ -0,0 +1,45 @@
+package statefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoadRemove(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	states := map[string]int{}
+	if err := Load("test.json", &states); err != nil || len(states) != 0 {
+		t.Fatalf("expected a missing file to load empty, got %v (%v)", states, err)
+	}
+
+	if err := Save("test.json", map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, ".runpod", "test.json")
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected %s with mode 0600: %v", path, err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary file to be renamed, got %v", err)
+	}
+	if err := Load("test.json", &states); err != nil || states["a"] != 1 {
+		t.Fatalf("unexpected state %v (%v)", states, err)
+	}
+
+	if err := Remove("test.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove("test.json"); err != nil {
+		t.Fatalf("removing a missing file: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load("test.json", &states); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
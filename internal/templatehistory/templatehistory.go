@@ -0,0 +1,144 @@
+// Package templatehistory records the changes made to templates through
+// runpodctl under ~/.runpod/history, one json line per revision, for
+// template history, diff and revert.
+package templatehistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/runpod/runpodctl/internal/api"
+	"github.com/runpod/runpodctl/internal/statefile"
+)
+
+// Revision is one recorded change to a template. Before is empty for a
+// create and After for a delete.
+type Revision struct {
+	Rev    int                `json:"rev"`
+	Time   time.Time          `json:"time"`
+	Action string             `json:"action"`
+	Before *api.TemplateState `json:"before,omitempty"`
+	After  *api.TemplateState `json:"after,omitempty"`
+}
+
+var templateIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Path returns the history file of a template
+func Path(templateID string) (string, error) {
+	if !templateIDPattern.MatchString(templateID) {
+		return "", fmt.Errorf("invalid template id %q", templateID)
+	}
+	return statefile.Path(filepath.Join("history", "templates", templateID+".jsonl"))
+}
+
+// Load reads a template's recorded changes, oldest first
+func Load(templateID string) ([]Revision, error) {
+	path, err := Path(templateID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template history: %w", err)
+	}
+	defer file.Close()
+
+	var revisions []Revision
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var revision Revision
+		if err := json.Unmarshal(scanner.Bytes(), &revision); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read template history: %w", err)
+	}
+	return revisions, nil
+}
+
+// Append numbers a revision after the last one and appends it. env values
+// are recorded as they are, so the file is private.
+func Append(templateID string, revision *Revision) error {
+	revisions, err := Load(templateID)
+	if err != nil {
+		return err
+	}
+	revision.Rev = 1
+	if len(revisions) > 0 {
+		revision.Rev = revisions[len(revisions)-1].Rev + 1
+	}
+	data, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+
+	path, err := Path(templateID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to write template history: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write template history: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write template history: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadState reads a template's state before a change. history is best
+// effort, so a failure is a note on w and the change goes ahead.
+func ReadState(w io.Writer, templateID string) *api.TemplateState {
+	return readStateWith(w, templateID, (*api.GraphQLClient).GetTemplateState)
+}
+
+func readStateWith(w io.Writer, templateID string, read func(*api.GraphQLClient, string) (*api.TemplateState, error)) *api.TemplateState {
+	client, err := api.NewGraphQLClient()
+	if err == nil {
+		var state *api.TemplateState
+		if state, err = read(client, templateID); err == nil {
+			return state
+		}
+	}
+	fmt.Fprintf(w, "note: couldn't read template %s for its history: %v\n", templateID, err)
+	return nil
+}
+
+// Record records a change made through the cli, reading the state after it
+// unless the template was deleted. a template created moments ago may take
+// a few tries to read; a delete with no state from before it has nothing to
+// record.
+func Record(w io.Writer, action, templateID string, before *api.TemplateState) {
+	revision := &Revision{Time: time.Now().UTC(), Action: action, Before: before}
+	if action == "delete" {
+		if before == nil {
+			return
+		}
+	} else if revision.After = readStateWith(w, templateID, (*api.GraphQLClient).WaitForTemplateState); revision.After == nil {
+		return
+	}
+	if err := Append(templateID, revision); err != nil {
+		fmt.Fprintf(w, "note: couldn't record the change to template %s: %v\n", templateID, err)
+	}
+}
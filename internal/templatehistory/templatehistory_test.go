@@ -0,0 +1,47 @@
+package templatehistory
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/runpod/runpodctl/internal/api"
+)
+
+func TestAppendLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if revisions, err := Load("tpl-1"); err != nil || len(revisions) != 0 {
+		t.Fatalf("expected no history yet, got %+v (%v)", revisions, err)
+	}
+	for _, action := range []string{"create", "update"} {
+		if err := Append("tpl-1", &Revision{Action: action, After: &api.TemplateState{ID: "tpl-1"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	revisions, err := Load("tpl-1")
+	if err != nil || len(revisions) != 2 || revisions[1].Rev != 2 || revisions[1].Action != "update" {
+		t.Fatalf("unexpected revisions %+v (%v)", revisions, err)
+	}
+
+	path, err := Path("tpl-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected %s with mode 0600: %v", path, err)
+	}
+	if _, err := Load("../tpl-1"); err == nil {
+		t.Fatal("expected an invalid template id error")
+	}
+}
+
+func TestRecord_DeleteWithoutState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// a delete whose state couldn't be read first has nothing to record
+	Record(io.Discard, "delete", "tpl-1", nil)
+	if revisions, err := Load("tpl-1"); err != nil || len(revisions) != 0 {
+		t.Fatalf("expected no revision, got %+v (%v)", revisions, err)
+	}
+}